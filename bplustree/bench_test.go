@@ -0,0 +1,204 @@
+package bplustree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/btree"
+)
+
+// 基准测试的数据规模
+var benchSizes = []int{1_000, 10_000, 100_000}
+
+// 范围扫描每次读取的条目数
+const benchScanLen = 100
+
+// google/btree 中保存的键值对
+type benchItem struct{ key, value int }
+
+func benchItemLess(a, b benchItem) bool { return a.key < b.key }
+
+// google/btree 的阶数，取其文档推荐的默认值
+const benchBTreeDegree = 32
+
+func sequentialKeys(n int) []int {
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = i
+	}
+	return keys
+}
+
+// 0..n-1 的固定随机排列，保证各实现处理完全相同的输入
+func randomKeys(n int) []int {
+	return rand.New(rand.NewSource(int64(n))).Perm(n)
+}
+
+func buildBPlusTree(keys []int) *BPlusTree {
+	bpt := NewBPlusTree()
+	for _, k := range keys {
+		bpt.Insert(k, k)
+	}
+	return bpt
+}
+
+func buildMap(keys []int) map[int]int {
+	m := make(map[int]int)
+	for _, k := range keys {
+		m[k] = k
+	}
+	return m
+}
+
+func buildBTree(keys []int) *btree.BTreeG[benchItem] {
+	t := btree.NewG(benchBTreeDegree, benchItemLess)
+	for _, k := range keys {
+		t.ReplaceOrInsert(benchItem{k, k})
+	}
+	return t
+}
+
+// 每次迭代把 keys 全部插入一个新的结构，结果以每个 key 的耗时报告
+func benchInsert(b *testing.B, keys func(n int) []int) {
+	for _, n := range benchSizes {
+		ks := keys(n)
+		run := func(name string, build func([]int)) {
+			b.Run(fmt.Sprintf("%s/n=%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					build(ks)
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/key")
+			})
+		}
+		run("bplustree", func(ks []int) { buildBPlusTree(ks) })
+		run("map", func(ks []int) { buildMap(ks) })
+		run("btree", func(ks []int) { buildBTree(ks) })
+	}
+}
+
+func BenchmarkInsertSequential(b *testing.B) {
+	benchInsert(b, sequentialKeys)
+}
+
+func BenchmarkInsertRandom(b *testing.B) {
+	benchInsert(b, randomKeys)
+}
+
+func BenchmarkLookup(b *testing.B) {
+	for _, n := range benchSizes {
+		ks := randomKeys(n)
+		b.Run(fmt.Sprintf("bplustree/n=%d", n), func(b *testing.B) {
+			bpt := buildBPlusTree(ks)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bpt.Search(ks[i%n])
+			}
+		})
+		b.Run(fmt.Sprintf("map/n=%d", n), func(b *testing.B) {
+			m := buildMap(ks)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = m[ks[i%n]]
+			}
+		})
+		b.Run(fmt.Sprintf("btree/n=%d", n), func(b *testing.B) {
+			t := buildBTree(ks)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				t.Get(benchItem{key: ks[i%n]})
+			}
+		})
+	}
+}
+
+// 每次迭代从随机起点按 key 顺序读取 benchScanLen 个条目。map 不保存顺序，不参与比较
+func BenchmarkRangeScan(b *testing.B) {
+	for _, n := range benchSizes {
+		ks := randomKeys(n)
+		b.Run(fmt.Sprintf("bplustree/n=%d", n), func(b *testing.B) {
+			bpt := buildBPlusTree(ks)
+			b.ResetTimer()
+			sum := 0
+			for i := 0; i < b.N; i++ {
+				left := benchScanLen
+				for leaf := bpt.findLeaf(bpt.root, ks[i%n]); leaf != nil && left > 0; leaf = leaf.next {
+					for j := 0; j < len(leaf.values) && left > 0; j++ {
+						sum += leaf.values[j]
+						left--
+					}
+				}
+			}
+			_ = sum
+		})
+		b.Run(fmt.Sprintf("btree/n=%d", n), func(b *testing.B) {
+			t := buildBTree(ks)
+			b.ResetTimer()
+			sum := 0
+			for i := 0; i < b.N; i++ {
+				left := benchScanLen
+				t.AscendGreaterOrEqual(benchItem{key: ks[i%n]}, func(it benchItem) bool {
+					sum += it.value
+					left--
+					return left > 0
+				})
+			}
+			_ = sum
+		})
+	}
+}
+
+// 每次迭代在计时之外重建结构，再按随机顺序删除全部 key，结果以每个 key 的耗时报告
+func BenchmarkDelete(b *testing.B) {
+	for _, n := range benchSizes {
+		ks := randomKeys(n)
+		order := rand.New(rand.NewSource(int64(n) + 1)).Perm(n)
+		run := func(name string, build func() func(key int)) {
+			b.Run(fmt.Sprintf("%s/n=%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					remove := build()
+					b.StartTimer()
+					for _, k := range order {
+						remove(k)
+					}
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/key")
+			})
+		}
+		run("bplustree", func() func(int) {
+			bpt := buildBPlusTree(ks)
+			return func(k int) { bpt.Remove(k) }
+		})
+		run("map", func() func(int) {
+			m := buildMap(ks)
+			return func(k int) { delete(m, k) }
+		})
+		run("btree", func() func(int) {
+			t := buildBTree(ks)
+			return func(k int) { t.Delete(benchItem{key: k}) }
+		})
+	}
+}
+
+// 在固定规模的树上交替插入与删除，衡量分裂与合并频繁发生时节点复用的效果
+func BenchmarkChurn(b *testing.B) {
+	const n = 10_000
+	for _, pool := range []int{0, 64} {
+		b.Run(fmt.Sprintf("pool=%d", pool), func(b *testing.B) {
+			bpt := NewBPlusTree(WithNodePool(pool))
+			for k := 0; k < n; k += 2 {
+				bpt.Insert(k, k)
+			}
+			r := rand.New(rand.NewSource(1))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := r.Intn(n)
+				if bpt.Remove(k) != nil {
+					bpt.Insert(k, k)
+				}
+			}
+		})
+	}
+}
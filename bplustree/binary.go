@@ -0,0 +1,91 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// 二进制格式：[魔数 "BPTB"][版本 1 字节][条目数 uvarint]，之后依次为各条目。
+// 条目按 key 升序排列：第一个 key 以 varint 编码，其后每个 key 以与前一个 key 之差的 uvarint 编码，
+// value 以 varint 编码。有序键的差值通常很小，因此大多数条目只占几个字节
+const (
+	binaryMagic   = "BPTB"
+	binaryVersion = 1
+)
+
+// MarshalBinary 将树中的全部键值对编码为紧凑的带版本二进制格式，
+// 可以通过网络传输或缓存下来，再由 UnmarshalBinary 还原
+func (bpt *BPlusTree) MarshalBinary() ([]byte, error) {
+	count := 0
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		count += len(leaf.keys)
+	}
+	buf := make([]byte, 0, len(binaryMagic)+1+binary.MaxVarintLen64+count*4)
+	buf = append(buf, binaryMagic...)
+	buf = append(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(count))
+	first, prev := true, 0
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, key := range leaf.keys {
+			if first {
+				buf = binary.AppendVarint(buf, int64(key))
+				first = false
+			} else {
+				buf = binary.AppendUvarint(buf, uint64(key-prev))
+			}
+			buf = binary.AppendVarint(buf, int64(leaf.values[i]))
+			prev = key
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary 解码 MarshalBinary 的输出并自底向上重建整棵树，替换树的当前内容
+func (bpt *BPlusTree) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("解码失败：魔数不匹配")
+	}
+	if v := data[len(binaryMagic)]; v != binaryVersion {
+		return fmt.Errorf("解码失败：不支持的格式版本 %d", v)
+	}
+	data = data[len(binaryMagic)+1:]
+	count, n := binary.Uvarint(data)
+	// 每个条目至少占 2 字节，据此拒绝声明了过多条目的输入，避免过度分配
+	if n <= 0 || count > uint64(len(data)-n)/2 {
+		return fmt.Errorf("解码失败：条目数无效")
+	}
+	data = data[n:]
+	keys := make([]int, count)
+	values := make([]int, count)
+	for i := range keys {
+		if i == 0 {
+			key, n := binary.Varint(data)
+			if n <= 0 {
+				return fmt.Errorf("解码失败：第 %d 个条目的 key 无效", i)
+			}
+			keys[i], data = int(key), data[n:]
+		} else {
+			delta, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("解码失败：第 %d 个条目的 key 无效", i)
+			}
+			keys[i], data = keys[i-1]+int(delta), data[n:]
+			if keys[i] < keys[i-1] {
+				return fmt.Errorf("解码失败：第 %d 个条目的 key 未按升序排列", i)
+			}
+		}
+		value, n := binary.Varint(data)
+		if n <= 0 {
+			return fmt.Errorf("解码失败：第 %d 个条目的 value 无效", i)
+		}
+		values[i], data = int(value), data[n:]
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("解码失败：末尾有 %d 字节多余数据", len(data))
+	}
+	bpt.bulkLoad(keys, values)
+	if bpt.indexes != nil {
+		bpt.rebuildIndexes()
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// 值页只使用页头：页类型为 4，[1] 为值的编码（只在首页有效，见 valuecodec.go），[2:4] 为本页存放的字节数，
+// [4:8] 为同一个值的下一页（0 表示最后一页），页头之后为编码后的值。
+// 一个字节值占用一条值页链表，叶节点条目中的 value 为链表首页的页号
+const blobPayloadSize = PageSize - pageHeaderSize
+
+// DefaultMaxValueSize 是 PutBytes 接受的字节值的默认大小上限
+const DefaultMaxValueSize = 1 << 20
+
+// ErrValueTooLarge 表示字节值超过了 WithMaxValueSize 设置的上限
+var ErrValueTooLarge = errors.New("值超过大小上限")
+
+// ErrByteValues 表示树中保存了字节值，而该操作只能处理 int 值
+var ErrByteValues = errors.New("树中保存了字节值")
+
+// ErrIntValues 表示树中保存了 int 值，而该操作只能处理字节值
+var ErrIntValues = errors.New("树中保存了 int 值")
+
+// WithMaxValueSize 设置 PutBytes 接受的字节值的大小上限，默认为 DefaultMaxValueSize
+func WithMaxValueSize(n int) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.maxValueSize = n
+	}
+}
+
+func (t *DiskBPlusTree) valueLimit() int {
+	if t.maxValueSize > 0 {
+		return t.maxValueSize
+	}
+	return DefaultMaxValueSize
+}
+
+// PutBytes 保存 key 对应的字节值，key 已存在时替换原值。值写入从树中分配的值页链表，
+// 超过一页的部分依次溢出到后续的页中，叶节点只保存链表首页的页号。
+// 一棵树只能使用 int 值（Insert、Search 等）或字节值（PutBytes、GetBytes、RemoveBytes、ScanBytes）之一：
+// 已保存 int 值的树上调用 PutBytes 返回 ErrIntValues，保存过字节值的树上调用 int 值的方法返回 ErrByteValues。
+// 保存过字节值的树也不再支持 CompactInto、RepairInto 与 ExportSSTable
+func (t *DiskBPlusTree) PutBytes(key int, value []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(value) > t.valueLimit() {
+		return fmt.Errorf("%w：%d 字节，上限为 %d 字节", ErrValueTooLarge, len(value), t.valueLimit())
+	}
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	if !t.meta.blobs {
+		// 第一次保存字节值：树中不能已有 int 值，否则它们会被当作值页的页号
+		root, err := t.readNode(t.meta.root)
+		if err != nil {
+			return err
+		}
+		if !root.isLeaf || len(root.keys) > 0 {
+			return fmt.Errorf("保存字节值失败：%w", ErrIntValues)
+		}
+	}
+	leaf, path, err := t.descend(key)
+	if err != nil {
+		return err
+	}
+	old := t.meta
+	t.meta.blobs = true
+	// 先写入新值，再让叶节点指向它，最后释放被替换的旧值
+	head, err := t.writeBlob(t.encodeValue(value))
+	if err != nil {
+		return err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos < len(leaf.keys) && leaf.keys[pos] == key {
+		replaced := PageID(leaf.values[pos])
+		leaf.values[pos] = int(head)
+		if err := t.writeNode(leaf); err != nil {
+			return err
+		}
+		if err := t.freeBlob(replaced); err != nil {
+			return err
+		}
+		return t.finish(old)
+	}
+	leaf.keys = insertAt(leaf.keys, pos, key)
+	leaf.values = insertAt(leaf.values, pos, int(head))
+	if err := t.insertUp(path, leaf); err != nil {
+		return err
+	}
+	return t.finish(old)
+}
+
+// GetBytes 返回 key 对应的字节值；key 不存在时 ok 为 false
+func (t *DiskBPlusTree) GetBytes(key int) (value []byte, ok bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	leaf, _, err := t.descend(key)
+	if err != nil {
+		return nil, false, err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		return nil, false, nil
+	}
+	if !t.meta.blobs {
+		return nil, false, fmt.Errorf("读取字节值失败：%w", ErrIntValues)
+	}
+	value, err = t.readBlob(PageID(leaf.values[pos]))
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// RemoveBytes 删除 key 及其字节值，值占用的页归还空闲页链表
+func (t *DiskBPlusTree) RemoveBytes(key int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	leaf, path, err := t.descend(key)
+	if err != nil {
+		return err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		return fmt.Errorf("删除失败：未找到 key = %d", key)
+	}
+	if !t.meta.blobs {
+		return fmt.Errorf("删除失败：%w", ErrIntValues)
+	}
+	head := PageID(leaf.values[pos])
+	leaf.keys = removeAt(leaf.keys, pos)
+	leaf.values = removeAt(leaf.values, pos)
+	old := t.meta
+	if err := t.removeUp(path, leaf); err != nil {
+		return err
+	}
+	if err := t.freeBlob(head); err != nil {
+		return err
+	}
+	return t.finish(old)
+}
+
+// ScanBytes 沿叶节点链表按 key 升序遍历全部字节值，fn 返回 false 时提前结束。
+// 遍历期间持有树的锁，fn 中不得再调用该树的方法；value 在 fn 返回后仍可使用
+func (t *DiskBPlusTree) ScanBytes(fn func(key int, value []byte) bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startDeadline(t.scanTimeout)
+	defer t.unpinAll()
+	if !t.meta.blobs {
+		// 没有保存过字节值的树只有为空时才能当作字节值树遍历
+		empty := true
+		if err := t.scanLocked(func(int, int) bool {
+			empty = false
+			return false
+		}); err != nil {
+			return err
+		}
+		if !empty {
+			return fmt.Errorf("遍历字节值失败：%w", ErrIntValues)
+		}
+		return nil
+	}
+	var err error
+	scanErr := t.scanLocked(func(key, head int) bool {
+		var value []byte
+		if value, err = t.readBlob(PageID(head)); err != nil {
+			return false
+		}
+		return fn(key, value)
+	})
+	return errors.Join(scanErr, err)
+}
+
+// int 值的操作不能用于保存了字节值的树：叶节点条目中存放的是值页链表的页号，
+// 直接修改或删除会使值页泄漏，读出的也不是值本身
+func (t *DiskBPlusTree) checkIntValues(op string) error {
+	if t.meta.blobs {
+		return fmt.Errorf("%s失败：%w，应使用 PutBytes、GetBytes 与 RemoveBytes", op, ErrByteValues)
+	}
+	return nil
+}
+
+// 将以 enc 编码的 value 写入新分配的值页链表，返回首页的页号；空值也占用一页
+func (t *DiskBPlusTree) writeBlob(enc valueEncoding, value []byte) (PageID, error) {
+	n := max((len(value)+blobPayloadSize-1)/blobPayloadSize, 1)
+	ids := make([]PageID, n)
+	for i := range ids {
+		id, err := t.allocatePage()
+		if err != nil {
+			return 0, err
+		}
+		ids[i] = id
+	}
+	for i, id := range ids {
+		chunk := value[min(i*blobPayloadSize, len(value)):min((i+1)*blobPayloadSize, len(value))]
+		clear(t.buf)
+		t.buf[0] = pageTypeBlob
+		if i == 0 {
+			t.buf[1] = byte(enc)
+		}
+		binary.LittleEndian.PutUint16(t.buf[2:], uint16(len(chunk)))
+		if i+1 < n {
+			binary.LittleEndian.PutUint32(t.buf[4:], uint32(ids[i+1]))
+		}
+		copy(t.buf[pageHeaderSize:], chunk)
+		t.meta.checksum.setPageChecksum(id, t.buf)
+		if err := t.store.WritePage(id, t.buf); err != nil {
+			return 0, err
+		}
+	}
+	return ids[0], nil
+}
+
+// 读取并校验值页链表中的一页，返回页中的内容与下一页的页号
+func (t *DiskBPlusTree) readBlobPage(id PageID) ([]byte, PageID, error) {
+	if err := t.store.ReadPage(id, t.buf); err != nil {
+		return nil, 0, err
+	}
+	if err := t.meta.checksum.verifyPageChecksum(id, t.buf); err != nil {
+		return nil, 0, err
+	}
+	if t.buf[0] != pageTypeBlob {
+		return nil, 0, corruptPage(id, "字节值指向了类型为 %d 的页", t.buf[0])
+	}
+	used := int(binary.LittleEndian.Uint16(t.buf[2:]))
+	if used > blobPayloadSize {
+		return nil, 0, corruptPage(id, "值页记录的长度 %d 超过页容量 %d", used, blobPayloadSize)
+	}
+	return t.buf[pageHeaderSize : pageHeaderSize+used], PageID(binary.LittleEndian.Uint32(t.buf[4:])), nil
+}
+
+// 沿值页链表读出完整的字节值并解码
+func (t *DiskBPlusTree) readBlob(head PageID) ([]byte, error) {
+	enc, data, err := t.readBlobData(head)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeValue(head, enc, data)
+}
+
+// 沿值页链表读出值的编码与编码后的数据。链表长度不会超过文件的页数，超过即说明链表成环
+func (t *DiskBPlusTree) readBlobData(head PageID) (valueEncoding, []byte, error) {
+	data := []byte{}
+	var enc valueEncoding
+	for id, pages := head, uint32(0); id != 0; pages++ {
+		if pages >= t.meta.numPages {
+			return 0, nil, corruptPage(head, "值页链表成环")
+		}
+		chunk, next, err := t.readBlobPage(id)
+		if err != nil {
+			return 0, nil, err
+		}
+		if id == head {
+			enc = valueEncoding(t.buf[1])
+		}
+		data = append(data, chunk...)
+		id = next
+	}
+	return enc, data, nil
+}
+
+// 释放值页链表中的全部页
+func (t *DiskBPlusTree) freeBlob(head PageID) error {
+	for id, pages := head, uint32(0); id != 0; pages++ {
+		if pages >= t.meta.numPages {
+			return corruptPage(head, "值页链表成环")
+		}
+		_, next, err := t.readBlobPage(id)
+		if err != nil {
+			return err
+		}
+		if err := t.free(id); err != nil {
+			return err
+		}
+		id = next
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTempDiskTree(t *testing.T, opts ...DiskOption) (*DiskBPlusTree, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree, err := OpenDiskBPlusTree(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, path
+}
+
+func TestBytesRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+	}{
+		{"empty", []byte{}},
+		{"small", []byte("hello")},
+		{"one-page", bytes.Repeat([]byte{1}, blobPayloadSize)},
+		{"spill", bytes.Repeat([]byte("abc"), blobPayloadSize)},
+	}
+	tree, path := openTempDiskTree(t)
+	for i, tt := range tests {
+		if err := tree.PutBytes(i, tt.value); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := OpenDiskBPlusTree(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	for i, tt := range tests {
+		got, ok, err := tree.GetBytes(i)
+		if err != nil || !ok || !bytes.Equal(got, tt.value) {
+			t.Errorf("%s: 重新打开后 GetBytes = %d 字节, %v, %v", tt.name, len(got), ok, err)
+		}
+	}
+	n := 0
+	if err := tree.ScanBytes(func(key int, value []byte) bool {
+		if !bytes.Equal(value, tests[key].value) {
+			t.Errorf("ScanBytes 中 key %d 的值不一致", key)
+		}
+		n++
+		return true
+	}); err != nil || n != len(tests) {
+		t.Fatalf("ScanBytes 遍历 %d 个条目, %v", n, err)
+	}
+	if _, ok, err := tree.GetBytes(100); ok || err != nil {
+		t.Errorf("GetBytes 不存在的 key 返回 %v, %v", ok, err)
+	}
+}
+
+// 替换与删除字节值后值页进入空闲页链表，反复写入不会让文件持续增长
+func TestBytesReuseFreedPages(t *testing.T) {
+	tree, path := openTempDiskTree(t)
+	defer tree.Close()
+	big := bytes.Repeat([]byte{9}, 3*blobPayloadSize)
+	// 替换时先写入新值再释放旧值，第一次替换需要额外的页
+	for range 2 {
+		for i := range 10 {
+			tree.PutBytes(i, big)
+		}
+	}
+	info, _ := os.Stat(path)
+	for range 5 {
+		for i := range 10 {
+			if err := tree.PutBytes(i, big); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := range 10 {
+			if err := tree.RemoveBytes(i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := range 10 {
+			tree.PutBytes(i, big)
+		}
+	}
+	if after, _ := os.Stat(path); after.Size() > info.Size() {
+		t.Fatalf("文件从 %d 字节增长到 %d 字节，释放的值页没有被复用", info.Size(), after.Size())
+	}
+}
+
+func TestBytesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, tree *DiskBPlusTree) error
+		want error
+	}{
+		{
+			name: "too-large",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				return tree.PutBytes(1, make([]byte, 101))
+			},
+			want: ErrValueTooLarge,
+		},
+		{
+			name: "insert-on-byte-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.PutBytes(1, []byte("x"))
+				return tree.Insert(2, 2)
+			},
+			want: ErrByteValues,
+		},
+		{
+			name: "remove-on-byte-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.PutBytes(1, []byte("x"))
+				return tree.Remove(1)
+			},
+			want: ErrByteValues,
+		},
+		{
+			name: "modify-on-byte-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.PutBytes(1, []byte("x"))
+				return tree.Modify(1, 5)
+			},
+			want: ErrByteValues,
+		},
+		{
+			name: "search-on-byte-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.PutBytes(1, []byte("x"))
+				_, err := tree.Search(1)
+				return err
+			},
+			want: ErrByteValues,
+		},
+		{
+			name: "scan-on-byte-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.PutBytes(1, []byte("x"))
+				return tree.Scan(func(int, int) bool { return true })
+			},
+			want: ErrByteValues,
+		},
+		{
+			name: "put-on-int-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.Insert(1, 1)
+				return tree.PutBytes(2, []byte("x"))
+			},
+			want: ErrIntValues,
+		},
+		{
+			name: "get-on-int-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.Insert(1, 1)
+				_, _, err := tree.GetBytes(1)
+				return err
+			},
+			want: ErrIntValues,
+		},
+		{
+			name: "remove-bytes-on-int-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.Insert(1, 1)
+				return tree.RemoveBytes(1)
+			},
+			want: ErrIntValues,
+		},
+		{
+			name: "scan-bytes-on-int-tree",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.Insert(1, 1)
+				return tree.ScanBytes(func(int, []byte) bool { return true })
+			},
+			want: ErrIntValues,
+		},
+		{
+			name: "byte-values-on-compact",
+			run: func(t *testing.T, tree *DiskBPlusTree) error {
+				tree.PutBytes(1, []byte("x"))
+				_, err := tree.CompactInto(nil)
+				return err
+			},
+			want: ErrByteValues,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, _ := openTempDiskTree(t, WithMaxValueSize(100))
+			defer tree.Close()
+			if err := tt.run(t, tree); !errors.Is(err, tt.want) {
+				t.Fatalf("返回 %v，期望 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// 值页损坏时 GetBytes 报告损坏的页，而不是返回错误的内容
+func TestBytesCorruptValuePage(t *testing.T) {
+	tree, path := openTempDiskTree(t)
+	value := bytes.Repeat([]byte("v"), 2*blobPayloadSize)
+	if err := tree.PutBytes(1, value); err != nil {
+		t.Fatal(err)
+	}
+	tree.Close()
+	// 页 0 为文件头，页 1 为根叶节点，值页从页 2 开始
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{0xff}, 3*PageSize+pageHeaderSize+10)
+	f.Close()
+	tree, err = OpenDiskBPlusTree(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	var corrupt *CorruptPageError
+	if _, _, err := tree.GetBytes(1); !errors.As(err, &corrupt) || corrupt.Page != 3 {
+		t.Fatalf("GetBytes 返回 %v，期望页 3 损坏", err)
+	}
+}
+
+func TestNewIntTree(t *testing.T) {
+	type record struct {
+		name string
+		age  int
+	}
+	tree := NewIntTree[record]()
+	tree.Insert(2, record{"b", 20})
+	tree.Insert(1, record{"a", 10})
+	if r, ok := tree.Search(2); !ok || r.name != "b" {
+		t.Fatalf("Search(2) = %v, %v", r, ok)
+	}
+	if k, _, _ := tree.Min(); k != 1 {
+		t.Fatalf("Min() = %d", k)
+	}
+}
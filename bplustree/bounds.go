@@ -0,0 +1,80 @@
+package bplustree
+
+// 空树只有一个没有键的叶节点作为根；非空树中除根以外的节点都至少有一个键，
+// 因此根叶节点为空是判断空树的唯一条件。以下方法在空树上均返回 ok=false 或不调用回调，不会越界访问
+
+// IsEmpty 报告树中是否没有任何键
+func (bpt *BPlusTree) IsEmpty() bool {
+	return bpt.root.isLeaf && len(bpt.root.keys) == 0
+}
+
+// Min 返回最小的键及其值；树为空时 ok 为 false
+func (bpt *BPlusTree) Min() (key, value int, ok bool) {
+	if bpt.IsEmpty() {
+		return 0, 0, false
+	}
+	leaf := bpt.leftmostLeaf()
+	return leaf.keys[0], leaf.values[0], true
+}
+
+// Max 返回最大的键及其值，存在重复键时返回排在最后的一个；树为空时 ok 为 false
+func (bpt *BPlusTree) Max() (key, value int, ok bool) {
+	if bpt.IsEmpty() {
+		return 0, 0, false
+	}
+	node := bpt.root
+	for !node.isLeaf {
+		node = node.children[len(node.children)-1]
+	}
+	last := len(node.keys) - 1
+	return node.keys[last], node.values[last], true
+}
+
+// Range 按 key 升序对 [lo, hi] 内的每个键值对调用 fn，fn 返回 false 时提前结束。
+// 树为空或 lo > hi 时不调用 fn
+func (bpt *BPlusTree) Range(lo, hi int, fn func(key, value int) bool) {
+	if bpt.IsEmpty() || lo > hi {
+		return
+	}
+	for leaf := bpt.findLeaf(bpt.root, lo); leaf != nil; leaf = leaf.next {
+		for i, k := range leaf.keys {
+			if k < lo || bpt.expired(k) {
+				continue
+			}
+			if k > hi {
+				return
+			}
+			if !fn(k, leaf.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// IsEmpty 在读锁保护下报告树中是否没有任何键
+func (c *ConcurrentBPlusTree) IsEmpty() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.IsEmpty()
+}
+
+// Min 在读锁保护下返回最小的键及其值
+func (c *ConcurrentBPlusTree) Min() (key, value int, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Min()
+}
+
+// Max 在读锁保护下返回最大的键及其值
+func (c *ConcurrentBPlusTree) Max() (key, value int, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Max()
+}
+
+// Range 在读锁保护下遍历 [lo, hi] 内的键值对；fn 中不能再调用该树的写方法，否则会死锁
+func (c *ConcurrentBPlusTree) Range(lo, hi int, fn func(key, value int) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.tree.Range(lo, hi, fn)
+}
@@ -0,0 +1,719 @@
+package bplustree
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxKeys 定义每个节点能够存储的最大关键字数量（适用于叶节点和内部节点）
+const MaxKeys = 3
+
+// getMinKeys 计算并返回叶节点和内部节点所需的最小关键字数量
+func getMinKeys() int {
+	// 如果最大关键字数为偶数，则最小值为其一半
+	if MaxKeys%2 == 0 {
+		return MaxKeys / 2
+	}
+	// 如果最大关键字数为奇数，则最小值为其一半向上取整
+	return (MaxKeys + 1) / 2
+}
+
+// Node 表示 B+ 树的节点
+type Node struct {
+	isLeaf   bool    // 是否为叶节点
+	keys     []int   // 对于叶节点：存储键；对于内部节点：每个关键词为对应子节点的最大键
+	values   []int   // 仅叶节点有效：保存对应的值
+	next     *Node   // 仅叶节点有效：链表指针
+	children []*Node // 仅内部节点有效：指向子节点
+	gen      uint64  // 创建节点时树的代号，小于树的当前代号说明节点可能被只读句柄共享
+
+	latch sync.RWMutex // 节点闩锁，仅由 LatchedBPlusTree 使用
+}
+
+// NewNode 创建一个新节点
+func NewNode(isLeaf bool) *Node {
+	return &Node{
+		isLeaf:   isLeaf,
+		keys:     make([]int, 0, MaxKeys),
+		values:   make([]int, 0, MaxKeys),
+		next:     nil,
+		children: make([]*Node, 0, MaxKeys+1),
+	}
+}
+
+// BPlusTree 表示 B+ 树
+type BPlusTree struct {
+	root  *Node
+	trace io.Writer // 非 nil 时逐步记录每个操作的执行过程
+	ops   opCounters
+
+	readerStacks bool // 创建快照与只读句柄时是否记录调用栈
+
+	pool     []*Node    // 已释放、等待复用的节点
+	poolSize int        // 节点池容量，0 表示不复用节点
+	path     []pathStep // findPath 复用的下降路径缓冲区
+	arena    *nodeArena // 非 nil 时新节点从区块中分配
+	edge     rightEdge  // 最右叶节点及其路径的缓存
+
+	splitBias float64 // 分裂最右叶节点时留在左侧的比例，0 表示均分
+
+	gen      uint64 // 当前代号，每次 ForkReadOnly 递增，新节点带有当前代号
+	shared   bool   // 是否仍有只读句柄，为 true 时写操作先复制被共享的节点，见 unshare
+	unlinked bool   // 叶节点的链表指针可能指向其他版本（只读句柄），遍历叶节点时按树结构下降
+
+	strict bool                          // 是否在操作前检查将要访问的下标，把内部状态异常转为错误
+	err    atomic.Pointer[InternalError] // 严格模式下使树停止服务的错误
+
+	ttl map[int]time.Time // InsertWithTTL 设置的各 key 的过期时刻
+	now func() time.Time  // 判断过期时使用的时钟，nil 表示 time.Now
+
+	watch watchHub // Watch 注册的变更订阅
+	hooks Hooks    // 结构变化的回调
+
+	logger *slog.Logger // 非 nil 时记录结构事件与异常
+
+	indexes map[string]*secondaryIndex // AddIndex 注册的二级索引
+}
+
+// Option 用于在创建树时调整其可选行为
+type Option func(*BPlusTree)
+
+// NewBPlusTree 创建一个新的 B+ 树
+func NewBPlusTree(opts ...Option) *BPlusTree {
+	bpt := &BPlusTree{
+		root: NewNode(true),
+	}
+	for _, opt := range opts {
+		opt(bpt)
+	}
+	return bpt
+}
+
+// 更新内部节点的关键词：每个关键词等于对应子节点的最大键
+func (bpt *BPlusTree) updateInternalKeys(node *Node) {
+	if node == nil || node.isLeaf {
+		return
+	}
+	node.keys = node.keys[:0] // 复用原有数组
+	for _, child := range node.children {
+		// 每个子节点至少有一个键
+		node.keys = append(node.keys, child.keys[len(child.keys)-1])
+	}
+}
+
+// 下降路径上的一步：经过的内部节点及所选子节点的下标。写操作自根向下记录路径，
+// 再沿路径自下而上传播分裂、合并与关键词的变化，节点因此不需要父指针
+type pathStep struct {
+	node  *Node
+	index int
+}
+
+// 若孩子结点的最大键发生变化，则沿 path 向上更新父节点中的对应关键词。
+// path 的最后一步是 child 的父节点。父节点中的关键词未变，或孩子不是父节点的最后一个子节点时，
+// 更上层的关键词不受影响，此时停止向上传播
+func (bpt *BPlusTree) updateParent(child *Node, path []pathStep) {
+	for level := len(path) - 1; level >= 0; level-- {
+		parent, i := path[level].node, path[level].index
+		if parent.keys[i] == maxKey(child) {
+			return
+		}
+		parent.keys[i] = maxKey(child)
+		if bpt.trace != nil {
+			bpt.tracef("step=update-parent keys=%v", parent.keys)
+		}
+		if i != len(parent.children)-1 {
+			return
+		}
+		child = parent
+	}
+}
+
+// 在内部节点中选择应继续下降的子节点下标：第一个最大键不小于 key 的子节点，否则为最后一个。
+// 关键词按升序排列，用二分查找定位
+func childIndex(node *Node, key int) int {
+	if i := sort.SearchInts(node.keys, key); i < len(node.keys) {
+		return i
+	}
+	return len(node.children) - 1
+}
+
+// 从根开始查找应存放 key 的叶节点
+func (bpt *BPlusTree) findLeaf(node *Node, key int) *Node {
+	for !node.isLeaf {
+		i := childIndex(node, key)
+		if bpt.trace != nil {
+			// 比较依据需要格式化字符串，只在开启追踪时生成
+			bpt.tracef("step=descend keys=%v cmp=%s child=%d", node.keys, routeReason(node, key, i), i)
+		}
+		node = node.children[i]
+	}
+	return node
+}
+
+// 与 findLeaf 相同地从根下降到叶节点，同时返回沿途的路径，供写操作向上传播结构变化。
+// 路径复用树上的缓冲区，只在下一次写操作之前有效
+func (bpt *BPlusTree) findPath(key int) (*Node, []pathStep) {
+	path := bpt.path[:0]
+	node := bpt.root
+	for !node.isLeaf {
+		i := childIndex(node, key)
+		if bpt.trace != nil {
+			bpt.tracef("step=descend keys=%v cmp=%s child=%d", node.keys, routeReason(node, key, i), i)
+		}
+		path = append(path, pathStep{node, i})
+		node = node.children[i]
+	}
+	bpt.path = path
+	return node, path
+}
+
+// 叶节点分裂：当叶节点中键数超过 MaxKeys 时。path 为到 leaf 父节点为止的下降路径，为空表示 leaf 是根。
+// 分裂不改变子树的最大键，因此祖先的关键词无需更新
+func (bpt *BPlusTree) splitLeaf(leaf *Node, path []pathStep) {
+	newLeaf := bpt.newNode(true)
+	total := len(leaf.keys)
+	mid := total / 2 // 前 mid 个保留，后半部分移至 newLeaf
+	if leaf.next == nil {
+		// 最右叶节点按分裂偏置多留一些在左侧
+		mid = splitPoint(total, bpt.splitBias, MaxKeys)
+	}
+
+	// 分裂时同步分裂 keys 与 values
+	newLeaf.keys = append(newLeaf.keys, leaf.keys[mid:]...)
+	newLeaf.values = append(newLeaf.values, leaf.values[mid:]...)
+	leaf.keys = leaf.keys[:mid]
+	leaf.values = leaf.values[:mid]
+
+	// 调整链表指针
+	newLeaf.next = leaf.next
+	leaf.next = newLeaf
+	bpt.ops.splits.Add(1)
+	bpt.hookSplit(leaf, newLeaf)
+	if bpt.trace != nil {
+		bpt.tracef("step=split-leaf left=%v right=%v", leaf.keys, newLeaf.keys)
+	}
+
+	if len(path) == 0 {
+		// 当前叶为根，则构造新根（内部节点）
+		newRoot := bpt.newNode(false)
+		newRoot.children = append(newRoot.children, leaf)
+		newRoot.children = append(newRoot.children, newLeaf)
+		newRoot.keys = append(newRoot.keys, leaf.keys[len(leaf.keys)-1])
+		newRoot.keys = append(newRoot.keys, newLeaf.keys[len(newLeaf.keys)-1])
+		bpt.root = newRoot
+		if bpt.trace != nil {
+			bpt.tracef("step=new-root keys=%v", newRoot.keys)
+		}
+	} else {
+		// leaf 在父节点中的位置由下降路径给出，在其后插入 newLeaf
+		parent, pos := path[len(path)-1].node, path[len(path)-1].index
+		// 插入子节点到 children 切片
+		parent.children = append(parent.children, nil)
+		copy(parent.children[pos+2:], parent.children[pos+1:])
+		parent.children[pos+1] = newLeaf
+		// 插入关键字到 keys 切片
+		parent.keys = append(parent.keys, 0)
+		copy(parent.keys[pos+2:], parent.keys[pos+1:])
+		parent.keys[pos+1] = newLeaf.keys[len(newLeaf.keys)-1]
+		parent.keys[pos] = leaf.keys[len(leaf.keys)-1]
+		if len(parent.children) > MaxKeys {
+			bpt.splitInternal(parent, path[:len(path)-1])
+		}
+	}
+}
+
+// 内部节点分裂：当内部节点的子节点数超过 MaxKeys 时。path 的含义与 splitLeaf 相同
+func (bpt *BPlusTree) splitInternal(node *Node, path []pathStep) {
+	newNode := bpt.newNode(false)
+	totalChildren := len(node.children)
+	mid := totalChildren / 2 // 左侧保留 mid 个子节点，右侧移至 newNode
+	newNode.children = append(newNode.children, node.children[mid:]...)
+	newNode.keys = append(newNode.keys, node.keys[mid:]...)
+	// 关键词与子节点一一对应，直接随子节点一同切分，无需重新读取子节点
+	node.children = node.children[:mid]
+	node.keys = node.keys[:mid]
+	bpt.ops.splits.Add(1)
+	bpt.hookSplit(node, newNode)
+	if bpt.trace != nil {
+		bpt.tracef("step=split-internal left=%v right=%v", node.keys, newNode.keys)
+	}
+
+	if len(path) == 0 {
+		newRoot := bpt.newNode(false)
+		newRoot.children = append(newRoot.children, node)
+		newRoot.children = append(newRoot.children, newNode)
+		newRoot.keys = append(newRoot.keys, node.keys[len(node.keys)-1])
+		newRoot.keys = append(newRoot.keys, newNode.keys[len(newNode.keys)-1])
+		bpt.root = newRoot
+		if bpt.trace != nil {
+			bpt.tracef("step=new-root keys=%v", newRoot.keys)
+		}
+	} else {
+		parent, pos := path[len(path)-1].node, path[len(path)-1].index
+		// 插入子节点到 children 切片
+		parent.children = append(parent.children, nil)
+		copy(parent.children[pos+2:], parent.children[pos+1:])
+		parent.children[pos+1] = newNode
+		// 插入关键字到 keys 切片
+		parent.keys = append(parent.keys, 0)
+		copy(parent.keys[pos+2:], parent.keys[pos+1:])
+		parent.keys[pos+1] = newNode.keys[len(newNode.keys)-1]
+		parent.keys[pos] = node.keys[len(node.keys)-1]
+		if len(parent.children) > MaxKeys {
+			bpt.splitInternal(parent, path[:len(path)-1])
+		}
+	}
+}
+
+// 删除后对节点进行借补或合并，保证节点达到最少关键字数要求。
+// 借补与合并只改写父节点中受影响子节点对应的关键词，不重新读取其余子节点。
+// path 为到 node 父节点为止的下降路径，为空表示 node 是路径的起点：它是根时才会下降为新根
+func (bpt *BPlusTree) rebalance(node *Node, path []pathStep) {
+	minRequired := getMinKeys() // 对于叶节点与内部节点均采用同一标准（非根节点最少关键字数）
+	// 先只检查节点自身：关键字充足时无需调整，也不必访问父节点
+	if len(node.keys) >= minRequired && len(node.keys) > 1 {
+		return
+	}
+	// 若 node 为根节点，特殊处理
+	if len(path) == 0 {
+		// 若根为内部节点且只有一个子节点，则下降为新根
+		if node == bpt.root && !node.isLeaf && len(node.children) == 1 {
+			newRoot := node.children[0]
+			bpt.root = newRoot
+			if bpt.trace != nil {
+				bpt.tracef("step=collapse-root keys=%v", newRoot.keys)
+			}
+			// 旧根交给 freeNode，未开启节点复用时由垃圾回收器回收
+			bpt.freeNode(node)
+		}
+		return
+	}
+	if len(node.keys) >= minRequired {
+		return // 已满足最小要求
+	}
+	if bpt.trace != nil {
+		bpt.tracef("step=underflow keys=%v min=%d", node.keys, minRequired)
+	}
+
+	// node 在父节点中的位置由下降路径给出
+	parent, index := path[len(path)-1].node, path[len(path)-1].index
+	path = path[:len(path)-1]
+	var leftSibling *Node
+	var rightSibling *Node
+	if index-1 >= 0 {
+		leftSibling = parent.children[index-1]
+	}
+	if index+1 < len(parent.children) {
+		rightSibling = parent.children[index+1]
+	}
+
+	if node.isLeaf {
+		// 叶节点：先尝试从左侧兄弟借补
+		if leftSibling != nil && len(leftSibling.keys) > minRequired {
+			// 从左侧兄弟借最后一个键值对
+			borrowedKey := leftSibling.keys[len(leftSibling.keys)-1]
+			borrowedValue := leftSibling.values[len(leftSibling.values)-1]
+			leftSibling.keys = leftSibling.keys[:len(leftSibling.keys)-1]
+			leftSibling.values = leftSibling.values[:len(leftSibling.values)-1]
+			node.keys = insertAt(node.keys, 0, borrowedKey)
+			node.values = insertAt(node.values, 0, borrowedValue)
+			parent.keys[index-1] = maxKey(leftSibling)
+			bpt.ops.borrows.Add(1)
+			if bpt.trace != nil {
+				bpt.tracef("step=borrow-left sibling=%v node=%v", leftSibling.keys, node.keys)
+			}
+			bpt.hookBorrow(leftSibling, node)
+			return
+		} else if rightSibling != nil && len(rightSibling.keys) > minRequired {
+			// 从右侧兄弟借第一个键值对
+			borrowedKey := rightSibling.keys[0]
+			borrowedValue := rightSibling.values[0]
+			rightSibling.keys = rightSibling.keys[1:]
+			rightSibling.values = rightSibling.values[1:]
+			node.keys = append(node.keys, borrowedKey)
+			node.values = append(node.values, borrowedValue)
+			parent.keys[index] = maxKey(node)
+			bpt.ops.borrows.Add(1)
+			if bpt.trace != nil {
+				bpt.tracef("step=borrow-right sibling=%v node=%v", rightSibling.keys, node.keys)
+			}
+			bpt.hookBorrow(rightSibling, node)
+			return
+		} else {
+			// 无法借补，则合并节点（优先与左侧合并）
+			if leftSibling != nil {
+				// 将当前节点的内容合并到左侧兄弟
+				leftSibling.keys = append(leftSibling.keys, node.keys...)
+				leftSibling.values = append(leftSibling.values, node.values...)
+				leftSibling.next = node.next
+				// 在父节点中删除当前节点对应的指针和关键字
+				parent.children = append(parent.children[:index], parent.children[index+1:]...)
+				parent.keys = append(parent.keys[:index], parent.keys[index+1:]...)
+				// 被删除的节点随后交给 freeNode，未开启节点复用时由垃圾回收器回收
+				parent.keys[index-1] = maxKey(leftSibling)
+				bpt.ops.merges.Add(1)
+				if bpt.trace != nil {
+					bpt.tracef("step=merge-left merged=%v parent=%v", leftSibling.keys, parent.keys)
+				}
+				bpt.hookMerge(leftSibling, node)
+				bpt.freeNode(node)
+				bpt.rebalance(parent, path)
+			} else if rightSibling != nil {
+				// 将右侧兄弟合并到当前节点
+				node.keys = append(node.keys, rightSibling.keys...)
+				node.values = append(node.values, rightSibling.values...)
+				node.next = rightSibling.next
+				parent.children = append(parent.children[:index+1], parent.children[index+2:]...)
+				parent.keys = append(parent.keys[:index+1], parent.keys[index+2:]...)
+				// 被删除的节点随后交给 freeNode，未开启节点复用时由垃圾回收器回收
+				parent.keys[index] = maxKey(node)
+				bpt.ops.merges.Add(1)
+				if bpt.trace != nil {
+					bpt.tracef("step=merge-right merged=%v parent=%v", node.keys, parent.keys)
+				}
+				bpt.hookMerge(node, rightSibling)
+				bpt.freeNode(rightSibling)
+				bpt.rebalance(parent, path)
+			}
+		}
+	} else {
+		// 内部节点：处理方式与叶节点类似，不过借补或合并时调整的是子节点指针，
+		// 子节点对应的关键词随指针一同移动
+		if leftSibling != nil && len(leftSibling.keys) > minRequired {
+			// 从左侧兄弟借出其最后一个子节点
+			borrowedChild := leftSibling.children[len(leftSibling.children)-1]
+			borrowedKey := leftSibling.keys[len(leftSibling.keys)-1]
+			leftSibling.children = leftSibling.children[:len(leftSibling.children)-1]
+			leftSibling.keys = leftSibling.keys[:len(leftSibling.keys)-1]
+			node.children = insertAt(node.children, 0, borrowedChild)
+			node.keys = insertAt(node.keys, 0, borrowedKey)
+			parent.keys[index-1] = maxKey(leftSibling)
+			bpt.ops.borrows.Add(1)
+			if bpt.trace != nil {
+				bpt.tracef("step=borrow-left sibling=%v node=%v", leftSibling.keys, node.keys)
+			}
+			bpt.hookBorrow(leftSibling, node)
+			return
+		} else if rightSibling != nil && len(rightSibling.keys) > minRequired {
+			// 从右侧兄弟借出其第一个子节点
+			borrowedChild := rightSibling.children[0]
+			borrowedKey := rightSibling.keys[0]
+			rightSibling.children = rightSibling.children[1:]
+			rightSibling.keys = rightSibling.keys[1:]
+			node.children = append(node.children, borrowedChild)
+			node.keys = append(node.keys, borrowedKey)
+			parent.keys[index] = maxKey(node)
+			bpt.ops.borrows.Add(1)
+			if bpt.trace != nil {
+				bpt.tracef("step=borrow-right sibling=%v node=%v", rightSibling.keys, node.keys)
+			}
+			bpt.hookBorrow(rightSibling, node)
+			return
+		} else {
+			// 合并内部节点（优先与左侧合并）
+			if leftSibling != nil {
+				// 将当前节点的所有子节点合并到左侧兄弟
+				leftSibling.children = append(leftSibling.children, node.children...)
+				leftSibling.keys = append(leftSibling.keys, node.keys...)
+				parent.children = append(parent.children[:index], parent.children[index+1:]...)
+				parent.keys = append(parent.keys[:index], parent.keys[index+1:]...)
+				// 被删除的节点随后交给 freeNode，未开启节点复用时由垃圾回收器回收
+				parent.keys[index-1] = maxKey(leftSibling)
+				bpt.ops.merges.Add(1)
+				if bpt.trace != nil {
+					bpt.tracef("step=merge-left merged=%v parent=%v", leftSibling.keys, parent.keys)
+				}
+				bpt.hookMerge(leftSibling, node)
+				bpt.freeNode(node)
+				bpt.rebalance(parent, path)
+			} else if rightSibling != nil {
+				// 将右侧兄弟的所有子节点合并到当前节点
+				node.children = append(node.children, rightSibling.children...)
+				node.keys = append(node.keys, rightSibling.keys...)
+				parent.children = append(parent.children[:index+1], parent.children[index+2:]...)
+				parent.keys = append(parent.keys[:index+1], parent.keys[index+2:]...)
+				// 被删除的节点随后交给 freeNode，未开启节点复用时由垃圾回收器回收
+				parent.keys[index] = maxKey(node)
+				bpt.ops.merges.Add(1)
+				if bpt.trace != nil {
+					bpt.tracef("step=merge-right merged=%v parent=%v", node.keys, parent.keys)
+				}
+				bpt.hookMerge(node, rightSibling)
+				bpt.freeNode(rightSibling)
+				bpt.rebalance(parent, path)
+			}
+		}
+	}
+}
+
+// 返回节点的最大键
+func maxKey(node *Node) int {
+	return node.keys[len(node.keys)-1]
+}
+
+// Insert 插入操作：在叶节点中插入 key 与 value，并在必要时分裂。
+// key 大于树中所有键时直接追加到缓存的最右叶节点，不从根下降
+func (bpt *BPlusTree) Insert(key, value int) {
+	if bpt.trace != nil {
+		bpt.tracef("op=insert key=%d value=%d", key, value)
+	}
+	if bpt.err.Load() != nil {
+		return
+	}
+	if bpt.strict && bpt.checkPath("insert", key, false) != nil {
+		return
+	}
+	if bpt.ttl != nil {
+		bpt.clearDeadline(key)
+	}
+	bpt.unshare(key, false)
+	leaf, path, ok := bpt.rightmostPath(key)
+	if !ok {
+		leaf, path = bpt.findPath(key)
+	} else if bpt.trace != nil {
+		bpt.tracef("step=rightmost-leaf keys=%v", leaf.keys)
+	}
+	rightmost := leaf.next == nil
+	bpt.insertIntoLeaf(leaf, path, key, value)
+	if rightmost {
+		// 插入落在最右叶节点上，下一次插入很可能也是如此：缓存失效时重新记录右边界
+		bpt.refreshRightEdge()
+	}
+}
+
+// 在已定位的叶节点中插入键值对，并在必要时更新父节点关键词或分裂；path 为到叶节点父节点为止的下降路径
+func (bpt *BPlusTree) insertIntoLeaf(leaf *Node, path []pathStep, key, value int) {
+	pos := sort.SearchInts(leaf.keys, key)
+
+	// Insert key and value
+	leaf.keys = append(leaf.keys, 0)
+	copy(leaf.keys[pos+1:], leaf.keys[pos:])
+	leaf.keys[pos] = key
+	leaf.values = append(leaf.values, 0)
+	copy(leaf.values[pos+1:], leaf.values[pos:])
+	leaf.values[pos] = value
+	bpt.ops.inserts.Add(1)
+	if bpt.trace != nil {
+		bpt.tracef("step=leaf-insert keys=%v pos=%d", leaf.keys, pos)
+	}
+	if bpt.watched() {
+		bpt.notify(Event{Kind: EventInsert, Key: key, Value: value})
+	}
+	if bpt.indexes != nil {
+		bpt.updateIndexes(key, nil, &value)
+	}
+
+	// Update parent only if the new key is the maximum and differs from the old maximum
+	if pos == len(leaf.keys)-1 && (len(leaf.keys) == 1 || key > leaf.keys[len(leaf.keys)-2]) {
+		bpt.updateParent(leaf, path)
+	}
+
+	if len(leaf.keys) > MaxKeys {
+		bpt.splitLeaf(leaf, path)
+	}
+}
+
+// Remove 删除 key；key 不存在时返回错误
+func (bpt *BPlusTree) Remove(key int) error {
+	if bpt.trace != nil {
+		bpt.tracef("op=remove key=%d", key)
+	}
+	if e := bpt.err.Load(); e != nil {
+		return e
+	}
+	if bpt.strict {
+		if err := bpt.checkPath("remove", key, true); err != nil {
+			return err
+		}
+	}
+	bpt.unshare(key, true)
+	leaf, path := bpt.findPath(key)
+	err := bpt.removeFromLeaf(leaf, path, key)
+	if err == nil && bpt.ttl != nil && !bpt.contains(key) {
+		// 过期时刻按 key 记录，删除其中一个重复条目后其余条目仍按原时刻过期
+		delete(bpt.ttl, key)
+	}
+	return err
+}
+
+// 从已定位的叶节点中删除 key，并在必要时更新父节点关键词或借补/合并；path 为到叶节点父节点为止的下降路径
+func (bpt *BPlusTree) removeFromLeaf(leaf *Node, path []pathStep, key int) error {
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		if bpt.trace != nil {
+			bpt.tracef("step=not-found keys=%v", leaf.keys)
+		}
+		return fmt.Errorf("删除失败：未找到 key = %d", key)
+	}
+	if bpt.watched() {
+		bpt.notify(Event{Kind: EventDelete, Key: key, Value: leaf.values[pos]})
+	}
+	if bpt.indexes != nil {
+		bpt.updateIndexes(key, &leaf.values[pos], nil)
+	}
+
+	leaf.keys = append(leaf.keys[:pos], leaf.keys[pos+1:]...)
+	leaf.values = append(leaf.values[:pos], leaf.values[pos+1:]...)
+	bpt.ops.removes.Add(1)
+	if bpt.trace != nil {
+		bpt.tracef("step=leaf-remove keys=%v pos=%d", leaf.keys, pos)
+	}
+	if pos == len(leaf.keys) {
+		if pos > 0 {
+			bpt.updateParent(leaf, path)
+		} else if len(path) > 0 && path[len(path)-1].index > 0 {
+			// 偏置分裂产生的最右叶节点可能只有一个键，删除后为空：它没有最大键，
+			// 先以左侧兄弟的最大键作为其关键词，随后的借补或合并据此维护祖先的关键词
+			parent, i := path[len(path)-1].node, path[len(path)-1].index
+			bpt.updateParent(parent.children[i-1], path)
+		}
+	}
+	if len(leaf.keys) < getMinKeys() && len(path) > 0 {
+		bpt.rebalance(leaf, path)
+	}
+	return nil
+}
+
+// Modify 修改 key 对应的 value；key 不存在时返回错误
+func (bpt *BPlusTree) Modify(key, newValue int) error {
+	if bpt.trace != nil {
+		bpt.tracef("op=modify key=%d value=%d", key, newValue)
+	}
+	if e := bpt.err.Load(); e != nil {
+		return e
+	}
+	if bpt.strict {
+		if err := bpt.checkPath("modify", key, false); err != nil {
+			return err
+		}
+	}
+	if bpt.expired(key) {
+		return fmt.Errorf("修改失败：未找到 key = %d", key)
+	}
+	bpt.unshare(key, false)
+	return bpt.modifyInLeaf(bpt.findLeaf(bpt.root, key), key, newValue)
+}
+
+// 在已定位的叶节点中修改 key 对应的 value
+func (bpt *BPlusTree) modifyInLeaf(leaf *Node, key, newValue int) error {
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		if bpt.trace != nil {
+			bpt.tracef("step=not-found keys=%v", leaf.keys)
+		}
+		return fmt.Errorf("修改失败：未找到 key = %d", key)
+	}
+	if bpt.watched() {
+		bpt.notify(Event{Kind: EventUpdate, Key: key, Value: newValue, OldValue: leaf.values[pos]})
+	}
+	if bpt.indexes != nil {
+		bpt.updateIndexes(key, &leaf.values[pos], &newValue)
+	}
+	leaf.values[pos] = newValue
+	bpt.ops.modifies.Add(1)
+	if bpt.trace != nil {
+		bpt.tracef("step=leaf-modify keys=%v pos=%d", leaf.keys, pos)
+	}
+	return nil
+}
+
+// Search 查找操作：返回 key 对应的 value；若不存在返回 -1
+func (bpt *BPlusTree) Search(key int) (value int) {
+	if bpt.trace != nil {
+		bpt.tracef("op=search key=%d", key)
+	}
+	// 严格模式下树已停止服务或下降路径检查发现异常时返回 -1
+	value = -1
+	if bpt.err.Load() != nil {
+		return value
+	}
+	if bpt.strict && bpt.checkPath("search", key, false) != nil {
+		return value
+	}
+	if bpt.expired(key) {
+		return value
+	}
+	value = searchLeaf(bpt.findLeaf(bpt.root, key), key)
+	if bpt.trace != nil {
+		bpt.tracef("step=result value=%d", value)
+	}
+	return value
+}
+
+// 查找 key 对应的 value，并以第二个返回值区分"不存在"与值恰为 -1 的情况；已过期的 key 视为不存在
+func (bpt *BPlusTree) lookup(key int) (int, bool) {
+	if bpt.expired(key) {
+		return 0, false
+	}
+	leaf := bpt.findLeaf(bpt.root, key)
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		return 0, false
+	}
+	return leaf.values[pos], true
+}
+
+// 在叶节点中查找 key 对应的 value；若不存在返回 -1
+func searchLeaf(leaf *Node, key int) int {
+	// 二分查找第一个不小于 key 的位置
+	if i := sort.SearchInts(leaf.keys, key); i < len(leaf.keys) && leaf.keys[i] == key {
+		return leaf.values[i]
+	}
+	return -1
+}
+
+// PrintTree 打印整棵树（层次遍历，用于调试）
+func (bpt *BPlusTree) PrintTree() {
+	if bpt.strict && bpt.checkTree("print-tree") != nil {
+		return
+	}
+	firstKeys := make(map[int]int) // 节点 ID -> 该节点的第一个关键词，用于输出父节点信息
+	currentLevel := 0
+	bpt.Walk(func(level int, n NodeInfo) {
+		if level != currentLevel {
+			fmt.Println()
+			currentLevel = level
+		}
+		nodeType := "Leaf"
+		if !n.IsLeaf {
+			nodeType = "Internal"
+		}
+		parentKey := -1
+		if k, ok := firstKeys[n.ParentID]; ok {
+			parentKey = k
+		}
+		if len(n.Keys) > 0 {
+			firstKeys[n.ID] = n.Keys[0]
+		}
+		fmt.Printf("[%s, %d: ", nodeType, parentKey)
+		for _, k := range n.Keys {
+			fmt.Printf("%d ", k)
+		}
+		fmt.Print("]")
+		if !n.IsLeaf {
+			fmt.Print("  ")
+		}
+	})
+	fmt.Println()
+}
+
+// PrintLeafValues 新增函数：一次性输出所有叶节点对应的值
+func (bpt *BPlusTree) PrintLeafValues() {
+	if bpt.strict && bpt.checkTree("print-leaf-values") != nil {
+		return
+	}
+	// 从最左侧叶节点开始沿链表输出
+	fmt.Print("所有叶节点对应的值：")
+	bpt.WalkLeaves(func(n NodeInfo) bool {
+		for _, value := range n.Values {
+			fmt.Printf("%d ", value)
+		}
+		return true
+	})
+	fmt.Println()
+}
@@ -0,0 +1,159 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStoreUnavailable 表示熔断器已断开：存储后端连续失败，请求被直接拒绝
+var ErrStoreUnavailable = errors.New("存储后端不可用")
+
+// BreakerState 是熔断器的状态
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota // 正常放行请求
+	BreakerOpen                       // 快速失败，后台探测恢复
+)
+
+func (s BreakerState) String() string {
+	if s == BreakerOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+// BreakerStore 是带熔断器的 PageStore 包装：底层存储连续失败 threshold 次后断开，
+// 之后的请求立即返回 ErrStoreUnavailable，而不是在故障的后端上排队阻塞；
+// 断开期间后台每隔 interval 读取一次文件头页进行探测，成功后自动恢复放行
+type BreakerStore struct {
+	store     PageStore
+	threshold int
+	interval  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int   // 连续失败次数
+	lastErr  error // 导致断开的最后一个错误
+	stop     chan struct{}
+	done     chan struct{} // 探测 goroutine 退出时关闭
+}
+
+// NewBreakerStore 在 store 之外包装熔断器
+func NewBreakerStore(store PageStore, threshold int, interval time.Duration) *BreakerStore {
+	return &BreakerStore{
+		store:     store,
+		threshold: max(threshold, 1),
+		interval:  interval,
+		stop:      make(chan struct{}),
+	}
+}
+
+// WithCircuitBreaker 为树的存储加上熔断器。与 WithBufferPool 同时使用时应放在其之前，
+// 使缓冲池位于熔断器之外，命中缓存的读取不受后端故障影响
+func WithCircuitBreaker(threshold int, interval time.Duration) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.store = NewBreakerStore(t.store, threshold, interval)
+	}
+}
+
+// State 返回熔断器的当前状态
+func (b *BreakerStore) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// 熔断器断开时返回错误，否则放行
+func (b *BreakerStore) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen {
+		return fmt.Errorf("%w：%v", ErrStoreUnavailable, b.lastErr)
+	}
+	return nil
+}
+
+// 记录一次请求结果；连续失败达到阈值时断开并启动后台探测
+func (b *BreakerStore) record(err error) error {
+	// 读取超出范围的页是正常的逻辑结果，不代表后端故障
+	if err == nil || errors.Is(err, ErrPageOutOfRange) {
+		b.mu.Lock()
+		b.failures = 0
+		b.mu.Unlock()
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == BreakerClosed && b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.lastErr = err
+		b.done = make(chan struct{})
+		go b.probe(b.done)
+	}
+	return err
+}
+
+// 后台探测：定期读取文件头页，成功后恢复放行
+func (b *BreakerStore) probe(done chan struct{}) {
+	defer close(done)
+	buf := make([]byte, PageSize)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+		err := b.store.ReadPage(0, buf)
+		if err != nil && !errors.Is(err, ErrPageOutOfRange) {
+			continue
+		}
+		b.mu.Lock()
+		b.state = BreakerClosed
+		b.failures = 0
+		b.lastErr = nil
+		b.mu.Unlock()
+		return
+	}
+}
+
+// ReadPage 在熔断器闭合时读取第 id 页
+func (b *BreakerStore) ReadPage(id PageID, buf []byte) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	return b.record(b.store.ReadPage(id, buf))
+}
+
+// WritePage 在熔断器闭合时写入第 id 页
+func (b *BreakerStore) WritePage(id PageID, buf []byte) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	return b.record(b.store.WritePage(id, buf))
+}
+
+// Sync 在熔断器闭合时同步底层存储
+func (b *BreakerStore) Sync() error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	return b.record(b.store.Sync())
+}
+
+// Close 停止后台探测并关闭底层存储
+func (b *BreakerStore) Close() error {
+	close(b.stop)
+	b.mu.Lock()
+	done := b.done
+	b.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	return b.store.Close()
+}
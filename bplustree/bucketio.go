@@ -0,0 +1,78 @@
+package bplustree
+
+import (
+	"fmt"
+	"io"
+)
+
+// ExportBucket 将名为 name 的桶中的全部键值对按 key 升序写入 w，格式与 BPlusTree.ExportJSON 相同，
+// 因此导出的数据既可以由 ImportBucket 导入另一个 Store，也可以由 ImportJSON 载入内存中的树。
+// 导出期间持有 Store 的锁
+func (s *Store) ExportBucket(name string, w io.Writer) error {
+	b := s.Bucket(name)
+	if b == nil {
+		return fmt.Errorf("导出桶失败：%w：%s", ErrBucketNotFound, name)
+	}
+	jw := newJSONWriter(w)
+	if err := b.Scan(func(key, value int) bool {
+		jw.add(key, value)
+		return true
+	}); err != nil {
+		return fmt.Errorf("导出桶失败：%w", err)
+	}
+	if err := jw.finish(); err != nil {
+		return fmt.Errorf("导出桶失败：%w", err)
+	}
+	return nil
+}
+
+// ImportBucket 从 r 中读取 ExportBucket（或 ExportJSON）格式的键值对，写入新建的名为 name 的桶。
+// 同名的桶已存在时返回 ErrBucketExists；输入有误或写入出错时不创建桶，已写入的页归还空闲页链表
+func (s *Store) ImportBucket(name string, r io.Reader) (*Bucket, error) {
+	keys, values, err := readJSON(r)
+	if err != nil {
+		return nil, fmt.Errorf("导入桶失败：%w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.create(name, func(t *DiskBPlusTree) error { return insertAll(t, keys, values) })
+	if err != nil {
+		return nil, fmt.Errorf("导入桶失败：%w", err)
+	}
+	return b, nil
+}
+
+// CloneBucket 把名为 src 的桶的全部键值对复制到新建的名为 dst 的桶中，两个桶此后互不影响。
+// dst 已存在时返回 ErrBucketExists；复制在 Store 的锁内完成，不会看到并发写入的中间状态
+func (s *Store) CloneBucket(src, dst string) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	from, ok := s.buckets[src]
+	if !ok {
+		return nil, fmt.Errorf("复制桶失败：%w：%s", ErrBucketNotFound, src)
+	}
+	var keys, values []int
+	if err := s.runLocked(from, func(t *DiskBPlusTree) error {
+		return t.Scan(func(key, value int) bool {
+			keys = append(keys, key)
+			values = append(values, value)
+			return true
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("复制桶失败：%w", err)
+	}
+	b, err := s.create(dst, func(t *DiskBPlusTree) error { return insertAll(t, keys, values) })
+	if err != nil {
+		return nil, fmt.Errorf("复制桶失败：%w", err)
+	}
+	return b, nil
+}
+
+func insertAll(t *DiskBPlusTree, keys, values []int) error {
+	for i, key := range keys {
+		if err := t.Insert(key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
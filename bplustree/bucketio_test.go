@@ -0,0 +1,188 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBucketExportImport(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"empty", 0},
+		{"single-leaf", 10},
+		{"multi-level", 3 * DiskMaxKeys},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := openTempStore(t)
+			defer s.Close()
+			a, _ := s.CreateBucket("a")
+			want := NewBPlusTree()
+			for i := range tt.n {
+				a.Insert(i, i*10)
+				want.Insert(i, i*10)
+			}
+			var got, ref bytes.Buffer
+			if err := s.ExportBucket("a", &got); err != nil {
+				t.Fatal(err)
+			}
+			// 与内存中的树导出的 JSON 逐字节相同
+			want.ExportJSON(&ref)
+			if got.String() != ref.String() {
+				t.Fatalf("ExportBucket 输出\n%s\n期望\n%s", got.String(), ref.String())
+			}
+
+			dst, path := openTempStore(t)
+			b, err := dst.ImportBucket("b", bytes.NewReader(got.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(bucketContents(t, b)); n != tt.n {
+				t.Fatalf("导入的桶有 %d 个条目，期望 %d", n, tt.n)
+			}
+			dst = reopenStore(t, dst, path)
+			defer dst.Close()
+			contents := bucketContents(t, dst.Bucket("b"))
+			for i := range tt.n {
+				if contents[i] != i*10 {
+					t.Fatalf("重新打开后 key %d 的值为 %d", i, contents[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCloneBucket(t *testing.T) {
+	s, path := openTempStore(t)
+	a, _ := s.CreateBucket("a")
+	for i := range 2 * DiskMaxKeys {
+		a.Insert(i, i)
+	}
+	c, err := s.CloneBucket("a", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.root == a.root {
+		t.Fatal("复制的桶与原桶共用根节点页")
+	}
+	// 两个桶互不影响
+	a.Modify(0, -1)
+	c.Remove(1)
+	s = reopenStore(t, s, path)
+	defer s.Close()
+	if got := s.Buckets(); !slices.Equal(got, []string{"a", "c"}) {
+		t.Fatalf("Buckets() = %v", got)
+	}
+	gotA, gotC := bucketContents(t, s.Bucket("a")), bucketContents(t, s.Bucket("c"))
+	if len(gotA) != 2*DiskMaxKeys || gotA[0] != -1 || gotA[1] != 1 {
+		t.Fatalf("桶 a 有 %d 个条目，key 0、1 的值为 %d、%d", len(gotA), gotA[0], gotA[1])
+	}
+	if _, ok := gotC[1]; len(gotC) != 2*DiskMaxKeys-1 || ok || gotC[0] != 0 {
+		t.Fatalf("桶 c 有 %d 个条目，key 0 的值为 %d", len(gotC), gotC[0])
+	}
+}
+
+func TestBucketIOErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s *Store) error
+		want error // nil 表示只要求返回错误
+	}{
+		{"export-missing", func(s *Store) error { return s.ExportBucket("x", new(bytes.Buffer)) }, ErrBucketNotFound},
+		{"import-existing", func(s *Store) error {
+			_, err := s.ImportBucket("a", strings.NewReader("[]"))
+			return err
+		}, ErrBucketExists},
+		{"import-empty-name", func(s *Store) error {
+			_, err := s.ImportBucket("", strings.NewReader("[]"))
+			return err
+		}, nil},
+		{"import-not-array", func(s *Store) error {
+			_, err := s.ImportBucket("b", strings.NewReader(`{"key":1}`))
+			return err
+		}, nil},
+		{"import-bad-element", func(s *Store) error {
+			_, err := s.ImportBucket("b", strings.NewReader(`[{"key":1,"value":1},{"key":"x"}]`))
+			return err
+		}, nil},
+		{"import-truncated", func(s *Store) error {
+			_, err := s.ImportBucket("b", strings.NewReader(`[{"key":1,"value":1}`))
+			return err
+		}, nil},
+		{"clone-missing", func(s *Store) error { _, err := s.CloneBucket("x", "b"); return err }, ErrBucketNotFound},
+		{"clone-existing", func(s *Store) error { _, err := s.CloneBucket("a", "a"); return err }, ErrBucketExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := openTempStore(t)
+			defer s.Close()
+			a, _ := s.CreateBucket("a")
+			a.Insert(1, 1)
+			err := tt.run(s)
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Fatalf("返回 %v，期望 %v", err, tt.want)
+			}
+			// 失败的操作不留下新桶，已有的桶保持不变
+			if got := s.Buckets(); !slices.Equal(got, []string{"a"}) {
+				t.Fatalf("Buckets() = %v", got)
+			}
+			if v, err := a.Search(1); v != 1 || err != nil {
+				t.Fatalf("桶 a 的 Search(1) = %d, %v", v, err)
+			}
+		})
+	}
+}
+
+// 写入初始内容失败时新桶的页归还空闲页链表，桶不出现在目录中
+func TestImportBucketFailureFreesPages(t *testing.T) {
+	s, path := openTempStore(t)
+	var in bytes.Buffer
+	want := NewBPlusTree()
+	var keys []int
+	for i := range 2 * DiskMaxKeys {
+		want.Insert(i, i)
+		keys = append(keys, i)
+	}
+	want.ExportJSON(&in)
+	data := in.Bytes()
+	if _, err := s.ImportBucket("a", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteBucket("a"); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+
+	fail := errors.New("写入失败")
+	s.mu.Lock()
+	_, err := s.create("b", func(t *DiskBPlusTree) error {
+		if err := insertAll(t, keys, keys); err != nil {
+			return err
+		}
+		return fail
+	})
+	s.mu.Unlock()
+	if !errors.Is(err, fail) {
+		t.Fatalf("create 返回 %v", err)
+	}
+	if s.Bucket("b") != nil {
+		t.Fatal("写入失败的桶出现在目录中")
+	}
+	if _, err := s.ImportBucket("c", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.Stat(path); after.Size() > info.Size() {
+		t.Fatalf("文件从 %d 字节增长到 %d 字节，失败的导入没有归还页", info.Size(), after.Size())
+	}
+	s = reopenStore(t, s, path)
+	defer s.Close()
+	if got := s.Buckets(); !slices.Equal(got, []string{"c"}) {
+		t.Fatalf("Buckets() = %v", got)
+	}
+}
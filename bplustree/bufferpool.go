@@ -0,0 +1,202 @@
+package bplustree
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BufferPool 是位于 PageStore 之前的页缓存：热点页常驻内存，写入先落在缓存中，
+// 在被淘汰或 Sync 时才写回底层存储。缓存的页数超过内存预算时按 LRU 淘汰未被固定（pin）的页；
+// 若全部页都被固定，则暂时超出预算，待页被释放（unpin）后再收缩。
+// BufferPool 本身实现了 PageStore，可以直接交给 NewDiskBPlusTree 使用
+type BufferPool struct {
+	mu       sync.Mutex
+	store    PageStore
+	capacity int               // 预算允许缓存的页数
+	frames   map[PageID]*frame // 已缓存的页
+	lru      *list.List        // 按最近使用排序的页，表头为最近使用
+	stats    BufferPoolStats
+}
+
+// 缓存中的一页
+type frame struct {
+	id    PageID
+	data  []byte
+	pins  int  // 固定计数，大于 0 时不可淘汰
+	dirty bool // 是否有尚未写回底层存储的修改
+	elem  *list.Element
+}
+
+// BufferPoolStats 记录缓冲池的命中与淘汰情况
+type BufferPoolStats struct {
+	Hits      uint64 // 请求的页已在缓存中
+	Misses    uint64 // 请求的页需要从底层存储读取
+	Evictions uint64 // 被淘汰的页数
+	Resident  int    // 当前缓存的页数
+}
+
+// NewBufferPool 在 store 之前创建缓冲池，budget 为可用于缓存页的内存字节数（至少缓存一页）
+func NewBufferPool(store PageStore, budget int) *BufferPool {
+	return &BufferPool{
+		store:    store,
+		capacity: max(budget/PageSize, 1),
+		frames:   make(map[PageID]*frame),
+		lru:      list.New(),
+	}
+}
+
+// WithBufferPool 在树的存储之前加一层内存预算为 budget 字节的缓冲池
+func WithBufferPool(budget int) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.store = NewBufferPool(t.store, budget)
+	}
+}
+
+// 返回第 id 页的缓存，不在缓存中时从底层存储读取；调用方须持有锁
+func (p *BufferPool) fetch(id PageID) (*frame, error) {
+	if f, ok := p.frames[id]; ok {
+		p.stats.Hits++
+		p.lru.MoveToFront(f.elem)
+		return f, nil
+	}
+	p.stats.Misses++
+	data := make([]byte, PageSize)
+	if err := p.store.ReadPage(id, data); err != nil {
+		return nil, err
+	}
+	return p.add(id, data)
+}
+
+// 将一页加入缓存，并在超出预算时淘汰；调用方须持有锁
+func (p *BufferPool) add(id PageID, data []byte) (*frame, error) {
+	f := &frame{id: id, data: data}
+	f.elem = p.lru.PushFront(f)
+	p.frames[id] = f
+	if err := p.evict(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// 从最久未使用的页开始淘汰未被固定的页（keep 除外），直到缓存页数不超过预算；调用方须持有锁
+func (p *BufferPool) evict(keep *frame) error {
+	for e := p.lru.Back(); e != nil && len(p.frames) > p.capacity; {
+		f := e.Value.(*frame)
+		e = e.Prev()
+		if f.pins > 0 || f == keep {
+			continue
+		}
+		if f.dirty {
+			if err := p.store.WritePage(f.id, f.data); err != nil {
+				return fmt.Errorf("淘汰页 %d 失败：%w", f.id, err)
+			}
+		}
+		p.lru.Remove(f.elem)
+		delete(p.frames, f.id)
+		p.stats.Evictions++
+	}
+	return nil
+}
+
+// Pin 固定第 id 页（必要时从底层存储读入），固定期间该页不会被淘汰。
+// 每次 Pin 都须对应一次 Unpin
+func (p *BufferPool) Pin(id PageID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := p.fetch(id)
+	if err != nil {
+		return err
+	}
+	f.pins++
+	return nil
+}
+
+// Unpin 释放一次对第 id 页的固定
+func (p *BufferPool) Unpin(id PageID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, ok := p.frames[id]
+	if !ok || f.pins == 0 {
+		panic(fmt.Sprintf("bplustree: 页 %d 未被固定", id))
+	}
+	f.pins--
+	if f.pins == 0 && len(p.frames) > p.capacity {
+		// 写回失败的脏页会保留在缓存中，由之后的 Sync 再次尝试并报告错误
+		_ = p.evict(nil)
+	}
+}
+
+// ReadPage 从缓存读取第 id 页到 buf
+func (p *BufferPool) ReadPage(id PageID, buf []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := p.fetch(id)
+	if err != nil {
+		return err
+	}
+	copy(buf, f.data)
+	return nil
+}
+
+// WritePage 将 buf 写入缓存中的第 id 页，并将其标记为脏页
+func (p *BufferPool) WritePage(id PageID, buf []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if f, ok := p.frames[id]; ok {
+		copy(f.data, buf)
+		f.dirty = true
+		p.lru.MoveToFront(f.elem)
+		return nil
+	}
+	f, err := p.add(id, append([]byte(nil), buf[:PageSize]...))
+	if err != nil {
+		return err
+	}
+	f.dirty = true
+	return nil
+}
+
+// 将全部脏页写回底层存储；调用方须持有锁
+func (p *BufferPool) flush() error {
+	var errs []error
+	for _, f := range p.frames {
+		if !f.dirty {
+			continue
+		}
+		if err := p.store.WritePage(f.id, f.data); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.dirty = false
+	}
+	return errors.Join(errs...)
+}
+
+// Sync 写回全部脏页并同步底层存储
+func (p *BufferPool) Sync() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.store.Sync()
+}
+
+// Close 写回全部脏页并关闭底层存储
+func (p *BufferPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.flush()
+	return errors.Join(err, p.store.Close())
+}
+
+// Stats 返回缓冲池的统计信息
+func (p *BufferPool) Stats() BufferPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Resident = len(p.frames)
+	return s
+}
@@ -0,0 +1,196 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// 记录写页次数、可以注入写页错误的内存 PageStore
+type recordingStore struct {
+	*ArenaPager
+	writes  map[PageID]int
+	syncs   int
+	failing bool // 为 true 时 WritePage 返回错误
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{ArenaPager: NewArenaPager(16), writes: make(map[PageID]int)}
+}
+
+var errInjected = errors.New("注入的写页错误")
+
+func (s *recordingStore) WritePage(id PageID, buf []byte) error {
+	if s.failing {
+		return errInjected
+	}
+	s.writes[id]++
+	return s.ArenaPager.WritePage(id, buf)
+}
+
+func (s *recordingStore) Sync() error {
+	s.syncs++
+	return s.ArenaPager.Sync()
+}
+
+func filledPage(b byte) []byte {
+	return bytes.Repeat([]byte{b}, PageSize)
+}
+
+// 按 LRU 淘汰：reads 依次读取的页号，期望的统计与仍在缓存中的页
+func TestBufferPoolLRU(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		reads    []PageID
+		want     BufferPoolStats
+		resident []PageID
+	}{
+		{"hits", 2, []PageID{0, 1, 0, 1}, BufferPoolStats{Hits: 2, Misses: 2, Resident: 2}, []PageID{0, 1}},
+		{"evict-oldest", 2, []PageID{0, 1, 2}, BufferPoolStats{Misses: 3, Evictions: 1, Resident: 2}, []PageID{1, 2}},
+		{"touch-keeps", 2, []PageID{0, 1, 0, 2}, BufferPoolStats{Hits: 1, Misses: 3, Evictions: 1, Resident: 2}, []PageID{0, 2}},
+		{"budget-below-page", 0, []PageID{0, 1, 1}, BufferPoolStats{Hits: 1, Misses: 2, Evictions: 1, Resident: 1}, []PageID{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newRecordingStore()
+			for id := range PageID(4) {
+				store.WritePage(id, filledPage(byte(id)))
+			}
+			pool := NewBufferPool(store, tt.capacity*PageSize)
+			buf := make([]byte, PageSize)
+			for _, id := range tt.reads {
+				if err := pool.ReadPage(id, buf); err != nil || buf[0] != byte(id) {
+					t.Fatalf("ReadPage(%d) = %d, %v", id, buf[0], err)
+				}
+			}
+			if got := pool.Stats(); got != tt.want {
+				t.Fatalf("Stats() = %+v，期望 %+v", got, tt.want)
+			}
+			for _, id := range tt.resident {
+				if _, ok := pool.frames[id]; !ok {
+					t.Fatalf("页 %d 应仍在缓存中", id)
+				}
+			}
+		})
+	}
+}
+
+// 写入先落在缓存中，淘汰或 Sync 时才写回底层存储；干净的页被淘汰时不写回
+func TestBufferPoolWriteBack(t *testing.T) {
+	store := newRecordingStore()
+	pool := NewBufferPool(store, 2*PageSize)
+	pool.WritePage(0, filledPage(1))
+	pool.WritePage(0, filledPage(2))
+	pool.WritePage(1, filledPage(3))
+	if len(store.writes) != 0 {
+		t.Fatalf("写回前底层存储收到了写入 %v", store.writes)
+	}
+	pool.WritePage(2, filledPage(4)) // 淘汰页 0
+	if store.writes[0] != 1 {
+		t.Fatalf("淘汰脏页 0 时写回了 %d 次，期望 1 次", store.writes[0])
+	}
+	buf := make([]byte, PageSize)
+	if err := store.ReadPage(0, buf); err != nil || buf[0] != 2 {
+		t.Fatalf("底层存储中的页 0 = %d, %v，期望最后一次写入的 2", buf[0], err)
+	}
+	if err := pool.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if store.writes[1] != 1 || store.writes[2] != 1 || store.syncs != 1 {
+		t.Fatalf("Sync 后写回 %v，同步 %d 次", store.writes, store.syncs)
+	}
+	// 再次 Sync 没有脏页需要写回
+	pool.Sync()
+	pool.ReadPage(0, buf) // 淘汰干净的页 1
+	if store.writes[1] != 1 || store.writes[2] != 1 {
+		t.Fatalf("干净的页被再次写回：%v", store.writes)
+	}
+}
+
+// 被固定的页不会被淘汰，全部页都被固定时暂时超出预算，释放后再收缩
+func TestBufferPoolPinning(t *testing.T) {
+	store := newRecordingStore()
+	for id := range PageID(3) {
+		store.WritePage(id, filledPage(byte(id)))
+	}
+	pool := NewBufferPool(store, PageSize)
+	for id := range PageID(3) {
+		if err := pool.Pin(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := pool.Stats(); s.Resident != 3 || s.Evictions != 0 {
+		t.Fatalf("全部页被固定时 Stats() = %+v", s)
+	}
+	pool.Unpin(0)
+	pool.Unpin(1)
+	if s := pool.Stats(); s.Resident != 1 || s.Evictions != 2 {
+		t.Fatalf("释放后 Stats() = %+v，期望只保留仍被固定的页 2", s)
+	}
+	pool.Unpin(2)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("释放未被固定的页应 panic")
+		}
+	}()
+	pool.Unpin(2)
+}
+
+// 写回失败时报告错误，脏页保留在缓存中，之后的 Sync 再次写回
+func TestBufferPoolWriteBackError(t *testing.T) {
+	store := newRecordingStore()
+	pool := NewBufferPool(store, PageSize)
+	pool.WritePage(0, filledPage(1))
+	store.failing = true
+	if err := pool.WritePage(1, filledPage(2)); !errors.Is(err, errInjected) {
+		t.Fatalf("淘汰时写回失败，WritePage 返回 %v", err)
+	}
+	if err := pool.Sync(); !errors.Is(err, errInjected) {
+		t.Fatalf("Sync 返回 %v", err)
+	}
+	store.failing = false
+	if err := pool.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, PageSize)
+	if err := store.ReadPage(0, buf); err != nil || buf[0] != 1 {
+		t.Fatalf("恢复后页 0 = %d, %v", buf[0], err)
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 预算远小于树的大小时，树的读写照常进行，Close 写回全部脏页
+func TestBufferPoolDiskTree(t *testing.T) {
+	store := newRecordingStore()
+	tree, err := NewDiskBPlusTree(store, WithBufferPool(2*PageSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 20 * DiskMaxKeys
+	for k := range n {
+		if err := tree.Insert(k, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool := tree.store.(*BufferPool)
+	if s := pool.Stats(); s.Evictions == 0 || s.Resident > 2+len(tree.pinned) {
+		t.Fatalf("Stats() = %+v", s)
+	}
+	// 关闭缓冲池会关闭底层存储，这里只写回脏页后直接在底层存储上重新打开
+	if err := pool.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewDiskBPlusTree(store.ArenaPager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range n {
+		if v, err := reopened.Search(k); err != nil || v != k {
+			t.Fatalf("Search(%d) = %d, %v", k, v, err)
+		}
+	}
+	tree.Close()
+}
@@ -0,0 +1,116 @@
+package bplustree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// CorruptPageError 表示从存储中读到的页已损坏（校验和不匹配或内容不合法），
+// 而不是把损坏的键值返回给调用方。可用 errors.As 取得出错的页号
+type CorruptPageError struct {
+	Page   PageID
+	Reason string
+}
+
+func (e *CorruptPageError) Error() string {
+	return fmt.Sprintf("页 %d 已损坏：%s", e.Page, e.Reason)
+}
+
+func corruptPage(id PageID, format string, args ...any) error {
+	return &CorruptPageError{Page: id, Reason: fmt.Sprintf(format, args...)}
+}
+
+// ChecksumAlgorithm 是页校验和的算法，在创建树文件时选定并记录在文件头中
+type ChecksumAlgorithm uint8
+
+const (
+	// ChecksumCRC32 为 CRC32（IEEE），默认算法
+	ChecksumCRC32 ChecksumAlgorithm = iota
+	// ChecksumCRC32C 为 CRC32C（Castagnoli），在支持 SSE4.2 或 ARMv8 CRC 指令的 CPU 上更快
+	ChecksumCRC32C
+	// ChecksumXXHash64 为 64 位的 xxHash，速度快且意外碰撞的概率远低于 32 位 CRC
+	ChecksumXXHash64
+	// ChecksumSHA256 为截断到 64 位的 SHA-256。CRC 是线性的，可以被有意构造的修改绕过，
+	// SHA-256 则不能；但它不带密钥，能够重写整个文件的攻击者仍可重新计算校验和，
+	// 需要防篡改时应配合 EncryptedPager 使用
+	ChecksumSHA256
+)
+
+func (a ChecksumAlgorithm) String() string {
+	switch a {
+	case ChecksumCRC32:
+		return "CRC32"
+	case ChecksumCRC32C:
+		return "CRC32C"
+	case ChecksumXXHash64:
+		return "xxHash64"
+	case ChecksumSHA256:
+		return "SHA-256"
+	}
+	return fmt.Sprintf("ChecksumAlgorithm(%d)", uint8(a))
+}
+
+func (a ChecksumAlgorithm) valid() bool {
+	return a <= ChecksumSHA256
+}
+
+// WithChecksum 选择新建树文件的页校验和算法，默认为 ChecksumCRC32。
+// 打开已有文件时使用文件头中记录的算法，与此处指定的算法不一致时返回错误
+func WithChecksum(a ChecksumAlgorithm) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.meta.checksum = a
+		t.checksumSet = true
+	}
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// 校验和在页中的位置：文件头位于 [32:40]，节点页位于页头的 [8:16]。
+// 32 位的算法只使用低 4 字节，高 4 字节为 0
+func checksumOffset(id PageID) int {
+	if id == 0 {
+		return 32
+	}
+	return 8
+}
+
+const checksumSize = 8
+
+// 计算页的校验和，校验和字段本身不参与计算
+func (a ChecksumAlgorithm) pageChecksum(id PageID, buf []byte) uint64 {
+	off := checksumOffset(id)
+	head, tail := buf[:off], buf[off+checksumSize:PageSize]
+	switch a {
+	case ChecksumCRC32C:
+		return uint64(crc32.Update(crc32.Update(0, castagnoliTable, head), castagnoliTable, tail))
+	case ChecksumXXHash64:
+		d := xxhash.New()
+		d.Write(head)
+		d.Write(tail)
+		return d.Sum64()
+	case ChecksumSHA256:
+		h := sha256.New()
+		h.Write(head)
+		h.Write(tail)
+		return binary.LittleEndian.Uint64(h.Sum(nil))
+	}
+	return uint64(crc32.Update(crc32.ChecksumIEEE(head), crc32.IEEETable, tail))
+}
+
+// 在写出前为页填入校验和
+func (a ChecksumAlgorithm) setPageChecksum(id PageID, buf []byte) {
+	binary.LittleEndian.PutUint64(buf[checksumOffset(id):], a.pageChecksum(id, buf))
+}
+
+// 校验读到的页，不匹配时返回 *CorruptPageError
+func (a ChecksumAlgorithm) verifyPageChecksum(id PageID, buf []byte) error {
+	want := binary.LittleEndian.Uint64(buf[checksumOffset(id):])
+	if got := a.pageChecksum(id, buf); got != want {
+		return corruptPage(id, "%s 校验和不匹配（存储值 %x，计算值 %x）", a, want, got)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package bplustree
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+var checksumAlgorithms = []ChecksumAlgorithm{ChecksumCRC32, ChecksumCRC32C, ChecksumXXHash64, ChecksumSHA256}
+
+// 返回 key 所在叶节点的页号
+func leafPage(t *testing.T, tree *DiskBPlusTree, key int) PageID {
+	t.Helper()
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	defer tree.unpinAll()
+	leaf, _, err := tree.descend(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.id
+}
+
+// 将文件中第 id 页偏移 off 处的字节取反
+func flipFileByte(t *testing.T, path string, id PageID, off int) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b := make([]byte, 1)
+	pos := int64(id)*PageSize + int64(off)
+	if _, err := f.ReadAt(b, pos); err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0xff
+	if _, err := f.WriteAt(b, pos); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChecksumRoundTrip(t *testing.T) {
+	for _, a := range checksumAlgorithms {
+		t.Run(a.String(), func(t *testing.T) {
+			tree, path := openTempDiskTree(t, WithChecksum(a))
+			for k := range 200 {
+				if err := tree.Insert(k, k*3); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tree.Close()
+			// 不指定算法时使用文件头记录的算法
+			tree, err := OpenDiskBPlusTree(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tree.meta.checksum != a {
+				t.Fatalf("文件头记录的算法为 %s，期望 %s", tree.meta.checksum, a)
+			}
+			for k := range 200 {
+				if v, err := tree.Search(k); err != nil || v != k*3 {
+					t.Fatalf("Search(%d) = %d, %v", k, v, err)
+				}
+			}
+			tree.Close()
+			for _, other := range checksumAlgorithms {
+				tree, err := OpenDiskBPlusTree(path, WithChecksum(other))
+				if (err == nil) != (other == a) {
+					t.Fatalf("以 %s 打开 %s 文件返回 %v", other, a, err)
+				}
+				if err == nil {
+					tree.Close()
+				}
+			}
+		})
+	}
+}
+
+// 页中任何一个字节被改动都会被发现：节点页报告为 *CorruptPageError 并隔离其区间，文件头损坏时打开失败
+func TestChecksumCorruption(t *testing.T) {
+	tests := []struct {
+		name string
+		page func(tree *DiskBPlusTree) PageID // 要损坏的页
+		off  int                              // 页内偏移
+	}{
+		{"leaf-payload", func(tree *DiskBPlusTree) PageID { return leafPage(t, tree, 3*DiskMaxKeys) }, pageHeaderSize + 5},
+		{"leaf-header", func(tree *DiskBPlusTree) PageID { return leafPage(t, tree, 3*DiskMaxKeys) }, 2},
+		{"leaf-checksum", func(tree *DiskBPlusTree) PageID { return leafPage(t, tree, 3*DiskMaxKeys) }, 8},
+		{"file-header", func(*DiskBPlusTree) PageID { return 0 }, 20},
+		{"header-checksum", func(*DiskBPlusTree) PageID { return 0 }, 33},
+	}
+	for _, a := range checksumAlgorithms {
+		for _, tt := range tests {
+			t.Run(a.String()+"/"+tt.name, func(t *testing.T) {
+				tree, path := openTempDiskTree(t, WithChecksum(a))
+				// 足够多的键使树有多个叶节点，损坏一个叶节点只影响它覆盖的区间
+				for k := range 4 * DiskMaxKeys {
+					tree.Insert(k, k)
+				}
+				id := tt.page(tree)
+				tree.Close()
+				flipFileByte(t, path, id, tt.off)
+
+				tree, err := OpenDiskBPlusTree(path)
+				if id == 0 {
+					var corrupt *CorruptPageError
+					if !errors.As(err, &corrupt) || corrupt.Page != 0 {
+						t.Fatalf("文件头损坏时打开返回 %v", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer tree.Close()
+				var corrupt *CorruptPageError
+				if _, err := tree.Search(3 * DiskMaxKeys); !errors.As(err, &corrupt) || corrupt.Page != id {
+					t.Fatalf("Search 返回 %v，期望页 %d 损坏", err, id)
+				}
+				if _, err := tree.Search(3 * DiskMaxKeys); !errors.Is(err, ErrRangeUnavailable) {
+					t.Fatalf("再次 Search 返回 %v，期望区间已隔离", err)
+				}
+				// 其余区间照常服务
+				if v, err := tree.Search(0); err != nil || v != 0 {
+					t.Fatalf("Search(0) = %d, %v", v, err)
+				}
+			})
+		}
+	}
+}
+
+func TestChecksumUnknownAlgorithm(t *testing.T) {
+	tree, path := openTempDiskTree(t)
+	tree.Close()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := make([]byte, PageSize)
+	f.ReadAt(page, 0)
+	page[28] = 9
+	ChecksumCRC32.setPageChecksum(0, page)
+	f.WriteAt(page, 0)
+	f.Close()
+	if _, err := OpenDiskBPlusTree(path); err == nil {
+		t.Fatal("未知的校验和算法应使打开失败")
+	}
+}
+
+// 校验和不覆盖校验和字段本身，32 位算法的高 4 字节为 0
+func TestPageChecksum(t *testing.T) {
+	for _, a := range checksumAlgorithms {
+		t.Run(a.String(), func(t *testing.T) {
+			for _, id := range []PageID{0, 7} {
+				buf := make([]byte, PageSize)
+				for i := range buf {
+					buf[i] = byte(i * 7)
+				}
+				a.setPageChecksum(id, buf)
+				if err := a.verifyPageChecksum(id, buf); err != nil {
+					t.Fatal(err)
+				}
+				sum := a.pageChecksum(id, buf)
+				if (a == ChecksumCRC32 || a == ChecksumCRC32C) && sum>>32 != 0 {
+					t.Fatalf("32 位校验和 %x 的高位不为 0", sum)
+				}
+				buf[PageSize-1] ^= 1
+				if err := a.verifyPageChecksum(id, buf); err == nil {
+					t.Fatal("修改最后一个字节后校验应失败")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Compact 将树重建为紧凑的形态：收集全部键值对后自底向上整体构建，
+// 使节点数降到最少，消除大量删除后残留的半空节点
+func (bpt *BPlusTree) Compact() {
+	keys, values := bpt.entries()
+	bpt.tracef("op=compact entries=%d", len(keys))
+	bpt.bulkLoad(keys, values)
+}
+
+// 按 key 升序收集全部键值对
+func (bpt *BPlusTree) entries() (keys, values []int) {
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		keys = append(keys, leaf.keys...)
+		values = append(values, leaf.values...)
+	}
+	return keys, values
+}
+
+// Compact 在读锁下构建紧凑的新树，期间读操作照常进行；构建完成后在写锁下替换。
+// 若读锁释放与写锁获取之间插入了写操作，则放弃新树，在写锁下重新构建
+func (c *ConcurrentBPlusTree) Compact() {
+	c.mu.RLock()
+	writes := c.writes
+	compacted := &BPlusTree{gen: c.tree.gen}
+	if c.tree.arena != nil {
+		// 读锁下可能有多个 Compact 同时构建，各自使用独立的区块
+		compacted.arena = &nodeArena{chunk: c.tree.arena.chunk}
+	}
+	compacted.bulkLoad(c.tree.entries())
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writes != writes {
+		c.tree.Compact()
+		return
+	}
+	c.tree.root = compacted.root
+}
+
+// 向空存储中按 key 顺序流式写入键值对，自底向上构建每页尽量填满的紧凑树。
+// 叶节点按顺序占用连续的页；为保证最后一个叶节点不少于最小关键词数，
+// 总是暂缓写出前一个叶节点，结束时在两者之间重新分配
+type packedBuilder struct {
+	out       *DiskBPlusTree
+	prev, cur *diskNode
+	level     []diskNode // 已写出节点的最大键与页号，用于构建上一层
+	err       error
+}
+
+func newPackedBuilder(dst PageStore, sum ChecksumAlgorithm) (*packedBuilder, error) {
+	probe := make([]byte, PageSize)
+	if err := dst.ReadPage(0, probe); !errors.Is(err, ErrPageOutOfRange) {
+		return nil, fmt.Errorf("目标存储不为空")
+	}
+	out := &DiskBPlusTree{store: dst, buf: make([]byte, PageSize), meta: diskMeta{numPages: 1, checksum: sum}}
+	return &packedBuilder{out: out}, nil
+}
+
+// 追加一个键值对，key 须不小于之前追加的 key；返回 false 表示写页失败，错误由 finish 返回
+func (b *packedBuilder) add(key, value int) bool {
+	if b.cur == nil || len(b.cur.keys) == DiskMaxKeys {
+		if b.prev != nil && !b.write(b.prev) {
+			return false
+		}
+		next := &diskNode{id: PageID(b.out.meta.numPages), isLeaf: true}
+		b.out.meta.numPages++
+		if b.cur != nil {
+			b.cur.next = next.id
+		}
+		b.prev, b.cur = b.cur, next
+	}
+	b.cur.keys = append(b.cur.keys, key)
+	b.cur.values = append(b.cur.values, value)
+	return true
+}
+
+func (b *packedBuilder) write(n *diskNode) bool {
+	if b.err = b.out.writeNode(n); b.err != nil {
+		return false
+	}
+	b.level = append(b.level, diskNode{id: n.id, keys: []int{n.maxKey()}})
+	return true
+}
+
+// 写出剩余的叶节点与全部内部节点，并写入文件头
+func (b *packedBuilder) finish() error {
+	if b.err != nil {
+		return b.err
+	}
+	if b.cur == nil {
+		// 空树只有一个空的根叶节点
+		root, err := b.out.allocate(true)
+		if err != nil {
+			return err
+		}
+		if err := b.out.writeNode(root); err != nil {
+			return err
+		}
+		return b.writeRoot(root.id)
+	}
+	if b.prev != nil {
+		if len(b.cur.keys) < diskMinKeys {
+			// 将前一个叶节点末尾的条目移给最后一个叶节点，使两者都不少于最小关键词数
+			total := len(b.prev.keys) + len(b.cur.keys)
+			for len(b.cur.keys) < total/2 {
+				moveEntry(b.prev, len(b.prev.keys)-1, b.cur, 0)
+			}
+		}
+		if !b.write(b.prev) {
+			return b.err
+		}
+	}
+	if !b.write(b.cur) {
+		return b.err
+	}
+
+	// 逐层向上构建内部节点，直到只剩一个根
+	level := b.level
+	for len(level) > 1 {
+		var parents []diskNode
+		for _, size := range spread(len(level), DiskMaxKeys) {
+			parent := &diskNode{id: PageID(b.out.meta.numPages)}
+			b.out.meta.numPages++
+			for _, child := range level[:size] {
+				parent.keys = append(parent.keys, child.keys[0])
+				parent.children = append(parent.children, child.id)
+			}
+			level = level[size:]
+			if err := b.out.writeNode(parent); err != nil {
+				return err
+			}
+			parents = append(parents, diskNode{id: parent.id, keys: []int{parent.maxKey()}})
+		}
+		level = parents
+	}
+	return b.writeRoot(level[0].id)
+}
+
+// 写入指向 root 的文件头并刷盘
+func (b *packedBuilder) writeRoot(root PageID) error {
+	b.out.meta.root = root
+	if err := b.out.writeMeta(); err != nil {
+		return err
+	}
+	return b.out.store.Sync()
+}
+
+// CompactInto 将树按 key 顺序流式写入空的存储 dst，生成每页尽量填满的紧凑副本，并返回其上打开的树。
+// 叶节点按顺序占用连续的页，扫描时近似顺序读。副本沿用源树的校验和算法，复制期间持有源树的锁
+func (t *DiskBPlusTree) CompactInto(dst PageStore, opts ...DiskOption) (*DiskBPlusTree, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer t.unpinAll()
+	if t.meta.blobs {
+		return nil, fmt.Errorf("压缩失败：%w", ErrByteValues)
+	}
+	b, err := newPackedBuilder(dst, t.meta.checksum)
+	if err != nil {
+		return nil, fmt.Errorf("压缩失败：%w", err)
+	}
+	if err := errors.Join(t.scanLocked(b.add), b.finish()); err != nil {
+		return nil, fmt.Errorf("压缩失败：%w", err)
+	}
+	return NewDiskBPlusTree(dst, opts...)
+}
+
+// CompactFile 压缩 path 处未被打开的树文件：先写出紧凑副本到临时文件，刷盘后原子替换原文件并刷盘所在目录，
+// 中途失败或崩溃时原文件保持不变
+func CompactFile(path string) error {
+	src, err := OpenDiskBPlusTree(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + ".compact"
+	os.Remove(tmp)
+	pager, err := OpenFilePager(tmp)
+	if err != nil {
+		return err
+	}
+	out, err := src.CompactInto(pager)
+	if err != nil {
+		pager.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
@@ -0,0 +1,76 @@
+package bplustree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompactFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree, err := OpenDiskBPlusTree(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2000 {
+		if err := tree.Insert(i, i*2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2000; i += 3 {
+		if err := tree.Remove(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.Stat(path)
+	if err := CompactFile(path); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Errorf("压缩后文件大小为 %d，未小于压缩前的 %d", after.Size(), before.Size())
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("压缩后残留临时文件：%v", err)
+	}
+	tree, err = OpenDiskBPlusTree(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	for i := range 2000 {
+		want := i * 2
+		if i%3 == 0 {
+			want = -1
+		}
+		if got, err := tree.Search(i); err != nil || got != want {
+			t.Fatalf("Search(%d) = %d, %v，期望 %d", i, got, err, want)
+		}
+	}
+}
+
+func TestCompactInMemory(t *testing.T) {
+	bpt := NewBPlusTree()
+	for i := range 500 {
+		bpt.Insert(i, i)
+	}
+	for i := 0; i < 500; i += 2 {
+		bpt.Remove(i)
+	}
+	bpt.Compact()
+	if err := bpt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 500 {
+		want := i
+		if i%2 == 0 {
+			want = -1
+		}
+		if got := bpt.Search(i); got != want {
+			t.Fatalf("Search(%d) = %d，期望 %d", i, got, want)
+		}
+	}
+}
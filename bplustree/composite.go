@@ -0,0 +1,218 @@
+package bplustree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KeyKind 是复合键中字段的类型
+type KeyKind int
+
+const (
+	KeyInt    KeyKind = iota // int64，编码时也接受 int
+	KeyUint                  // uint64
+	KeyString                // string
+	KeyBytes                 // []byte
+	KeyTime                  // time.Time，按纳秒精度的 Unix 时间排序，解码结果为 UTC
+)
+
+func (k KeyKind) String() string {
+	switch k {
+	case KeyInt:
+		return "int"
+	case KeyUint:
+		return "uint"
+	case KeyString:
+		return "string"
+	case KeyBytes:
+		return "bytes"
+	case KeyTime:
+		return "time"
+	}
+	return fmt.Sprintf("KeyKind(%d)", int(k))
+}
+
+// KeyField 描述复合键中的一个字段：类型及是否降序排列
+type KeyField struct {
+	Kind KeyKind
+	Desc bool
+}
+
+// KeySchema 是复合键的字段列表，例如 (userID 升序, timestamp 降序)
+type KeySchema []KeyField
+
+// Tuple 是复合键的一组字段值，元素类型须与 KeySchema 中对应字段的类型一致
+type Tuple []any
+
+// ErrKeyEncoding 表示复合键的字段值与模式不符，或字节串不是该模式下的合法编码
+var ErrKeyEncoding = errors.New("复合键编码错误")
+
+// 变长字段的转义：字段中的 0x00 写作 0x00 0xFF，字段以 0x00 0x01 结束。
+// 这样的编码没有一个是另一个的前缀，按字节比较的结果与按原值比较一致
+const (
+	escByte  = 0x00
+	escZero  = 0xFF
+	escClose = 0x01
+)
+
+// Encode 将 t 编码为保序的字节串：编码按 bytes.Compare 比较的结果，与逐个字段依次比较
+// （降序字段的比较结果取反）一致，可直接作为 NewBytesTree 的键。
+// t 可以只包含前若干个字段，此时得到的是所有以这些字段开头的完整键的公共前缀，用于 ScanPrefix
+func (s KeySchema) Encode(t Tuple) ([]byte, error) {
+	if len(t) > len(s) {
+		return nil, fmt.Errorf("%w：%d 个字段值多于模式中的 %d 个字段", ErrKeyEncoding, len(t), len(s))
+	}
+	var out []byte
+	for i, v := range t {
+		start := len(out)
+		var err error
+		if out, err = appendKeyField(out, s[i].Kind, v); err != nil {
+			return nil, fmt.Errorf("%w：第 %d 个字段：%v", ErrKeyEncoding, i, err)
+		}
+		if s[i].Desc {
+			// 按位取反使该字段的顺序反转；各字段的编码都不是彼此的前缀，取反后依然如此
+			for j := start; j < len(out); j++ {
+				out[j] = ^out[j]
+			}
+		}
+	}
+	return out, nil
+}
+
+func appendKeyField(out []byte, kind KeyKind, v any) ([]byte, error) {
+	switch kind {
+	case KeyInt:
+		var n int64
+		switch x := v.(type) {
+		case int64:
+			n = x
+		case int:
+			n = int64(x)
+		default:
+			return nil, fmt.Errorf("需要 int64，得到 %T", v)
+		}
+		// 翻转符号位，使负数排在正数之前
+		return binary.BigEndian.AppendUint64(out, uint64(n)^(1<<63)), nil
+	case KeyUint:
+		n, ok := v.(uint64)
+		if !ok {
+			return nil, fmt.Errorf("需要 uint64，得到 %T", v)
+		}
+		return binary.BigEndian.AppendUint64(out, n), nil
+	case KeyTime:
+		ts, ok := v.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("需要 time.Time，得到 %T", v)
+		}
+		return appendKeyField(out, KeyInt, ts.UnixNano())
+	case KeyString:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("需要 string，得到 %T", v)
+		}
+		return appendEscaped(out, []byte(str)), nil
+	case KeyBytes:
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("需要 []byte，得到 %T", v)
+		}
+		return appendEscaped(out, b), nil
+	}
+	return nil, fmt.Errorf("未知的字段类型 %v", kind)
+}
+
+func appendEscaped(out, b []byte) []byte {
+	for _, c := range b {
+		out = append(out, c)
+		if c == escByte {
+			out = append(out, escZero)
+		}
+	}
+	return append(out, escByte, escClose)
+}
+
+// Decode 将 Encode 得到的完整键还原为各字段的值：KeyInt 字段为 int64，KeyUint 为 uint64，
+// KeyString 为 string，KeyBytes 为 []byte，KeyTime 为 UTC 的 time.Time
+func (s KeySchema) Decode(b []byte) (Tuple, error) {
+	t := make(Tuple, 0, len(s))
+	for i, f := range s {
+		v, n, err := decodeKeyField(b, f)
+		if err != nil {
+			return nil, fmt.Errorf("%w：第 %d 个字段：%v", ErrKeyEncoding, i, err)
+		}
+		t = append(t, v)
+		b = b[n:]
+	}
+	if len(b) > 0 {
+		return nil, fmt.Errorf("%w：末尾多出 %d 个字节", ErrKeyEncoding, len(b))
+	}
+	return t, nil
+}
+
+// 解码 b 开头的一个字段，返回其值与占用的字节数
+func decodeKeyField(b []byte, f KeyField) (any, int, error) {
+	// 降序字段的字节已按位取反，读取时逐字节还原
+	at := func(i int) byte {
+		if f.Desc {
+			return ^b[i]
+		}
+		return b[i]
+	}
+	switch f.Kind {
+	case KeyInt, KeyUint, KeyTime:
+		if len(b) < 8 {
+			return nil, 0, fmt.Errorf("需要 8 个字节，剩余 %d 个", len(b))
+		}
+		var n uint64
+		for i := 0; i < 8; i++ {
+			n = n<<8 | uint64(at(i))
+		}
+		switch f.Kind {
+		case KeyUint:
+			return n, 8, nil
+		case KeyTime:
+			return time.Unix(0, int64(n^(1<<63))).UTC(), 8, nil
+		}
+		return int64(n ^ (1 << 63)), 8, nil
+	case KeyString, KeyBytes:
+		var raw []byte
+		for i := 0; i < len(b); i++ {
+			c := at(i)
+			if c != escByte {
+				raw = append(raw, c)
+				continue
+			}
+			if i+1 == len(b) {
+				break
+			}
+			switch at(i + 1) {
+			case escZero:
+				raw = append(raw, escByte)
+				i++
+			case escClose:
+				if f.Kind == KeyString {
+					return string(raw), i + 2, nil
+				}
+				if raw == nil {
+					raw = []byte{}
+				}
+				return raw, i + 2, nil
+			default:
+				return nil, 0, fmt.Errorf("第 %d 个字节处的转义序列无效", i)
+			}
+		}
+		return nil, 0, errors.New("缺少字段结束标记")
+	}
+	return nil, 0, fmt.Errorf("未知的字段类型 %v", f.Kind)
+}
+
+// ScanPrefix 按键的顺序对 t 中所有以 prefix 开头的键值对调用 fn，fn 返回 false 时提前结束。
+// 与 KeySchema.Encode 对部分字段的编码配合，可以扫描某个 userID 下的全部行等
+func ScanPrefix[V any](t *OrderedTree[[]byte, V], prefix []byte, fn func(key []byte, value V) bool) {
+	t.ascend(prefix, func(k []byte, v V) bool {
+		return bytes.HasPrefix(k, prefix) && fn(k, v)
+	})
+}
@@ -0,0 +1,112 @@
+package bplustree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentBPlusTree 是 BPlusTree 的并发安全包装：所有操作由读写锁保护，
+// 允许多个读者同时查询，写操作互斥执行
+type ConcurrentBPlusTree struct {
+	mu      sync.RWMutex
+	tree    *BPlusTree
+	writes  uint64         // 写操作计数，供 Compact 判断构建期间树是否被修改
+	forked  *forkVersion   // 最近一次 ForkReadOnly 共享出去的版本
+	forks   atomic.Int64   // 全部版本上尚未释放的只读句柄数
+	private uint64         // Update 复制整棵树时的代号，等于树的当前代号说明没有节点被共享
+	readers readerRegistry // 尚未释放的只读句柄，用于发现长期未释放的句柄
+}
+
+// NewConcurrentBPlusTree 创建一个新的并发安全 B+ 树，opts 会传递给底层的 BPlusTree
+func NewConcurrentBPlusTree(opts ...Option) *ConcurrentBPlusTree {
+	return &ConcurrentBPlusTree{tree: NewBPlusTree(opts...)}
+}
+
+// Insert 在写锁保护下插入键值对
+func (c *ConcurrentBPlusTree) Insert(key, value int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	c.detachForks()
+	c.tree.Insert(key, value)
+}
+
+// Remove 在写锁保护下删除 key
+func (c *ConcurrentBPlusTree) Remove(key int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	c.detachForks()
+	return c.tree.Remove(key)
+}
+
+// Modify 在写锁保护下修改 key 对应的 value
+func (c *ConcurrentBPlusTree) Modify(key, newValue int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	c.detachForks()
+	return c.tree.Modify(key, newValue)
+}
+
+// Search 在读锁保护下查找 key 对应的 value；若不存在返回 -1
+func (c *ConcurrentBPlusTree) Search(key int) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Search(key)
+}
+
+// PrintTree 在读锁保护下打印整棵树
+func (c *ConcurrentBPlusTree) PrintTree() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.tree.PrintTree()
+}
+
+// PrintLeafValues 在读锁保护下输出所有叶节点的值
+func (c *ConcurrentBPlusTree) PrintLeafValues() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.tree.PrintLeafValues()
+}
+
+// View 在读锁保护下执行 fn，用于组合多个只读操作；fn 不得修改树，也不得在返回后继续持有 t
+func (c *ConcurrentBPlusTree) View(fn func(t *BPlusTree)) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fn(c.tree)
+}
+
+// Update 在写锁保护下执行 fn，用于需要原子完成的复合修改（如 Merge）；fn 不得在返回后继续持有 t
+func (c *ConcurrentBPlusTree) Update(fn func(t *BPlusTree)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	c.detachForks()
+	if c.tree.shared {
+		// fn 可能修改任意节点，无法只复制路径
+		c.tree.root = c.tree.cloneTree(c.tree.root)
+		c.tree.edge.leaf = nil
+		c.private, c.tree.shared = c.tree.gen, false
+	}
+	fn(c.tree)
+}
+
+// Walk 在读锁保护下按层次顺序遍历整棵树
+func (c *ConcurrentBPlusTree) Walk(fn func(level int, n NodeInfo)) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.tree.Walk(fn)
+}
+
+// WalkLeaves 在读锁保护下沿叶节点链表遍历所有叶节点
+func (c *ConcurrentBPlusTree) WalkLeaves(fn func(n NodeInfo) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.tree.WalkLeaves(fn)
+}
+
+// Err 返回严格模式下使树停止服务的内部错误，无需加锁
+func (c *ConcurrentBPlusTree) Err() error {
+	return c.tree.Err()
+}
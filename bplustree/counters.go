@@ -0,0 +1,59 @@
+package bplustree
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// OpCounters 是树自创建以来累计的操作次数，用于监控写入速率与结构调整的频率。
+// 批量构建（Compact、ImportCSV 等）不逐条计数
+type OpCounters struct {
+	Inserts  uint64 `json:"inserts"`  // 插入的键值对数
+	Removes  uint64 `json:"removes"`  // 成功删除的键数
+	Modifies uint64 `json:"modifies"` // 成功修改的键数
+	Splits   uint64 `json:"splits"`   // 节点分裂次数，叶节点与内部节点均计入
+	Merges   uint64 `json:"merges"`   // 节点合并次数
+	Borrows  uint64 `json:"borrows"`  // 从兄弟节点借补的次数
+}
+
+// 树内部的计数器。LatchedBPlusTree 的写操作可以并发修改不同子树，因此使用原子计数
+type opCounters struct {
+	inserts, removes, modifies atomic.Uint64
+	splits, merges, borrows    atomic.Uint64
+}
+
+// Counters 返回累计操作次数的快照，可在任意 goroutine 中调用
+func (bpt *BPlusTree) Counters() OpCounters {
+	return OpCounters{
+		Inserts:  bpt.ops.inserts.Load(),
+		Removes:  bpt.ops.removes.Load(),
+		Modifies: bpt.ops.modifies.Load(),
+		Splits:   bpt.ops.splits.Load(),
+		Merges:   bpt.ops.merges.Load(),
+		Borrows:  bpt.ops.borrows.Load(),
+	}
+}
+
+// Counters 返回累计操作次数的快照，无需加锁
+func (c *ConcurrentBPlusTree) Counters() OpCounters {
+	return c.tree.Counters()
+}
+
+// Counters 返回累计操作次数的快照，无需加锁
+func (l *LatchedBPlusTree) Counters() OpCounters {
+	return l.tree.Counters()
+}
+
+// PublishExpvar 将操作次数、条目数与树高以 name 为名注册到 expvar，
+// 通过 /debug/vars 以 JSON 对象的形式读取，无需引入 Prometheus。
+// 每次读取都会在读锁下遍历整棵树统计条目数。与 expvar.Publish 相同，name 重复时会 panic
+func (c *ConcurrentBPlusTree) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		s := c.Stats()
+		return struct {
+			OpCounters
+			Entries int `json:"entries"`
+			Height  int `json:"height"`
+		}{c.Counters(), s.Entries, s.Height}
+	}))
+}
@@ -0,0 +1,51 @@
+package bplustree
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ImportCSV 从 r 中流式读取 CSV，以第 keyCol 列为 key、第 valCol 列为 value（从 0 开始计数）
+// 插入树中；树为空且各行按 key 升序排列时自底向上整体构建。
+// 第一行的 key 列不是整数时视为表头跳过。输入有误时返回带行号的错误，树保持不变
+func (bpt *BPlusTree) ImportCSV(r io.Reader, keyCol, valCol int) error {
+	if keyCol < 0 || valCol < 0 {
+		return fmt.Errorf("导入 CSV 失败：列号不能为负数")
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	var keys, values []int
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("导入 CSV 失败：%w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if keyCol >= len(record) || valCol >= len(record) {
+			return fmt.Errorf("导入 CSV 失败：第 %d 行只有 %d 列", line, len(record))
+		}
+		key, err := strconv.Atoi(strings.TrimSpace(record[keyCol]))
+		if err != nil {
+			if first {
+				continue
+			}
+			return fmt.Errorf("导入 CSV 失败：第 %d 行的 key %q 不是整数", line, record[keyCol])
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(record[valCol]))
+		if err != nil {
+			return fmt.Errorf("导入 CSV 失败：第 %d 行的 value %q 不是整数", line, record[valCol])
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	bpt.load(keys, values)
+	return nil
+}
@@ -0,0 +1,92 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 压缩字典的大小上限，即 DEFLATE 的窗口大小：超出窗口的字典内容不会被匹配
+const maxDictionarySize = 32 << 10
+
+// TrainDictionary 从树中按 key 均匀抽取至多 sampleSize 个字节值构造 DEFLATE 预置字典并保存在文件中，
+// 此后以 WithValueCompression(CompressionFlate) 写入的值都使用它压缩。大量相似的短值单独压缩时几乎无法变小，
+// 共享字典后只需保存与字典不同的部分。已写入的值不会重新压缩；再次训练时新字典取代旧字典用于之后的写入，
+// 旧字典仍被以它压缩的值引用，因此不会释放
+func (t *DiskBPlusTree) TrainDictionary(sampleSize int) error {
+	if sampleSize <= 0 {
+		return fmt.Errorf("训练字典失败：样本数 %d 必须为正数", sampleSize)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startDeadline(t.scanTimeout)
+	defer t.unpinAll()
+	if !t.meta.blobs {
+		return fmt.Errorf("训练字典失败：树中没有字节值")
+	}
+	_, n, err := t.treeUsage(t.meta.root)
+	if err != nil {
+		return err
+	}
+	step := max(n/sampleSize, 1)
+	var samples [][]byte
+	i := 0
+	scanErr := t.scanLocked(func(_, head int) bool {
+		if i%step == 0 {
+			var value []byte
+			if value, err = t.readBlob(PageID(head)); err != nil {
+				return false
+			}
+			samples = append(samples, value)
+		}
+		i++
+		return len(samples) < sampleSize
+	})
+	if err := errors.Join(scanErr, err); err != nil {
+		return err
+	}
+	dict := buildDictionary(samples)
+	if len(dict) == 0 {
+		return fmt.Errorf("训练字典失败：样本均为空值")
+	}
+	old := t.meta
+	head, err := t.writeBlob(valueRaw, dict)
+	if err != nil {
+		return err
+	}
+	t.meta.dict = head
+	if t.dicts == nil {
+		t.dicts = make(map[PageID][]byte)
+	}
+	t.dicts[head] = dict
+	return t.finish(old)
+}
+
+// 以样本构造预置字典：去除重复的样本后依次拼接。总长超过窗口时截短每个样本，使每个样本都有内容进入字典
+func buildDictionary(samples [][]byte) []byte {
+	seen := make(map[string]bool)
+	var unique [][]byte
+	total := 0
+	for _, s := range samples {
+		if len(s) == 0 || seen[string(s)] {
+			continue
+		}
+		seen[string(s)] = true
+		unique = append(unique, s)
+		total += len(s)
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	per := total
+	if total > maxDictionarySize {
+		per = max(maxDictionarySize/len(unique), 1)
+	}
+	dict := make([]byte, 0, min(total, maxDictionarySize))
+	for _, s := range unique {
+		if len(dict) >= maxDictionarySize {
+			break
+		}
+		dict = append(dict, s[:min(len(s), per, maxDictionarySize-len(dict))]...)
+	}
+	return dict
+}
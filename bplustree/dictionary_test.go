@@ -0,0 +1,222 @@
+package bplustree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// 相似的短 JSON 记录：单独压缩几乎无法变小，共享字典后大幅缩小
+func dictionaryRecord(i int) []byte {
+	return fmt.Appendf(nil, `{"id":%d,"status":"active","region":"eu-west-1","plan":"enterprise","tags":["alpha","beta"]}`, i)
+}
+
+func TestTrainDictionary(t *testing.T) {
+	const n = 200
+	fill := func(tree *DiskBPlusTree, from int) {
+		t.Helper()
+		for i := from; i < from+n; i++ {
+			if err := tree.PutBytes(i, dictionaryRecord(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	stored := func(tree *DiskBPlusTree) int64 {
+		t.Helper()
+		s, err := tree.Stats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.StoredValueSizes.Total
+	}
+
+	plain, _ := openTempDiskTree(t, WithValueCompression(CompressionFlate))
+	defer plain.Close()
+	fill(plain, 0)
+	fill(plain, n)
+
+	tree, path := openTempDiskTree(t, WithValueCompression(CompressionFlate))
+	fill(tree, 0)
+	if err := tree.TrainDictionary(50); err != nil {
+		t.Fatal(err)
+	}
+	first := tree.meta.dict
+	if first == 0 {
+		t.Fatal("训练后文件头未记录字典")
+	}
+	fill(tree, n)
+	// 训练前写入的值按原样保存，之后写入的值以字典压缩
+	if got := valueEncoding(pageByte(t, tree, 0, 1)); got != valueRaw {
+		t.Fatalf("训练前写入的值的编码为 %d，期望 %d", got, valueRaw)
+	}
+	if got := valueEncoding(pageByte(t, tree, n, 1)); got != valueFlateDict {
+		t.Fatalf("训练后写入的值的编码为 %d，期望 %d", got, valueFlateDict)
+	}
+	if p, q := stored(plain), stored(tree); q*10 > p*8 {
+		t.Fatalf("使用字典时值页中占用 %d 字节，不使用时 %d 字节", q, p)
+	}
+	tree.Close()
+
+	// 重新打开时不指定压缩方式，字典从文件中读出，全部值照常读出
+	tree, err := OpenDiskBPlusTree(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.meta.dict != first {
+		t.Fatalf("重新打开后字典首页为 %d，期望 %d", tree.meta.dict, first)
+	}
+	for i := range 2 * n {
+		if got, ok, err := tree.GetBytes(i); err != nil || !ok || !bytes.Equal(got, dictionaryRecord(i)) {
+			t.Fatalf("GetBytes(%d) = %q, %v, %v", i, got, ok, err)
+		}
+	}
+	tree.Close()
+
+	// 再次训练后新写入的值使用新字典，以旧字典压缩的值仍可读出
+	tree, err = OpenDiskBPlusTree(path, WithValueCompression(CompressionFlate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if err := tree.TrainDictionary(10); err != nil {
+		t.Fatal(err)
+	}
+	if tree.meta.dict == first {
+		t.Fatal("再次训练后字典未更换")
+	}
+	if err := tree.PutBytes(-1, dictionaryRecord(-1)); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	if err := tree.ScanBytes(func(k int, v []byte) bool {
+		if !bytes.Equal(v, dictionaryRecord(k)) {
+			t.Errorf("ScanBytes 读到 %d => %q", k, v)
+		}
+		count++
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2*n+1 {
+		t.Fatalf("ScanBytes 读到 %d 个值，期望 %d", count, 2*n+1)
+	}
+}
+
+func TestTrainDictionaryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		fill   func(tree *DiskBPlusTree) error
+		sample int
+	}{
+		{"zero-sample", func(tree *DiskBPlusTree) error { return tree.PutBytes(1, []byte("v")) }, 0},
+		{"negative-sample", func(tree *DiskBPlusTree) error { return tree.PutBytes(1, []byte("v")) }, -1},
+		{"empty", func(*DiskBPlusTree) error { return nil }, 10},
+		{"int-values", func(tree *DiskBPlusTree) error { return tree.Insert(1, 1) }, 10},
+		{"empty-values", func(tree *DiskBPlusTree) error { return tree.PutBytes(1, nil) }, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, _ := openTempDiskTree(t)
+			defer tree.Close()
+			if err := tt.fill(tree); err != nil {
+				t.Fatal(err)
+			}
+			pages := tree.meta.numPages
+			if err := tree.TrainDictionary(tt.sample); err == nil {
+				t.Fatal("TrainDictionary 应返回错误")
+			}
+			if tree.meta.dict != 0 || tree.meta.numPages != pages {
+				t.Fatalf("失败的训练写入了字典：首页 %d，文件从 %d 页增长到 %d 页", tree.meta.dict, pages, tree.meta.numPages)
+			}
+		})
+	}
+}
+
+// 值引用的字典页号无效或字典页损坏时报告值的首页损坏，而不是以错误的字典解压
+func TestTrainDictionaryCorrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(tree *DiskBPlusTree, head PageID)
+		page    func(tree *DiskBPlusTree, head PageID) PageID // 期望报告损坏的页
+	}{
+		{"dict-out-of-range", func(tree *DiskBPlusTree, head PageID) {
+			rewritePage(tree, head, func(page []byte) {
+				// 首页依次记录原始长度与字典页号，把字典页号改为超出文件的值，保持长度不变
+				_, k := binary.Uvarint(page[pageHeaderSize:])
+				page[pageHeaderSize+k] = 0x7f
+			})
+		}, func(_ *DiskBPlusTree, head PageID) PageID { return head }},
+		{"dict-not-raw", func(tree *DiskBPlusTree, _ PageID) {
+			rewritePage(tree, tree.meta.dict, func(page []byte) { page[1] = byte(valueFlate) })
+		}, func(tree *DiskBPlusTree, _ PageID) PageID { return tree.meta.dict }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, path := openTempDiskTree(t, WithValueCompression(CompressionFlate))
+			for i := range 20 {
+				tree.PutBytes(i, dictionaryRecord(i))
+			}
+			if err := tree.TrainDictionary(20); err != nil {
+				t.Fatal(err)
+			}
+			if err := tree.PutBytes(100, dictionaryRecord(100)); err != nil {
+				t.Fatal(err)
+			}
+			tree.mu.Lock()
+			leaf, _, _ := tree.descend(100)
+			tree.unpinAll()
+			head := PageID(leaf.values[slices.Index(leaf.keys, 100)])
+			tt.corrupt(tree, head)
+			want := tt.page(tree, head)
+			tree.mu.Unlock()
+			tree.Close()
+			// 重新打开以丢弃缓存的字典
+			tree, err := OpenDiskBPlusTree(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tree.Close()
+			var corrupt *CorruptPageError
+			if _, _, err := tree.GetBytes(100); !errors.As(err, &corrupt) || corrupt.Page != want {
+				t.Fatalf("GetBytes 返回 %v，期望页 %d 损坏", err, want)
+			}
+		})
+	}
+}
+
+// 以 fn 修改页 id 的内容并重新计算校验和，调用方持有树的锁
+func rewritePage(tree *DiskBPlusTree, id PageID, fn func(page []byte)) {
+	page := make([]byte, PageSize)
+	tree.store.ReadPage(id, page)
+	fn(page)
+	tree.meta.checksum.setPageChecksum(id, page)
+	tree.store.WritePage(id, page)
+}
+
+func TestBuildDictionary(t *testing.T) {
+	long := bytes.Repeat([]byte("x"), maxDictionarySize)
+	tests := []struct {
+		name    string
+		samples [][]byte
+		want    int
+	}{
+		{"none", nil, 0},
+		{"empty-values", [][]byte{{}, nil}, 0},
+		{"duplicates", [][]byte{[]byte("abc"), []byte("abc"), []byte("de")}, 5},
+		{"truncated", [][]byte{long, append([]byte("y"), long...)}, maxDictionarySize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildDictionary(tt.samples)
+			if len(got) != tt.want {
+				t.Fatalf("字典长 %d 字节，期望 %d", len(got), tt.want)
+			}
+			if tt.name == "truncated" && !bytes.Contains(got, []byte("y")) {
+				t.Fatal("截短后每个样本都应有内容进入字典")
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Package difftest 对两棵有序树执行相同的操作序列，逐个比较操作结果，
+// 并定期比较全部条目的遍历顺序，用来在重构 B+ 树时与 google/btree 这样的成熟实现做差分测试。
+// 各实现通过 Target 接口接入，包内提供了 BPlusTree、OrderedTree 与 google/btree 的适配器
+package difftest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// OpKind 是操作的类型
+type OpKind int
+
+const (
+	OpPut    OpKind = iota // 插入或替换 Key 的值
+	OpDelete               // 删除 Key
+	OpGet                  // 查找 Key
+	OpRange                // 按顺序遍历 [Key, Hi] 内的条目
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpPut:
+		return "put"
+	case OpDelete:
+		return "delete"
+	case OpGet:
+		return "get"
+	case OpRange:
+		return "range"
+	}
+	return fmt.Sprintf("OpKind(%d)", int(k))
+}
+
+// Op 是操作序列中的一个操作
+type Op struct {
+	Kind  OpKind
+	Key   int
+	Value int // 仅 OpPut 使用
+	Hi    int // 仅 OpRange 使用，区间为 [Key, Hi]
+}
+
+func (op Op) String() string {
+	switch op.Kind {
+	case OpPut:
+		return fmt.Sprintf("put(%d, %d)", op.Key, op.Value)
+	case OpRange:
+		return fmt.Sprintf("range(%d, %d)", op.Key, op.Hi)
+	}
+	return fmt.Sprintf("%s(%d)", op.Kind, op.Key)
+}
+
+// Entry 是遍历得到的一个键值对
+type Entry struct {
+	Key, Value int
+}
+
+// Target 是参与差分测试的有序映射，key 唯一
+type Target interface {
+	// Put 插入 key，key 已存在时替换其值
+	Put(key, value int)
+	// Delete 删除 key，返回 key 是否存在
+	Delete(key int) bool
+	// Get 返回 key 对应的值，ok 为 false 表示不存在
+	Get(key int) (value int, ok bool)
+	// Ascend 按 key 升序对 [lo, hi] 内的条目调用 fn，fn 返回 false 时提前结束
+	Ascend(lo, hi int, fn func(key, value int) bool)
+	// Len 返回条目数
+	Len() int
+}
+
+// Divergence 描述两个实现第一次出现分歧的位置
+type Divergence struct {
+	Index int    // 出现分歧的操作在序列中的下标；全量比较时为刚执行完的操作的下标
+	Op    Op     // 出现分歧的操作；全量比较时为刚执行完的操作
+	What  string // 分歧的内容
+}
+
+func (d *Divergence) Error() string {
+	return fmt.Sprintf("第 %d 个操作 %s 之后出现分歧：%s", d.Index, d.Op, d.What)
+}
+
+// Options 调整 Run 的行为
+type Options struct {
+	// CheckEvery 为每隔多少个操作比较一次两个实现的全部条目与遍历顺序，0 表示只在最后比较一次
+	CheckEvery int
+}
+
+// Run 依次对 a 与 b 执行 ops 中的操作并比较结果，返回第一次分歧对应的 *Divergence；
+// 全部一致时返回 nil。a 与 b 在调用前应为空，或者包含相同的条目
+func Run(ops []Op, a, b Target, opts Options) error {
+	for i, op := range ops {
+		if what := apply(op, a, b); what != "" {
+			return &Divergence{Index: i, Op: op, What: what}
+		}
+		last := i == len(ops)-1
+		if last || opts.CheckEvery > 0 && (i+1)%opts.CheckEvery == 0 {
+			if what := compareAll(a, b); what != "" {
+				return &Divergence{Index: i, Op: op, What: what}
+			}
+		}
+	}
+	return nil
+}
+
+// 对两个实现执行同一个操作，返回结果的差异，一致时返回空串
+func apply(op Op, a, b Target) string {
+	switch op.Kind {
+	case OpPut:
+		a.Put(op.Key, op.Value)
+		b.Put(op.Key, op.Value)
+	case OpDelete:
+		if x, y := a.Delete(op.Key), b.Delete(op.Key); x != y {
+			return fmt.Sprintf("删除结果为 %t 与 %t", x, y)
+		}
+	case OpGet:
+		xv, xok := a.Get(op.Key)
+		yv, yok := b.Get(op.Key)
+		if xok != yok || xok && xv != yv {
+			return fmt.Sprintf("查找结果为 (%d, %t) 与 (%d, %t)", xv, xok, yv, yok)
+		}
+	case OpRange:
+		x, y := collect(a, op.Key, op.Hi), collect(b, op.Key, op.Hi)
+		if what := diffEntries(x, y); what != "" {
+			return "区间遍历" + what
+		}
+	default:
+		return fmt.Sprintf("未知的操作类型 %v", op.Kind)
+	}
+	return ""
+}
+
+// 比较两个实现的条目数与完整的遍历结果
+func compareAll(a, b Target) string {
+	if x, y := a.Len(), b.Len(); x != y {
+		return fmt.Sprintf("条目数为 %d 与 %d", x, y)
+	}
+	if what := diffEntries(collect(a, math.MinInt, math.MaxInt), collect(b, math.MinInt, math.MaxInt)); what != "" {
+		return "全量遍历" + what
+	}
+	return ""
+}
+
+func collect(t Target, lo, hi int) []Entry {
+	var out []Entry
+	t.Ascend(lo, hi, func(key, value int) bool {
+		out = append(out, Entry{key, value})
+		return true
+	})
+	return out
+}
+
+// 返回两个遍历结果的第一处差异，一致时返回空串
+func diffEntries(x, y []Entry) string {
+	for i := range min(len(x), len(y)) {
+		if x[i] != y[i] {
+			return fmt.Sprintf("第 %d 个条目为 %v 与 %v", i, x[i], y[i])
+		}
+	}
+	if len(x) != len(y) {
+		return fmt.Sprintf("得到 %d 个与 %d 个条目", len(x), len(y))
+	}
+	return ""
+}
+
+// RandomOps 用 rng 生成 n 个随机操作，key 取自 [0, keySpace)。
+// 写操作（Put、Delete）约占一半，较小的 keySpace 会让同一个 key 反复被插入与删除，更容易触发合并与借位
+func RandomOps(rng *rand.Rand, n, keySpace int) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		key := rng.Intn(keySpace)
+		switch r := rng.Intn(10); {
+		case r < 3:
+			ops[i] = Op{Kind: OpPut, Key: key, Value: rng.Int()}
+		case r < 5:
+			ops[i] = Op{Kind: OpDelete, Key: key}
+		case r < 9:
+			ops[i] = Op{Kind: OpGet, Key: key}
+		default:
+			ops[i] = Op{Kind: OpRange, Key: key, Hi: key + rng.Intn(keySpace/10+1)}
+		}
+	}
+	return ops
+}
+
+// Shrink 在 ops 会让 a 与 b 出现分歧时，尝试删去尽量多的操作，返回仍能复现分歧的较短序列。
+// newA 与 newB 每次返回一对新的空实现。ops 本身不能复现分歧时原样返回
+func Shrink(ops []Op, newA, newB func() Target) []Op {
+	fails := func(ops []Op) bool {
+		return Run(ops, newA(), newB(), Options{CheckEvery: 1}) != nil
+	}
+	if !fails(ops) {
+		return ops
+	}
+	// 逐步减小每次删除的块，直到连单个操作也删不掉
+	for chunk := len(ops) / 2; chunk >= 1; chunk /= 2 {
+		for start := 0; start < len(ops); {
+			candidate := slices.Delete(slices.Clone(ops), start, min(start+chunk, len(ops)))
+			if fails(candidate) {
+				ops = candidate
+			} else {
+				start += chunk
+			}
+		}
+	}
+	return ops
+}
@@ -0,0 +1,133 @@
+package difftest
+
+import (
+	"cmp"
+	"math"
+
+	"bplus-go/bplustree"
+
+	"github.com/google/btree"
+)
+
+// BPlusTarget 将 bplustree.BPlusTree 适配为 Target。BPlusTree 允许重复的 key，
+// Put 在 key 已存在时改用 Modify，使其行为与 key 唯一的映射一致
+type BPlusTarget struct {
+	Tree *bplustree.BPlusTree
+}
+
+// NewBPlusTarget 返回包装一棵空 BPlusTree 的 Target，opts 传给 NewBPlusTree
+func NewBPlusTarget(opts ...bplustree.Option) *BPlusTarget {
+	return &BPlusTarget{Tree: bplustree.NewBPlusTree(opts...)}
+}
+
+// Put 插入 key，key 已存在时替换其值
+func (t *BPlusTarget) Put(key, value int) {
+	if t.Tree.Modify(key, value) != nil {
+		t.Tree.Insert(key, value)
+	}
+}
+
+// Delete 删除 key，返回 key 是否存在
+func (t *BPlusTarget) Delete(key int) bool {
+	return t.Tree.Remove(key) == nil
+}
+
+// Get 通过单点区间查找，不依赖 Search 以 -1 表示不存在的约定
+func (t *BPlusTarget) Get(key int) (value int, ok bool) {
+	t.Tree.Range(key, key, func(_, v int) bool {
+		value, ok = v, true
+		return false
+	})
+	return value, ok
+}
+
+// Ascend 按 key 升序遍历 [lo, hi] 内的条目
+func (t *BPlusTarget) Ascend(lo, hi int, fn func(key, value int) bool) {
+	t.Tree.Range(lo, hi, fn)
+}
+
+// Len 返回条目数
+func (t *BPlusTarget) Len() int {
+	n := 0
+	t.Tree.Range(math.MinInt, math.MaxInt, func(_, _ int) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// OrderedTarget 将泛型的 bplustree.OrderedTree 以 int 为键与值适配为 Target
+type OrderedTarget struct {
+	Tree *bplustree.OrderedTree[int, int]
+}
+
+// NewOrderedTarget 返回包装一棵空 OrderedTree 的 Target
+func NewOrderedTarget() *OrderedTarget {
+	return &OrderedTarget{Tree: bplustree.NewOrderedTree[int, int](cmp.Compare[int])}
+}
+
+// Put 插入 key，key 已存在时替换其值
+func (t *OrderedTarget) Put(key, value int) {
+	if t.Tree.Modify(key, value) != nil {
+		t.Tree.Insert(key, value)
+	}
+}
+
+// Delete 删除 key，返回 key 是否存在
+func (t *OrderedTarget) Delete(key int) bool {
+	return t.Tree.Remove(key) == nil
+}
+
+// Get 返回 key 对应的值
+func (t *OrderedTarget) Get(key int) (int, bool) {
+	return t.Tree.Search(key)
+}
+
+// Ascend 按 key 升序遍历 [lo, hi] 内的条目
+func (t *OrderedTarget) Ascend(lo, hi int, fn func(key, value int) bool) {
+	t.Tree.Range(lo, hi, fn)
+}
+
+// Len 返回条目数
+func (t *OrderedTarget) Len() int {
+	return t.Tree.Len()
+}
+
+// BTreeTarget 将 google/btree 适配为 Target，作为差分测试的参考实现
+type BTreeTarget struct {
+	Tree *btree.BTreeG[Entry]
+}
+
+// NewBTreeTarget 返回包装一棵空 google/btree 的 Target，degree 为其阶数
+func NewBTreeTarget(degree int) *BTreeTarget {
+	return &BTreeTarget{Tree: btree.NewG(degree, func(a, b Entry) bool { return a.Key < b.Key })}
+}
+
+// Put 插入 key，key 已存在时替换其值
+func (t *BTreeTarget) Put(key, value int) {
+	t.Tree.ReplaceOrInsert(Entry{key, value})
+}
+
+// Delete 删除 key，返回 key 是否存在
+func (t *BTreeTarget) Delete(key int) bool {
+	_, ok := t.Tree.Delete(Entry{Key: key})
+	return ok
+}
+
+// Get 返回 key 对应的值
+func (t *BTreeTarget) Get(key int) (int, bool) {
+	e, ok := t.Tree.Get(Entry{Key: key})
+	return e.Value, ok
+}
+
+// Ascend 按 key 升序遍历 [lo, hi] 内的条目
+func (t *BTreeTarget) Ascend(lo, hi int, fn func(key, value int) bool) {
+	t.Tree.AscendGreaterOrEqual(Entry{Key: lo}, func(e Entry) bool {
+		return e.Key <= hi && fn(e.Key, e.Value)
+	})
+}
+
+// Len 返回条目数
+func (t *BTreeTarget) Len() int {
+	return t.Tree.Len()
+}
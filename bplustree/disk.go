@@ -0,0 +1,801 @@
+package bplustree
+
+import (
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 文件头（0 号页）布局：
+//
+//	[0:8]   魔数 "BPTDISK\x00"
+//	[8:12]  格式版本
+//	[12:16] 页大小
+//	[16:20] 根节点页号
+//	[20:24] 已分配的页数（含文件头）
+//	[24:28] 空闲页链表的表头页号（0 表示没有空闲页）
+//	[28]    页校验和的算法（ChecksumAlgorithm）
+//	[29]    是否保存过字节值（见 PutBytes），1 表示是
+//	[32:40] 本页的校验和
+//	[40:44] 当前压缩字典的值页链表首页页号（0 表示没有字典，见 TrainDictionary）
+//
+// 节点页布局：16 字节页头之后紧跟各条目。
+//
+//	[0]     页类型：1 为叶节点，2 为内部节点
+//	[2:4]   关键词数量
+//	[4:8]   叶节点链表中下一个叶节点的页号（0 表示链表末尾）
+//	[8:16]  本页的校验和
+//
+// 空闲页只使用页头：页类型为 3，[4:8] 为空闲页链表中下一个空闲页的页号。
+// 值页的页类型为 4，布局见 blob.go。
+//
+//	叶节点条目：key int64、value int64
+//	内部节点条目：key int64（对应子节点的最大键）、child PageID
+const (
+	diskMagic      = "BPTDISK\x00"
+	diskVersion    = 5
+	pageHeaderSize = 16
+	pageTypeLeaf   = 1
+	pageTypeInner  = 2
+	pageTypeFree   = 3
+	pageTypeBlob   = 4
+	leafEntrySize  = 16
+	innerEntrySize = 12
+)
+
+// DiskMaxKeys 是磁盘节点能够存储的最大关键词数量，由页大小决定
+const DiskMaxKeys = (PageSize - pageHeaderSize) / leafEntrySize
+
+// 磁盘节点的最小关键词数量，计算方式与内存中的树相同
+const diskMinKeys = (DiskMaxKeys + 1) / 2
+
+// 反序列化后的磁盘节点
+type diskNode struct {
+	id       PageID
+	isLeaf   bool
+	keys     []int
+	values   []int    // 仅叶节点有效
+	children []PageID // 仅内部节点有效
+	next     PageID   // 仅叶节点有效
+}
+
+func (n *diskNode) maxKey() int {
+	return n.keys[len(n.keys)-1]
+}
+
+// 将节点编码到 buf 中，校验和由调用方填入
+func (n *diskNode) encode(buf []byte) {
+	clear(buf)
+	if n.isLeaf {
+		buf[0] = pageTypeLeaf
+	} else {
+		buf[0] = pageTypeInner
+	}
+	binary.LittleEndian.PutUint16(buf[2:], uint16(len(n.keys)))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(n.next))
+	off := pageHeaderSize
+	for i, key := range n.keys {
+		binary.LittleEndian.PutUint64(buf[off:], uint64(int64(key)))
+		if n.isLeaf {
+			binary.LittleEndian.PutUint64(buf[off+8:], uint64(int64(n.values[i])))
+			off += leafEntrySize
+		} else {
+			binary.LittleEndian.PutUint32(buf[off+8:], uint32(n.children[i]))
+			off += innerEntrySize
+		}
+	}
+}
+
+// 以算法 sum 校验并解码第 id 页上的节点
+func decodeDiskNode(sum ChecksumAlgorithm, id PageID, buf []byte) (*diskNode, error) {
+	if err := sum.verifyPageChecksum(id, buf); err != nil {
+		return nil, err
+	}
+	n := &diskNode{id: id}
+	switch buf[0] {
+	case pageTypeLeaf:
+		n.isLeaf = true
+	case pageTypeInner:
+	default:
+		return nil, corruptPage(id, "未知的页类型 %d", buf[0])
+	}
+	count := int(binary.LittleEndian.Uint16(buf[2:]))
+	if count > DiskMaxKeys {
+		return nil, corruptPage(id, "关键词数量 %d 超过上限 %d", count, DiskMaxKeys)
+	}
+	n.next = PageID(binary.LittleEndian.Uint32(buf[4:]))
+	n.keys = make([]int, count, DiskMaxKeys+1)
+	if n.isLeaf {
+		n.values = make([]int, count, DiskMaxKeys+1)
+	} else {
+		n.children = make([]PageID, count, DiskMaxKeys+1)
+	}
+	off := pageHeaderSize
+	for i := 0; i < count; i++ {
+		n.keys[i] = int(int64(binary.LittleEndian.Uint64(buf[off:])))
+		if n.isLeaf {
+			n.values[i] = int(int64(binary.LittleEndian.Uint64(buf[off+8:])))
+			off += leafEntrySize
+		} else {
+			n.children[i] = PageID(binary.LittleEndian.Uint32(buf[off+8:]))
+			off += innerEntrySize
+		}
+	}
+	return n, nil
+}
+
+// 文件头中保存的元数据
+type diskMeta struct {
+	root     PageID
+	numPages uint32
+	freeHead PageID // 空闲页链表的表头
+	checksum ChecksumAlgorithm
+	blobs    bool   // 是否保存过字节值，此时叶节点条目的 value 为值页链表的首页页号
+	dict     PageID // 当前压缩字典的值页链表首页，0 表示没有字典
+}
+
+// DiskBPlusTree 是以页为单位持久化在 PageStore 中的 B+ 树：每个节点占用一页，
+// 子节点以页号而非指针引用，操作时按需读写页。
+// 结构规则与内存中的 BPlusTree 相同（内部节点的关键词为对应子节点的最大键），
+// 但节点容量由页大小决定（DiskMaxKeys）。
+// 所有操作由一把互斥锁串行执行，因此可以在多个 goroutine 中使用（包括后台校验器）
+type DiskBPlusTree struct {
+	mu     sync.Mutex
+	store  PageStore
+	meta   diskMeta
+	buf    []byte   // 页读写缓冲区
+	pinned []PageID // 当前操作中固定的页，操作结束时统一释放
+
+	checksumSet bool // 是否通过 WithChecksum 指定了校验和算法
+
+	quarantine []QuarantinedRange // 因页损坏而暂停服务的 key 区间
+
+	opTimeout   time.Duration // 单次点操作的时限，0 表示不限时
+	scanTimeout time.Duration // 单次 Scan 的时限，0 表示不限时
+	started     time.Time     // 当前操作的开始时间
+	deadline    time.Time     // 当前操作的截止时间，零值表示不限时
+
+	splitBias float64 // 分裂最右叶节点时留在左侧的比例，0 表示均分
+
+	maxValueSize int // PutBytes 接受的字节值的大小上限，0 表示使用 DefaultMaxValueSize
+
+	compression ValueCompression  // PutBytes 写入字节值时的压缩方式
+	zw          *flate.Writer     // 复用的压缩器，以 zwDict 为预置字典
+	zwDict      PageID            // zw 使用的字典，0 表示没有字典
+	dicts       map[PageID][]byte // 已读入的压缩字典，按值页链表首页索引
+
+	seedPath string // 新建树文件时写入的种子数据文件，空串表示不写入
+
+	logger *slog.Logger // 非 nil 时记录区间隔离等异常
+
+	deferMeta bool // 为 true 时操作结束不写回文件头：Store 借用本树操作桶期间，根节点暂时是桶的根
+	fixedRoot bool // 为 true 时根节点页号保持不变，根分裂或降低一层时改写根节点页的内容：桶的根记录在目录中
+}
+
+// DiskOption 用于在打开磁盘树时调整其可选行为
+type DiskOption func(*DiskBPlusTree)
+
+// 支持固定页的存储（如 BufferPool）：操作期间读到的页会被固定，直到操作结束
+type pagePinner interface {
+	Pin(id PageID) error
+	Unpin(id PageID)
+}
+
+// OpenDiskBPlusTree 打开 path 处的树文件，文件不存在时创建一棵空树
+func OpenDiskBPlusTree(path string, opts ...DiskOption) (*DiskBPlusTree, error) {
+	pager, err := OpenFilePager(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := NewDiskBPlusTree(pager, opts...)
+	if err != nil {
+		pager.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// NewDiskBPlusTree 在 store 之上打开一棵树；store 为空时初始化文件头与空的根叶节点，
+// 指定了 WithSeedFile 时随后写入种子数据
+func NewDiskBPlusTree(store PageStore, opts ...DiskOption) (*DiskBPlusTree, error) {
+	t := &DiskBPlusTree{store: store, buf: make([]byte, PageSize)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	err := t.store.ReadPage(0, t.buf)
+	if errors.Is(err, ErrPageOutOfRange) {
+		if t.seedPath == "" {
+			return t, t.init()
+		}
+		// 先解析种子文件，出错时不写入任何页
+		keys, values, err := readSeedFile(t.seedPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.init(); err != nil {
+			return nil, err
+		}
+		return t, t.seed(keys, values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	want := t.meta.checksum
+	if t.meta, err = decodeDiskMeta(t.buf); err != nil {
+		return nil, err
+	}
+	if t.checksumSet && t.meta.checksum != want {
+		return nil, fmt.Errorf("打开树文件失败：文件使用 %s 校验和，与指定的 %s 不一致", t.meta.checksum, want)
+	}
+	return t, nil
+}
+
+// 校验并解码文件头
+func decodeDiskMeta(buf []byte) (diskMeta, error) {
+	if string(buf[0:8]) != diskMagic {
+		return diskMeta{}, fmt.Errorf("打开树文件失败：魔数不匹配")
+	}
+	// 版本 4 与版本 5 的区别只是值页可以保存压缩的值，版本 4 的文件可以直接打开，写回文件头时升级为版本 5
+	if v := binary.LittleEndian.Uint32(buf[8:]); v != diskVersion && v != 4 {
+		return diskMeta{}, fmt.Errorf("打开树文件失败：不支持的格式版本 %d", v)
+	}
+	if size := binary.LittleEndian.Uint32(buf[12:]); size != PageSize {
+		return diskMeta{}, fmt.Errorf("打开树文件失败：页大小 %d 与当前的 %d 不一致", size, PageSize)
+	}
+	sum := ChecksumAlgorithm(buf[28])
+	if !sum.valid() {
+		return diskMeta{}, fmt.Errorf("打开树文件失败：未知的校验和算法 %d", buf[28])
+	}
+	if err := sum.verifyPageChecksum(0, buf); err != nil {
+		return diskMeta{}, fmt.Errorf("打开树文件失败：%w", err)
+	}
+	return diskMeta{
+		root:     PageID(binary.LittleEndian.Uint32(buf[16:])),
+		numPages: binary.LittleEndian.Uint32(buf[20:]),
+		freeHead: PageID(binary.LittleEndian.Uint32(buf[24:])),
+		checksum: sum,
+		blobs:    buf[29] == 1,
+		dict:     PageID(binary.LittleEndian.Uint32(buf[40:])),
+	}, nil
+}
+
+// 初始化空树：文件头与一个空的根叶节点
+func (t *DiskBPlusTree) init() error {
+	t.meta = diskMeta{numPages: 1, checksum: t.meta.checksum}
+	root, err := t.allocate(true)
+	if err != nil {
+		return err
+	}
+	t.meta.root = root.id
+	if err := t.writeNode(root); err != nil {
+		return err
+	}
+	return t.writeMeta()
+}
+
+func (t *DiskBPlusTree) writeMeta() error {
+	clear(t.buf)
+	copy(t.buf[0:8], diskMagic)
+	binary.LittleEndian.PutUint32(t.buf[8:], diskVersion)
+	binary.LittleEndian.PutUint32(t.buf[12:], PageSize)
+	binary.LittleEndian.PutUint32(t.buf[16:], uint32(t.meta.root))
+	binary.LittleEndian.PutUint32(t.buf[20:], t.meta.numPages)
+	binary.LittleEndian.PutUint32(t.buf[24:], uint32(t.meta.freeHead))
+	t.buf[28] = byte(t.meta.checksum)
+	if t.meta.blobs {
+		t.buf[29] = 1
+	}
+	binary.LittleEndian.PutUint32(t.buf[40:], uint32(t.meta.dict))
+	t.meta.checksum.setPageChecksum(0, t.buf)
+	return t.store.WritePage(0, t.buf)
+}
+
+// 分配一个页并返回其上的空节点，文件头在本次操作结束时统一写回
+func (t *DiskBPlusTree) allocate(isLeaf bool) (*diskNode, error) {
+	id, err := t.allocatePage()
+	if err != nil {
+		return nil, err
+	}
+	return &diskNode{id: id, isLeaf: isLeaf}, nil
+}
+
+// 分配一个页：优先复用空闲页链表中的页，没有空闲页时才扩展文件
+func (t *DiskBPlusTree) allocatePage() (PageID, error) {
+	if id := t.meta.freeHead; id != 0 {
+		if err := t.store.ReadPage(id, t.buf); err != nil {
+			return 0, err
+		}
+		if err := t.meta.checksum.verifyPageChecksum(id, t.buf); err != nil {
+			return 0, err
+		}
+		if t.buf[0] != pageTypeFree {
+			return 0, corruptPage(id, "空闲页链表指向了类型为 %d 的页", t.buf[0])
+		}
+		t.meta.freeHead = PageID(binary.LittleEndian.Uint32(t.buf[4:]))
+		return id, nil
+	}
+	id := PageID(t.meta.numPages)
+	t.meta.numPages++
+	return id, nil
+}
+
+// 释放不再使用的页，将其加入空闲页链表的表头
+func (t *DiskBPlusTree) free(id PageID) error {
+	clear(t.buf)
+	t.buf[0] = pageTypeFree
+	binary.LittleEndian.PutUint32(t.buf[4:], uint32(t.meta.freeHead))
+	t.meta.checksum.setPageChecksum(id, t.buf)
+	if err := t.store.WritePage(id, t.buf); err != nil {
+		return err
+	}
+	t.meta.freeHead = id
+	return nil
+}
+
+func (t *DiskBPlusTree) readNode(id PageID) (*diskNode, error) {
+	if p, ok := t.store.(pagePinner); ok {
+		if err := p.Pin(id); err != nil {
+			return nil, err
+		}
+		t.pinned = append(t.pinned, id)
+	}
+	if err := t.store.ReadPage(id, t.buf); err != nil {
+		return nil, err
+	}
+	return decodeDiskNode(t.meta.checksum, id, t.buf)
+}
+
+func (t *DiskBPlusTree) writeNode(n *diskNode) error {
+	n.encode(t.buf)
+	t.meta.checksum.setPageChecksum(n.id, t.buf)
+	return t.store.WritePage(n.id, t.buf)
+}
+
+// 释放本次操作中固定的全部页
+func (t *DiskBPlusTree) unpinAll() {
+	if p, ok := t.store.(pagePinner); ok {
+		for _, id := range t.pinned {
+			p.Unpin(id)
+		}
+	}
+	t.pinned = t.pinned[:0]
+}
+
+// 下降路径上的一步：经过的内部节点及所选子节点的下标
+type diskPathEntry struct {
+	node  *diskNode
+	index int
+}
+
+// 从根下降到应存放 key 的叶节点，返回叶节点及沿途经过的内部节点
+func (t *DiskBPlusTree) descend(key int) (*diskNode, []diskPathEntry, error) {
+	var path []diskPathEntry
+	if err := t.checkQuarantine(key); err != nil {
+		return nil, nil, err
+	}
+	if err := t.checkDeadline(); err != nil {
+		return nil, nil, err
+	}
+	// [lo, hi] 为当前节点覆盖的 key 区间，读到损坏页时据此隔离
+	lo, hi := math.MinInt, math.MaxInt
+	id := t.meta.root
+	node, err := t.readNode(id)
+	for err == nil && !node.isLeaf {
+		// 第一个最大键不小于 key 的子节点，否则为最后一个
+		i := sort.SearchInts(node.keys, key)
+		if i == len(node.keys) {
+			i--
+		}
+		lo, hi = childRange(node, i, lo, hi)
+		path = append(path, diskPathEntry{node, i})
+		if err = t.checkDeadline(); err != nil {
+			break
+		}
+		id = node.children[i]
+		node, err = t.readNode(id)
+	}
+	if err != nil {
+		return nil, nil, t.quarantineOnCorrupt(err, key, KeyRange{Min: lo, Max: hi}, id)
+	}
+	return node, path, nil
+}
+
+// 将 node 的后半部分移至新分配的兄弟节点
+func (t *DiskBPlusTree) split(node *diskNode) (*diskNode, error) {
+	sibling, err := t.allocate(node.isLeaf)
+	if err != nil {
+		return nil, err
+	}
+	mid := len(node.keys) / 2
+	if node.isLeaf && node.next == 0 {
+		// 最右叶节点按分裂偏置多留一些在左侧
+		mid = splitPoint(len(node.keys), t.splitBias, DiskMaxKeys)
+	}
+	sibling.keys = append(make([]int, 0, DiskMaxKeys+1), node.keys[mid:]...)
+	node.keys = node.keys[:mid]
+	if node.isLeaf {
+		sibling.values = append(make([]int, 0, DiskMaxKeys+1), node.values[mid:]...)
+		node.values = node.values[:mid]
+		sibling.next = node.next
+		node.next = sibling.id
+	} else {
+		sibling.children = append(make([]PageID, 0, DiskMaxKeys+1), node.children[mid:]...)
+		node.children = node.children[:mid]
+	}
+	return sibling, nil
+}
+
+// Insert 插入键值对，并在必要时分裂节点
+func (t *DiskBPlusTree) Insert(key, value int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.checkIntValues("插入"); err != nil {
+		return err
+	}
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	leaf, path, err := t.descend(key)
+	if err != nil {
+		return err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	leaf.keys = insertAt(leaf.keys, pos, key)
+	leaf.values = insertAt(leaf.values, pos, value)
+	old := t.meta
+	if err := t.insertUp(path, leaf); err != nil {
+		return err
+	}
+	return t.finish(old)
+}
+
+// 写回被修改的 node，并沿下降路径向上处理分裂与父节点关键词的更新
+func (t *DiskBPlusTree) insertUp(path []diskPathEntry, node *diskNode) error {
+	for level := len(path) - 1; level >= 0; level-- {
+		var sibling *diskNode
+		if len(node.keys) > DiskMaxKeys {
+			var err error
+			if sibling, err = t.split(node); err != nil {
+				return err
+			}
+			if err := t.writeNode(sibling); err != nil {
+				return err
+			}
+		}
+		if err := t.writeNode(node); err != nil {
+			return err
+		}
+		parent, i := path[level].node, path[level].index
+		changed := parent.keys[i] != node.maxKey()
+		parent.keys[i] = node.maxKey()
+		if sibling != nil {
+			parent.keys = insertAt(parent.keys, i+1, sibling.maxKey())
+			parent.children = insertAt(parent.children, i+1, sibling.id)
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		node = parent
+	}
+
+	// node 为根节点：根分裂时树长高一层
+	if len(node.keys) > DiskMaxKeys {
+		sibling, err := t.split(node)
+		if err != nil {
+			return err
+		}
+		if t.fixedRoot {
+			return t.growFixedRoot(node, sibling)
+		}
+		root, err := t.allocate(false)
+		if err != nil {
+			return err
+		}
+		root.keys = []int{node.maxKey(), sibling.maxKey()}
+		root.children = []PageID{node.id, sibling.id}
+		for _, n := range []*diskNode{sibling, root} {
+			if err := t.writeNode(n); err != nil {
+				return err
+			}
+		}
+		t.setRoot(root.id)
+	}
+	return t.writeNode(node)
+}
+
+// 根节点页号固定时的根分裂：左半部分移到新分配的页，根节点页改写为指向左右两半的内部节点
+func (t *DiskBPlusTree) growFixedRoot(root, sibling *diskNode) error {
+	left, err := t.allocate(root.isLeaf)
+	if err != nil {
+		return err
+	}
+	left.keys, left.values, left.children, left.next = root.keys, root.values, root.children, root.next
+	if err := t.writeNodes(sibling, left); err != nil {
+		return err
+	}
+	root.isLeaf, root.values, root.next = false, nil, 0
+	root.keys = []int{left.maxKey(), sibling.maxKey()}
+	root.children = []PageID{left.id, sibling.id}
+	return t.writeNode(root)
+}
+
+// 更换根节点页号，已隔离的区间随之归属新的根
+func (t *DiskBPlusTree) setRoot(id PageID) {
+	for i := range t.quarantine {
+		if t.quarantine[i].Root == t.meta.root {
+			t.quarantine[i].Root = id
+		}
+	}
+	t.meta.root = id
+}
+
+// 操作结束时，若文件头中的元数据（根节点、页数、空闲页链表）发生变化则写回
+func (t *DiskBPlusTree) finish(old diskMeta) error {
+	if t.meta == old || t.deferMeta {
+		return nil
+	}
+	return t.writeMeta()
+}
+
+// Remove 删除 key，并在必要时借补或合并节点
+func (t *DiskBPlusTree) Remove(key int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.checkIntValues("删除"); err != nil {
+		return err
+	}
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	leaf, path, err := t.descend(key)
+	if err != nil {
+		return err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		return fmt.Errorf("删除失败：未找到 key = %d", key)
+	}
+	leaf.keys = removeAt(leaf.keys, pos)
+	leaf.values = removeAt(leaf.values, pos)
+	old := t.meta
+	if err := t.removeUp(path, leaf); err != nil {
+		return err
+	}
+	return t.finish(old)
+}
+
+// 写回被修改的 node，并沿下降路径向上处理下溢（借补或合并）与父节点关键词的更新
+func (t *DiskBPlusTree) removeUp(path []diskPathEntry, node *diskNode) error {
+	for level := len(path) - 1; level >= 0; level-- {
+		parent, i := path[level].node, path[level].index
+		if len(node.keys) >= diskMinKeys {
+			if err := t.writeNode(node); err != nil {
+				return err
+			}
+			if parent.keys[i] == node.maxKey() {
+				return nil
+			}
+			parent.keys[i] = node.maxKey()
+			node = parent
+			continue
+		}
+		if err := t.fixUnderflow(parent, i, node); err != nil {
+			return err
+		}
+		node = parent
+	}
+
+	// node 为根节点：只剩一个子节点的内部根节点被移除，树降低一层
+	if !node.isLeaf && len(node.children) == 1 {
+		if t.fixedRoot {
+			// 根节点页号固定：把唯一的子节点搬进根节点页，释放子节点的页。
+			// 子节点是叶节点时它是唯一的叶节点，没有其他叶节点指向它
+			child, err := t.readNode(node.children[0])
+			if err != nil {
+				return err
+			}
+			childID := child.id
+			child.id = node.id
+			if err := t.writeNode(child); err != nil {
+				return err
+			}
+			return t.free(childID)
+		}
+		t.setRoot(node.children[0])
+		return t.free(node.id)
+	}
+	return t.writeNode(node)
+}
+
+// 处理 parent 第 i 个子节点 node 的下溢：优先向左右兄弟借一个条目，否则与兄弟合并。
+// 修改后的子节点会被写回，parent 的关键词随之更新，但 parent 本身由调用方写回
+func (t *DiskBPlusTree) fixUnderflow(parent *diskNode, i int, node *diskNode) error {
+	var left, right *diskNode
+	var err error
+	if i > 0 {
+		if left, err = t.readNode(parent.children[i-1]); err != nil {
+			return err
+		}
+		if len(left.keys) > diskMinKeys {
+			moveEntry(left, len(left.keys)-1, node, 0)
+			parent.keys[i-1] = left.maxKey()
+			parent.keys[i] = node.maxKey()
+			return t.writeNodes(left, node)
+		}
+	}
+	if i < len(parent.children)-1 {
+		if right, err = t.readNode(parent.children[i+1]); err != nil {
+			return err
+		}
+		if len(right.keys) > diskMinKeys {
+			moveEntry(right, 0, node, len(node.keys))
+			parent.keys[i] = node.maxKey()
+			return t.writeNodes(node, right)
+		}
+	}
+	if left != nil {
+		mergeInto(left, node)
+		parent.keys[i-1] = left.maxKey()
+		parent.keys = removeAt(parent.keys, i)
+		parent.children = removeAt(parent.children, i)
+		if err := t.writeNode(left); err != nil {
+			return err
+		}
+		return t.free(node.id)
+	}
+	mergeInto(node, right)
+	parent.keys[i] = node.maxKey()
+	parent.keys = removeAt(parent.keys, i+1)
+	parent.children = removeAt(parent.children, i+1)
+	if err := t.writeNode(node); err != nil {
+		return err
+	}
+	return t.free(right.id)
+}
+
+func (t *DiskBPlusTree) writeNodes(nodes ...*diskNode) error {
+	for _, n := range nodes {
+		if err := t.writeNode(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将 from 的第 i 个条目移到 to 的第 j 个位置
+func moveEntry(from *diskNode, i int, to *diskNode, j int) {
+	to.keys = insertAt(to.keys, j, from.keys[i])
+	from.keys = removeAt(from.keys, i)
+	if from.isLeaf {
+		to.values = insertAt(to.values, j, from.values[i])
+		from.values = removeAt(from.values, i)
+	} else {
+		to.children = insertAt(to.children, j, from.children[i])
+		from.children = removeAt(from.children, i)
+	}
+}
+
+// 将右侧相邻节点 right 的全部条目追加到 left 中
+func mergeInto(left, right *diskNode) {
+	left.keys = append(left.keys, right.keys...)
+	if left.isLeaf {
+		left.values = append(left.values, right.values...)
+		left.next = right.next
+	} else {
+		left.children = append(left.children, right.children...)
+	}
+}
+
+func insertAt[T any](s []T, i int, v T) []T {
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func removeAt[T any](s []T, i int) []T {
+	return append(s[:i], s[i+1:]...)
+}
+
+// Modify 修改 key 对应的 value
+func (t *DiskBPlusTree) Modify(key, newValue int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.checkIntValues("修改"); err != nil {
+		return err
+	}
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	leaf, _, err := t.descend(key)
+	if err != nil {
+		return err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		return fmt.Errorf("修改失败：未找到 key = %d", key)
+	}
+	leaf.values[pos] = newValue
+	return t.writeNode(leaf)
+}
+
+// Search 返回 key 对应的 value；若不存在返回 -1
+func (t *DiskBPlusTree) Search(key int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.checkIntValues("查找"); err != nil {
+		return -1, err
+	}
+	t.startDeadline(t.opTimeout)
+	defer t.unpinAll()
+	leaf, _, err := t.descend(key)
+	if err != nil {
+		return -1, err
+	}
+	pos := sort.SearchInts(leaf.keys, key)
+	if pos >= len(leaf.keys) || leaf.keys[pos] != key {
+		return -1, nil
+	}
+	return leaf.values[pos], nil
+}
+
+// Scan 沿叶节点链表按 key 升序遍历全部键值对，fn 返回 false 时提前结束。
+// 遍历期间持有树的锁，fn 中不得再调用该树的方法
+func (t *DiskBPlusTree) Scan(fn func(key, value int) bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.checkIntValues("遍历"); err != nil {
+		return err
+	}
+	t.startDeadline(t.scanTimeout)
+	defer t.unpinAll()
+	return t.scanLocked(fn)
+}
+
+// Scan 的实现，调用方须持有锁
+func (t *DiskBPlusTree) scanLocked(fn func(key, value int) bool) error {
+	node, err := t.readNode(t.meta.root)
+	for err == nil && !node.isLeaf {
+		node, err = t.readNode(node.children[0])
+	}
+	for err == nil {
+		for i, key := range node.keys {
+			if !fn(key, node.values[i]) {
+				return nil
+			}
+		}
+		if node.next == 0 {
+			return nil
+		}
+		// 已遍历完的叶节点不再需要固定，避免长扫描占满缓冲池
+		t.unpinAll()
+		if err = t.checkDeadline(); err != nil {
+			return err
+		}
+		node, err = t.readNode(node.next)
+	}
+	return err
+}
+
+// Sync 将已写入的页刷到持久存储
+func (t *DiskBPlusTree) Sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.store.Sync()
+}
+
+// Close 刷盘并关闭底层存储
+func (t *DiskBPlusTree) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.store.Sync(); err != nil {
+		t.store.Close()
+		return err
+	}
+	return t.store.Close()
+}
@@ -0,0 +1,276 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// 读出磁盘树的全部键值对
+func diskContents(t *testing.T, tree *DiskBPlusTree) []KeyValue {
+	t.Helper()
+	var kvs []KeyValue
+	if err := tree.Scan(func(k, v int) bool {
+		kvs = append(kvs, KeyValue{Key: k, Value: v})
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return kvs
+}
+
+// 随机的插入、删除与修改（足以引起叶节点的分裂、借补与合并）之后，树的内容与对照的 map 一致，重新打开后仍然一致
+func TestDiskTreeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []DiskOption
+	}{
+		{"file", nil},
+		{"buffer-pool", []DiskOption{WithBufferPool(8 * PageSize)}},
+		{"xxhash", []DiskOption{WithChecksum(ChecksumXXHash64)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, path := openTempDiskTree(t, tt.opts...)
+			rng := rand.New(rand.NewSource(1))
+			want := make(map[int]int)
+			for range 20000 {
+				k := rng.Intn(3 * DiskMaxKeys * DiskMaxKeys / 100)
+				_, ok := want[k]
+				switch op := rng.Intn(10); {
+				case !ok && op < 6:
+					if err := tree.Insert(k, k); err != nil {
+						t.Fatal(err)
+					}
+					want[k] = k
+				case ok && op < 8:
+					if err := tree.Remove(k); err != nil {
+						t.Fatal(err)
+					}
+					delete(want, k)
+				case ok:
+					if err := tree.Modify(k, -k); err != nil {
+						t.Fatal(err)
+					}
+					want[k] = -k
+				}
+			}
+			if got := diskContents(t, tree); !slices.Equal(got, sortedEntries(want)) {
+				t.Fatalf("树中有 %d 个条目，期望 %d 个", len(got), len(want))
+			}
+			if err := tree.Close(); err != nil {
+				t.Fatal(err)
+			}
+			tree, err := OpenDiskBPlusTree(path, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tree.Close()
+			if got := diskContents(t, tree); !slices.Equal(got, sortedEntries(want)) {
+				t.Fatalf("重新打开后有 %d 个条目，期望 %d 个", len(got), len(want))
+			}
+			for k := range 50 {
+				v, err := tree.Search(k)
+				if w, ok := want[k]; err != nil || ok && v != w || !ok && v != -1 {
+					t.Fatalf("Search(%d) = %d, %v", k, v, err)
+				}
+			}
+		})
+	}
+}
+
+// 删除释放的页进入空闲页链表，之后的插入复用它们而不扩展文件
+func TestDiskTreeReusesFreedPages(t *testing.T) {
+	tree, _ := openTempDiskTree(t)
+	defer tree.Close()
+	n := 10 * DiskMaxKeys
+	for k := range n {
+		tree.Insert(k, k)
+	}
+	pages := tree.meta.numPages
+	for k := range n {
+		if err := tree.Remove(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for k := range n {
+		tree.Insert(k, k)
+	}
+	if tree.meta.numPages > pages {
+		t.Fatalf("删除后重新插入使文件从 %d 页增长到 %d 页", pages, tree.meta.numPages)
+	}
+}
+
+func TestDiskTreeMissingKey(t *testing.T) {
+	tree, _ := openTempDiskTree(t)
+	defer tree.Close()
+	tree.Insert(1, 1)
+	if err := tree.Remove(2); err == nil {
+		t.Fatal("删除不存在的 key 应返回错误")
+	}
+	if err := tree.Modify(2, 2); err == nil {
+		t.Fatal("修改不存在的 key 应返回错误")
+	}
+	if v, err := tree.Search(2); err != nil || v != -1 {
+		t.Fatalf("Search(2) = %d, %v", v, err)
+	}
+}
+
+// 不是树文件、版本或页大小不符的文件无法打开
+func TestDiskTreeOpenErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch func(header []byte)
+	}{
+		{"bad-magic", func(h []byte) { copy(h, "NOTATREE") }},
+		{"future-version", func(h []byte) { binary.LittleEndian.PutUint32(h[8:], diskVersion+1) }},
+		{"page-size", func(h []byte) { binary.LittleEndian.PutUint32(h[12:], PageSize*2) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, path := openTempDiskTree(t)
+			tree.Close()
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header := make([]byte, PageSize)
+			f.ReadAt(header, 0)
+			tt.patch(header)
+			ChecksumCRC32.setPageChecksum(0, header)
+			f.WriteAt(header, 0)
+			f.Close()
+			if _, err := OpenDiskBPlusTree(path); err == nil {
+				t.Fatal("打开应失败")
+			}
+		})
+	}
+}
+
+// 损坏一个叶节点页：它覆盖的区间被隔离，其余区间照常读写；RepairInto 原样复制完好的子树，
+// 损坏的区间由 restore 从备份补齐，restore 返回的区间之外的条目被忽略
+func TestDiskTreeQuarantineRepair(t *testing.T) {
+	const target = 3 * DiskMaxKeys
+	n := 4 * DiskMaxKeys
+	backup := make([]KeyValue, n)
+	for k := range n {
+		backup[k] = KeyValue{Key: k, Value: k * 2}
+	}
+	tests := []struct {
+		name    string
+		search  bool // 修复前先通过 Search 发现损坏并隔离区间
+		restore func(r KeyRange) ([]KeyValue, error)
+		wantErr bool
+		lost    bool // 损坏区间内的条目在修复结果中缺失
+	}{
+		{"restore-backup", true, func(KeyRange) ([]KeyValue, error) { return backup, nil }, false, false},
+		{"restore-undiscovered", false, func(KeyRange) ([]KeyValue, error) { return backup, nil }, false, false},
+		{"restore-nothing", true, func(KeyRange) ([]KeyValue, error) { return nil, nil }, false, true},
+		{"restore-error", true, func(KeyRange) ([]KeyValue, error) { return nil, errInjected }, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, path := openTempDiskTree(t)
+			for _, kv := range backup {
+				tree.Insert(kv.Key, kv.Value)
+			}
+			id := leafPage(t, tree, target)
+			tree.Close()
+			flipFileByte(t, path, id, pageHeaderSize+3)
+			tree, err := OpenDiskBPlusTree(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tree.Close()
+
+			var quarantined KeyRange
+			if tt.search {
+				if _, err := tree.Search(target); !errors.Is(err, ErrRangeUnavailable) {
+					t.Fatalf("Search(%d) 返回 %v，期望区间被隔离", target, err)
+				}
+				q := tree.Quarantined()
+				if len(q) != 1 || q[0].Page != id || !q[0].Range.Contains(target) || q[0].Range.Contains(0) {
+					t.Fatalf("Quarantined() = %+v，期望只隔离页 %d 覆盖的区间", q, id)
+				}
+				quarantined = q[0].Range
+				if err := tree.Insert(target, 1); !errors.Is(err, ErrRangeUnavailable) {
+					t.Fatalf("向隔离区间插入返回 %v", err)
+				}
+				if err := tree.Remove(quarantined.Min); !errors.Is(err, ErrRangeUnavailable) {
+					t.Fatalf("从隔离区间删除返回 %v", err)
+				}
+				// 区间之外照常读写
+				for _, k := range []int{0, quarantined.Min - 1} {
+					if v, err := tree.Search(k); err != nil || v != k*2 {
+						t.Fatalf("Search(%d) = %d, %v", k, v, err)
+					}
+				}
+				if err := tree.Modify(1, 2); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var restored []KeyRange
+			repaired, err := tree.RepairInto(NewArenaPager(16), func(r KeyRange) ([]KeyValue, error) {
+				restored = append(restored, r)
+				return tt.restore(r)
+			})
+			if tt.wantErr {
+				if !errors.Is(err, errInjected) {
+					t.Fatalf("RepairInto 返回 %v，期望 restore 的错误", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer repaired.Close()
+			if len(restored) != 1 || !restored[0].Contains(target) || tt.search && restored[0] != quarantined {
+				t.Fatalf("restore 收到的区间为 %v，期望只有损坏页覆盖的区间 %v", restored, quarantined)
+			}
+			want := slices.DeleteFunc(slices.Clone(backup), func(kv KeyValue) bool {
+				return tt.lost && restored[0].Contains(kv.Key)
+			})
+			if got := diskContents(t, repaired); !slices.Equal(got, want) {
+				t.Fatalf("修复后有 %d 个条目，期望 %d 个", len(got), len(want))
+			}
+			if q := repaired.Quarantined(); len(q) != 0 {
+				t.Fatalf("修复后的树仍有隔离区间 %+v", q)
+			}
+		})
+	}
+}
+
+func TestFilePager(t *testing.T) {
+	p, err := OpenFilePager(filepath.Join(t.TempDir(), "pages"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	buf := make([]byte, PageSize)
+	if err := p.ReadPage(0, buf); !errors.Is(err, ErrPageOutOfRange) {
+		t.Fatalf("读取空文件返回 %v，期望 ErrPageOutOfRange", err)
+	}
+	page := make([]byte, PageSize)
+	page[0], page[PageSize-1] = 1, 2
+	if err := p.WritePage(3, page); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ReadPage(3, buf); err != nil || buf[0] != 1 || buf[PageSize-1] != 2 {
+		t.Fatalf("ReadPage(3) = %v", err)
+	}
+	// 文件中的空洞读出全零
+	if err := p.ReadPage(1, buf); err != nil || buf[0] != 0 {
+		t.Fatalf("ReadPage(1) = %d, %v", buf[0], err)
+	}
+	if err := p.ReadPage(4, buf); !errors.Is(err, ErrPageOutOfRange) {
+		t.Fatalf("ReadPage(4) 返回 %v，期望 ErrPageOutOfRange", err)
+	}
+	if _, err := OpenFilePager(filepath.Join(t.TempDir(), "missing", "pages")); err == nil {
+		t.Fatal("目录不存在时应返回错误")
+	}
+}
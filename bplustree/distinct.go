@@ -0,0 +1,48 @@
+package bplustree
+
+import "bytes"
+
+// CountDistinctPrefixes 返回 t 中不同的 depth 字节前缀的个数，长度不足 depth 的键以整个键为前缀；
+// 例如键为 "tenant/…" 形式且租户名定长时，可以不遍历全部键统计租户数。
+// 内部节点的关键词是对应子树中键的上界：若遍历到的上一个键与子树的上界前缀相同，
+// 子树中的键都介于两者之间，必然带有同一个前缀，整棵子树被跳过。
+// 因此开销与不同前缀的个数和树高成正比，而不是与键的个数成正比，结果是精确的
+func CountDistinctPrefixes[V any](t *OrderedTree[[]byte, V], depth int) int {
+	if t.size == 0 {
+		return 0
+	}
+	c := prefixCounter[V]{t: t, depth: max(depth, 0)}
+	c.visit(t.root)
+	return c.count
+}
+
+type prefixCounter[V any] struct {
+	t      *OrderedTree[[]byte, V]
+	depth  int
+	last   []byte // 遍历到的上一个键的前缀
+	count  int
+	leaves int // 访问过的叶节点数
+}
+
+func (c *prefixCounter[V]) prefix(key []byte) []byte {
+	return key[:min(len(key), c.depth)]
+}
+
+func (c *prefixCounter[V]) visit(n *orderedNode[[]byte, V]) {
+	if n.isLeaf {
+		c.leaves++
+		for i := range n.keys {
+			if p := c.prefix(c.t.key(n, i)); c.count == 0 || !bytes.Equal(p, c.last) {
+				c.count++
+				c.last = p
+			}
+		}
+		return
+	}
+	for i, child := range n.children {
+		if c.count > 0 && bytes.Equal(c.prefix(n.keys[i]), c.last) {
+			continue
+		}
+		c.visit(child)
+	}
+}
@@ -0,0 +1,79 @@
+package bplustree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// 逐个遍历键统计不同前缀的个数，作为对照
+func bruteDistinctPrefixes(t *OrderedTree[[]byte, int], depth int) int {
+	seen := make(map[string]bool)
+	t.Scan(func(k []byte, _ int) bool {
+		seen[string(k[:min(len(k), max(depth, 0))])] = true
+		return true
+	})
+	return len(seen)
+}
+
+func TestCountDistinctPrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		key  func(r *rand.Rand, i int) []byte
+	}{
+		{"empty", 0, nil},
+		{"tenants", 2000, func(r *rand.Rand, i int) []byte {
+			return fmt.Appendf(nil, "t%02d/obj/%d", r.Intn(37), i)
+		}},
+		{"single-tenant", 500, func(r *rand.Rand, i int) []byte { return fmt.Appendf(nil, "only/%d", i) }},
+		{"short-keys", 500, func(r *rand.Rand, i int) []byte { return []byte("abcdef")[:r.Intn(7)] }},
+		{"random-bytes", 1000, func(r *rand.Rand, i int) []byte {
+			k := make([]byte, r.Intn(6))
+			r.Read(k)
+			return k
+		}},
+	}
+	for _, tt := range tests {
+		for _, newTree := range []func() *OrderedTree[[]byte, int]{NewBytesTree[int], NewPrefixBytesTree[int]} {
+			tree := newTree()
+			r := rand.New(rand.NewSource(1))
+			for i := range tt.n {
+				tree.Insert(tt.key(r, i), i)
+			}
+			// 删除一部分键，使内部节点的关键词经过更新
+			for i := 0; i < tt.n; i += 3 {
+				if k, _, ok := tree.Min(); ok && i%2 == 0 {
+					tree.Remove(k)
+				}
+			}
+			for _, depth := range []int{-1, 0, 1, 2, 3, 4, 100} {
+				t.Run(fmt.Sprintf("%s/prefixed=%v/depth=%d", tt.name, tree.prefixed, depth), func(t *testing.T) {
+					if got, want := CountDistinctPrefixes(tree, depth), bruteDistinctPrefixes(tree, depth); got != want {
+						t.Fatalf("CountDistinctPrefixes = %d，期望 %d", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+// 前缀很少时只访问少数叶节点，不遍历全部键
+func TestCountDistinctPrefixesSkipsSubtrees(t *testing.T) {
+	tree := NewBytesTree[int]()
+	for i := range 10000 {
+		tree.Insert(fmt.Appendf(nil, "tenant-%d/%05d", i%4, i), i)
+	}
+	c := prefixCounter[int]{t: tree, depth: len("tenant-0")}
+	c.visit(tree.root)
+	if c.count != 4 {
+		t.Fatalf("统计到 %d 个前缀，期望 4", c.count)
+	}
+	total := 0
+	for leaf := tree.findLeaf([]byte{}); leaf != nil; leaf = leaf.next {
+		total++
+	}
+	if c.leaves*20 > total {
+		t.Fatalf("访问了 %d 个叶节点，共 %d 个", c.leaves, total)
+	}
+}
@@ -0,0 +1,71 @@
+package bplustree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT 以 Graphviz DOT 格式输出树的结构：内部节点、叶节点、父子边（实线）
+// 以及叶节点链表的 next 边（虚线，同层叶节点排在同一行）。
+// 链表边按节点实际的 next 指针绘制，指向树外节点的 next 会画成红色的悬空节点，便于排查分裂、合并中的错误。
+// 可用 `dot -Tsvg tree.dot -o tree.svg` 渲染
+func (bpt *BPlusTree) WriteDOT(w io.Writer) error {
+	// 按层次顺序为节点编号，与 Walk 中的编号一致
+	ids := map[*Node]int{}
+	var nodes []*Node
+	for level := []*Node{bpt.root}; len(level) > 0; {
+		var next []*Node
+		for _, node := range level {
+			ids[node] = len(nodes)
+			nodes = append(nodes, node)
+			next = append(next, node.children...)
+		}
+		level = next
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph bplustree {")
+	fmt.Fprintln(bw, "\tnode [shape=record, fontname=\"monospace\"];")
+	var leaves []string
+	for _, node := range nodes {
+		id := ids[node]
+		fields := make([]string, len(node.keys))
+		for i, key := range node.keys {
+			if node.isLeaf {
+				fields[i] = fmt.Sprintf("%d: %d", key, node.values[i])
+			} else {
+				fields[i] = fmt.Sprint(key)
+			}
+		}
+		label := strings.Join(fields, "|")
+		if label == "" {
+			label = " "
+		}
+		if node.isLeaf {
+			fmt.Fprintf(bw, "\tn%d [label=\"%s\", style=filled, fillcolor=\"#e8f4e8\"];\n", id, label)
+			leaves = append(leaves, fmt.Sprintf("n%d", id))
+		} else {
+			fmt.Fprintf(bw, "\tn%d [label=\"%s\"];\n", id, label)
+		}
+		for _, child := range node.children {
+			fmt.Fprintf(bw, "\tn%d -> n%d;\n", id, ids[child])
+		}
+		if node.next == nil {
+			continue
+		}
+		if next, ok := ids[node.next]; ok {
+			fmt.Fprintf(bw, "\tn%d -> n%d [style=dashed, constraint=false];\n", id, next)
+		} else {
+			fmt.Fprintf(bw, "\tdangling%d [label=\"?\", color=red, fontcolor=red];\n", id)
+			fmt.Fprintf(bw, "\tn%d -> dangling%d [style=dashed, color=red];\n", id, id)
+		}
+	}
+	fmt.Fprintf(bw, "\t{ rank=same; %s }\n", strings.Join(leaves, "; "))
+	fmt.Fprintln(bw, "}")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("输出 DOT 失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package bplustree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// MirrorStore 是双写迁移中旧存储需要实现的接口。map、bolt 或另一棵树都可以通过适配实现它
+type MirrorStore interface {
+	Get(key int) (value int, ok bool, err error)
+	Put(key, value int) error
+	Delete(key int) error
+}
+
+// MapMirror 把 map 适配为 MirrorStore
+type MapMirror map[int]int
+
+func (m MapMirror) Get(key int) (int, bool, error) {
+	v, ok := m[key]
+	return v, ok, nil
+}
+
+func (m MapMirror) Put(key, value int) error {
+	m[key] = value
+	return nil
+}
+
+func (m MapMirror) Delete(key int) error {
+	delete(m, key)
+	return nil
+}
+
+// TreeMirror 把另一棵 BPlusTree 适配为 MirrorStore，Put 在 key 已存在时覆盖
+func TreeMirror(tree *BPlusTree) MirrorStore {
+	return treeMirror{tree}
+}
+
+type treeMirror struct {
+	tree *BPlusTree
+}
+
+func (m treeMirror) Get(key int) (int, bool, error) {
+	v, ok := m.tree.lookup(key)
+	return v, ok, nil
+}
+
+func (m treeMirror) Put(key, value int) error {
+	if _, ok := m.tree.lookup(key); ok {
+		return m.tree.Modify(key, value)
+	}
+	m.tree.Insert(key, value)
+	return nil
+}
+
+func (m treeMirror) Delete(key int) error {
+	if _, ok := m.tree.lookup(key); !ok {
+		return nil
+	}
+	return m.tree.Remove(key)
+}
+
+// Divergence 描述一次被发现的新旧存储不一致
+type Divergence struct {
+	Op        string // 发现不一致时执行的操作：insert、remove、modify 或 search
+	Key       int
+	Tree      int  // 树中的值，TreeFound 为 false 时无意义
+	TreeFound bool // key 是否存在于树中
+	// Legacy 与 LegacyFound 为旧存储中的值及 key 是否存在
+	Legacy      int
+	LegacyFound bool
+	Err         error // 旧存储返回的错误；非 nil 时 Legacy 与 LegacyFound 无意义
+}
+
+func (d Divergence) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s key = %d：旧存储出错：%v", d.Op, d.Key, d.Err)
+	}
+	return fmt.Sprintf("%s key = %d：树中为 (%d, %t)，旧存储中为 (%d, %t)",
+		d.Op, d.Key, d.Tree, d.TreeFound, d.Legacy, d.LegacyFound)
+}
+
+// DualWriteOptions 配置双写包装
+type DualWriteOptions struct {
+	// SampleRate 为每次操作之后对该 key 做一致性比对的概率，取值 (0, 1]，默认为 0.01
+	SampleRate float64
+	// OnDivergence 在每次发现不一致时被调用；它在持有包装锁时执行，不得调用包装的方法
+	OnDivergence func(d Divergence)
+	// Rand 为抽样使用的随机源，nil 时使用全局随机源；固定种子可以重放同一组抽样
+	Rand *rand.Rand
+}
+
+// DualWriteStats 是双写包装的累计统计
+type DualWriteStats struct {
+	Writes      uint64 // 已镜像的写操作数
+	Sampled     uint64 // 已做一致性比对的次数
+	Divergences uint64 // 发现的不一致次数（包括旧存储写入失败）
+}
+
+// DualWriter 在迁移期间把所有修改同时应用到树和旧存储，读操作由树提供，
+// 并按比例抽样比对两边的结果，在切换之前暴露两者之间的差异
+type DualWriter struct {
+	mu     sync.Mutex
+	tree   *BPlusTree
+	legacy MirrorStore
+	opts   DualWriteOptions
+	stats  DualWriteStats
+	rand   *randSource
+}
+
+// NewDualWriter 创建双写包装。tree 与 legacy 此后只应通过包装修改，否则比对结果没有意义
+func NewDualWriter(tree *BPlusTree, legacy MirrorStore, opts DualWriteOptions) *DualWriter {
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		opts.SampleRate = 0.01
+	}
+	return &DualWriter{tree: tree, legacy: legacy, opts: opts, rand: newRandSource(opts.Rand)}
+}
+
+// Insert 向树插入键值对并写入旧存储
+func (w *DualWriter) Insert(key, value int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tree.Insert(key, value)
+	return w.mirror("insert", key, w.legacy.Put(key, value))
+}
+
+// Remove 从树中删除 key 并从旧存储删除；树中不存在 key 时两边都不修改
+func (w *DualWriter) Remove(key int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.tree.Remove(key); err != nil {
+		return err
+	}
+	return w.mirror("remove", key, w.legacy.Delete(key))
+}
+
+// Modify 修改树中 key 对应的 value 并写入旧存储；树中不存在 key 时两边都不修改
+func (w *DualWriter) Modify(key, newValue int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.tree.Modify(key, newValue); err != nil {
+		return err
+	}
+	return w.mirror("modify", key, w.legacy.Put(key, newValue))
+}
+
+// Search 从树中查找 key 对应的 value，若不存在返回 -1；按抽样比例与旧存储比对
+func (w *DualWriter) Search(key int) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sample("search", key)
+	return w.tree.Search(key)
+}
+
+// Stats 返回累计统计
+func (w *DualWriter) Stats() DualWriteStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// 记录一次写操作的镜像结果：旧存储写入失败直接视为不一致，否则按抽样比例比对
+func (w *DualWriter) mirror(op string, key int, err error) error {
+	w.stats.Writes++
+	if err != nil {
+		w.report(Divergence{Op: op, Key: key, Err: err})
+		return fmt.Errorf("双写失败：写入旧存储出错：%w", err)
+	}
+	w.sample(op, key)
+	return nil
+}
+
+// 以 SampleRate 的概率比对 key 在树与旧存储中的值
+func (w *DualWriter) sample(op string, key int) {
+	if w.rand.Float64() >= w.opts.SampleRate {
+		return
+	}
+	w.stats.Sampled++
+	d := Divergence{Op: op, Key: key}
+	d.Tree, d.TreeFound = w.tree.lookup(key)
+	d.Legacy, d.LegacyFound, d.Err = w.legacy.Get(key)
+	if d.Err != nil || d.TreeFound != d.LegacyFound || (d.TreeFound && d.Tree != d.Legacy) {
+		w.report(d)
+	}
+}
+
+func (w *DualWriter) report(d Divergence) {
+	w.stats.Divergences++
+	if w.opts.OnDivergence != nil {
+		w.opts.OnDivergence(d)
+	}
+}
@@ -0,0 +1,107 @@
+package bplustree
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 由调用方提供的密钥创建 AES-GCM，密钥长度须为 16、24 或 32 字节（AES-128/192/256）
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败：%w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// 加密 plain 并追加到 dst，密文格式为 [随机 nonce][密文及认证标签]
+func sealBytes(aead cipher.AEAD, dst, plain, aad []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err) // crypto/rand 不会失败
+	}
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plain, aad)
+}
+
+// 解密 sealBytes 生成的密文
+func openSealed(aead cipher.AEAD, dst, sealed, aad []byte) ([]byte, error) {
+	n := aead.NonceSize()
+	if len(sealed) < n {
+		return nil, fmt.Errorf("密文过短")
+	}
+	return aead.Open(dst, sealed[:n], sealed[n:], aad)
+}
+
+// 每页加密后增加的字节数：12 字节 nonce 与 16 字节认证标签
+const encryptedPageOverhead = 12 + 16
+
+// EncryptedPager 是加密存储的文件 PageStore：每页以 AES-GCM 加密后写入
+// PageSize+28 字节的槽位，页号作为附加认证数据，使页被篡改、截断或调换位置都能被发现。
+// 解密失败时返回 *CorruptPageError。每次写页使用随机 nonce，
+// 同一密钥下的写页次数应远小于 2^32
+type EncryptedPager struct {
+	file   *os.File
+	aead   cipher.AEAD
+	sealed []byte // 写页时复用的密文缓冲区
+}
+
+// OpenEncryptedFilePager 以密钥 key 打开（不存在时创建）path 处的加密页文件，
+// 可将其传给 NewDiskBPlusTree
+func OpenEncryptedFilePager(path string, key []byte) (*EncryptedPager, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("打开页文件失败：%w", err)
+	}
+	return &EncryptedPager{file: file, aead: aead}, nil
+}
+
+const encryptedSlotSize = PageSize + encryptedPageOverhead
+
+func pageAAD(id PageID) []byte {
+	return binary.LittleEndian.AppendUint32(nil, uint32(id))
+}
+
+// ReadPage 读取并解密第 id 页到 buf
+func (p *EncryptedPager) ReadPage(id PageID, buf []byte) error {
+	slot := make([]byte, encryptedSlotSize)
+	n, err := p.file.ReadAt(slot, int64(id)*encryptedSlotSize)
+	if err == io.EOF && n < encryptedSlotSize {
+		return fmt.Errorf("读取页 %d 失败：%w", id, ErrPageOutOfRange)
+	}
+	if err != nil {
+		return fmt.Errorf("读取页 %d 失败：%w", id, err)
+	}
+	if _, err := openSealed(p.aead, buf[:0], slot, pageAAD(id)); err != nil {
+		return corruptPage(id, "解密失败（密钥错误或页被篡改）")
+	}
+	return nil
+}
+
+// WritePage 加密 buf 并写入第 id 页
+func (p *EncryptedPager) WritePage(id PageID, buf []byte) error {
+	p.sealed = sealBytes(p.aead, p.sealed[:0], buf[:PageSize], pageAAD(id))
+	if _, err := p.file.WriteAt(p.sealed, int64(id)*encryptedSlotSize); err != nil {
+		return fmt.Errorf("写入页 %d 失败：%w", id, err)
+	}
+	return nil
+}
+
+// Sync 将已写入的页刷到磁盘
+func (p *EncryptedPager) Sync() error {
+	return p.file.Sync()
+}
+
+// Close 关闭页文件
+func (p *EncryptedPager) Close() error {
+	return p.file.Close()
+}
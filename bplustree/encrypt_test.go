@@ -0,0 +1,155 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testPageKey = bytes.Repeat([]byte{7}, 32)
+
+// 在加密页文件上打开树，失败时关闭页文件
+func openEncryptedTree(path string, key []byte) (*DiskBPlusTree, error) {
+	pager, err := OpenEncryptedFilePager(path, key)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := NewDiskBPlusTree(pager)
+	if err != nil {
+		pager.Close()
+		return nil, err
+	}
+	return tree, nil
+}
+
+func TestEncryptedPagerRoundTrip(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		t.Run(fmt.Sprintf("AES-%d", size*8), func(t *testing.T) {
+			key := testPageKey[:size]
+			path := filepath.Join(t.TempDir(), "tree.db")
+			tree, err := openEncryptedTree(path, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k := range 2 * DiskMaxKeys {
+				if err := tree.Insert(k, k+1); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tree.Close(); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(data, []byte(diskMagic)) {
+				t.Fatal("文件中出现了明文的文件头")
+			}
+			if len(data)%encryptedSlotSize != 0 {
+				t.Fatalf("文件长 %d 字节，不是槽位大小 %d 的整数倍", len(data), encryptedSlotSize)
+			}
+			tree, err = openEncryptedTree(path, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tree.Close()
+			for k := range 2 * DiskMaxKeys {
+				if v, err := tree.Search(k); err != nil || v != k+1 {
+					t.Fatalf("Search(%d) = %d, %v", k, v, err)
+				}
+			}
+		})
+	}
+}
+
+// 密钥错误、页被篡改、调换或截断时都被发现，而不是返回错误的内容
+func TestEncryptedPagerTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    []byte
+		tamper func(data []byte) []byte
+		page   PageID // 期望报告损坏的页
+	}{
+		{"wrong-key", bytes.Repeat([]byte{8}, 32), nil, 0},
+		{"flipped-byte", testPageKey, func(data []byte) []byte {
+			data[encryptedSlotSize+100] ^= 1
+			return data
+		}, 1},
+		{"flipped-nonce", testPageKey, func(data []byte) []byte {
+			data[0] ^= 1
+			return data
+		}, 0},
+		{"swapped-pages", testPageKey, func(data []byte) []byte {
+			a := bytes.Clone(data[:encryptedSlotSize])
+			copy(data, data[encryptedSlotSize:2*encryptedSlotSize])
+			copy(data[encryptedSlotSize:], a)
+			return data
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tree.db")
+			tree, err := openEncryptedTree(path, testPageKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree.Insert(1, 1)
+			tree.Close()
+			if tt.tamper != nil {
+				data, _ := os.ReadFile(path)
+				if err := os.WriteFile(path, tt.tamper(data), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tree, err = openEncryptedTree(path, tt.key)
+			if err == nil {
+				defer tree.Close()
+				_, err = tree.Search(1)
+			}
+			var corrupt *CorruptPageError
+			if !errors.As(err, &corrupt) || corrupt.Page != tt.page {
+				t.Fatalf("返回 %v，期望页 %d 损坏", err, tt.page)
+			}
+		})
+	}
+}
+
+func TestEncryptedPagerPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages")
+	p, err := OpenEncryptedFilePager(path, testPageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	page := bytes.Repeat([]byte{0xab}, PageSize)
+	buf := make([]byte, PageSize)
+	if err := p.ReadPage(0, buf); !errors.Is(err, ErrPageOutOfRange) {
+		t.Fatalf("读取空文件返回 %v，期望 ErrPageOutOfRange", err)
+	}
+	// 每次写入使用新的 nonce，同一内容两次写入的密文不同
+	p.WritePage(0, page)
+	first, _ := os.ReadFile(path)
+	p.WritePage(0, page)
+	second, _ := os.ReadFile(path)
+	if bytes.Equal(first, second) {
+		t.Fatal("同一内容两次写入的密文相同")
+	}
+	if err := p.ReadPage(0, buf); err != nil || !bytes.Equal(buf, page) {
+		t.Fatalf("ReadPage = %v", err)
+	}
+	// 截断的最后一个槽位视为不存在
+	p.WritePage(1, page)
+	if err := os.Truncate(path, 2*encryptedSlotSize-1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ReadPage(1, buf); !errors.Is(err, ErrPageOutOfRange) {
+		t.Fatalf("读取截断的页返回 %v，期望 ErrPageOutOfRange", err)
+	}
+	if _, err := OpenEncryptedFilePager(path, []byte("short")); err == nil {
+		t.Fatal("密钥长度不合法时应返回错误")
+	}
+}
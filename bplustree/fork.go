@@ -0,0 +1,176 @@
+package bplustree
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// 被只读句柄共享的一个树版本：root 之下的节点在 refs 归零之前不会再被修改（叶节点的链表指针除外）
+type forkVersion struct {
+	root *Node
+	refs atomic.Int64 // 尚未释放的只读句柄数
+}
+
+// ReadOnlyTree 是 ForkReadOnly 返回的只读句柄，固定在创建时的树版本上，
+// 之后对原树的修改对它不可见。读取时不加锁，可以交给短期运行的 worker goroutine 并发使用
+type ReadOnlyTree struct {
+	tree     *BPlusTree
+	released atomic.Bool
+	cleanup  runtime.Cleanup
+	release  forkRelease
+}
+
+// 释放只读句柄时需要更新的状态。不引用句柄本身，才能在句柄被垃圾回收时由清理函数使用
+type forkRelease struct {
+	version *forkVersion
+	forks   *atomic.Int64
+	readers *readerRegistry
+	info    *readerInfo
+}
+
+func (r forkRelease) run() {
+	r.version.refs.Add(-1)
+	r.forks.Add(-1)
+	r.readers.remove(r.info)
+}
+
+// ForkReadOnly 返回当前树版本的只读句柄，开销为常数时间，不复制任何节点。
+// 只读句柄存活期间，原树的写操作不再原地修改被共享的节点，而是只复制从根到目标叶节点的路径
+// （删除时连同路径上各节点的左右兄弟，供借补与合并使用），每次写操作复制的节点数与树高成正比；
+// 已复制的节点属于原树，之后的写操作直接修改。Update 中的写操作无法预知要修改哪些节点，仍复制整棵树。
+// 句柄用完后应调用 Release；忘记释放的句柄在被垃圾回收时自动释放。全部句柄释放后，写操作恢复为原地修改
+func (c *ConcurrentBPlusTree) ForkReadOnly() *ReadOnlyTree {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.forked == nil || c.forked.root != c.tree.root {
+		c.forked = &forkVersion{root: c.tree.root}
+	}
+	// 递增代号，使此刻之前创建的节点都被视为共享，即使它们是上一批句柄释放后原地修改过的
+	c.tree.gen++
+	v := c.forked
+	v.refs.Add(1)
+	c.forks.Add(1)
+	h := &ReadOnlyTree{tree: &BPlusTree{root: v.root, unlinked: true}}
+	h.release = forkRelease{version: v, forks: &c.forks, readers: &c.readers, info: c.readers.add(0, c.tree.readerStacks)}
+	h.cleanup = runtime.AddCleanup(h, forkRelease.run, h.release)
+	return h
+}
+
+// LiveForks 返回当前树版本上尚未释放的只读句柄数
+func (c *ConcurrentBPlusTree) LiveForks() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.forked == nil || c.forked.root != c.tree.root {
+		return 0
+	}
+	return int(c.forked.refs.Load())
+}
+
+// 写操作前调用，调用方须持有写锁：记录是否仍有任一版本上的只读句柄，决定树的写操作是否需要复制节点
+func (c *ConcurrentBPlusTree) detachForks() {
+	c.tree.shared = c.forks.Load() > 0 && c.private != c.tree.gen
+}
+
+// 在写操作修改之前，把 key 的下降路径上被只读句柄共享的节点换成私有副本，siblings 为 true 时一并复制路径上每个节点的左右兄弟。
+// 复制的叶节点在链表中的前驱原地改为指向副本：只读句柄按树结构遍历叶节点，从不读取链表指针，
+// 前驱即使被共享也可以修改。最右叶节点的缓存可能指向被替换的节点，一并清除
+func (bpt *BPlusTree) unshare(key int, siblings bool) {
+	if !bpt.shared {
+		return
+	}
+	if bpt.root.gen < bpt.gen {
+		bpt.root = bpt.copyNode(bpt.root)
+	}
+	var left *Node // 路径左侧紧邻的子树
+	for node := bpt.root; !node.isLeaf; {
+		i := childIndex(node, key)
+		lo, hi := i, i
+		if siblings {
+			lo, hi = max(i-1, 0), min(i+1, len(node.children)-1)
+		}
+		for j := lo; j <= hi; j++ {
+			if node.children[j].gen < bpt.gen {
+				node.children[j] = bpt.copyNode(node.children[j])
+			}
+		}
+		if node.children[i].isLeaf {
+			prev := left
+			if lo > 0 {
+				prev = node.children[lo-1]
+			}
+			for prev != nil && !prev.isLeaf {
+				prev = prev.children[len(prev.children)-1]
+			}
+			for _, leaf := range node.children[lo : hi+1] {
+				if prev != nil && prev.next != leaf {
+					prev.next = leaf
+				}
+				prev = leaf
+			}
+		}
+		if i > 0 {
+			left = node.children[i-1]
+		}
+		node = node.children[i]
+	}
+	bpt.edge.leaf = nil
+}
+
+// 返回 n 的私有副本：内容相同，代号为当前代号，子节点与链表指针仍指向原来的节点
+func (bpt *BPlusTree) copyNode(n *Node) *Node {
+	c := bpt.newNode(n.isLeaf)
+	c.keys = append(c.keys, n.keys...)
+	if n.isLeaf {
+		c.values = append(c.values, n.values...)
+		c.next = n.next
+	} else {
+		c.children = append(c.children, n.children...)
+	}
+	return c
+}
+
+// 深度复制以 root 为根的树，副本中的节点均为当前代号，并重建副本中的叶节点链表
+func (bpt *BPlusTree) cloneTree(root *Node) *Node {
+	var prevLeaf *Node
+	var clone func(n *Node) *Node
+	clone = func(n *Node) *Node {
+		c := bpt.copyNode(n)
+		if n.isLeaf {
+			c.next = nil
+			if prevLeaf != nil {
+				prevLeaf.next = c
+			}
+			prevLeaf = c
+			return c
+		}
+		for i, child := range c.children {
+			c.children[i] = clone(child)
+		}
+		return c
+	}
+	return clone(root)
+}
+
+// Search 在固定的版本中查找 key 对应的 value；若不存在返回 -1
+func (h *ReadOnlyTree) Search(key int) int {
+	return h.tree.Search(key)
+}
+
+// Walk 按层次顺序遍历固定的版本
+func (h *ReadOnlyTree) Walk(fn func(level int, n NodeInfo)) {
+	h.tree.Walk(fn)
+}
+
+// WalkLeaves 沿叶节点链表遍历固定的版本
+func (h *ReadOnlyTree) WalkLeaves(fn func(n NodeInfo) bool) {
+	h.tree.WalkLeaves(fn)
+}
+
+// Release 释放句柄，使原树之后的写操作不必再为它复制。重复调用无副作用；释放后不得再使用句柄
+func (h *ReadOnlyTree) Release() {
+	if h.released.Swap(true) {
+		return
+	}
+	h.cleanup.Stop()
+	h.release.run()
+}
@@ -0,0 +1,262 @@
+package bplustree
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// 句柄看到的全部键值对：分别经 Iter 与 WalkLeaves 读出，两者须一致
+func forkContents(t *testing.T, h *ReadOnlyTree) []KeyValue {
+	t.Helper()
+	var iter, leaves []KeyValue
+	for it := h.Iter(-1<<62, 1<<62); it.Next(); {
+		iter = append(iter, KeyValue{Key: it.Key(), Value: it.Value()})
+	}
+	h.WalkLeaves(func(n NodeInfo) bool {
+		for i, k := range n.Keys {
+			leaves = append(leaves, KeyValue{Key: k, Value: n.Values[i]})
+		}
+		return true
+	})
+	if !slices.Equal(iter, leaves) {
+		t.Fatalf("Iter 读出 %d 个条目，WalkLeaves 读出 %d 个", len(iter), len(leaves))
+	}
+	return iter
+}
+
+func sortedEntries(m map[int]int) []KeyValue {
+	var kvs []KeyValue
+	for k, v := range m {
+		kvs = append(kvs, KeyValue{Key: k, Value: v})
+	}
+	slices.SortFunc(kvs, func(a, b KeyValue) int { return a.Key - b.Key })
+	return kvs
+}
+
+// 句柄固定在 fork 时的版本上：之后的插入、删除与修改（包括引起分裂、借补与合并的）对它不可见，
+// 原树的结构与叶节点链表保持正确
+func TestForkReadOnlyIsolation(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *ConcurrentBPlusTree, want map[int]int, rng *rand.Rand)
+	}{
+		{"insert", func(c *ConcurrentBPlusTree, want map[int]int, rng *rand.Rand) {
+			k := rng.Intn(4000)
+			if _, ok := want[k]; !ok {
+				c.Insert(k, k*10)
+				want[k] = k * 10
+			}
+		}},
+		{"remove", func(c *ConcurrentBPlusTree, want map[int]int, rng *rand.Rand) {
+			k := rng.Intn(2000)
+			if _, ok := want[k]; ok {
+				if err := c.Remove(k); err != nil {
+					t.Fatal(err)
+				}
+				delete(want, k)
+			}
+		}},
+		{"modify", func(c *ConcurrentBPlusTree, want map[int]int, rng *rand.Rand) {
+			k := rng.Intn(2000)
+			if _, ok := want[k]; ok {
+				if err := c.Modify(k, -k); err != nil {
+					t.Fatal(err)
+				}
+				want[k] = -k
+			}
+		}},
+		{"append", func(c *ConcurrentBPlusTree, want map[int]int, _ *rand.Rand) {
+			k := 2000 + len(want)
+			c.Insert(k, k)
+			want[k] = k
+		}},
+		{"update", func(c *ConcurrentBPlusTree, want map[int]int, rng *rand.Rand) {
+			k := rng.Intn(2000)
+			c.Update(func(tree *BPlusTree) {
+				if tree.Remove(k) == nil {
+					delete(want, k)
+				}
+			})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			c := NewConcurrentBPlusTree()
+			want := make(map[int]int)
+			for _, k := range rng.Perm(2000) {
+				c.Insert(k, k)
+				want[k] = k
+			}
+			var handles []*ReadOnlyTree
+			var snapshots [][]KeyValue
+			for round := range 4 {
+				handles = append(handles, c.ForkReadOnly())
+				snapshots = append(snapshots, sortedEntries(want))
+				for range 300 {
+					tt.write(c, want, rng)
+				}
+				if round == 1 {
+					// 中途释放一个较早的句柄，其余句柄不受影响
+					handles[0].Release()
+				}
+				if err := c.Validate(); err != nil {
+					t.Fatalf("第 %d 轮写入后：%v", round, err)
+				}
+			}
+			for i, h := range handles {
+				if i == 0 {
+					continue
+				}
+				if got := forkContents(t, h); !slices.Equal(got, snapshots[i]) {
+					t.Fatalf("第 %d 个句柄读到 %d 个条目，fork 时有 %d 个", i, len(got), len(snapshots[i]))
+				}
+				for _, kv := range snapshots[i] {
+					if got := h.Search(kv.Key); got != kv.Value {
+						t.Fatalf("第 %d 个句柄 Search(%d) = %d，期望 %d", i, kv.Key, got, kv.Value)
+					}
+				}
+				h.Release()
+			}
+			var got []KeyValue
+			c.View(func(tree *BPlusTree) {
+				for it := tree.Iter(-1<<62, 1<<62); it.Next(); {
+					got = append(got, KeyValue{Key: it.Key(), Value: it.Value()})
+				}
+			})
+			if !slices.Equal(got, sortedEntries(want)) {
+				t.Fatalf("原树有 %d 个条目，期望 %d 个", len(got), len(want))
+			}
+		})
+	}
+}
+
+// 句柄存活时每次写操作只复制路径上的节点，而不是整棵树
+func TestForkReadOnlyCopiesPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *ConcurrentBPlusTree) error
+		limit func(height int) int // 允许复制与新建的节点数
+	}{
+		{"insert", func(c *ConcurrentBPlusTree) error { c.Insert(5001, 1); return nil }, func(h int) int { return 2 * (h + 1) }},
+		{"modify", func(c *ConcurrentBPlusTree) error { return c.Modify(5000, 1) }, func(h int) int { return h }},
+		{"remove", func(c *ConcurrentBPlusTree) error { return c.Remove(5000) }, func(h int) int { return 3 * h }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConcurrentBPlusTree()
+			for k := range 10000 {
+				c.Insert(k, k)
+			}
+			h := c.ForkReadOnly()
+			defer h.Release()
+			if err := tt.write(c); err != nil {
+				t.Fatal(err)
+			}
+			copied, total := 0, 0
+			c.tree.Walk(func(int, NodeInfo) { total++ })
+			var walk func(n *Node)
+			walk = func(n *Node) {
+				if n.gen == c.tree.gen {
+					copied++
+				}
+				for _, child := range n.children {
+					walk(child)
+				}
+			}
+			walk(c.tree.root)
+			height := c.Stats().Height
+			if copied == 0 || copied > tt.limit(height) {
+				t.Fatalf("树高 %d、共 %d 个节点，一次写操作复制了 %d 个节点", height, total, copied)
+			}
+			if err := c.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// 全部句柄释放后写操作恢复为原地修改，再次 fork 时此前原地修改过的节点同样受到保护
+func TestForkReadOnlyRelease(t *testing.T) {
+	c := NewConcurrentBPlusTree()
+	for k := range 100 {
+		c.Insert(k, k)
+	}
+	h := c.ForkReadOnly()
+	if got := c.LiveForks(); got != 1 {
+		t.Fatalf("LiveForks() = %d，期望 1", got)
+	}
+	h.Release()
+	h.Release() // 重复释放无副作用
+	if got := c.LiveForks(); got != 0 {
+		t.Fatalf("释放后 LiveForks() = %d", got)
+	}
+	root := c.tree.root
+	if err := c.Modify(50, -50); err != nil {
+		t.Fatal(err)
+	}
+	if c.tree.root != root {
+		t.Fatal("没有句柄时写操作不应复制节点")
+	}
+	h = c.ForkReadOnly()
+	defer h.Release()
+	if err := c.Modify(50, 50); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Search(50); got != -50 {
+		t.Fatalf("句柄 Search(50) = %d，期望 fork 时的 -50", got)
+	}
+	if c.tree.root == root {
+		t.Fatal("句柄存活时写操作应复制根节点")
+	}
+}
+
+// 句柄在 worker goroutine 中无锁读取的同时原树持续写入；配合 -race 运行可发现对共享节点的写入
+func TestForkReadOnlyConcurrentReaders(t *testing.T) {
+	c := NewConcurrentBPlusTree()
+	for k := range 1000 {
+		c.Insert(k, k)
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := range 4 {
+		h := c.ForkReadOnly()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer h.Release()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				n := 0
+				for it := h.Iter(0, 1<<30); it.Next(); n++ {
+					if it.Value() != it.Key() {
+						t.Errorf("worker %d 读到 %d => %d", w, it.Key(), it.Value())
+						return
+					}
+				}
+				if n != 1000 {
+					t.Errorf("worker %d 读到 %d 个条目，期望 1000", w, n)
+					return
+				}
+			}
+		}()
+	}
+	rng := rand.New(rand.NewSource(2))
+	for range 2000 {
+		k := rng.Intn(2000)
+		if c.Remove(k) != nil {
+			c.Insert(k, -k)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
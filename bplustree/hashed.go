@@ -0,0 +1,112 @@
+package bplustree
+
+import (
+	"fmt"
+	"hash/maphash"
+)
+
+// HashedBPlusTree 在 B+ 树外包装一层带密钥的哈希变换：内部树以 hash(key) 为键，
+// 原始键值对保存在条目表中。哈希种子在创建时随机生成，外部无法预测键在树中的排列，
+// 从而抵御刻意构造的顺序插入模式对平衡性与局部性的破坏。
+// 代价是失去键的有序性，仅支持点查询（Insert/Search/Modify/Remove）
+type HashedBPlusTree struct {
+	tree    *BPlusTree
+	seed    maphash.Seed
+	entries []hashedEntry // 条目表，内部树的值为条目下标
+	free    []int         // 已释放、可复用的条目下标
+}
+
+// 哈希冲突的条目通过 next 串成链表，-1 表示链表结束
+type hashedEntry struct {
+	key   int
+	value int
+	next  int
+}
+
+// NewHashedBPlusTree 创建一个使用随机哈希种子的 HashedBPlusTree
+func NewHashedBPlusTree() *HashedBPlusTree {
+	return &HashedBPlusTree{
+		tree: NewBPlusTree(),
+		seed: maphash.MakeSeed(),
+	}
+}
+
+func (h *HashedBPlusTree) hash(key int) int {
+	return int(maphash.Comparable(h.seed, key))
+}
+
+// 分配一个条目并返回其下标，优先复用已释放的位置
+func (h *HashedBPlusTree) alloc(e hashedEntry) int {
+	if n := len(h.free); n > 0 {
+		idx := h.free[n-1]
+		h.free = h.free[:n-1]
+		h.entries[idx] = e
+		return idx
+	}
+	h.entries = append(h.entries, e)
+	return len(h.entries) - 1
+}
+
+// 在 hash 对应的冲突链中查找 key，返回目标条目及其前驱的下标（不存在时为 -1）
+func (h *HashedBPlusTree) lookup(hk, key int) (idx, prev int) {
+	prev = -1
+	for idx = h.tree.Search(hk); idx != -1; idx = h.entries[idx].next {
+		if h.entries[idx].key == key {
+			return idx, prev
+		}
+		prev = idx
+	}
+	return -1, -1
+}
+
+// Insert 插入键值对；与 BPlusTree 相同，重复插入同一 key 时新条目优先被查到
+func (h *HashedBPlusTree) Insert(key, value int) {
+	hk := h.hash(key)
+	head := h.tree.Search(hk)
+	idx := h.alloc(hashedEntry{key: key, value: value, next: head})
+	if head == -1 {
+		h.tree.Insert(hk, idx)
+	} else {
+		h.tree.Modify(hk, idx)
+	}
+}
+
+// Search 返回 key 对应的 value；若不存在返回 -1
+func (h *HashedBPlusTree) Search(key int) int {
+	idx, _ := h.lookup(h.hash(key), key)
+	if idx == -1 {
+		return -1
+	}
+	return h.entries[idx].value
+}
+
+// Modify 修改 key 对应的 value
+func (h *HashedBPlusTree) Modify(key, newValue int) error {
+	idx, _ := h.lookup(h.hash(key), key)
+	if idx == -1 {
+		return fmt.Errorf("修改失败：未找到 key = %d", key)
+	}
+	h.entries[idx].value = newValue
+	return nil
+}
+
+// Remove 删除 key 对应的条目
+func (h *HashedBPlusTree) Remove(key int) error {
+	hk := h.hash(key)
+	idx, prev := h.lookup(hk, key)
+	if idx == -1 {
+		return fmt.Errorf("删除失败：未找到 key = %d", key)
+	}
+	next := h.entries[idx].next
+	switch {
+	case prev != -1:
+		h.entries[prev].next = next
+	case next == -1:
+		h.tree.Remove(hk)
+	default:
+		h.tree.Modify(hk, next)
+	}
+	h.entries[idx] = hashedEntry{}
+	h.free = append(h.free, idx)
+	return nil
+}
@@ -0,0 +1,78 @@
+package bplustree
+
+import "sort"
+
+// ValueVersion 是 History 返回的一个历史版本
+type ValueVersion struct {
+	Version uint64 // 写入该版本时的全局版本号
+	Value   int
+	Deleted bool // 该版本是删除标记
+}
+
+// SetHistoryLimit 设置每个 key 最多保留的版本数（含最新版本与删除标记），n 不为正时不限制（默认）。
+// 写入使版本数超过上限时，最旧的版本随即被丢弃，但存活快照可见的版本会保留到快照释放之后该 key 的下一次写入或 GC。
+// GC 同样会为每个 key 保留最近的 n 个版本，因此设置了上限的树可以回答“上一次修改之前的值是什么”
+func (m *MVCCTree) SetHistoryLimit(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.historyLimit = max(n, 0)
+	if m.historyLimit == 0 {
+		return
+	}
+	for leaf := m.index.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for _, idx := range leaf.values {
+			m.trimHistory(idx)
+		}
+	}
+}
+
+// 按保留上限丢弃 chains[idx] 中最旧的版本，回收边界时刻可见的版本及其后的版本不丢弃。调用方须持有写锁
+func (m *MVCCTree) trimHistory(idx int) {
+	chain := m.chains[idx]
+	drop := len(chain) - m.historyLimit
+	if m.historyLimit == 0 || drop <= 0 {
+		return
+	}
+	h := m.horizon()
+	visible := sort.Search(len(chain), func(i int) bool { return chain[i].version > h }) - 1
+	drop = min(drop, max(visible, 0))
+	if drop == 0 {
+		return
+	}
+	n := copy(chain, chain[drop:])
+	clear(chain[n:])
+	m.chains[idx] = chain[:n]
+}
+
+// GetVersion 返回 key 在倒数第 n 次写入之后的值：n 为 0 时是当前值，为 1 时是上一次修改之前的值，依此类推。
+// 该版本已被丢弃、key 不存在，或该版本是删除标记时 ok 为 false
+func (m *MVCCTree) GetVersion(key, n int) (value int, version uint64, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	idx := m.index.Search(key)
+	if idx == -1 || n < 0 {
+		return 0, 0, false
+	}
+	chain := m.chains[idx]
+	if n >= len(chain) {
+		return 0, 0, false
+	}
+	v := chain[len(chain)-1-n]
+	return v.value, v.version, !v.deleted
+}
+
+// History 返回 key 仍保留的全部版本，最新的在前，下标与 GetVersion 的 n 一致；key 不存在时返回 nil
+func (m *MVCCTree) History(key int) []ValueVersion {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	idx := m.index.Search(key)
+	if idx == -1 {
+		return nil
+	}
+	chain := m.chains[idx]
+	out := make([]ValueVersion, len(chain))
+	for i, v := range chain {
+		out[len(chain)-1-i] = ValueVersion{Version: v.version, Value: v.value, Deleted: v.deleted}
+	}
+	return out
+}
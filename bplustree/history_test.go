@@ -0,0 +1,112 @@
+package bplustree
+
+import (
+	"slices"
+	"testing"
+)
+
+// 历史中各版本的值，最新的在前；删除标记记为 -1
+func historyValues(m *MVCCTree, key int) []int {
+	var vals []int
+	for _, v := range m.History(key) {
+		if v.Deleted {
+			vals = append(vals, -1)
+		} else {
+			vals = append(vals, v.Value)
+		}
+	}
+	return vals
+}
+
+// 写入超过上限时丢弃最旧的版本，存活快照可见的版本保留到快照释放之后
+func TestHistoryLimit(t *testing.T) {
+	m := NewMVCCTree()
+	m.SetHistoryLimit(2)
+	m.Insert(1, 1)
+	m.Modify(1, 2)
+	m.Modify(1, 3)
+	if got := historyValues(m, 1); !slices.Equal(got, []int{3, 2}) {
+		t.Fatalf("History(1) = %v，期望 [3 2]", got)
+	}
+	snap := m.Snapshot()
+	m.Modify(1, 4)
+	m.Modify(1, 5)
+	if got := historyValues(m, 1); !slices.Equal(got, []int{5, 4, 3}) {
+		t.Fatalf("快照存活时 History(1) = %v，期望保留快照可见的 3", got)
+	}
+	if v, ok := snap.Get(1); !ok || v != 3 {
+		t.Fatalf("快照 Get(1) = %d, %v", v, ok)
+	}
+	snap.Release()
+	if r := m.GC(); r.ReclaimedVersions != 1 {
+		t.Fatalf("GC() = %+v，期望回收 1 个版本", r)
+	}
+	// 设置了上限时 GC 保留最近的版本，最新版本为删除标记的 key 也不被回收
+	m.Remove(1)
+	if r := m.GC(); r.ReclaimedVersions != 0 || r.ReclaimedKeys != 0 {
+		t.Fatalf("GC() = %+v，期望保留最近 2 个版本", r)
+	}
+	if got := historyValues(m, 1); !slices.Equal(got, []int{-1, 5}) {
+		t.Fatalf("删除后 History(1) = %v，期望 [-1 5]", got)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	m := NewMVCCTree()
+	m.SetHistoryLimit(3)
+	v1 := m.Insert(1, 10)
+	v2, _ := m.Modify(1, 20)
+	v3, _ := m.Remove(1)
+	tests := []struct {
+		key, n  int
+		value   int
+		version uint64
+		ok      bool
+	}{
+		{1, 0, 0, v3, false}, // 删除标记
+		{1, 1, 20, v2, true},
+		{1, 2, 10, v1, true},
+		{1, 3, 0, 0, false}, // 超出保留的版本
+		{1, -1, 0, 0, false},
+		{2, 0, 0, 0, false}, // key 不存在
+	}
+	for _, tt := range tests {
+		value, version, ok := m.GetVersion(tt.key, tt.n)
+		if value != tt.value || version != tt.version || ok != tt.ok {
+			t.Fatalf("GetVersion(%d, %d) = %d, %d, %v，期望 %d, %d, %v",
+				tt.key, tt.n, value, version, ok, tt.value, tt.version, tt.ok)
+		}
+	}
+	if h := m.History(2); h != nil {
+		t.Fatalf("History(2) = %v，期望 nil", h)
+	}
+}
+
+// 对已有数据设置上限时立即裁剪；上限不为正时不限制
+func TestSetHistoryLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  []int
+	}{
+		{0, []int{4, 3, 2, 1}},
+		{-1, []int{4, 3, 2, 1}},
+		{1, []int{4}},
+		{3, []int{4, 3, 2}},
+		{10, []int{4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		m := NewMVCCTree()
+		for k := range 20 {
+			m.Insert(k, 1)
+			for v := 2; v <= 4; v++ {
+				m.Modify(k, v)
+			}
+		}
+		m.SetHistoryLimit(tt.limit)
+		for k := range 20 {
+			if got := historyValues(m, k); !slices.Equal(got, tt.want) {
+				t.Fatalf("SetHistoryLimit(%d) 后 History(%d) = %v，期望 %v", tt.limit, k, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package bplustree
+
+// Hooks 是树的结构发生变化时的回调，用于统计，或让建立在树之上的分页存储层同步这些变化。
+// 回调收到的是受影响节点所覆盖的键区间（子树中最小与最大的键），leaf 表示这些节点是否为叶节点。
+// 回调在写操作中途同步执行，此时树的结构尚未调整完毕，回调中不得调用该树的任何方法；
+// 各字段为 nil 时不调用
+type Hooks struct {
+	// OnSplit 在节点分裂后调用：left 为原节点，right 为分裂出的新节点
+	OnSplit func(leaf bool, left, right KeyRange)
+	// OnMerge 在两个相邻节点合并后调用：into 为合并后保留的节点，absorbed 为被并入后释放的节点合并前的区间
+	OnMerge func(leaf bool, into, absorbed KeyRange)
+	// OnBorrow 在节点从相邻兄弟借入一个条目或子节点后调用，区间均为借补之后的值
+	OnBorrow func(leaf bool, from, to KeyRange)
+}
+
+// WithHooks 为树设置结构变化的回调
+func WithHooks(h Hooks) Option {
+	return func(bpt *BPlusTree) {
+		bpt.hooks = h
+	}
+}
+
+// 返回以 n 为根的子树覆盖的键区间：最大键即 n 的最后一个关键词，最小键沿最左侧的子节点下降到叶节点取得
+func subtreeRange(n *Node) KeyRange {
+	if len(n.keys) == 0 {
+		return KeyRange{}
+	}
+	r := KeyRange{Max: n.keys[len(n.keys)-1]}
+	for !n.isLeaf {
+		n = n.children[0]
+	}
+	r.Min = n.keys[0]
+	return r
+}
+
+func (bpt *BPlusTree) hookSplit(left, right *Node) {
+	if bpt.hooks.OnSplit != nil {
+		bpt.hooks.OnSplit(left.isLeaf, subtreeRange(left), subtreeRange(right))
+	}
+	if bpt.debugEnabled() {
+		bpt.logStructural("split", left.isLeaf, "left", subtreeRange(left), "right", subtreeRange(right))
+	}
+}
+
+// absorbed 须在交给 freeNode 之前传入，此时它仍保留合并前的关键词与子节点
+func (bpt *BPlusTree) hookMerge(into, absorbed *Node) {
+	if bpt.hooks.OnMerge != nil {
+		bpt.hooks.OnMerge(into.isLeaf, subtreeRange(into), subtreeRange(absorbed))
+	}
+	if bpt.debugEnabled() {
+		bpt.logStructural("merge", into.isLeaf, "into", subtreeRange(into), "absorbed", subtreeRange(absorbed))
+	}
+}
+
+func (bpt *BPlusTree) hookBorrow(from, to *Node) {
+	if bpt.hooks.OnBorrow != nil {
+		bpt.hooks.OnBorrow(to.isLeaf, subtreeRange(from), subtreeRange(to))
+	}
+	if bpt.debugEnabled() {
+		bpt.logStructural("borrow", to.isLeaf, "from", subtreeRange(from), "to", subtreeRange(to))
+	}
+}
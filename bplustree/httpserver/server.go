@@ -0,0 +1,211 @@
+// Package httpserver 以 HTTP/JSON 接口对外提供基于 B+ 树的键值服务，用于原型开发：
+//
+//	GET    /keys/{k}          返回 {"key": k, "value": v}，key 不存在时返回 404
+//	PUT    /keys/{k}          请求体为一个整数，写入或覆盖 k 的值；新建时返回 201，覆盖时返回 200
+//	DELETE /keys/{k}          删除 k，成功时返回 204，key 不存在时返回 404
+//	GET    /range?lo=&hi=     按 key 升序返回 [lo, hi] 内的条目数组；省略 lo 或 hi 表示不设该端的界限，
+//	                          limit 限制返回的条目数
+//
+// 出错时返回 {"error": "..."}。键与值均为 int
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bplus-go/bplustree"
+)
+
+// 请求体的大小上限，足以容纳任意 int 的十进制表示及空白
+const maxBodySize = 64
+
+// Entry 是接口返回的一个键值对
+type Entry struct {
+	Key   int `json:"key"`
+	Value int `json:"value"`
+}
+
+// Server 是提供上述接口的 http.Handler，并发请求由 ConcurrentBPlusTree 的读写锁串行化
+type Server struct {
+	tree *bplustree.ConcurrentBPlusTree
+	mux  *http.ServeMux
+}
+
+// New 创建以 tree 为存储的 Server；tree 为 nil 时使用一棵新建的空树
+func New(tree *bplustree.ConcurrentBPlusTree) *Server {
+	if tree == nil {
+		tree = bplustree.NewConcurrentBPlusTree()
+	}
+	s := &Server{tree: tree, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /keys/{k}", s.get)
+	s.mux.HandleFunc("PUT /keys/{k}", s.put)
+	s.mux.HandleFunc("DELETE /keys/{k}", s.del)
+	s.mux.HandleFunc("GET /range", s.scan)
+	return s
+}
+
+// Tree 返回 Server 使用的树
+func (s *Server) Tree() *bplustree.ConcurrentBPlusTree {
+	return s.tree
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe 在 addr 上提供服务，直到监听失败
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	key, ok := pathKey(w, r)
+	if !ok {
+		return
+	}
+	var e Entry
+	found := false
+	s.tree.View(func(t *bplustree.BPlusTree) {
+		e, found = lookup(t, key)
+	})
+	if err := s.tree.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("key %d 不存在", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request) {
+	key, ok := pathKey(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("读取请求体失败：%v", err))
+		return
+	}
+	if len(body) > maxBodySize {
+		writeError(w, http.StatusRequestEntityTooLarge, "请求体过大，应为一个整数")
+		return
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "请求体应为一个整数")
+		return
+	}
+	created := false
+	s.tree.Update(func(t *bplustree.BPlusTree) {
+		if _, found := lookup(t, key); found {
+			err = t.Modify(key, value)
+			return
+		}
+		t.Insert(key, value)
+		created = true
+	})
+	if err == nil {
+		err = s.tree.Err()
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, Entry{Key: key, Value: value})
+}
+
+func (s *Server) del(w http.ResponseWriter, r *http.Request) {
+	key, ok := pathKey(w, r)
+	if !ok {
+		return
+	}
+	found := false
+	var err error
+	s.tree.Update(func(t *bplustree.BPlusTree) {
+		if _, found = lookup(t, key); found {
+			err = t.Remove(key)
+		}
+	})
+	switch {
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	case !found:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("key %d 不存在", key))
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) scan(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lo, hi, limit := math.MinInt, math.MaxInt, math.MaxInt
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"lo", &lo}, {"hi", &hi}, {"limit", &limit}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("参数 %s=%q 不是整数", p.name, v))
+			return
+		}
+		*p.dst = n
+	}
+	entries := []Entry{}
+	if limit > 0 {
+		s.tree.Range(lo, hi, func(k, v int) bool {
+			entries = append(entries, Entry{Key: k, Value: v})
+			return len(entries) < limit
+		})
+	}
+	if err := s.tree.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// 查找 key，区分"不存在"与值恰为 -1 的情况
+func lookup(t *bplustree.BPlusTree, key int) (e Entry, found bool) {
+	t.Range(key, key, func(k, v int) bool {
+		e, found = Entry{Key: k, Value: v}, true
+		return false
+	})
+	return e, found
+}
+
+// 解析路径中的 {k}；不是整数时写出 400 并返回 false
+func pathKey(w http.ResponseWriter, r *http.Request) (int, bool) {
+	k, err := strconv.Atoi(r.PathValue("k"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("key %q 不是整数", r.PathValue("k")))
+		return 0, false
+	}
+	return k, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
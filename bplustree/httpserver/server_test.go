@@ -0,0 +1,136 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"bplus-go/bplustree"
+)
+
+// 向 s 发送一个请求，返回状态码与去掉末尾换行的响应体
+func do(s *Server, method, path, body string) (int, string) {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w.Code, strings.TrimSuffix(w.Body.String(), "\n")
+}
+
+// 依次执行的请求；want 为空时只检查响应体是 {"error": ...}
+func TestServer(t *testing.T) {
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{"GET", "/keys/1", "", http.StatusNotFound, ""},
+		{"PUT", "/keys/1", "10", http.StatusCreated, `{"key":1,"value":10}`},
+		{"PUT", "/keys/1", " 11\n", http.StatusOK, `{"key":1,"value":11}`},
+		{"GET", "/keys/1", "", http.StatusOK, `{"key":1,"value":11}`},
+		// 值为 -1 的 key 与不存在的 key 可以区分
+		{"PUT", "/keys/-3", "-1", http.StatusCreated, `{"key":-3,"value":-1}`},
+		{"GET", "/keys/-3", "", http.StatusOK, `{"key":-3,"value":-1}`},
+		{"PUT", "/keys/5", "50", http.StatusCreated, `{"key":5,"value":50}`},
+		{"PUT", "/keys/9", "90", http.StatusCreated, `{"key":9,"value":90}`},
+		{"GET", "/range", "", http.StatusOK, `[{"key":-3,"value":-1},{"key":1,"value":11},{"key":5,"value":50},{"key":9,"value":90}]`},
+		{"GET", "/range?lo=1&hi=5", "", http.StatusOK, `[{"key":1,"value":11},{"key":5,"value":50}]`},
+		{"GET", "/range?lo=2", "", http.StatusOK, `[{"key":5,"value":50},{"key":9,"value":90}]`},
+		{"GET", "/range?hi=0&limit=1", "", http.StatusOK, `[{"key":-3,"value":-1}]`},
+		{"GET", "/range?limit=0", "", http.StatusOK, `[]`},
+		{"GET", "/range?lo=6&hi=8", "", http.StatusOK, `[]`},
+		{"DELETE", "/keys/5", "", http.StatusNoContent, ""},
+		{"DELETE", "/keys/5", "", http.StatusNotFound, ""},
+		{"GET", "/keys/5", "", http.StatusNotFound, ""},
+		// 请求错误
+		{"GET", "/keys/abc", "", http.StatusBadRequest, ""},
+		{"PUT", "/keys/1", "ten", http.StatusBadRequest, ""},
+		{"PUT", "/keys/1", "", http.StatusBadRequest, ""},
+		{"PUT", "/keys/1", strings.Repeat("1", maxBodySize+1), http.StatusRequestEntityTooLarge, ""},
+		{"GET", "/range?lo=x", "", http.StatusBadRequest, ""},
+		{"GET", "/keys/1", "", http.StatusOK, `{"key":1,"value":11}`},
+	}
+	s := New(nil)
+	for i, tt := range tests {
+		status, body := do(s, tt.method, tt.path, tt.body)
+		if status != tt.status {
+			t.Fatalf("#%d %s %s 返回 %d %s，期望 %d", i, tt.method, tt.path, status, body, tt.status)
+		}
+		switch {
+		case tt.want != "":
+			if body != tt.want {
+				t.Fatalf("#%d %s %s 返回 %s，期望 %s", i, tt.method, tt.path, body, tt.want)
+			}
+		case status >= 400:
+			var e map[string]string
+			if err := json.Unmarshal([]byte(body), &e); err != nil || e["error"] == "" {
+				t.Fatalf("#%d %s %s 的错误响应 %q 不是 {\"error\": ...}", i, tt.method, tt.path, body)
+			}
+		}
+	}
+	if v := s.Tree().Search(9); v != 90 {
+		t.Fatalf("树中 key 9 = %d，期望 90", v)
+	}
+}
+
+// 未注册的方法与路径由 ServeMux 拒绝
+func TestServerRouting(t *testing.T) {
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"POST", "/keys/1", http.StatusMethodNotAllowed},
+		{"DELETE", "/range", http.StatusMethodNotAllowed},
+		{"GET", "/keys", http.StatusNotFound},
+		{"GET", "/other", http.StatusNotFound},
+	}
+	s := New(nil)
+	for _, tt := range tests {
+		if status, _ := do(s, tt.method, tt.path, ""); status != tt.status {
+			t.Fatalf("%s %s 返回 %d，期望 %d", tt.method, tt.path, status, tt.status)
+		}
+	}
+}
+
+// 使用传入的树，并发的请求互不干扰；配合 -race 运行
+func TestServerConcurrent(t *testing.T) {
+	tree := bplustree.NewConcurrentBPlusTree()
+	tree.Insert(-1, -1)
+	srv := httptest.NewServer(New(tree))
+	defer srv.Close()
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := w * 100; k < (w+1)*100; k++ {
+				req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/keys/%d", srv.URL, k), strings.NewReader(fmt.Sprint(k)))
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusCreated {
+					t.Errorf("PUT %d 返回 %d", k, resp.StatusCode)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	resp, err := http.Get(srv.URL + "/range")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var entries []Entry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 401 || entries[0] != (Entry{-1, -1}) || entries[400] != (Entry{399, 399}) {
+		t.Fatalf("/range 返回 %d 个条目", len(entries))
+	}
+}
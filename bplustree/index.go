@@ -0,0 +1,151 @@
+package bplustree
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+)
+
+// 二级索引：由 value 派生出的二级键到主键的映射，按 (二级键, 主键) 排序存放在一棵 OrderedTree 中。
+// 树允许重复的主键，索引条目的值为该 (二级键, 主键) 组合出现的次数
+type secondaryIndex struct {
+	keyOf   func(value int) int
+	entries *OrderedTree[indexKey, int]
+}
+
+type indexKey struct {
+	secondary, primary int
+}
+
+func compareIndexKeys(a, b indexKey) int {
+	if c := cmp.Compare(a.secondary, b.secondary); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.primary, b.primary)
+}
+
+func newSecondaryIndex(keyOf func(value int) int) *secondaryIndex {
+	return &secondaryIndex{keyOf: keyOf, entries: NewOrderedTree[indexKey, int](compareIndexKeys)}
+}
+
+func (ix *secondaryIndex) add(key, value int) {
+	k := indexKey{ix.keyOf(value), key}
+	if n, ok := ix.entries.Search(k); ok {
+		ix.entries.Modify(k, n+1)
+	} else {
+		ix.entries.Insert(k, 1)
+	}
+}
+
+func (ix *secondaryIndex) remove(key, value int) {
+	k := indexKey{ix.keyOf(value), key}
+	switch n, ok := ix.entries.Search(k); {
+	case !ok:
+	case n > 1:
+		ix.entries.Modify(k, n-1)
+	default:
+		ix.entries.Remove(k)
+	}
+}
+
+// AddIndex 注册名为 name 的二级索引：keyOf 由 value 计算二级键，注册时为现有的全部条目建立索引，
+// 之后 Insert、Modify、Remove（以及经由它们的事务、TTL 清理等）会自动维护索引。
+// 整体替换树内容的操作（Merge、UnmarshalBinary、空树上的有序批量导入）之后索引被重建；
+// SplitAt 拆分出的两棵新树各自注册同样的索引。keyOf 须是纯函数，且不得调用该树的方法
+func (bpt *BPlusTree) AddIndex(name string, keyOf func(value int) int) error {
+	if _, ok := bpt.indexes[name]; ok {
+		return fmt.Errorf("注册索引失败：索引 %q 已存在", name)
+	}
+	ix := newSecondaryIndex(keyOf)
+	bpt.WalkLeaves(func(n NodeInfo) bool {
+		for i, key := range n.Keys {
+			ix.add(key, n.Values[i])
+		}
+		return true
+	})
+	if bpt.indexes == nil {
+		bpt.indexes = make(map[string]*secondaryIndex)
+	}
+	bpt.indexes[name] = ix
+	return nil
+}
+
+// DropIndex 删除名为 name 的二级索引
+func (bpt *BPlusTree) DropIndex(name string) error {
+	if _, ok := bpt.indexes[name]; !ok {
+		return fmt.Errorf("删除索引失败：索引 %q 不存在", name)
+	}
+	delete(bpt.indexes, name)
+	return nil
+}
+
+// LookupIndex 按升序返回二级键等于 secondary 的全部主键；同一主键有多个条目时只出现一次
+func (bpt *BPlusTree) LookupIndex(name string, secondary int) ([]int, error) {
+	var keys []int
+	err := bpt.RangeIndex(name, secondary, secondary, func(_, primary int) bool {
+		keys = append(keys, primary)
+		return true
+	})
+	return keys, err
+}
+
+// RangeIndex 按 (二级键, 主键) 升序遍历二级键在 [lo, hi] 内的索引条目，fn 返回 false 时提前结束
+func (bpt *BPlusTree) RangeIndex(name string, lo, hi int, fn func(secondary, primary int) bool) error {
+	ix, ok := bpt.indexes[name]
+	if !ok {
+		return fmt.Errorf("查询索引失败：索引 %q 不存在", name)
+	}
+	ix.entries.Range(indexKey{lo, math.MinInt}, indexKey{hi, math.MaxInt}, func(k indexKey, _ int) bool {
+		return fn(k.secondary, k.primary)
+	})
+	return nil
+}
+
+// 在写路径上维护索引：old 为被删除或被替换的条目，new 为插入或替换后的条目，不存在的一侧传 nil
+func (bpt *BPlusTree) updateIndexes(key int, old, new *int) {
+	for _, ix := range bpt.indexes {
+		if old != nil {
+			ix.remove(key, *old)
+		}
+		if new != nil {
+			ix.add(key, *new)
+		}
+	}
+}
+
+// 树的内容被整体替换后重建全部索引
+func (bpt *BPlusTree) rebuildIndexes() {
+	old := bpt.indexes
+	bpt.indexes = nil
+	for name, ix := range old {
+		bpt.AddIndex(name, ix.keyOf)
+	}
+}
+
+// AddIndex 在写锁保护下注册二级索引，见 BPlusTree.AddIndex
+func (c *ConcurrentBPlusTree) AddIndex(name string, keyOf func(value int) int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.AddIndex(name, keyOf)
+}
+
+// DropIndex 在写锁保护下删除二级索引
+func (c *ConcurrentBPlusTree) DropIndex(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.DropIndex(name)
+}
+
+// LookupIndex 在读锁保护下按二级键查找主键，见 BPlusTree.LookupIndex
+func (c *ConcurrentBPlusTree) LookupIndex(name string, secondary int) ([]int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.LookupIndex(name, secondary)
+}
+
+// RangeIndex 在读锁保护下遍历索引条目，fn 中不得再调用该树的方法
+func (c *ConcurrentBPlusTree) RangeIndex(name string, lo, hi int, fn func(secondary, primary int) bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.RangeIndex(name, lo, hi, fn)
+}
@@ -0,0 +1,109 @@
+package bplustree
+
+// 迭代器每次重新定位后最多装入的条目数（装满后读完当前叶节点为止）
+const iterBatch = 64
+
+// Iterator 按 key 升序遍历 [lo, hi] 内的键值对。迭代器不持有节点指针：
+// 每批条目被复制到缓冲区中，缓冲区读完后按已返回的最后一个 key 重新从根下降定位，
+// 因此两次 Next 之间树可以被修改（包括删除当前条目、引起分裂与合并），迭代器不会失效。
+// 遍历期间一直存在的条目按 key 升序恰好返回一次；已经越过的位置上的修改不会被看到。
+// 尚未装入缓冲区的范围内的插入会被看到；已装入缓冲区、尚未返回的条目仍按装入时的值返回，
+// 即使它随后被修改或删除，落在这一范围内的插入也不会被看到。
+// 重复的 key 按已返回的个数跳过，遍历期间增删同一个 key 的重复条目时可能多返回或少返回其中的一条。
+// 需要整体一致的视图时，在 ConcurrentBPlusTree.ForkReadOnly 返回的只读句柄上迭代
+type Iterator struct {
+	fill func(it *Iterator) // 重新定位并装入下一批条目
+
+	lo, hi int
+	buf    []KeyValue
+	pos    int
+	done   bool // 已到达 hi 或最后一个叶节点，缓冲区读完即结束
+
+	started bool // 是否已返回过条目
+	last    int  // 已返回的最后一个 key
+	dup     int  // 与 last 相同的 key 已返回的条目数
+
+	cur KeyValue
+}
+
+// Next 前进到下一个条目，没有更多条目时返回 false
+func (it *Iterator) Next() bool {
+	if it.pos == len(it.buf) {
+		if it.done {
+			return false
+		}
+		it.buf, it.pos = it.buf[:0], 0
+		it.fill(it)
+		if len(it.buf) == 0 {
+			it.done = true
+			return false
+		}
+	}
+	it.cur = it.buf[it.pos]
+	it.pos++
+	if it.started && it.cur.Key == it.last {
+		it.dup++
+	} else {
+		it.started, it.last, it.dup = true, it.cur.Key, 1
+	}
+	return true
+}
+
+// Key 返回当前条目的 key，须在 Next 返回 true 之后调用
+func (it *Iterator) Key() int {
+	return it.cur.Key
+}
+
+// Value 返回当前条目的 value，须在 Next 返回 true 之后调用
+func (it *Iterator) Value() int {
+	return it.cur.Value
+}
+
+// Iter 返回遍历 [lo, hi] 的迭代器；lo > hi 时迭代器为空。
+// 迭代期间可以调用该树的写方法，语义见 Iterator
+func (bpt *BPlusTree) Iter(lo, hi int) *Iterator {
+	return &Iterator{fill: bpt.fillIter, lo: lo, hi: hi, done: lo > hi}
+}
+
+// 从上次停下的位置重新定位，装入下一批条目
+func (bpt *BPlusTree) fillIter(it *Iterator) {
+	start, skip := it.lo, 0
+	if it.started {
+		start, skip = it.last, it.dup
+	}
+	end := bpt.leavesFrom(start, func(leaf *Node) bool {
+		for i, k := range leaf.keys {
+			if k < start || bpt.expired(k) {
+				continue
+			}
+			if k > it.hi {
+				it.done = true
+				return false
+			}
+			if k == start && skip > 0 {
+				skip--
+				continue
+			}
+			it.buf = append(it.buf, KeyValue{Key: k, Value: leaf.values[i]})
+		}
+		return len(it.buf) < iterBatch
+	})
+	if end {
+		it.done = true
+	}
+}
+
+// Iter 返回遍历 [lo, hi] 的迭代器。每批条目在读锁下装入，两次装入之间不持有锁，
+// 因此长时间的遍历不会阻塞写操作，迭代期间也可以调用该树的任何方法，语义见 Iterator
+func (c *ConcurrentBPlusTree) Iter(lo, hi int) *Iterator {
+	return &Iterator{fill: func(it *Iterator) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		c.tree.fillIter(it)
+	}, lo: lo, hi: hi, done: lo > hi}
+}
+
+// Iter 返回遍历固定版本中 [lo, hi] 的迭代器，看到的是 fork 时刻的一致视图
+func (h *ReadOnlyTree) Iter(lo, hi int) *Iterator {
+	return h.tree.Iter(lo, hi)
+}
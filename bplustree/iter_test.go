@@ -0,0 +1,191 @@
+package bplustree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// 迭代期间对树的修改，记录每个 key 是否存在过、是否在迭代器越过之前被删除
+type iterMutator struct {
+	bpt     *BPlusTree
+	cur     int          // 迭代器刚返回的 key
+	ever    map[int]bool // 遍历期间存在过的 key
+	removed map[int]bool // 在迭代器越过之前被删除的 key
+}
+
+func (m *iterMutator) insert(k int) {
+	if m.bpt.Search(k) == -1 {
+		m.bpt.Insert(k, k)
+		m.ever[k] = true
+	}
+}
+
+func (m *iterMutator) remove(k int) {
+	if m.bpt.Remove(k) == nil && k > m.cur {
+		m.removed[k] = true
+	}
+}
+
+// 在每次 Next 之后修改树（删除当前条目、在游标前后插入与删除、大量写入引起分裂与合并），
+// 一直存在的 key 按升序恰好返回一次，返回的 key 都在遍历期间存在过，值与 key 一致
+func TestIterMutation(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(m *iterMutator, rng *rand.Rand)
+		exact  bool // 返回的 key 恰好是原有的、未在越过之前被删除的 key
+	}{
+		{"delete-current", func(m *iterMutator, _ *rand.Rand) { m.remove(m.cur) }, true},
+		{"delete-behind", func(m *iterMutator, _ *rand.Rand) { m.remove(m.cur - 2) }, true},
+		{"insert-behind", func(m *iterMutator, _ *rand.Rand) { m.insert(m.cur - 1) }, true},
+		{"insert-ahead", func(m *iterMutator, _ *rand.Rand) {
+			if m.cur%2 == 0 {
+				m.insert(m.cur + 1)
+			}
+		}, false},
+		{"delete-ahead", func(m *iterMutator, _ *rand.Rand) { m.remove(m.cur + 4) }, false},
+		{"split-merge", func(m *iterMutator, rng *rand.Rand) {
+			// 每步在游标附近与远处成批增删，不断引起分裂、借补与合并
+			for range 8 {
+				k := m.cur + rng.Intn(400) - 200
+				if rng.Intn(2) == 0 {
+					m.insert(k)
+				} else {
+					m.remove(k)
+				}
+			}
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bpt := NewBPlusTree()
+			m := &iterMutator{bpt: bpt, ever: make(map[int]bool), removed: make(map[int]bool)}
+			for k := 0; k < 2000; k += 2 {
+				bpt.Insert(k, k)
+				m.ever[k] = true
+			}
+			original := sortedKeys(m.ever)
+			rng := rand.New(rand.NewSource(1))
+			var got []int
+			for it := bpt.Iter(math.MinInt, math.MaxInt); it.Next(); {
+				k := it.Key()
+				if it.Value() != k || !m.ever[k] {
+					t.Fatalf("返回了从未存在过的条目 %d => %d", k, it.Value())
+				}
+				if n := len(got); n > 0 && got[n-1] >= k {
+					t.Fatalf("返回 %d 之后又返回 %d", got[n-1], k)
+				}
+				got = append(got, k)
+				m.cur = k
+				tt.mutate(m, rng)
+			}
+			var stable []int
+			for _, k := range original {
+				if !m.removed[k] {
+					stable = append(stable, k)
+				}
+			}
+			for _, k := range stable {
+				if _, ok := slices.BinarySearch(got, k); !ok {
+					t.Fatalf("一直存在的 key %d 没有被返回", k)
+				}
+			}
+			if tt.exact && !slices.Equal(got, stable) {
+				t.Fatalf("返回了 %d 个 key，期望 %d 个", len(got), len(stable))
+			}
+			if err := bpt.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func sortedKeys(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// 越过当前缓冲区的插入一定会被看到
+func TestIterInsertBeyondBatch(t *testing.T) {
+	bpt := NewBPlusTree()
+	for k := 0; k < 2000; k += 2 {
+		bpt.Insert(k, k)
+	}
+	var got []int
+	for it := bpt.Iter(0, math.MaxInt); it.Next(); {
+		got = append(got, it.Key())
+		if k := it.Key(); k%2 == 0 {
+			// 远在当前批次之后的奇数 key
+			bpt.Insert(k+1001, k+1001)
+		}
+	}
+	var want []int
+	for k := 0; k < 2000; k += 2 {
+		want = append(want, k)
+	}
+	for k := 1001; k < 3000; k += 2 {
+		want = append(want, k)
+	}
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("返回了 %d 个 key，期望 %d 个", len(got), len(want))
+	}
+}
+
+// 一个 goroutine 持续写入的同时另一个 goroutine 迭代；写入方不触碰 10 的倍数，
+// 这些 key 每一轮都须按升序恰好返回一次。配合 -race 运行
+func TestConcurrentIterMutation(t *testing.T) {
+	c := NewConcurrentBPlusTree()
+	for k := range 3000 {
+		c.Insert(k, k)
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rng := rand.New(rand.NewSource(2))
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			k := rng.Intn(3000)
+			if k%10 == 0 {
+				continue
+			}
+			if c.Remove(k) != nil {
+				c.Insert(k, k)
+			}
+		}
+	}()
+	for range 20 {
+		var stable []int
+		prev := math.MinInt
+		for it := c.Iter(math.MinInt, math.MaxInt); it.Next(); {
+			k := it.Key()
+			if k <= prev || it.Value() != k {
+				t.Fatalf("返回 %d 之后又返回 %d => %d", prev, k, it.Value())
+			}
+			prev = k
+			if k%10 == 0 {
+				stable = append(stable, k)
+			}
+		}
+		if len(stable) != 300 {
+			t.Fatalf("10 的倍数返回了 %d 个，期望 300 个", len(stable))
+		}
+	}
+	close(stop)
+	wg.Wait()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
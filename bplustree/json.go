@@ -0,0 +1,105 @@
+package bplustree
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ExportJSON 将全部键值对按 key 升序以 JSON 数组的形式流式写入 w，每个元素独占一行：
+//
+//	[
+//	{"key":1,"value":10},
+//	{"key":2,"value":20}
+//	]
+//
+// 只导出数据而不包含树的内部结构，便于其他工具查看、比较与重新导入
+func (bpt *BPlusTree) ExportJSON(w io.Writer) error {
+	jw := newJSONWriter(w)
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, key := range leaf.keys {
+			jw.add(key, leaf.values[i])
+		}
+	}
+	if err := jw.finish(); err != nil {
+		return fmt.Errorf("导出 JSON 失败：%w", err)
+	}
+	return nil
+}
+
+// 流式写出 ExportJSON 格式的键值对数组，写入错误在 finish 时返回
+type jsonWriter struct {
+	bw    *bufio.Writer
+	first bool
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[")
+	return &jsonWriter{bw: bw, first: true}
+}
+
+func (jw *jsonWriter) add(key, value int) {
+	if !jw.first {
+		jw.bw.WriteString(",")
+	}
+	jw.first = false
+	fmt.Fprintf(jw.bw, "\n{\"key\":%d,\"value\":%d}", key, value)
+}
+
+func (jw *jsonWriter) finish() error {
+	jw.bw.WriteString("\n]\n")
+	return jw.bw.Flush()
+}
+
+// ImportJSON 从 r 中流式读取 ExportJSON 格式的键值对数组并插入树中；
+// 输入有误时返回错误，树保持不变
+func (bpt *BPlusTree) ImportJSON(r io.Reader) error {
+	keys, values, err := readJSON(r)
+	if err != nil {
+		return fmt.Errorf("导入 JSON 失败：%w", err)
+	}
+	bpt.load(keys, values)
+	return nil
+}
+
+// 读取 ExportJSON 格式的键值对数组，按输入顺序返回全部键与值
+func readJSON(r io.Reader) (keys, values []int, err error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, nil, fmt.Errorf("应为键值对数组")
+	}
+	for dec.More() {
+		var kv KeyValue
+		if err := dec.Decode(&kv); err != nil {
+			return nil, nil, fmt.Errorf("第 %d 个元素：%w", len(keys), err)
+		}
+		keys = append(keys, kv.Key)
+		values = append(values, kv.Value)
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim(']') {
+		return nil, nil, fmt.Errorf("数组未正确结束")
+	}
+	return keys, values, nil
+}
+
+// 将一批键值对加入树中：树为空且输入已按 key 升序排列时自底向上整体构建，否则逐个插入
+func (bpt *BPlusTree) load(keys, values []int) {
+	if bpt.root.isLeaf && len(bpt.root.keys) == 0 && sort.IntsAreSorted(keys) {
+		bpt.bulkLoad(keys, values)
+		if bpt.indexes != nil {
+			bpt.rebuildIndexes()
+		}
+		if bpt.watched() {
+			for i, key := range keys {
+				bpt.notify(Event{Kind: EventInsert, Key: key, Value: values[i]})
+			}
+		}
+		return
+	}
+	for i, key := range keys {
+		bpt.Insert(key, values[i])
+	}
+}
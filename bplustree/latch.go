@@ -0,0 +1,139 @@
+package bplustree
+
+import "sync"
+
+// LatchedBPlusTree 使用节点级闩锁与锁耦合（latch crabbing）实现并发访问：
+// 读操作沿路径依次获取读闩锁，拿到子节点后立即释放父节点；
+// 写操作沿路径获取写闩锁，一旦子节点对本次操作是"安全"的（不会分裂、合并或改变最大键），
+// 就释放所有祖先节点的闩锁。因此修改不同叶节点的写操作可以并行执行，
+// 只有可能向上传播结构变化的操作才会长时间持有上层节点
+type LatchedBPlusTree struct {
+	rootLatch sync.RWMutex // 保护 tree.root 指针本身
+	tree      *BPlusTree
+}
+
+// NewLatchedBPlusTree 创建一个使用节点级闩锁的并发 B+ 树
+func NewLatchedBPlusTree() *LatchedBPlusTree {
+	return &LatchedBPlusTree{tree: NewBPlusTree()}
+}
+
+// 写操作在下降过程中仍持有的闩锁
+type latchSet struct {
+	rootLatch *sync.RWMutex // 非 nil 表示仍持有根指针闩锁
+	nodes     []*Node
+}
+
+// 释放当前持有的全部闩锁
+func (s *latchSet) releaseAll() {
+	if s.rootLatch != nil {
+		s.rootLatch.Unlock()
+		s.rootLatch = nil
+	}
+	for _, n := range s.nodes {
+		n.latch.Unlock()
+	}
+	s.nodes = s.nodes[:0]
+}
+
+// 以写闩锁沿路径下降到 key 所在的叶节点。safe 判断节点对本次操作是否安全，
+// 安全时释放其全部祖先；latchSiblings 为 true 时，对不安全的节点额外锁住其左右兄弟，
+// 供删除时的借补与合并使用。返回的叶节点已被写锁定，调用方须在操作完成后调用 releaseAll。
+// 返回的路径从仍持有闩锁的最上层节点开始：结构变化不会越过安全的节点，更上层的节点无需出现在路径中
+func (l *LatchedBPlusTree) descendForWrite(key int, safe func(n *Node, isRoot bool) bool, latchSiblings bool) (*Node, []pathStep, *latchSet) {
+	held := &latchSet{rootLatch: &l.rootLatch}
+	l.rootLatch.Lock()
+	node := l.tree.root
+	node.latch.Lock()
+	if safe(node, true) {
+		held.releaseAll()
+	}
+	held.nodes = append(held.nodes, node)
+
+	var path []pathStep
+	for !node.isLeaf {
+		i := childIndex(node, key)
+		child := node.children[i]
+		child.latch.Lock()
+		if safe(child, false) {
+			held.releaseAll()
+			held.nodes = append(held.nodes, child)
+			path = path[:0]
+		} else {
+			held.nodes = append(held.nodes, child)
+			path = append(path, pathStep{node, i})
+			if latchSiblings {
+				if i > 0 {
+					node.children[i-1].latch.Lock()
+					held.nodes = append(held.nodes, node.children[i-1])
+				}
+				if i+1 < len(node.children) {
+					node.children[i+1].latch.Lock()
+					held.nodes = append(held.nodes, node.children[i+1])
+				}
+			}
+		}
+		node = child
+	}
+	return node, path, held
+}
+
+// Insert 插入键值对。节点未满且 key 不超过其最大键时，插入既不会分裂该节点，也不会改变其祖先的关键词
+func (l *LatchedBPlusTree) Insert(key, value int) {
+	leaf, path, held := l.descendForWrite(key, func(n *Node, isRoot bool) bool {
+		if len(n.keys) >= MaxKeys {
+			return false
+		}
+		return isRoot || key <= maxKey(n)
+	}, false)
+	defer held.releaseAll()
+	l.tree.insertIntoLeaf(leaf, path, key, value)
+}
+
+// Remove 删除 key。节点关键字数高于下限且 key 小于其最大键时，删除既不会引起借补/合并，也不会改变其祖先的关键词
+func (l *LatchedBPlusTree) Remove(key int) error {
+	leaf, path, held := l.descendForWrite(key, func(n *Node, isRoot bool) bool {
+		if isRoot {
+			// 根节点没有下限，只需保证删除后不会因只剩一个子节点而下降
+			return n.isLeaf || len(n.children) > 2
+		}
+		return len(n.keys) > getMinKeys() && key < maxKey(n)
+	}, true)
+	defer held.releaseAll()
+	return l.tree.removeFromLeaf(leaf, path, key)
+}
+
+// Modify 修改 key 对应的 value：内部节点只需读闩锁，仅目标叶节点加写闩锁
+func (l *LatchedBPlusTree) Modify(key, newValue int) error {
+	leaf := l.descendForRead(key, true)
+	defer leaf.latch.Unlock()
+	return l.tree.modifyInLeaf(leaf, key, newValue)
+}
+
+// Search 查找 key 对应的 value；若不存在返回 -1
+func (l *LatchedBPlusTree) Search(key int) int {
+	leaf := l.descendForRead(key, false)
+	defer leaf.latch.RUnlock()
+	return searchLeaf(leaf, key)
+}
+
+// 以读闩锁沿路径下降到 key 所在的叶节点，writeLeaf 为 true 时叶节点改为加写闩锁
+func (l *LatchedBPlusTree) descendForRead(key int, writeLeaf bool) *Node {
+	lock := func(n *Node) {
+		if writeLeaf && n.isLeaf {
+			n.latch.Lock()
+		} else {
+			n.latch.RLock()
+		}
+	}
+	l.rootLatch.RLock()
+	node := l.tree.root
+	lock(node)
+	l.rootLatch.RUnlock()
+	for !node.isLeaf {
+		child := node.children[childIndex(node, key)]
+		lock(child)
+		node.latch.RUnlock()
+		node = child
+	}
+	return node
+}
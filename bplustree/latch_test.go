@@ -0,0 +1,93 @@
+package bplustree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// 多个 goroutine 并发插入、删除、修改与查找，与互斥锁保护的 map 对照；配合 -race 运行可发现闩锁遗漏。
+// 每个 worker 只写 key%workers 等于自己编号的 key，因此它对自己的 key 的读取结果是确定的，
+// 而相邻的 key 属于不同的 worker，写操作在同一批叶节点上交错，频繁引起并发的分裂、借补与合并
+func TestLatchedBPlusTreeConcurrent(t *testing.T) {
+	const (
+		workers = 8
+		keys    = 2000
+		ops     = 5000
+	)
+	l := NewLatchedBPlusTree()
+	var mu sync.Mutex
+	model := make(map[int]int)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for range ops {
+				k := rng.Intn(keys/workers)*workers + w
+				mu.Lock()
+				v, ok := model[k]
+				mu.Unlock()
+				switch op := rng.Intn(10); {
+				case op < 3:
+					// 查找其他 worker 的 key：结果不确定，但值只能属于该 key
+					other := rng.Intn(keys)
+					if got := l.Search(other); got != -1 && got != other && got != -other {
+						t.Errorf("Search(%d) = %d", other, got)
+						return
+					}
+				case op < 5:
+					want := -1
+					if ok {
+						want = v
+					}
+					if got := l.Search(k); got != want {
+						t.Errorf("Search(%d) = %d，期望 %d", k, got, want)
+						return
+					}
+				case !ok:
+					l.Insert(k, k)
+					mu.Lock()
+					model[k] = k
+					mu.Unlock()
+				case op < 8:
+					if err := l.Remove(k); err != nil {
+						t.Errorf("Remove(%d): %v", k, err)
+						return
+					}
+					mu.Lock()
+					delete(model, k)
+					mu.Unlock()
+				default:
+					if err := l.Modify(k, -v); err != nil {
+						t.Errorf("Modify(%d): %v", k, err)
+						return
+					}
+					mu.Lock()
+					model[k] = -v
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := treeContents(t, l.tree); len(got) != len(model) {
+		t.Fatalf("树中有 %d 个条目，期望 %d 个", len(got), len(model))
+	}
+	for k := range keys {
+		want, ok := model[k]
+		if !ok {
+			want = -1
+		}
+		if got := l.Search(k); got != want {
+			t.Fatalf("Search(%d) = %d，期望 %d", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package memcache 以 memcached 文本协议对外提供基于 B+ 树的键值存储，
+// 现有的 memcached 客户端无需修改即可使用有序存储。支持 get、set、delete、incr、decr 与过期时间
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bplus-go/bplustree"
+	"bplus-go/bplustree/workload"
+)
+
+// 协议限制：键最长 250 字节，数据最大 1 MiB（与 memcached 的默认值相同），命令行最长 8 KiB；
+// 过期时间不超过 30 天时为相对秒数，否则为 Unix 时间戳
+const (
+	maxKeyLen      = 250
+	maxLineLen     = 8 << 10
+	maxItemSize    = 1 << 20
+	maxRelativeTTL = 30 * 24 * 60 * 60
+)
+
+// errLineTooLong 表示客户端发送的命令行超过 maxLineLen，连接随之关闭
+var errLineTooLong = errors.New("memcache: 命令行过长")
+
+// 一条缓存数据
+type item struct {
+	flags   uint32
+	data    []byte
+	expires time.Time // 零值表示永不过期
+}
+
+func (it item) expired(now time.Time) bool {
+	return !it.expires.IsZero() && !now.Before(it.expires)
+}
+
+// Server 在 B+ 树上实现 memcached 文本协议，可同时服务多个连接。
+// 过期的数据在被访问时删除，也可以通过 PurgeExpired 定期清理
+type Server struct {
+	mu    sync.Mutex
+	items *bplustree.OrderedTree[[]byte, item]
+	now   func() time.Time
+
+	capture *workload.Recorder // 非 nil 时抽样记录操作
+}
+
+// NewServer 创建一个空的 Server
+func NewServer() *Server {
+	return &Server{items: bplustree.NewBytesTree[item](), now: time.Now}
+}
+
+// Capture 让服务把抽中的 get、set、delete、incr、decr 操作记录到 rec（incr、decr 记为 set），
+// 用于离线重放调优。须在 Serve 之前调用；键在记录前已由 rec 匿名化
+func (s *Server) Capture(rec *workload.Recorder) {
+	s.capture = rec
+}
+
+func (s *Server) record(kind workload.OpKind, key []byte) {
+	if s.capture != nil {
+		s.capture.Record(kind, key)
+	}
+}
+
+// ListenAndServe 监听 TCP 地址 addr 并处理连接，直到监听失败
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 接受 l 上的连接，每个连接由单独的 goroutine 处理；l 被关闭后返回 Accept 的错误
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil {
+				log.Printf("memcache: %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn 处理一个连接上的全部命令，直到客户端发送 quit 或关闭连接。
+// 命令行超过 maxLineLen 时回复 CLIENT_ERROR 并关闭连接
+func (s *Server) ServeConn(rw io.ReadWriter) error {
+	r := bufio.NewReaderSize(rw, maxLineLen)
+	w := bufio.NewWriter(rw)
+	for {
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			fmt.Fprint(w, "CLIENT_ERROR line too long\r\n")
+			return errors.Join(errLineTooLong, w.Flush())
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		args := strings.Fields(string(line))
+		if len(args) == 0 {
+			fmt.Fprint(w, "ERROR\r\n")
+		} else if args[0] == "quit" {
+			return w.Flush()
+		} else if err := s.dispatch(args, r, w); err != nil {
+			return err
+		}
+		// 流水线中的后续命令已在缓冲区时合并写出
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// 执行一条命令；只有读取连接失败时返回错误，协议错误以 ERROR 或 CLIENT_ERROR 回复客户端
+func (s *Server) dispatch(args []string, r *bufio.Reader, w *bufio.Writer) error {
+	switch args[0] {
+	case "get":
+		s.get(args[1:], w)
+	case "set":
+		return s.set(args[1:], r, w)
+	case "delete":
+		s.delete(args[1:], w)
+	case "incr", "decr":
+		s.incr(args[0] == "decr", args[1:], w)
+	case "version":
+		fmt.Fprint(w, "VERSION bplus-go\r\n")
+	default:
+		fmt.Fprint(w, "ERROR\r\n")
+	}
+	return nil
+}
+
+// 命令末尾的 noreply 表示不需要回复
+func noreply(args []string, n int) bool {
+	return len(args) == n+1 && args[n] == "noreply"
+}
+
+// 键不能为空、不能超长，也不能含控制字符
+func validKey(key string) bool {
+	return len(key) > 0 && len(key) <= maxKeyLen &&
+		strings.IndexFunc(key, func(r rune) bool { return r < 0x21 || r == 0x7f }) < 0
+}
+
+// 查找 key，已过期的数据在此时删除。调用方须持有 s.mu
+func (s *Server) lookup(key []byte) (item, bool) {
+	it, ok := s.items.Search(key)
+	if ok && it.expired(s.now()) {
+		s.items.Remove(key)
+		return item{}, false
+	}
+	return it, ok
+}
+
+// get <key>*
+func (s *Server) get(keys []string, w *bufio.Writer) {
+	if len(keys) == 0 {
+		fmt.Fprint(w, "ERROR\r\n")
+		return
+	}
+	for _, key := range keys {
+		if !validKey(key) {
+			fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		s.record(workload.OpGet, []byte(key))
+		if it, ok := s.lookup([]byte(key)); ok {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, it.flags, len(it.data))
+			w.Write(it.data)
+			fmt.Fprint(w, "\r\n")
+		}
+	}
+	fmt.Fprint(w, "END\r\n")
+}
+
+// set <key> <flags> <exptime> <bytes> [noreply]，随后一行为数据块
+func (s *Server) set(args []string, r *bufio.Reader, w *bufio.Writer) error {
+	if len(args) != 4 && !noreply(args, 4) {
+		fmt.Fprint(w, "ERROR\r\n")
+		return nil
+	}
+	flags, err1 := strconv.ParseUint(args[1], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[2], 10, 64)
+	size, err3 := strconv.Atoi(args[3])
+	if !validKey(args[0]) || err1 != nil || err2 != nil || err3 != nil || size < 0 {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+	if size > maxItemSize {
+		// 丢弃数据块，连接上的后续命令仍可正常解析
+		fmt.Fprint(w, "SERVER_ERROR object too large for cache\r\n")
+		_, err := io.CopyN(io.Discard, r, int64(size)+2)
+		return err
+	}
+	block := make([]byte, size+2)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return err
+	}
+	if string(block[size:]) != "\r\n" {
+		fmt.Fprint(w, "CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it := item{flags: uint32(flags), data: block[:size:size], expires: s.expiry(exptime)}
+	key := []byte(args[0])
+	s.record(workload.OpSet, key)
+	if s.items.Modify(key, it) != nil {
+		s.items.Insert(key, it)
+	}
+	if !noreply(args, 4) {
+		fmt.Fprint(w, "STORED\r\n")
+	}
+	return nil
+}
+
+// 按 memcached 的规则换算过期时间：0 表示永不过期，负数表示立即过期，
+// 不超过 30 天的值为相对秒数，更大的值为 Unix 时间戳
+func (s *Server) expiry(exptime int64) time.Time {
+	now := s.now()
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return now
+	case exptime <= maxRelativeTTL:
+		return now.Add(time.Duration(exptime) * time.Second)
+	}
+	return time.Unix(exptime, 0)
+}
+
+// delete <key> [noreply]
+func (s *Server) delete(args []string, w *bufio.Writer) {
+	if len(args) != 1 && !noreply(args, 1) {
+		fmt.Fprint(w, "ERROR\r\n")
+		return
+	}
+	if !validKey(args[0]) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := []byte(args[0])
+	s.record(workload.OpDelete, key)
+	reply := "NOT_FOUND\r\n"
+	if _, ok := s.lookup(key); ok {
+		s.items.Remove(key)
+		reply = "DELETED\r\n"
+	}
+	if !noreply(args, 1) {
+		fmt.Fprint(w, reply)
+	}
+}
+
+// incr|decr <key> <value> [noreply]：数据须为十进制的 64 位无符号整数，
+// incr 溢出时回绕，decr 不低于 0
+func (s *Server) incr(decr bool, args []string, w *bufio.Writer) {
+	if len(args) != 2 && !noreply(args, 2) {
+		fmt.Fprint(w, "ERROR\r\n")
+		return
+	}
+	if !validKey(args[0]) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprint(w, "CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := []byte(args[0])
+	s.record(workload.OpSet, key)
+	it, ok := s.lookup(key)
+	if !ok {
+		if !noreply(args, 2) {
+			fmt.Fprint(w, "NOT_FOUND\r\n")
+		}
+		return
+	}
+	n, err := strconv.ParseUint(string(it.data), 10, 64)
+	if err != nil {
+		fmt.Fprint(w, "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+		return
+	}
+	switch {
+	case !decr:
+		n += delta
+	case delta > n:
+		n = 0
+	default:
+		n -= delta
+	}
+	it.data = strconv.AppendUint(nil, n, 10)
+	s.items.Modify(key, it)
+	if !noreply(args, 2) {
+		fmt.Fprintf(w, "%d\r\n", n)
+	}
+}
+
+// PurgeExpired 删除所有已过期的数据，返回删除的条数。过期数据在被访问时也会删除，
+// 定期调用本方法可以回收长期无人访问的数据
+func (s *Server) PurgeExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var expired [][]byte
+	s.items.Scan(func(key []byte, it item) bool {
+		if it.expired(now) {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	for _, key := range expired {
+		s.items.Remove(key)
+	}
+	return len(expired)
+}
@@ -0,0 +1,136 @@
+package memcache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 以 in 作为客户端发送的全部数据执行一个连接，返回服务端的回复
+func serve(t *testing.T, s *Server, in string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := s.ServeConn(struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(in), &out})
+	return out.String(), err
+}
+
+func TestServeConn(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "set-get",
+			in:   "set a 5 0 3\r\nabc\r\nget a b\r\n",
+			want: "STORED\r\nVALUE a 5 3\r\nabc\r\nEND\r\n",
+		},
+		{
+			name: "overwrite",
+			in:   "set a 0 0 1\r\nx\r\nset a 0 0 2\r\nyz\r\nget a\r\n",
+			want: "STORED\r\nSTORED\r\nVALUE a 0 2\r\nyz\r\nEND\r\n",
+		},
+		{
+			name: "noreply",
+			in:   "set a 0 0 1 noreply\r\nx\r\ndelete a noreply\r\nget a\r\n",
+			want: "END\r\n",
+		},
+		{
+			name: "delete",
+			in:   "set a 0 0 1\r\nx\r\ndelete a\r\ndelete a\r\n",
+			want: "STORED\r\nDELETED\r\nNOT_FOUND\r\n",
+		},
+		{
+			name: "incr-decr",
+			in:   "set n 0 0 2\r\n10\r\nincr n 5\r\ndecr n 100\r\nincr x 1\r\nset s 0 0 1\r\na\r\nincr s 1\r\n",
+			want: "STORED\r\n15\r\n0\r\nNOT_FOUND\r\nSTORED\r\nCLIENT_ERROR cannot increment or decrement non-numeric value\r\n",
+		},
+		{
+			name: "expired-immediately",
+			in:   "set a 0 -1 1\r\nx\r\nget a\r\n",
+			want: "STORED\r\nEND\r\n",
+		},
+		{
+			name: "bad-data-chunk",
+			in:   "set a 0 0 1\r\nxy\r\nget a\r\n",
+			// 按声明的长度读取数据块，多出的内容被当作下一条命令
+			want: "CLIENT_ERROR bad data chunk\r\nERROR\r\nEND\r\n",
+		},
+		{
+			name: "too-large",
+			in:   "set a 0 0 1048577\r\n" + strings.Repeat("x", 1048577) + "\r\nversion\r\n",
+			want: "SERVER_ERROR object too large for cache\r\nVERSION bplus-go\r\n",
+		},
+		{
+			name: "unknown-command",
+			in:   "bogus\r\n\r\nget\r\n",
+			want: "ERROR\r\nERROR\r\nERROR\r\n",
+		},
+		{
+			name: "get-invalid-key",
+			in:   "get ok " + strings.Repeat("k", maxKeyLen+1) + "\r\nget a\x01b\r\n",
+			want: "CLIENT_ERROR bad command line format\r\nCLIENT_ERROR bad command line format\r\n",
+		},
+		{
+			name: "delete-incr-invalid-key",
+			in:   "delete " + strings.Repeat("k", maxKeyLen+1) + "\r\nincr " + strings.Repeat("k", maxKeyLen+1) + " 1\r\n",
+			want: "CLIENT_ERROR bad command line format\r\nCLIENT_ERROR bad command line format\r\n",
+		},
+		{
+			name: "set-invalid-key",
+			in:   "set a\x7fb 0 0 1\r\nx\r\n",
+			want: "CLIENT_ERROR bad command line format\r\nERROR\r\n",
+		},
+		{
+			name: "quit",
+			in:   "version\r\nquit\r\nversion\r\n",
+			want: "VERSION bplus-go\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serve(t, NewServer(), tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("回复为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 超长的命令行不能让服务端无限制地缓冲：回复错误后关闭连接
+func TestServeConnLineTooLong(t *testing.T) {
+	got, err := serve(t, NewServer(), "get "+strings.Repeat("k", maxLineLen)+"\r\nversion\r\n")
+	if !errors.Is(err, errLineTooLong) {
+		t.Fatalf("ServeConn 返回 %v，期望 errLineTooLong", err)
+	}
+	if got != "CLIENT_ERROR line too long\r\n" {
+		t.Errorf("回复为 %q", got)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	s := NewServer()
+	clock := time.Unix(3_000_000, 0)
+	s.now = func() time.Time { return clock }
+	// a 为相对过期时间，c 为超过 30 天、按 Unix 时间戳解释的绝对过期时间
+	if _, err := serve(t, s, "set a 0 10 1\r\nx\r\nset b 0 0 1\r\ny\r\nset c 0 3000005 1\r\nz\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(10 * time.Second)
+	if n := s.PurgeExpired(); n != 2 {
+		t.Fatalf("PurgeExpired 删除 %d 条，期望 2", n)
+	}
+	got, _ := serve(t, s, "get a b c\r\n")
+	if want := "VALUE b 0 1\r\ny\r\nEND\r\n"; got != want {
+		t.Errorf("回复为 %q，期望 %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package bplustree
+
+// 由已按 key 升序排列的键值对自底向上构建整棵树，替换当前根节点。
+// 每层节点数取满足 MaxKeys 上限的最小值，并将条目均匀分配，
+// 因此除根以外的每个节点都不少于 getMinKeys() 个关键字
+func (bpt *BPlusTree) bulkLoad(keys, values []int) {
+	if len(keys) == 0 {
+		bpt.root = bpt.newNode(true)
+		return
+	}
+
+	// 构建叶节点层并串联链表
+	level := make([]*Node, 0, (len(keys)+MaxKeys-1)/MaxKeys)
+	var prev *Node
+	for _, size := range spread(len(keys), MaxKeys) {
+		leaf := bpt.newNode(true)
+		leaf.keys = append(leaf.keys, keys[:size]...)
+		leaf.values = append(leaf.values, values[:size]...)
+		keys, values = keys[size:], values[size:]
+		if prev != nil {
+			prev.next = leaf
+		}
+		prev = leaf
+		level = append(level, leaf)
+	}
+
+	// 逐层向上构建内部节点，直到只剩一个根
+	for len(level) > 1 {
+		parents := make([]*Node, 0, (len(level)+MaxKeys-1)/MaxKeys)
+		for _, size := range spread(len(level), MaxKeys) {
+			parent := bpt.newNode(false)
+			parent.children = append(parent.children, level[:size]...)
+			bpt.updateInternalKeys(parent)
+			level = level[size:]
+			parents = append(parents, parent)
+		}
+		level = parents
+	}
+	bpt.root = level[0]
+}
+
+// 将 n 个条目均匀分配到 ceil(n/capacity) 个节点中，返回每个节点的条目数
+func spread(n, capacity int) []int {
+	count := (n + capacity - 1) / capacity
+	sizes := make([]int, count)
+	for i := range sizes {
+		sizes[i] = n / count
+		if i < n%count {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// Merge 将 other 中的全部键值对合并到当前树：沿两棵树的叶节点链表归并，
+// 再自底向上整体重建，而不是逐个 Insert。两棵树中存在相同 key 时，
+// 取 onConflict(当前树的值, other 的值) 作为结果；onConflict 为 nil 时保留 other 的值。
+// other 本身不会被修改
+func (bpt *BPlusTree) Merge(other *BPlusTree, onConflict func(a, b int) int) {
+	if other == nil {
+		return
+	}
+	var keys, values []int
+	a, b := bpt.leftmostLeaf(), other.leftmostLeaf()
+	i, j := 0, 0
+	for {
+		// 跳过已耗尽的叶节点
+		for a != nil && i >= len(a.keys) {
+			a, i = a.next, 0
+		}
+		for b != nil && j >= len(b.keys) {
+			b, j = b.next, 0
+		}
+		if a == nil && b == nil {
+			break
+		}
+
+		switch {
+		case b == nil || (a != nil && a.keys[i] < b.keys[j]):
+			keys = append(keys, a.keys[i])
+			values = append(values, a.values[i])
+			i++
+		case a == nil || b.keys[j] < a.keys[i]:
+			keys = append(keys, b.keys[j])
+			values = append(values, b.values[j])
+			j++
+		default:
+			// 相同 key：按冲突策略合并为一个条目
+			value := b.values[j]
+			if onConflict != nil {
+				value = onConflict(a.values[i], b.values[j])
+			}
+			keys = append(keys, a.keys[i])
+			values = append(values, value)
+			i++
+			j++
+		}
+	}
+	bpt.tracef("op=merge entries=%d", len(keys))
+	bpt.bulkLoad(keys, values)
+	if bpt.indexes != nil {
+		bpt.rebuildIndexes()
+	}
+}
@@ -0,0 +1,113 @@
+package bplustree
+
+import (
+	"slices"
+	"testing"
+)
+
+// 由 keys 构建树，每个 key 的值为 key*scale
+func treeOf(keys []int, scale int) *BPlusTree {
+	bpt := NewBPlusTree()
+	for _, k := range keys {
+		bpt.Insert(k, k*scale)
+	}
+	return bpt
+}
+
+func keyRange(lo, hi, step int) []int {
+	var keys []int
+	for k := lo; k < hi; k += step {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestMerge(t *testing.T) {
+	sum := func(a, b int) int { return a + b }
+	tests := []struct {
+		name       string
+		a, b       []int // 当前树与 other 中的 key，值分别为 key 与 key*10
+		onConflict func(a, b int) int
+	}{
+		{"disjoint", keyRange(0, 100, 1), keyRange(100, 250, 1), nil},
+		{"disjoint-other-first", keyRange(500, 600, 1), keyRange(0, 100, 1), nil},
+		{"interleaved", keyRange(0, 300, 2), keyRange(1, 300, 2), nil},
+		{"conflict-keeps-other", keyRange(0, 200, 1), keyRange(100, 300, 1), nil},
+		{"conflict-callback", keyRange(0, 200, 2), keyRange(0, 200, 3), sum},
+		{"empty-other", keyRange(0, 100, 1), nil, nil},
+		{"empty-self", nil, keyRange(0, 100, 1), nil},
+		{"both-empty", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := treeOf(tt.a, 1), treeOf(tt.b, 10)
+			otherBefore := treeContents(t, b)
+			want := make(map[int]int)
+			for _, k := range tt.a {
+				want[k] = k
+			}
+			for _, k := range tt.b {
+				if _, ok := want[k]; ok && tt.onConflict != nil {
+					want[k] = tt.onConflict(k, k*10)
+				} else {
+					want[k] = k * 10
+				}
+			}
+			a.Merge(b, tt.onConflict)
+			if got := treeContents(t, a); !slices.Equal(got, sortedEntries(want)) {
+				t.Fatalf("合并后有 %d 个条目，期望 %d 个", len(got), len(want))
+			}
+			if got := treeContents(t, b); !slices.Equal(got, otherBefore) {
+				t.Fatal("Merge 修改了 other")
+			}
+			// 重建后的树可以照常写入与删除
+			for k := range 300 {
+				if _, ok := want[k]; ok {
+					a.Remove(k)
+				} else {
+					a.Insert(k, k)
+				}
+			}
+			if err := a.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMergeNil(t *testing.T) {
+	a := treeOf(keyRange(0, 10, 1), 1)
+	a.Merge(nil, nil)
+	if got := treeContents(t, a); len(got) != 10 {
+		t.Fatalf("与 nil 合并后有 %d 个条目", len(got))
+	}
+}
+
+// 任意条目数下批量构建的树都满足结构不变式，每个节点不少于最少关键字数
+func TestBulkLoad(t *testing.T) {
+	for n := range 200 {
+		keys := keyRange(0, n, 1)
+		bpt := NewBPlusTree()
+		bpt.bulkLoad(keys, keys)
+		got := treeContents(t, bpt)
+		if len(got) != n || n > 0 && got[n-1] != (KeyValue{n - 1, n - 1}) {
+			t.Fatalf("bulkLoad(%d) 构建出 %d 个条目", n, len(got))
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for n := 1; n < 100; n++ {
+		sizes := spread(n, MaxKeys)
+		total := 0
+		for _, s := range sizes {
+			if s > MaxKeys || len(sizes) > 1 && s < getMinKeys() {
+				t.Fatalf("spread(%d) = %v", n, sizes)
+			}
+			total += s
+		}
+		if total != n {
+			t.Fatalf("spread(%d) = %v，合计 %d", n, sizes, total)
+		}
+	}
+}
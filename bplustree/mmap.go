@@ -0,0 +1,149 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// 只读下降时允许的最大层数，防止损坏文件中的环导致死循环
+const maxDiskDepth = 64
+
+// MmapDiskTree 以只读方式打开 DiskBPlusTree 写出的树文件：文件被映射到内存中，
+// Search 直接在映射的页上二分查找，不反序列化节点，也不需要在启动时读入整个文件，
+// 适合快速加载大型只读数据集。打开期间文件不得被修改
+type MmapDiskTree struct {
+	data     []byte
+	root     PageID
+	numPages uint32
+	checksum ChecksumAlgorithm
+	unmap    func() error
+}
+
+// OpenMmapDiskTree 以只读内存映射的方式打开 path 处的树文件
+func OpenMmapDiskTree(path string) (*MmapDiskTree, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("映射树文件失败：%w", err)
+	}
+	t := &MmapDiskTree{data: data, unmap: unmap}
+	if err := t.readHeader(); err != nil {
+		unmap()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *MmapDiskTree) readHeader() error {
+	if len(t.data) < PageSize {
+		return fmt.Errorf("打开树文件失败：文件过短")
+	}
+	meta, err := decodeDiskMeta(t.data[:PageSize])
+	if err != nil {
+		return err
+	}
+	t.root, t.numPages, t.checksum = meta.root, meta.numPages, meta.checksum
+	if uint64(t.numPages)*PageSize > uint64(len(t.data)) {
+		return fmt.Errorf("打开树文件失败：文件头记录了 %d 页，但文件只有 %d 字节", t.numPages, len(t.data))
+	}
+	return nil
+}
+
+// 映射中的一页，每次访问前都会校验其校验和
+type mappedPage []byte
+
+func (t *MmapDiskTree) page(id PageID) (mappedPage, error) {
+	if id == 0 || uint32(id) >= t.numPages {
+		return nil, fmt.Errorf("读取页 %d 失败：%w", id, ErrPageOutOfRange)
+	}
+	p := mappedPage(t.data[int(id)*PageSize : int(id+1)*PageSize])
+	if err := t.checksum.verifyPageChecksum(id, p); err != nil {
+		return nil, err
+	}
+	if p[0] != pageTypeLeaf && p[0] != pageTypeInner {
+		return nil, corruptPage(id, "未知的页类型 %d", p[0])
+	}
+	if p.count() > DiskMaxKeys {
+		return nil, corruptPage(id, "关键词数量 %d 超过上限 %d", p.count(), DiskMaxKeys)
+	}
+	return p, nil
+}
+
+func (p mappedPage) isLeaf() bool { return p[0] == pageTypeLeaf }
+func (p mappedPage) count() int   { return int(binary.LittleEndian.Uint16(p[2:])) }
+func (p mappedPage) next() PageID { return PageID(binary.LittleEndian.Uint32(p[4:])) }
+
+func (p mappedPage) entry(i int) int {
+	if p.isLeaf() {
+		return pageHeaderSize + i*leafEntrySize
+	}
+	return pageHeaderSize + i*innerEntrySize
+}
+
+func (p mappedPage) key(i int) int {
+	return int(int64(binary.LittleEndian.Uint64(p[p.entry(i):])))
+}
+
+func (p mappedPage) value(i int) int {
+	return int(int64(binary.LittleEndian.Uint64(p[p.entry(i)+8:])))
+}
+
+func (p mappedPage) child(i int) PageID {
+	return PageID(binary.LittleEndian.Uint32(p[p.entry(i)+8:]))
+}
+
+// 返回第一个不小于 key 的条目下标
+func (p mappedPage) search(key int) int {
+	return sort.Search(p.count(), func(i int) bool { return p.key(i) >= key })
+}
+
+// Search 返回 key 对应的 value；若不存在返回 -1
+func (t *MmapDiskTree) Search(key int) (int, error) {
+	p, err := t.page(t.root)
+	for depth := 0; err == nil && !p.isLeaf(); depth++ {
+		if depth == maxDiskDepth || p.count() == 0 {
+			return -1, fmt.Errorf("查找失败：树结构损坏")
+		}
+		i := min(p.search(key), p.count()-1)
+		p, err = t.page(p.child(i))
+	}
+	if err != nil {
+		return -1, err
+	}
+	if i := p.search(key); i < p.count() && p.key(i) == key {
+		return p.value(i), nil
+	}
+	return -1, nil
+}
+
+// Scan 沿叶节点链表按 key 升序遍历全部键值对，fn 返回 false 时提前结束
+func (t *MmapDiskTree) Scan(fn func(key, value int) bool) error {
+	p, err := t.page(t.root)
+	for depth := 0; err == nil && !p.isLeaf(); depth++ {
+		if depth == maxDiskDepth || p.count() == 0 {
+			return fmt.Errorf("遍历失败：树结构损坏")
+		}
+		p, err = t.page(p.child(0))
+	}
+	// 叶节点数不会超过总页数，借此防止链表成环
+	for visited := uint32(0); err == nil; visited++ {
+		if visited == t.numPages {
+			return fmt.Errorf("遍历失败：叶节点链表成环")
+		}
+		for i := 0; i < p.count(); i++ {
+			if !fn(p.key(i), p.value(i)) {
+				return nil
+			}
+		}
+		if p.next() == 0 {
+			return nil
+		}
+		p, err = t.page(p.next())
+	}
+	return err
+}
+
+// Close 解除内存映射
+func (t *MmapDiskTree) Close() error {
+	return t.unmap()
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package bplustree
+
+import "os"
+
+// 不支持 mmap 的平台上退化为一次性读入整个文件
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
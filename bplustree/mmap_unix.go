@@ -0,0 +1,34 @@
+//go:build unix
+
+package bplustree
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// 将文件以只读方式映射到内存，返回映射的内容与解除映射的函数
+func mapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil, fmt.Errorf("%s 为空文件", path)
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("%s 过大，无法映射", path)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,146 @@
+package bplustree
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// KeyValue 表示一个键值对
+type KeyValue struct {
+	Key   int `json:"key"`
+	Value int `json:"value"`
+}
+
+// MVCCTree 为每个条目保存带版本号的历史，读者可以按任意已提交版本读取一致的时间点视图，
+// 不受之后的写操作影响。每次写操作都会生成一个新的全局版本号。
+// B+ 树作为索引，将 key 映射到其版本链在 chains 中的下标
+type MVCCTree struct {
+	mu      sync.RWMutex
+	index   *BPlusTree
+	chains  [][]entryVersion
+	version uint64 // 最近一次提交的版本号，初始为 0
+
+	readers readerRegistry // 尚未释放的快照，决定 GC 的回收边界
+	free    []int          // GC 回收后可复用的 chains 下标
+
+	historyLimit int // 每个 key 最多保留的版本数，0 表示不限制
+}
+
+// 条目的一个版本；deleted 为 true 表示该版本是删除标记
+type entryVersion struct {
+	version uint64
+	value   int
+	deleted bool
+}
+
+// NewMVCCTree 创建一个新的多版本 B+ 树，opts 会传递给作为索引的 BPlusTree
+func NewMVCCTree(opts ...Option) *MVCCTree {
+	return &MVCCTree{index: NewBPlusTree(opts...)}
+}
+
+// Version 返回最近一次提交的版本号
+func (m *MVCCTree) Version() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.version
+}
+
+// 为 key 追加一个新版本并返回其版本号，调用方须持有写锁
+func (m *MVCCTree) appendVersion(key int, v entryVersion) uint64 {
+	m.version++
+	v.version = m.version
+	if idx := m.index.Search(key); idx != -1 {
+		m.chains[idx] = append(m.chains[idx], v)
+		m.trimHistory(idx)
+	} else if n := len(m.free); n > 0 {
+		idx := m.free[n-1]
+		m.free = m.free[:n-1]
+		m.chains[idx] = []entryVersion{v}
+		m.index.Insert(key, idx)
+	} else {
+		m.chains = append(m.chains, []entryVersion{v})
+		m.index.Insert(key, len(m.chains)-1)
+	}
+	return m.version
+}
+
+// 返回 key 的最新版本，调用方须持有锁
+func (m *MVCCTree) latest(key int) (entryVersion, bool) {
+	idx := m.index.Search(key)
+	if idx == -1 {
+		return entryVersion{}, false
+	}
+	chain := m.chains[idx]
+	return chain[len(chain)-1], true
+}
+
+// Insert 写入 key 的新值（key 已存在时覆盖），返回本次写入的版本号
+func (m *MVCCTree) Insert(key, value int) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.appendVersion(key, entryVersion{value: value})
+}
+
+// Modify 修改已存在 key 的值，返回本次写入的版本号
+func (m *MVCCTree) Modify(key, newValue int) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.latest(key); !ok || v.deleted {
+		return 0, fmt.Errorf("修改失败：未找到 key = %d", key)
+	}
+	return m.appendVersion(key, entryVersion{value: newValue}), nil
+}
+
+// Remove 以删除标记的形式删除 key，旧版本仍可通过 GetAt 读取，返回本次写入的版本号
+func (m *MVCCTree) Remove(key int) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.latest(key); !ok || v.deleted {
+		return 0, fmt.Errorf("删除失败：未找到 key = %d", key)
+	}
+	return m.appendVersion(key, entryVersion{deleted: true}), nil
+}
+
+// 返回版本链中在 version 时刻可见的版本
+func visibleAt(chain []entryVersion, version uint64) (entryVersion, bool) {
+	// 版本链按版本号递增排列，找到最后一个不晚于 version 的版本
+	i := sort.Search(len(chain), func(i int) bool { return chain[i].version > version })
+	if i == 0 || chain[i-1].deleted {
+		return entryVersion{}, false
+	}
+	return chain[i-1], true
+}
+
+// GetAt 返回 key 在 version 版本时的值；该版本时 key 不存在或已被删除时返回 false。
+// 早于 GC 回收边界的版本可能已被回收，需要长期读取的版本应通过 Snapshot 固定
+func (m *MVCCTree) GetAt(key int, version uint64) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	idx := m.index.Search(key)
+	if idx == -1 {
+		return 0, false
+	}
+	v, ok := visibleAt(m.chains[idx], version)
+	return v.value, ok
+}
+
+// Get 返回 key 的最新值
+func (m *MVCCTree) Get(key int) (int, bool) {
+	return m.GetAt(key, m.Version())
+}
+
+// ScanAt 按 key 升序返回 version 版本时可见的全部键值对
+func (m *MVCCTree) ScanAt(version uint64) []KeyValue {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var result []KeyValue
+	for leaf := m.index.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, key := range leaf.keys {
+			if v, ok := visibleAt(m.chains[leaf.values[i]], version); ok {
+				result = append(result, KeyValue{Key: key, Value: v.value})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,224 @@
+package bplustree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// 每个版本上的读取只反映该版本及之前的写入，删除后重新插入的 key 在两段区间内可见
+func TestMVCCVersionedReads(t *testing.T) {
+	m := NewMVCCTree()
+	v1 := m.Insert(1, 10)
+	v2 := m.Insert(2, 20)
+	v3, _ := m.Modify(1, 11)
+	v4, _ := m.Remove(2)
+	v5 := m.Insert(2, 21)
+	tests := []struct {
+		version uint64
+		want    []KeyValue
+	}{
+		{0, nil},
+		{v1, []KeyValue{{1, 10}}},
+		{v2, []KeyValue{{1, 10}, {2, 20}}},
+		{v3, []KeyValue{{1, 11}, {2, 20}}},
+		{v4, []KeyValue{{1, 11}}},
+		{v5, []KeyValue{{1, 11}, {2, 21}}},
+		{v5 + 100, []KeyValue{{1, 11}, {2, 21}}},
+	}
+	for _, tt := range tests {
+		if got := m.ScanAt(tt.version); !slices.Equal(got, tt.want) {
+			t.Fatalf("ScanAt(%d) = %v，期望 %v", tt.version, got, tt.want)
+		}
+		for _, key := range []int{1, 2, 3} {
+			i := slices.IndexFunc(tt.want, func(kv KeyValue) bool { return kv.Key == key })
+			v, ok := m.GetAt(key, tt.version)
+			if ok != (i >= 0) || ok && v != tt.want[i].Value {
+				t.Fatalf("GetAt(%d, %d) = %d, %v", key, tt.version, v, ok)
+			}
+		}
+	}
+	if m.Version() != v5 {
+		t.Fatalf("Version() = %d，期望 %d", m.Version(), v5)
+	}
+	if v, ok := m.Get(2); !ok || v != 21 {
+		t.Fatalf("Get(2) = %d, %v", v, ok)
+	}
+}
+
+// 修改或删除不存在、已被删除的 key 返回错误，且不产生新版本
+func TestMVCCWriteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(m *MVCCTree) (uint64, error)
+	}{
+		{"modify-missing", func(m *MVCCTree) (uint64, error) { return m.Modify(9, 1) }},
+		{"remove-missing", func(m *MVCCTree) (uint64, error) { return m.Remove(9) }},
+		{"modify-deleted", func(m *MVCCTree) (uint64, error) { return m.Modify(2, 1) }},
+		{"remove-deleted", func(m *MVCCTree) (uint64, error) { return m.Remove(2) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMVCCTree()
+			m.Insert(1, 1)
+			m.Insert(2, 2)
+			m.Remove(2)
+			before := m.Version()
+			if v, err := tt.write(m); err == nil || v != 0 {
+				t.Fatalf("返回 %d, %v，期望错误", v, err)
+			}
+			if m.Version() != before {
+				t.Fatalf("失败的写操作使版本号从 %d 变为 %d", before, m.Version())
+			}
+		})
+	}
+}
+
+// 快照固定在创建时的版本上，之后的写入对它不可见；释放前 GC 不回收它可见的版本
+func TestMVCCSnapshot(t *testing.T) {
+	m := NewMVCCTree()
+	want := make(map[int]int)
+	for k := range 100 {
+		m.Insert(k, k)
+		want[k] = k
+	}
+	snap := m.Snapshot()
+	if snap.Version() != m.Version() {
+		t.Fatalf("快照版本 %d，期望 %d", snap.Version(), m.Version())
+	}
+	for k := range 100 {
+		if k%2 == 0 {
+			m.Remove(k)
+		} else {
+			m.Modify(k, -k)
+		}
+	}
+	m.GC()
+	if got := snap.Scan(); !slices.Equal(got, sortedEntries(want)) {
+		t.Fatalf("GC 后快照读到 %d 个条目，期望 %d 个", len(got), len(want))
+	}
+	if v, ok := snap.Get(4); !ok || v != 4 {
+		t.Fatalf("快照 Get(4) = %d, %v", v, ok)
+	}
+	if v, ok := m.Get(4); ok {
+		t.Fatalf("Get(4) = %d，key 已被删除", v)
+	}
+	snap.Release()
+	snap.Release() // 重复释放无副作用
+	r := m.GC()
+	if r.Horizon != m.Version() || r.ReclaimedKeys != 50 || r.ReclaimedVersions != 150 {
+		t.Fatalf("释放后 GC() = %+v", r)
+	}
+	if s := m.Stats(); s.Keys != 50 || s.Versions != 50 || s.Superseded != 0 {
+		t.Fatalf("GC 后 Stats() = %+v", s)
+	}
+}
+
+// 写入序列：每个 key 的版本链在不同的快照位置下被 GC 回收的结果
+func TestMVCCGC(t *testing.T) {
+	writes := []func(m *MVCCTree){
+		func(m *MVCCTree) { m.Insert(1, 1) }, // 版本 1
+		func(m *MVCCTree) { m.Modify(1, 2) }, // 版本 2
+		func(m *MVCCTree) { m.Insert(2, 1) }, // 版本 3
+		func(m *MVCCTree) { m.Remove(2) },    // 版本 4
+		func(m *MVCCTree) { m.Insert(3, 3) }, // 版本 5
+	}
+	tests := []struct {
+		name     string
+		snapshot int // 在第几次写入之后创建快照，-1 表示没有快照
+		want     MVCCGCResult
+		stats    MVCCStats // GC 之后的统计
+	}{
+		{"no-snapshot", -1, MVCCGCResult{Horizon: 5, ReclaimedVersions: 3, ReclaimedKeys: 1},
+			MVCCStats{Version: 5, Horizon: 5, Keys: 2, LiveKeys: 2, Versions: 2}},
+		{"before-first-write", 0, MVCCGCResult{},
+			MVCCStats{Version: 5, ActiveSnapshots: 1, Keys: 3, LiveKeys: 2, Versions: 5, Superseded: 3}},
+		{"after-modify", 2, MVCCGCResult{Horizon: 2, ReclaimedVersions: 1},
+			MVCCStats{Version: 5, Horizon: 2, ActiveSnapshots: 1, Keys: 3, LiveKeys: 2, Versions: 4, Superseded: 2}},
+		{"before-remove", 3, MVCCGCResult{Horizon: 3, ReclaimedVersions: 1},
+			MVCCStats{Version: 5, Horizon: 3, ActiveSnapshots: 1, Keys: 3, LiveKeys: 2, Versions: 4, Superseded: 2}},
+		{"after-remove", 4, MVCCGCResult{Horizon: 4, ReclaimedVersions: 3, ReclaimedKeys: 1},
+			MVCCStats{Version: 5, Horizon: 4, ActiveSnapshots: 1, Keys: 2, LiveKeys: 2, Versions: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMVCCTree()
+			var snap *MVCCSnapshot
+			for i, write := range writes {
+				if i == tt.snapshot {
+					snap = m.Snapshot()
+				}
+				write(m)
+			}
+			var before []KeyValue
+			if snap != nil {
+				before = snap.Scan()
+			}
+			if got := m.GC(); got != tt.want {
+				t.Fatalf("GC() = %+v，期望 %+v", got, tt.want)
+			}
+			if got := m.Stats(); got != tt.stats {
+				t.Fatalf("Stats() = %+v，期望 %+v", got, tt.stats)
+			}
+			if snap != nil && !slices.Equal(snap.Scan(), before) {
+				t.Fatalf("GC 后快照读到 %v，GC 前为 %v", snap.Scan(), before)
+			}
+			// 被整体回收的 key 可以重新插入，并复用回收的版本链
+			m.Insert(2, 7)
+			if v, ok := m.Get(2); !ok || v != 7 {
+				t.Fatalf("重新插入后 Get(2) = %d, %v", v, ok)
+			}
+			if tt.want.ReclaimedKeys > 0 && len(m.free) != 0 {
+				t.Fatalf("重新插入后仍有 %d 条空闲的版本链", len(m.free))
+			}
+		})
+	}
+}
+
+// 随机写入与多个快照之后，GC 不改变回收边界及之后任何版本上的读取结果
+func TestMVCCGCPreservesVisibleVersions(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewMVCCTree()
+	var snaps []*MVCCSnapshot
+	for round := range 5 {
+		for range 400 {
+			k := rng.Intn(200)
+			if _, ok := m.Get(k); !ok {
+				m.Insert(k, rng.Int())
+			} else if rng.Intn(3) == 0 {
+				m.Remove(k)
+			} else {
+				m.Modify(k, rng.Int())
+			}
+		}
+		snaps = append(snaps, m.Snapshot())
+		if round == 2 {
+			// 释放最早的快照，回收边界随之前移
+			snaps[0].Release()
+		}
+	}
+	horizon := snaps[1].Version()
+	views := make(map[uint64][]KeyValue)
+	for v := horizon; v <= m.Version(); v++ {
+		views[v] = m.ScanAt(v)
+	}
+	stats := m.Stats()
+	r := m.GC()
+	if r.Horizon != horizon || r.ReclaimedVersions == 0 {
+		t.Fatalf("GC() = %+v，期望回收边界 %d", r, horizon)
+	}
+	if after := m.Stats(); after.Versions != stats.Versions-r.ReclaimedVersions || after.Keys != stats.Keys-r.ReclaimedKeys {
+		t.Fatalf("GC 前 %+v，GC 后 %+v，结果 %+v", stats, after, r)
+	}
+	for v, want := range views {
+		if got := m.ScanAt(v); !slices.Equal(got, want) {
+			t.Fatalf("GC 后 ScanAt(%d) 读到 %d 个条目，GC 前 %d 个", v, len(got), len(want))
+		}
+	}
+	for _, s := range snaps[1:] {
+		s.Release()
+	}
+	if s := m.Stats(); s.ActiveSnapshots != 0 || s.Horizon != m.Version() {
+		t.Fatalf("全部快照释放后 Stats() = %+v", s)
+	}
+}
@@ -0,0 +1,133 @@
+package bplustree
+
+import "sort"
+
+// MVCCSnapshot 是固定在创建时版本上的读视图。存活的快照阻止 GC 回收它可见的版本，
+// 用完后应调用 Release，否则旧版本会一直保留
+type MVCCSnapshot struct {
+	tree    *MVCCTree
+	version uint64
+	info    *readerInfo
+}
+
+// Snapshot 固定最近一次提交的版本并返回其快照
+func (m *MVCCTree) Snapshot() *MVCCSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &MVCCSnapshot{
+		tree:    m,
+		version: m.version,
+		info:    m.readers.add(m.version, m.index.readerStacks),
+	}
+}
+
+// Version 返回快照固定的版本号
+func (s *MVCCSnapshot) Version() uint64 {
+	return s.version
+}
+
+// Get 返回 key 在快照版本时的值
+func (s *MVCCSnapshot) Get(key int) (int, bool) {
+	return s.tree.GetAt(key, s.version)
+}
+
+// Scan 按 key 升序返回快照版本时可见的全部键值对
+func (s *MVCCSnapshot) Scan() []KeyValue {
+	return s.tree.ScanAt(s.version)
+}
+
+// Release 释放快照，使 GC 可以回收只有它可见的版本。重复调用无副作用；释放后不得再读取
+func (s *MVCCSnapshot) Release() {
+	s.tree.readers.remove(s.info)
+}
+
+// 回收边界：最早的存活快照的版本，没有快照时为最近一次提交的版本。调用方须持有锁
+func (m *MVCCTree) horizon() uint64 {
+	return m.readers.oldestVersion(m.version)
+}
+
+// MVCCGCResult 描述一次 GC 的结果
+type MVCCGCResult struct {
+	Horizon           uint64 // 本次使用的回收边界
+	ReclaimedVersions int    // 回收的版本数（含删除标记）
+	ReclaimedKeys     int    // 版本链被整体回收、从索引中删除的 key 数
+}
+
+// GC 回收在回收边界及之后的任何版本上都不可见的版本：每个 key 只保留边界时刻可见的版本
+// 及其后的版本；边界时刻可见的若是删除标记，则连同标记一起回收，链为空的 key 从索引中删除。
+// GC 之后，早于回收边界的 GetAt 与 ScanAt 不再保证返回历史值。
+// 通过 SetHistoryLimit 设置了保留上限时，每个 key 最近的若干个版本不被回收。
+// GC 在写锁下遍历全部 key，期间读写操作都会等待
+func (m *MVCCTree) GC() MVCCGCResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := MVCCGCResult{Horizon: m.horizon()}
+	var dead []int
+	for leaf := m.index.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, key := range leaf.keys {
+			idx := leaf.values[i]
+			chain := m.chains[idx]
+			// 边界时刻可见的版本是最后一个不晚于边界的版本，它之前的版本都已被取代
+			k := sort.Search(len(chain), func(j int) bool { return chain[j].version > r.Horizon }) - 1
+			if k < 0 {
+				continue
+			}
+			if chain[k].deleted {
+				// 链首的删除标记与“没有版本”的读取结果相同，可以一起回收
+				k++
+			}
+			if m.historyLimit > 0 {
+				// 设置了保留上限时，为每个 key 保留最近的 historyLimit 个版本
+				k = min(k, max(len(chain)-m.historyLimit, 0))
+			}
+			if k == 0 {
+				continue
+			}
+			r.ReclaimedVersions += k
+			if k == len(chain) {
+				dead = append(dead, key)
+				m.chains[idx] = nil
+				m.free = append(m.free, idx)
+				continue
+			}
+			// 复制到新的底层数组，使被回收的版本可以被垃圾回收
+			m.chains[idx] = append([]entryVersion(nil), chain[k:]...)
+		}
+	}
+	for _, key := range dead {
+		m.index.Remove(key)
+	}
+	r.ReclaimedKeys = len(dead)
+	return r
+}
+
+// MVCCStats 描述版本的保留情况。Superseded 与 Versions 之比反映了旧版本造成的膨胀，
+// 比值持续升高通常意味着 GC 不够频繁，或有快照长期未释放
+type MVCCStats struct {
+	Version         uint64 // 最近一次提交的版本号
+	Horizon         uint64 // 当前的回收边界
+	ActiveSnapshots int    // 尚未释放的快照数
+	Keys            int    // 索引中的 key 数，包括最新版本为删除标记的 key
+	LiveKeys        int    // 最新版本不是删除标记的 key 数
+	Versions        int    // 保存的版本总数
+	Superseded      int    // 除各 key 最新存活值以外的版本数，即 GC 可能回收的上限
+}
+
+// Stats 遍历全部版本链，返回版本保留情况的统计
+func (m *MVCCTree) Stats() MVCCStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s := MVCCStats{Version: m.version, Horizon: m.horizon(), ActiveSnapshots: m.readers.len()}
+	for leaf := m.index.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for _, idx := range leaf.values {
+			chain := m.chains[idx]
+			s.Keys++
+			s.Versions += len(chain)
+			if !chain[len(chain)-1].deleted {
+				s.LiveKeys++
+			}
+		}
+	}
+	s.Superseded = s.Versions - s.LiveKeys
+	return s
+}
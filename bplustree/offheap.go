@@ -0,0 +1,105 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPagerClosed 表示页存储已经关闭
+var ErrPagerClosed = errors.New("页存储已关闭")
+
+// 未指定时每块堆外内存容纳的页数（4 MiB）
+const defaultArenaPages = 1024
+
+// ArenaPager 是保存在堆外内存中的 PageStore：页按块存放在以匿名 mmap 申请的内存中，
+// Go 堆上只有每块一个的切片头。在它之上打开的 DiskBPlusTree 以 PutBytes 保存的字节值都位于值页中，
+// 叶节点只记录值页的页号，因此数百万个值既不增加垃圾回收需要扫描和标记的对象，也不计入触发回收的堆大小。
+// 内容在 Close 后丢失，适合作为进程内的大容量缓存。不支持 mmap 的平台上退化为堆上分配的块。
+// 可以并发使用
+type ArenaPager struct {
+	mu         sync.RWMutex
+	chunkPages int
+	chunks     [][]byte // 每块 chunkPages 页
+	pages      int      // 已写入的最大页号加一，读取此范围以外的页返回 ErrPageOutOfRange
+	closed     bool
+}
+
+// NewArenaPager 创建空的堆外页存储，每次扩展申请 chunkPages 页，不大于 0 时使用 1024 页（4 MiB）
+func NewArenaPager(chunkPages int) *ArenaPager {
+	if chunkPages <= 0 {
+		chunkPages = defaultArenaPages
+	}
+	return &ArenaPager{chunkPages: chunkPages}
+}
+
+// 返回第 id 页在块中的内容；调用方须持有锁且 id 所在的块已分配
+func (p *ArenaPager) page(id PageID) []byte {
+	off := int(id) % p.chunkPages * PageSize
+	return p.chunks[int(id)/p.chunkPages][off : off+PageSize]
+}
+
+// ReadPage 读取第 id 页到 buf；写入过的最大页号以内从未写入的页读出全零，与文件中的空洞相同
+func (p *ArenaPager) ReadPage(id PageID, buf []byte) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return fmt.Errorf("读取页 %d 失败：%w", id, ErrPagerClosed)
+	}
+	if int(id) >= p.pages {
+		return fmt.Errorf("读取页 %d 失败：%w", id, ErrPageOutOfRange)
+	}
+	copy(buf[:PageSize], p.page(id))
+	return nil
+}
+
+// WritePage 将 buf 写入第 id 页，必要时申请新的块
+func (p *ArenaPager) WritePage(id PageID, buf []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return fmt.Errorf("写入页 %d 失败：%w", id, ErrPagerClosed)
+	}
+	for len(p.chunks) <= int(id)/p.chunkPages {
+		chunk, err := mapAnon(p.chunkPages * PageSize)
+		if err != nil {
+			return fmt.Errorf("写入页 %d 失败：申请堆外内存：%w", id, err)
+		}
+		p.chunks = append(p.chunks, chunk)
+	}
+	copy(p.page(id), buf[:PageSize])
+	p.pages = max(p.pages, int(id)+1)
+	return nil
+}
+
+// Sync 没有需要刷写的内容
+func (p *ArenaPager) Sync() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPagerClosed
+	}
+	return nil
+}
+
+// Close 释放全部块，之后的读写返回 ErrPagerClosed
+func (p *ArenaPager) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	var errs []error
+	for _, chunk := range p.chunks {
+		errs = append(errs, unmapAnon(chunk))
+	}
+	p.chunks, p.pages, p.closed = nil, 0, true
+	return errors.Join(errs...)
+}
+
+// Size 返回已申请的堆外内存字节数
+func (p *ArenaPager) Size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.chunks) * p.chunkPages * PageSize
+}
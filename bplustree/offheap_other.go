@@ -0,0 +1,12 @@
+//go:build !unix
+
+package bplustree
+
+// 不支持 mmap 的平台上在堆上分配。块中不含指针，垃圾回收只需标记块本身
+func mapAnon(n int) ([]byte, error) {
+	return make([]byte, n), nil
+}
+
+func unmapAnon([]byte) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestArenaPager(t *testing.T) {
+	page := func(b byte) []byte { return bytes.Repeat([]byte{b}, PageSize) }
+	tests := []struct {
+		name   string
+		writes []PageID
+		read   PageID
+		want   []byte // nil 表示期望 ErrPageOutOfRange
+	}{
+		{"empty", nil, 0, nil},
+		{"written", []PageID{0, 1}, 1, page(1)},
+		{"hole", []PageID{0, 5}, 3, page(0)},
+		{"past-end", []PageID{0, 1}, 2, nil},
+		{"next-chunk", []PageID{9}, 9, page(9)},
+		{"overwrite", []PageID{2, 2}, 2, page(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewArenaPager(4)
+			defer p.Close()
+			for _, id := range tt.writes {
+				if err := p.WritePage(id, page(byte(id))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			buf := page(0xff)
+			err := p.ReadPage(tt.read, buf)
+			if tt.want == nil {
+				if !errors.Is(err, ErrPageOutOfRange) {
+					t.Fatalf("ReadPage(%d) 返回 %v，期望 ErrPageOutOfRange", tt.read, err)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(buf, tt.want) {
+				t.Fatalf("ReadPage(%d) = %x..., %v", tt.read, buf[:4], err)
+			}
+		})
+	}
+}
+
+func TestArenaPagerClose(t *testing.T) {
+	p := NewArenaPager(0)
+	if err := p.WritePage(0, make([]byte, PageSize)); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Size(); got != defaultArenaPages*PageSize {
+		t.Fatalf("Size() = %d，期望 %d", got, defaultArenaPages*PageSize)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("重复 Close 返回 %v", err)
+	}
+	buf := make([]byte, PageSize)
+	for name, err := range map[string]error{
+		"ReadPage":  p.ReadPage(0, buf),
+		"WritePage": p.WritePage(0, buf),
+		"Sync":      p.Sync(),
+	} {
+		if !errors.Is(err, ErrPagerClosed) {
+			t.Errorf("关闭后 %s 返回 %v，期望 ErrPagerClosed", name, err)
+		}
+	}
+	if p.Size() != 0 {
+		t.Fatalf("关闭后 Size() = %d", p.Size())
+	}
+}
+
+// 在堆外页存储上保存字节值：值跨越多个块，覆盖与删除后的页被复用
+func TestArenaPagerByteValues(t *testing.T) {
+	p := NewArenaPager(16)
+	tree, err := NewDiskBPlusTree(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	value := func(i int) []byte {
+		return bytes.Repeat(fmt.Appendf(nil, "value-%d;", i), 1+i%700)
+	}
+	const n = 500
+	for i := range n {
+		if err := tree.PutBytes(i, value(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Size() <= 16*PageSize {
+		t.Fatalf("值应跨越多个块，只申请了 %d 字节", p.Size())
+	}
+	for i := 0; i < n; i += 2 {
+		if err := tree.RemoveBytes(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := p.Size()
+	for i := 0; i < n; i += 2 {
+		if err := tree.PutBytes(i, value(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Size() != size {
+		t.Fatalf("删除后重新写入使堆外内存从 %d 字节增长到 %d 字节", size, p.Size())
+	}
+	for i := range n {
+		if got, ok, err := tree.GetBytes(i); err != nil || !ok || !bytes.Equal(got, value(i)) {
+			t.Fatalf("GetBytes(%d) = %d 字节, %v, %v", i, len(got), ok, err)
+		}
+	}
+}
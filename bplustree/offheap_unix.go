@@ -0,0 +1,14 @@
+//go:build unix
+
+package bplustree
+
+import "syscall"
+
+// 以匿名私有映射申请 n 字节的堆外内存，内容初始为零
+func mapAnon(n int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+func unmapAnon(b []byte) error {
+	return syscall.Munmap(b)
+}
@@ -0,0 +1,382 @@
+package bplustree
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// OrderedTree 是键与值类型可自定义的 B+ 树：键按 cmp 给出的顺序排列，
+// cmp(a, b) 小于、等于、大于 0 分别表示 a 小于、等于、大于 b。
+// 节点结构与 BPlusTree 相同：每个节点最多 MaxKeys 个关键字，内部节点的关键词为对应子节点的最大键，
+// 叶节点以链表相连，允许重复的键。它不支持 BPlusTree 的选项，也不是并发安全的
+type OrderedTree[K, V any] struct {
+	root  *orderedNode[K, V]
+	cmp   func(a, b K) int
+	clone func(K) K // 非 nil 时插入前复制键，使树不与调用方共享底层数组
+	size  int
+	path  []orderedStep[K, V] // findPath 复用的下降路径缓冲区
+
+	// 叶节点做前缀压缩，只用于 NewPrefixBytesTree 创建的 []byte 键树，见 prefix.go
+	prefixed bool
+}
+
+type orderedNode[K, V any] struct {
+	isLeaf   bool
+	keys     []K                  // 对于叶节点：存储键；对于内部节点：每个关键词为对应子节点的最大键
+	values   []V                  // 仅叶节点有效
+	next     *orderedNode[K, V]   // 仅叶节点有效：链表指针
+	children []*orderedNode[K, V] // 仅内部节点有效
+	prefix   []byte               // 仅前缀压缩的叶节点有效：全部键共有的前缀，keys 中只保存其后的部分
+}
+
+// 下降路径上的一步，含义与 pathStep 相同
+type orderedStep[K, V any] struct {
+	node  *orderedNode[K, V]
+	index int
+}
+
+// NewOrderedTree 创建一棵按 cmp 排序的空树，cmp 可以是 cmp.Compare、strings.Compare 等
+func NewOrderedTree[K, V any](cmp func(a, b K) int) *OrderedTree[K, V] {
+	return &OrderedTree[K, V]{root: &orderedNode[K, V]{isLeaf: true}, cmp: cmp}
+}
+
+// NewIntTree 创建以 int 为键、值为任意类型 V 的树，用于在内存中按整数键索引结构体等完整记录；
+// BPlusTree 的值只能是 int
+func NewIntTree[V any]() *OrderedTree[int, V] {
+	return NewOrderedTree[int, V](cmp.Compare[int])
+}
+
+// NewBytesTree 创建以 []byte 为键、按 bytes.Compare 排序的树，适合已按保序方式编码为二进制的键。
+// Insert 会复制键，调用方之后可以复用自己的缓冲区；Min、Max 与 Range 交给调用方的键是树中的数据，不能修改
+func NewBytesTree[V any]() *OrderedTree[[]byte, V] {
+	t := NewOrderedTree[[]byte, V](bytes.Compare)
+	t.clone = bytes.Clone
+	return t
+}
+
+// NewPrefixBytesTree 与 NewBytesTree 相同，但每个叶节点只保存一次其全部键共有的前缀，
+// 各个键只保存前缀之后的部分，读取时再拼回完整的键。URL、路径等共享长前缀的键因此占用更少的内存，
+// 代价是 Min、Max、Range 与 Scan 交给调用方的键需要重新拼接
+func NewPrefixBytesTree[V any]() *OrderedTree[[]byte, V] {
+	t := NewBytesTree[V]()
+	t.prefixed = true
+	return t
+}
+
+// 返回 keys 中第一个不小于 key 的位置
+func (t *OrderedTree[K, V]) search(keys []K, key K) int {
+	return sort.Search(len(keys), func(i int) bool { return t.cmp(keys[i], key) >= 0 })
+}
+
+// 在内部节点中选择应继续下降的子节点下标：第一个最大键不小于 key 的子节点，否则为最后一个
+func (t *OrderedTree[K, V]) childIndex(node *orderedNode[K, V], key K) int {
+	if i := t.search(node.keys, key); i < len(node.keys) {
+		return i
+	}
+	return len(node.children) - 1
+}
+
+// 从根下降到应存放 key 的叶节点，同时返回沿途的路径。路径复用树上的缓冲区，只在下一次写操作之前有效
+func (t *OrderedTree[K, V]) findPath(key K) (*orderedNode[K, V], []orderedStep[K, V]) {
+	path := t.path[:0]
+	node := t.root
+	for !node.isLeaf {
+		i := t.childIndex(node, key)
+		path = append(path, orderedStep[K, V]{node, i})
+		node = node.children[i]
+	}
+	t.path = path
+	return node, path
+}
+
+func (t *OrderedTree[K, V]) findLeaf(key K) *orderedNode[K, V] {
+	node := t.root
+	for !node.isLeaf {
+		node = node.children[t.childIndex(node, key)]
+	}
+	return node
+}
+
+// 节点的最大键；前缀压缩的叶节点返回拼接后的完整键
+func (t *OrderedTree[K, V]) maxKey(n *orderedNode[K, V]) K {
+	if n.isLeaf {
+		return t.key(n, len(n.keys)-1)
+	}
+	return n.keys[len(n.keys)-1]
+}
+
+// 若孩子结点的最大键发生变化，则沿 path 向上更新父节点中的对应关键词，规则与 BPlusTree.updateParent 相同
+func (t *OrderedTree[K, V]) updateParent(child *orderedNode[K, V], path []orderedStep[K, V]) {
+	for level := len(path) - 1; level >= 0; level-- {
+		parent, i := path[level].node, path[level].index
+		maxKey := t.maxKey(child)
+		if t.cmp(parent.keys[i], maxKey) == 0 {
+			return
+		}
+		parent.keys[i] = maxKey
+		if i != len(parent.children)-1 {
+			return
+		}
+		child = parent
+	}
+}
+
+// Insert 插入键值对，并在必要时分裂
+func (t *OrderedTree[K, V]) Insert(key K, value V) {
+	leaf, path := t.findPath(key)
+	pos := t.leafSearch(leaf, key)
+	if t.prefixed {
+		t.insertPrefixed(leaf, pos, key)
+	} else {
+		if t.clone != nil {
+			key = t.clone(key)
+		}
+		leaf.keys = slices.Insert(leaf.keys, pos, key)
+	}
+	leaf.values = slices.Insert(leaf.values, pos, value)
+	t.size++
+	if pos == len(leaf.keys)-1 {
+		t.updateParent(leaf, path)
+	}
+	if len(leaf.keys) > MaxKeys {
+		t.split(leaf, path)
+	}
+}
+
+// 节点分裂：后半部分移至新节点，path 为到 node 父节点为止的下降路径，为空表示 node 是根
+func (t *OrderedTree[K, V]) split(node *orderedNode[K, V], path []orderedStep[K, V]) {
+	if node.isLeaf {
+		t.unpack(node)
+	}
+	mid := len(node.keys) / 2
+	sibling := &orderedNode[K, V]{isLeaf: node.isLeaf}
+	sibling.keys = append(sibling.keys, node.keys[mid:]...)
+	clear(node.keys[mid:])
+	node.keys = node.keys[:mid]
+	if node.isLeaf {
+		sibling.values = append(sibling.values, node.values[mid:]...)
+		clear(node.values[mid:])
+		node.values = node.values[:mid]
+		sibling.next = node.next
+		node.next = sibling
+		t.pack(node)
+		t.pack(sibling)
+	} else {
+		sibling.children = append(sibling.children, node.children[mid:]...)
+		clear(node.children[mid:])
+		node.children = node.children[:mid]
+	}
+
+	if len(path) == 0 {
+		t.root = &orderedNode[K, V]{
+			keys:     []K{t.maxKey(node), t.maxKey(sibling)},
+			children: []*orderedNode[K, V]{node, sibling},
+		}
+		return
+	}
+	parent, pos := path[len(path)-1].node, path[len(path)-1].index
+	parent.keys[pos] = t.maxKey(node)
+	parent.keys = slices.Insert(parent.keys, pos+1, t.maxKey(sibling))
+	parent.children = slices.Insert(parent.children, pos+1, sibling)
+	if len(parent.children) > MaxKeys {
+		t.split(parent, path[:len(path)-1])
+	}
+}
+
+// Remove 删除 key，存在重复的键时删除第一个；key 不存在时返回错误
+func (t *OrderedTree[K, V]) Remove(key K) error {
+	leaf, path := t.findPath(key)
+	pos := t.leafSearch(leaf, key)
+	if !t.leafEqual(leaf, pos, key) {
+		return fmt.Errorf("删除失败：未找到 key = %v", key)
+	}
+	leaf.keys = slices.Delete(leaf.keys, pos, pos+1)
+	leaf.values = slices.Delete(leaf.values, pos, pos+1)
+	t.size--
+	if pos == len(leaf.keys) && len(leaf.keys) > 0 {
+		t.updateParent(leaf, path)
+	}
+	if len(leaf.keys) < getMinKeys() && len(path) > 0 {
+		t.rebalance(leaf, path)
+	}
+	return nil
+}
+
+// 删除后对节点进行借补或合并，规则与 BPlusTree.rebalance 相同：先向左、再向右借补，都不行时优先与左侧合并
+func (t *OrderedTree[K, V]) rebalance(node *orderedNode[K, V], path []orderedStep[K, V]) {
+	if len(path) == 0 {
+		// 根为内部节点且只剩一个子节点时下降为新根
+		if !node.isLeaf && len(node.children) == 1 {
+			t.root = node.children[0]
+		}
+		return
+	}
+	minKeys := getMinKeys()
+	if len(node.keys) >= minKeys {
+		return
+	}
+	parent, index := path[len(path)-1].node, path[len(path)-1].index
+	path = path[:len(path)-1]
+	var left, right *orderedNode[K, V]
+	if index > 0 {
+		left = parent.children[index-1]
+	}
+	if index+1 < len(parent.children) {
+		right = parent.children[index+1]
+	}
+
+	switch {
+	case left != nil && len(left.keys) > minKeys:
+		// 从左侧兄弟借最后一项
+		t.moveEntry(left, len(left.keys)-1, node, 0)
+		parent.keys[index-1] = t.maxKey(left)
+	case right != nil && len(right.keys) > minKeys:
+		// 从右侧兄弟借第一项
+		t.moveEntry(right, 0, node, len(node.keys))
+		parent.keys[index] = t.maxKey(node)
+	case left != nil:
+		t.mergeInto(left, node)
+		parent.keys = slices.Delete(parent.keys, index, index+1)
+		parent.children = slices.Delete(parent.children, index, index+1)
+		parent.keys[index-1] = t.maxKey(left)
+		t.rebalance(parent, path)
+	case right != nil:
+		t.mergeInto(node, right)
+		parent.keys = slices.Delete(parent.keys, index+1, index+2)
+		parent.children = slices.Delete(parent.children, index+1, index+2)
+		parent.keys[index] = t.maxKey(node)
+		t.rebalance(parent, path)
+	}
+}
+
+// 将 from 的第 i 项（叶节点为键值对，内部节点为关键词及子节点）移到 to 的第 j 个位置
+func (t *OrderedTree[K, V]) moveEntry(from *orderedNode[K, V], i int, to *orderedNode[K, V], j int) {
+	if from.isLeaf {
+		t.unpack(from)
+		t.unpack(to)
+		defer t.pack(from)
+		defer t.pack(to)
+	}
+	to.keys = slices.Insert(to.keys, j, from.keys[i])
+	from.keys = slices.Delete(from.keys, i, i+1)
+	if from.isLeaf {
+		to.values = slices.Insert(to.values, j, from.values[i])
+		from.values = slices.Delete(from.values, i, i+1)
+	} else {
+		to.children = slices.Insert(to.children, j, from.children[i])
+		from.children = slices.Delete(from.children, i, i+1)
+	}
+}
+
+// 将右侧相邻的 right 整体并入 left
+func (t *OrderedTree[K, V]) mergeInto(left, right *orderedNode[K, V]) {
+	if left.isLeaf {
+		t.unpack(left)
+		t.unpack(right)
+		defer t.pack(left)
+	}
+	left.keys = append(left.keys, right.keys...)
+	if left.isLeaf {
+		left.values = append(left.values, right.values...)
+		left.next = right.next
+	} else {
+		left.children = append(left.children, right.children...)
+	}
+}
+
+// Search 返回 key 对应的值，存在重复的键时返回第一个；ok 为 false 表示不存在
+func (t *OrderedTree[K, V]) Search(key K) (value V, ok bool) {
+	leaf := t.findLeaf(key)
+	if pos := t.leafSearch(leaf, key); t.leafEqual(leaf, pos, key) {
+		return leaf.values[pos], true
+	}
+	return value, false
+}
+
+// Modify 修改 key 对应的值，存在重复的键时修改第一个；key 不存在时返回错误
+func (t *OrderedTree[K, V]) Modify(key K, value V) error {
+	leaf := t.findLeaf(key)
+	pos := t.leafSearch(leaf, key)
+	if !t.leafEqual(leaf, pos, key) {
+		return fmt.Errorf("修改失败：未找到 key = %v", key)
+	}
+	leaf.values[pos] = value
+	return nil
+}
+
+// Len 返回树中的键值对数量
+func (t *OrderedTree[K, V]) Len() int {
+	return t.size
+}
+
+// IsEmpty 报告树中是否没有任何键
+func (t *OrderedTree[K, V]) IsEmpty() bool {
+	return t.size == 0
+}
+
+// Min 返回最小的键及其值；树为空时 ok 为 false
+func (t *OrderedTree[K, V]) Min() (key K, value V, ok bool) {
+	if t.size == 0 {
+		return key, value, false
+	}
+	node := t.root
+	for !node.isLeaf {
+		node = node.children[0]
+	}
+	return t.key(node, 0), node.values[0], true
+}
+
+// Max 返回最大的键及其值；树为空时 ok 为 false
+func (t *OrderedTree[K, V]) Max() (key K, value V, ok bool) {
+	if t.size == 0 {
+		return key, value, false
+	}
+	node := t.root
+	for !node.isLeaf {
+		node = node.children[len(node.children)-1]
+	}
+	last := len(node.keys) - 1
+	return t.key(node, last), node.values[last], true
+}
+
+// Range 按键的顺序对 [lo, hi] 内的每个键值对调用 fn，fn 返回 false 时提前结束
+func (t *OrderedTree[K, V]) Range(lo, hi K, fn func(key K, value V) bool) {
+	if t.cmp(lo, hi) > 0 {
+		return
+	}
+	t.ascend(lo, func(k K, v V) bool {
+		return t.cmp(k, hi) <= 0 && fn(k, v)
+	})
+}
+
+// Scan 按键的顺序对所有键值对调用 fn，fn 返回 false 时提前结束
+func (t *OrderedTree[K, V]) Scan(fn func(key K, value V) bool) {
+	node := t.root
+	for !node.isLeaf {
+		node = node.children[0]
+	}
+	for ; node != nil; node = node.next {
+		for i := range node.keys {
+			if !fn(t.key(node, i), node.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// 从第一个不小于 lo 的键开始按顺序调用 fn，直到 fn 返回 false 或遍历完所有键
+func (t *OrderedTree[K, V]) ascend(lo K, fn func(key K, value V) bool) {
+	if t.size == 0 {
+		return
+	}
+	for leaf := t.findLeaf(lo); leaf != nil; leaf = leaf.next {
+		for i := range leaf.keys {
+			if k := t.key(leaf, i); t.cmp(k, lo) >= 0 && !fn(k, leaf.values[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// PageSize 是磁盘页的固定大小（字节）
+const PageSize = 4096
+
+// PageID 是页在存储中的编号，第 i 页位于偏移 i*PageSize 处。
+// 0 号页固定为文件头，因此 0 也用作"无此页"的标记
+type PageID uint32
+
+// ErrPageOutOfRange 表示读取的页超出了存储的当前范围
+var ErrPageOutOfRange = errors.New("页号超出存储范围")
+
+// PageStore 是按页读写的存储后端。buf 的长度必须为 PageSize
+type PageStore interface {
+	ReadPage(id PageID, buf []byte) error
+	WritePage(id PageID, buf []byte) error
+	Sync() error
+	Close() error
+}
+
+// FilePager 是基于本地文件的 PageStore
+type FilePager struct {
+	file *os.File
+}
+
+// OpenFilePager 打开（不存在时创建）path 处的页文件
+func OpenFilePager(path string) (*FilePager, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开页文件失败：%w", err)
+	}
+	return &FilePager{file: file}, nil
+}
+
+// ReadPage 读取第 id 页到 buf
+func (p *FilePager) ReadPage(id PageID, buf []byte) error {
+	n, err := p.file.ReadAt(buf[:PageSize], int64(id)*PageSize)
+	if err == io.EOF && n < PageSize {
+		return fmt.Errorf("读取页 %d 失败：%w", id, ErrPageOutOfRange)
+	}
+	if err != nil {
+		return fmt.Errorf("读取页 %d 失败：%w", id, err)
+	}
+	return nil
+}
+
+// WritePage 将 buf 写入第 id 页
+func (p *FilePager) WritePage(id PageID, buf []byte) error {
+	if _, err := p.file.WriteAt(buf[:PageSize], int64(id)*PageSize); err != nil {
+		return fmt.Errorf("写入页 %d 失败：%w", id, err)
+	}
+	return nil
+}
+
+// Sync 将已写入的页刷到磁盘
+func (p *FilePager) Sync() error {
+	return p.file.Sync()
+}
+
+// Close 关闭页文件
+func (p *FilePager) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,42 @@
+package bplustree
+
+// WithNodePool 开启节点复用：合并与根节点下降释放的节点最多保留 size 个，
+// 之后的分裂优先取用它们，连同 keys、values、children 的底层数组一起复用，减少频繁增删时的内存分配与 GC 压力。
+// 开启后，被释放的节点随时可能被改作他用，因此不能在写操作之后继续使用此前取得的 NodeView。
+// LatchedBPlusTree 的读者可能仍停留在刚被合并的节点上，它不支持该选项
+func WithNodePool(size int) Option {
+	return func(bpt *BPlusTree) {
+		bpt.poolSize = size
+	}
+}
+
+// 创建节点，节点池非空时取用池中的节点，其次从区块中分配
+func (bpt *BPlusTree) newNode(isLeaf bool) *Node {
+	var node *Node
+	if n := len(bpt.pool); n > 0 {
+		node = bpt.pool[n-1]
+		bpt.pool[n-1] = nil
+		bpt.pool = bpt.pool[:n-1]
+		node.isLeaf = isLeaf
+	} else if bpt.arena != nil {
+		node = bpt.arena.node(isLeaf)
+	} else {
+		node = NewNode(isLeaf)
+	}
+	node.gen = bpt.gen
+	return node
+}
+
+// 释放已从树中摘下的节点：清空内容后放入节点池，池已满或未开启节点复用时交给垃圾回收器
+func (bpt *BPlusTree) freeNode(node *Node) {
+	if len(bpt.pool) >= bpt.poolSize {
+		return
+	}
+	// 清除指针，避免池中的节点使其他节点无法被回收
+	clear(node.children)
+	node.keys = node.keys[:0]
+	node.values = node.values[:0]
+	node.children = node.children[:0]
+	node.next = nil
+	bpt.pool = append(bpt.pool, node)
+}
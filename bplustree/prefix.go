@@ -0,0 +1,90 @@
+package bplustree
+
+import (
+	"bytes"
+	"slices"
+)
+
+// 前缀压缩的叶节点：prefix 为叶节点全部键的最长公共前缀，keys 中只保存前缀之后的部分。
+// 由于叶节点内的键有序，全部键的公共前缀就是最小键与最大键的公共前缀。
+// 分裂、借补与合并先用 unpack 还原完整的键，结束后再用 pack 重新压缩；
+// 插入的键带有当前前缀时直接保存其余部分，否则同样重新压缩。以下方法只在 K 为 []byte 时使用 prefix
+
+// 叶节点第 i 个完整的键
+func (t *OrderedTree[K, V]) key(n *orderedNode[K, V], i int) K {
+	if len(n.prefix) == 0 {
+		return n.keys[i]
+	}
+	suffix := any(n.keys[i]).([]byte)
+	return any(append(slices.Clip(n.prefix), suffix...)).(K)
+}
+
+// 返回叶节点中第一个不小于 key 的位置。key 不带有前缀时，它小于或大于叶节点的全部键
+func (t *OrderedTree[K, V]) leafSearch(n *orderedNode[K, V], key K) int {
+	if len(n.prefix) == 0 {
+		return t.search(n.keys, key)
+	}
+	kb := any(key).([]byte)
+	if !bytes.HasPrefix(kb, n.prefix) {
+		if bytes.Compare(kb, n.prefix) < 0 {
+			return 0
+		}
+		return len(n.keys)
+	}
+	return t.search(n.keys, any(kb[len(n.prefix):]).(K))
+}
+
+// 报告叶节点第 pos 个键是否等于 key，不拼接完整的键
+func (t *OrderedTree[K, V]) leafEqual(n *orderedNode[K, V], pos int, key K) bool {
+	if pos >= len(n.keys) {
+		return false
+	}
+	if len(n.prefix) == 0 {
+		return t.cmp(n.keys[pos], key) == 0
+	}
+	kb := any(key).([]byte)
+	return bytes.HasPrefix(kb, n.prefix) && bytes.Equal(any(n.keys[pos]).([]byte), kb[len(n.prefix):])
+}
+
+// 在前缀压缩的叶节点的第 pos 个位置插入 key 的副本
+func (t *OrderedTree[K, V]) insertPrefixed(n *orderedNode[K, V], pos int, key K) {
+	kb := any(key).([]byte)
+	if len(n.prefix) > 0 && bytes.HasPrefix(kb, n.prefix) {
+		n.keys = slices.Insert(n.keys, pos, any(bytes.Clone(kb[len(n.prefix):])).(K))
+		return
+	}
+	t.unpack(n)
+	n.keys = slices.Insert(n.keys, pos, t.clone(key))
+	t.pack(n)
+}
+
+// 把叶节点的 keys 还原为完整的键
+func (t *OrderedTree[K, V]) unpack(n *orderedNode[K, V]) {
+	if len(n.prefix) == 0 {
+		return
+	}
+	for i := range n.keys {
+		n.keys[i] = t.key(n, i)
+	}
+	n.prefix = nil
+}
+
+// 重新计算叶节点的公共前缀并压缩 keys，keys 中须为完整的键。
+// 各个键的其余部分复制到新的切片中，使完整的键的底层数组可以被回收
+func (t *OrderedTree[K, V]) pack(n *orderedNode[K, V]) {
+	if !t.prefixed || len(n.keys) < 2 {
+		return
+	}
+	first, last := any(n.keys[0]).([]byte), any(n.keys[len(n.keys)-1]).([]byte)
+	m := 0
+	for m < len(first) && m < len(last) && first[m] == last[m] {
+		m++
+	}
+	if m == 0 {
+		return
+	}
+	n.prefix = bytes.Clone(first[:m])
+	for i, k := range n.keys {
+		n.keys[i] = any(bytes.Clone(any(k).([]byte)[m:])).(K)
+	}
+}
@@ -0,0 +1,140 @@
+package bplustree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// 依次比较两棵树的全部键值对
+func sameBytesTrees(t *testing.T, got, want *OrderedTree[[]byte, int]) {
+	t.Helper()
+	if got.Len() != want.Len() {
+		t.Fatalf("Len() = %d，期望 %d", got.Len(), want.Len())
+	}
+	type kv struct {
+		k []byte
+		v int
+	}
+	var a, b []kv
+	got.Scan(func(k []byte, v int) bool { a = append(a, kv{k, v}); return true })
+	want.Scan(func(k []byte, v int) bool { b = append(b, kv{k, v}); return true })
+	for i := range b {
+		if !bytes.Equal(a[i].k, b[i].k) || a[i].v != b[i].v {
+			t.Fatalf("第 %d 个键值对为 %q=%d，期望 %q=%d", i, a[i].k, a[i].v, b[i].k, b[i].v)
+		}
+	}
+}
+
+// 统计叶节点中保存的键的字节数
+func storedKeyBytes(tree *OrderedTree[[]byte, int]) int {
+	node := tree.root
+	for !node.isLeaf {
+		node = node.children[0]
+	}
+	n := 0
+	for ; node != nil; node = node.next {
+		n += len(node.prefix)
+		for _, k := range node.keys {
+			n += len(k)
+		}
+	}
+	return n
+}
+
+func TestPrefixBytesTree(t *testing.T) {
+	tests := []struct {
+		name string
+		key  func(i int) []byte
+	}{
+		{"url", func(i int) []byte { return fmt.Appendf(nil, "https://example.com/tenant/%03d/item/%d", i%7, i) }},
+		{"no-common-prefix", func(i int) []byte { return []byte{byte(i), byte(i >> 8)} }},
+		{"key-is-prefix", func(i int) []byte { return bytes.Repeat([]byte("a"), i%13) }},
+		{"empty-and-binary", func(i int) []byte { return []byte{0, 0, byte(i % 5), 0xff}[:i%5] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			got, want := NewPrefixBytesTree[int](), NewBytesTree[int]()
+			for i := range 500 {
+				k := tt.key(r.Intn(300))
+				switch op := r.Intn(10); {
+				case op < 6:
+					got.Insert(k, i)
+					want.Insert(k, i)
+				case op < 8:
+					if (got.Remove(k) == nil) != (want.Remove(k) == nil) {
+						t.Fatalf("Remove(%q) 结果不一致", k)
+					}
+				default:
+					if (got.Modify(k, -i) == nil) != (want.Modify(k, -i) == nil) {
+						t.Fatalf("Modify(%q) 结果不一致", k)
+					}
+				}
+				k = tt.key(r.Intn(300))
+				v1, ok1 := got.Search(k)
+				v2, ok2 := want.Search(k)
+				if v1 != v2 || ok1 != ok2 {
+					t.Fatalf("Search(%q) = %d, %v，期望 %d, %v", k, v1, ok1, v2, ok2)
+				}
+			}
+			sameBytesTrees(t, got, want)
+			lo, hi := tt.key(40), tt.key(80)
+			var a, b [][]byte
+			got.Range(lo, hi, func(k []byte, _ int) bool { a = append(a, k); return true })
+			want.Range(lo, hi, func(k []byte, _ int) bool { b = append(b, k); return true })
+			if len(a) != len(b) {
+				t.Fatalf("Range 返回 %d 个键，期望 %d", len(a), len(b))
+			}
+			k1, v1, _ := got.Min()
+			k2, v2, _ := want.Min()
+			if !bytes.Equal(k1, k2) || v1 != v2 {
+				t.Fatalf("Min() = %q，期望 %q", k1, k2)
+			}
+			k1, v1, _ = got.Max()
+			k2, v2, _ = want.Max()
+			if !bytes.Equal(k1, k2) || v1 != v2 {
+				t.Fatalf("Max() = %q，期望 %q", k1, k2)
+			}
+			// 删除全部键后树为空
+			for k := range 300 {
+				for got.Remove(tt.key(k)) == nil {
+				}
+			}
+			if !got.IsEmpty() {
+				t.Fatalf("删除全部键后还剩 %d 个", got.Len())
+			}
+		})
+	}
+}
+
+func TestPrefixBytesTreeSavesSpace(t *testing.T) {
+	plain, packed := NewBytesTree[int](), NewPrefixBytesTree[int]()
+	for i := range 1000 {
+		k := fmt.Appendf(nil, "/var/lib/service/data/shard-%04d", i)
+		plain.Insert(k, i)
+		packed.Insert(k, i)
+	}
+	sameBytesTrees(t, packed, plain)
+	// 叶节点最多 MaxKeys 个键，每个叶节点的前缀只保存一次
+	if p, q := storedKeyBytes(packed), storedKeyBytes(plain); p*10 > q*6 {
+		t.Fatalf("前缀压缩后叶节点保存 %d 字节的键，未压缩时 %d 字节", p, q)
+	}
+}
+
+// 插入的键被复制，调用方之后修改自己的缓冲区不影响树
+func TestPrefixBytesTreeCopiesKeys(t *testing.T) {
+	tree := NewPrefixBytesTree[int]()
+	buf := []byte("prefix/a")
+	tree.Insert(buf, 1)
+	buf[7] = 'b'
+	tree.Insert(buf, 2)
+	buf[0] = 'X'
+	if v, ok := tree.Search([]byte("prefix/a")); !ok || v != 1 {
+		t.Fatalf("Search(prefix/a) = %d, %v", v, ok)
+	}
+	if v, ok := tree.Search([]byte("prefix/b")); !ok || v != 2 {
+		t.Fatalf("Search(prefix/b) = %d, %v", v, ok)
+	}
+}
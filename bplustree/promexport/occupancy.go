@@ -0,0 +1,65 @@
+// Package promexport 将 B+ 树的运行状态以 Prometheus 指标的形式导出。
+// 它独立于 bplustree 包，只有需要 Prometheus 监控的程序才会引入相关依赖
+package promexport
+
+import (
+	"bplus-go/bplustree"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LeafWalker 是可以沿叶节点链表遍历的树，例如 *bplustree.ConcurrentBPlusTree。
+// 采集在抓取指标的 goroutine 中进行，因此树必须能在并发写入时安全遍历
+type LeafWalker interface {
+	WalkLeaves(fn func(n bplustree.NodeInfo) bool)
+}
+
+// 叶节点填充率直方图的桶边界。直接写出而不用 LinearBuckets 累加生成，
+// 避免浮点误差使最后一个桶略小于 1，漏掉完全填满的叶节点
+var fillBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+
+// LeafOccupancyCollector 在每次抓取时遍历全部叶节点，导出叶节点填充率（键数 / MaxKeys）的直方图。
+// 随着时间推移观察该分布向低填充率偏移，即可发现碎片的积累，从而在性能下降之前安排 Compact
+type LeafOccupancyCollector struct {
+	tree LeafWalker
+	desc *prometheus.Desc
+}
+
+// NewLeafOccupancyCollector 为 tree 创建叶节点填充率采集器，labels 为附加到指标上的固定标签
+func NewLeafOccupancyCollector(tree LeafWalker, labels prometheus.Labels) *LeafOccupancyCollector {
+	return &LeafOccupancyCollector{
+		tree: tree,
+		desc: prometheus.NewDesc(
+			"bplustree_leaf_fill_ratio",
+			"Distribution of leaf node fill factors (keys per leaf / MaxKeys).",
+			nil, labels,
+		),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *LeafOccupancyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect 实现 prometheus.Collector：遍历叶节点并生成直方图
+func (c *LeafOccupancyCollector) Collect(ch chan<- prometheus.Metric) {
+	buckets := make(map[float64]uint64, len(fillBuckets))
+	for _, upper := range fillBuckets {
+		buckets[upper] = 0
+	}
+	var count uint64
+	var sum float64
+	c.tree.WalkLeaves(func(n bplustree.NodeInfo) bool {
+		fill := float64(len(n.Keys)) / bplustree.MaxKeys
+		count++
+		sum += fill
+		for _, upper := range fillBuckets {
+			if fill <= upper {
+				buckets[upper]++
+			}
+		}
+		return true
+	})
+	ch <- prometheus.MustNewConstHistogram(c.desc, count, sum, buckets)
+}
@@ -0,0 +1,43 @@
+package promexport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"bplus-go/bplustree"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeStore struct {
+	stats []bplustree.BucketStats
+	err   error
+}
+
+func (f fakeStore) Stats() ([]bplustree.BucketStats, error) {
+	return f.stats, f.err
+}
+
+func TestStoreCollector(t *testing.T) {
+	c := NewStoreCollector(fakeStore{stats: []bplustree.BucketStats{
+		{Name: "a", Inserts: 3, Removes: 1, Searches: 2, Keys: 2, Pages: 1, Bytes: bplustree.PageSize},
+	}}, nil)
+	want := `
+# HELP bplustree_bucket_keys Number of key-value pairs stored in the bucket.
+# TYPE bplustree_bucket_keys gauge
+bplustree_bucket_keys{bucket="a"} 2
+# HELP bplustree_bucket_operations_total Total number of successful operations on the bucket since the store was opened.
+# TYPE bplustree_bucket_operations_total counter
+bplustree_bucket_operations_total{bucket="a",op="insert"} 3
+bplustree_bucket_operations_total{bucket="a",op="modify"} 0
+bplustree_bucket_operations_total{bucket="a",op="remove"} 1
+bplustree_bucket_operations_total{bucket="a",op="search"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "bplustree_bucket_keys", "bplustree_bucket_operations_total"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.CollectAndCompare(NewStoreCollector(fakeStore{err: errors.New("boom")}, nil), strings.NewReader("")); err == nil {
+		t.Fatal("读取统计失败时应报告无效指标")
+	}
+}
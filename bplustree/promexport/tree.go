@@ -0,0 +1,74 @@
+package promexport
+
+import (
+	"bplus-go/bplustree"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TreeSource 是可以报告操作计数与树形状的树，例如 *bplustree.ConcurrentBPlusTree。
+// 与 LeafWalker 一样，它必须能在并发写入时安全读取
+type TreeSource interface {
+	Counters() bplustree.OpCounters
+	Stats() bplustree.TreeStats
+}
+
+// TreeCollector 导出树的累计操作次数（插入、删除、修改、分裂、合并、借补）以及树高、节点数与条目数。
+// 操作次数以 counter 导出，每秒速率由 Prometheus 计算，例如 rate(bplustree_inserts_total[1m])。
+// 树的形状在每次抓取时通过 Stats 遍历整棵树得到，开销与节点数成正比
+type TreeCollector struct {
+	tree TreeSource
+
+	inserts, removes, modifies *prometheus.Desc
+	splits, merges, borrows    *prometheus.Desc
+	height, nodes, entries     *prometheus.Desc
+}
+
+// NewTreeCollector 为 tree 创建采集器，labels 为附加到指标上的固定标签
+func NewTreeCollector(tree TreeSource, labels prometheus.Labels) *TreeCollector {
+	desc := func(name, help string, variable ...string) *prometheus.Desc {
+		return prometheus.NewDesc("bplustree_"+name, help, variable, labels)
+	}
+	return &TreeCollector{
+		tree:     tree,
+		inserts:  desc("inserts_total", "Total number of key-value pairs inserted."),
+		removes:  desc("removes_total", "Total number of keys removed."),
+		modifies: desc("modifies_total", "Total number of values modified in place."),
+		splits:   desc("splits_total", "Total number of node splits, leaf and internal."),
+		merges:   desc("merges_total", "Total number of node merges."),
+		borrows:  desc("borrows_total", "Total number of keys borrowed from sibling nodes."),
+		height:   desc("height", "Number of levels in the tree."),
+		nodes:    desc("nodes", "Number of nodes in the tree.", "kind"),
+		entries:  desc("entries", "Number of key-value pairs stored in the tree."),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *TreeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.inserts, c.removes, c.modifies, c.splits, c.merges, c.borrows,
+		c.height, c.nodes, c.entries,
+	} {
+		ch <- d
+	}
+}
+
+// Collect 实现 prometheus.Collector：读取操作计数并遍历树统计形状
+func (c *TreeCollector) Collect(ch chan<- prometheus.Metric) {
+	ops := c.tree.Counters()
+	counter := func(d *prometheus.Desc, v uint64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, float64(v))
+	}
+	counter(c.inserts, ops.Inserts)
+	counter(c.removes, ops.Removes)
+	counter(c.modifies, ops.Modifies)
+	counter(c.splits, ops.Splits)
+	counter(c.merges, ops.Merges)
+	counter(c.borrows, ops.Borrows)
+
+	s := c.tree.Stats()
+	ch <- prometheus.MustNewConstMetric(c.height, prometheus.GaugeValue, float64(s.Height))
+	ch <- prometheus.MustNewConstMetric(c.nodes, prometheus.GaugeValue, float64(s.InternalNodes), "internal")
+	ch <- prometheus.MustNewConstMetric(c.nodes, prometheus.GaugeValue, float64(s.LeafNodes), "leaf")
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.Entries))
+}
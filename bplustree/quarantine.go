@@ -0,0 +1,140 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// ErrRangeUnavailable 表示 key 所在的区间因页损坏已被隔离，暂时无法读写
+var ErrRangeUnavailable = errors.New("区间不可用")
+
+// KeyRange 表示闭区间 [Min, Max] 内的全部 key
+type KeyRange struct {
+	Min, Max int
+}
+
+// Contains 判断 key 是否位于区间内
+func (r KeyRange) Contains(key int) bool {
+	return r.Min <= key && key <= r.Max
+}
+
+// QuarantinedRange 记录一个被隔离的区间以及导致隔离的损坏页。
+// Root 为该区间所属的树的根节点页号：Store 中的各个桶共用一个文件，区间只对所属的桶生效
+type QuarantinedRange struct {
+	Range KeyRange
+	Page  PageID
+	Root  PageID
+}
+
+// 计算内部节点 node 的第 i 个子节点覆盖的区间，[lo, hi] 为 node 自身覆盖的区间。
+// 查找时等于 keys[i-1] 的 key 总是进入第 i-1 个子节点，因此下界从 keys[i-1]+1 开始；
+// 大于全部关键词的 key 进入最后一个子节点，因此最后一个子节点的上界沿用 hi
+func childRange(node *diskNode, i int, lo, hi int) (int, int) {
+	if i > 0 {
+		lo = node.keys[i-1]
+		if lo < math.MaxInt {
+			lo++
+		}
+	}
+	if i < len(node.keys)-1 {
+		hi = node.keys[i]
+	}
+	return lo, hi
+}
+
+// 若 key 位于已隔离的区间内则返回包装了 ErrRangeUnavailable 的错误
+func (t *DiskBPlusTree) checkQuarantine(key int) error {
+	for _, q := range t.quarantine {
+		if q.Root == t.meta.root && q.Range.Contains(key) {
+			return fmt.Errorf("%w：key = %d 所在区间 [%d, %d] 已隔离（页 %d 已损坏）",
+				ErrRangeUnavailable, key, q.Range.Min, q.Range.Max, q.Page)
+		}
+	}
+	return nil
+}
+
+// 下降时读页出错：若是页损坏，则隔离该页覆盖的区间 r，树的其余部分照常服务；其他错误原样返回
+func (t *DiskBPlusTree) quarantineOnCorrupt(err error, key int, r KeyRange, page PageID) error {
+	var corrupt *CorruptPageError
+	if !errors.As(err, &corrupt) {
+		return err
+	}
+	t.quarantine = append(t.quarantine, QuarantinedRange{Range: r, Page: page, Root: t.meta.root})
+	t.logWarn("页损坏，已隔离其覆盖的区间", "page", page, "min", r.Min, "max", r.Max, "err", err)
+	return fmt.Errorf("%w：key = %d 所在区间 [%d, %d] 已隔离：%w", ErrRangeUnavailable, key, r.Min, r.Max, err)
+}
+
+// Quarantined 返回当前被隔离的全部区间
+func (t *DiskBPlusTree) Quarantined() []QuarantinedRange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]QuarantinedRange(nil), t.quarantine...)
+}
+
+// 撤销以 root 为根的树上的全部隔离，用于该树的页被整体释放之后，调用方须持有锁
+func (t *DiskBPlusTree) dropQuarantine(root PageID) {
+	t.quarantine = slices.DeleteFunc(t.quarantine, func(q QuarantinedRange) bool { return q.Root == root })
+}
+
+// RepairInto 将树重建到空的存储 dst 上，并返回其上打开的树：完好的子树原样复制，
+// 损坏的子树（已隔离的区间以及遍历中新发现的损坏页）由 restore 按区间从备份中取回键值对补齐，
+// restore 返回的区间之外的条目会被忽略。重建结果与 CompactInto 一样是紧凑树，
+// 调用方确认无误后用它替换原树。重建期间持有源树的锁
+func (t *DiskBPlusTree) RepairInto(dst PageStore, restore func(r KeyRange) ([]KeyValue, error), opts ...DiskOption) (*DiskBPlusTree, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.meta.blobs {
+		return nil, fmt.Errorf("修复失败：%w", ErrByteValues)
+	}
+	b, err := newPackedBuilder(dst, t.meta.checksum)
+	if err != nil {
+		return nil, fmt.Errorf("修复失败：%w", err)
+	}
+	err = t.repairWalk(t.meta.root, KeyRange{Min: math.MinInt, Max: math.MaxInt}, b, restore)
+	if err := errors.Join(err, b.finish()); err != nil {
+		return nil, fmt.Errorf("修复失败：%w", err)
+	}
+	return NewDiskBPlusTree(dst, opts...)
+}
+
+// 按 key 顺序深度优先遍历以 id 为根、覆盖区间 r 的子树，把条目写入 b；子树根页损坏时改由 restore 补齐
+func (t *DiskBPlusTree) repairWalk(id PageID, r KeyRange, b *packedBuilder, restore func(r KeyRange) ([]KeyValue, error)) error {
+	node, err := t.readNode(id)
+	t.unpinAll()
+	var corrupt *CorruptPageError
+	if errors.As(err, &corrupt) {
+		entries, err := restore(r)
+		if err != nil {
+			return fmt.Errorf("恢复区间 [%d, %d] 失败：%w", r.Min, r.Max, err)
+		}
+		entries = append([]KeyValue(nil), entries...)
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+		for _, kv := range entries {
+			if r.Contains(kv.Key) && !b.add(kv.Key, kv.Value) {
+				return b.err
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if node.isLeaf {
+		for i, key := range node.keys {
+			if !b.add(key, node.values[i]) {
+				return b.err
+			}
+		}
+		return nil
+	}
+	for i, child := range node.children {
+		lo, hi := childRange(node, i, r.Min, r.Max)
+		if err := t.repairWalk(child, KeyRange{Min: lo, Max: hi}, b, restore); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package bplustree
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// 抽样、重试抖动等随机行为使用的随机源。通过各选项注入 *rand.Rand 后，
+// 同一种子下的抽样顺序可以完整重放；未注入时使用 math/rand/v2 的全局随机源。
+// *rand.Rand 不能被并发使用，这里用互斥锁保护
+type randSource struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newRandSource(rng *rand.Rand) *randSource {
+	return &randSource{rng: rng}
+}
+
+func (r *randSource) Float64() float64 {
+	if r == nil || r.rng == nil {
+		return rand.Float64()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
+
+func (r *randSource) Uint32N(n uint32) uint32 {
+	if r == nil || r.rng == nil {
+		return rand.Uint32N(n)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Uint32N(n)
+}
@@ -0,0 +1,113 @@
+package bplustree
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WithReaderStacks 开启调试模式：创建快照与只读句柄时记录调用栈，
+// 使 LongLivedReaders 能指出长期未释放的读者是在哪里创建的。记录调用栈有额外开销，只应在排查泄漏时开启
+func WithReaderStacks() Option {
+	return func(bpt *BPlusTree) {
+		bpt.readerStacks = true
+	}
+}
+
+// LongLivedReader 描述一个存活时间超过阈值的快照或只读句柄。
+// 它们会阻止 MVCC 的版本回收，或使原树的写操作为其复制共享的节点
+type LongLivedReader struct {
+	Version uint64        // 快照固定的版本号；只读句柄没有版本号，为 0
+	Created time.Time     // 创建时间
+	Age     time.Duration // 截至查询时的存活时间
+	Stack   string        // 创建时的调用栈，仅在 WithReaderStacks 开启时记录
+}
+
+func (r LongLivedReader) String() string {
+	s := fmt.Sprintf("读者已存活 %s（创建于 %s，版本 %d）", r.Age.Round(time.Millisecond), r.Created.Format(time.RFC3339), r.Version)
+	if r.Stack != "" {
+		s += "\n" + r.Stack
+	}
+	return s
+}
+
+// 一个尚未释放的读者
+type readerInfo struct {
+	version uint64
+	created time.Time
+	stack   []byte
+}
+
+// 记录尚未释放的读者，自带互斥锁，可在垃圾回收触发的清理函数中调用
+type readerRegistry struct {
+	mu   sync.Mutex
+	open map[*readerInfo]struct{}
+}
+
+// 登记一个新读者；stacks 为 true 时记录调用方的调用栈
+func (r *readerRegistry) add(version uint64, stacks bool) *readerInfo {
+	info := &readerInfo{version: version, created: time.Now()}
+	if stacks {
+		buf := make([]byte, 4096)
+		info.stack = buf[:runtime.Stack(buf, false)]
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.open == nil {
+		r.open = make(map[*readerInfo]struct{})
+	}
+	r.open[info] = struct{}{}
+	return info
+}
+
+// 注销读者，重复调用无副作用
+func (r *readerRegistry) remove(info *readerInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.open, info)
+}
+
+func (r *readerRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.open)
+}
+
+// 返回存活读者中最早固定的版本；没有读者时返回 limit
+func (r *readerRegistry) oldestVersion(limit uint64) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for info := range r.open {
+		limit = min(limit, info.version)
+	}
+	return limit
+}
+
+// 返回存活时间不短于 threshold 的读者，按创建时间从早到晚排列
+func (r *readerRegistry) longLived(threshold time.Duration) []LongLivedReader {
+	now := time.Now()
+	r.mu.Lock()
+	var result []LongLivedReader
+	for info := range r.open {
+		if age := now.Sub(info.created); age >= threshold {
+			result = append(result, LongLivedReader{Version: info.version, Created: info.created, Age: age, Stack: string(info.stack)})
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Created.Before(result[j].Created) })
+	return result
+}
+
+// LongLivedReaders 返回存活时间不短于 threshold 且尚未释放的快照，按创建时间从早到晚排列。
+// 这些快照使 GC 无法回收它们可见的旧版本
+func (m *MVCCTree) LongLivedReaders(threshold time.Duration) []LongLivedReader {
+	return m.readers.longLived(threshold)
+}
+
+// LongLivedReaders 返回存活时间不短于 threshold 且尚未释放的只读句柄，按创建时间从早到晚排列。
+// 已被垃圾回收自动释放的句柄不会出现在结果中
+func (c *ConcurrentBPlusTree) LongLivedReaders(threshold time.Duration) []LongLivedReader {
+	return c.readers.longLived(threshold)
+}
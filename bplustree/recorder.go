@@ -0,0 +1,167 @@
+package bplustree
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// 时间线文件的格式标识与版本
+const (
+	timelineFormat  = "bplustree-timeline"
+	timelineVersion = 1
+)
+
+// Frame 是某次修改之后的树结构快照。Levels[l][i] 为第 l 层第 i 个节点的关键词；
+// 由于内部节点的关键词数等于子节点数，按顺序消费下一层的节点即可还原父子关系
+type Frame struct {
+	Op     string    `json:"op"`
+	Levels [][][]int `json:"levels"`
+}
+
+type timelineHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// 捕获当前树的结构快照
+func captureFrame(bpt *BPlusTree, op string) Frame {
+	frame := Frame{Op: op}
+	bpt.Walk(func(level int, n NodeInfo) {
+		if level == len(frame.Levels) {
+			frame.Levels = append(frame.Levels, nil)
+		}
+		frame.Levels[level] = append(frame.Levels[level], n.Keys)
+	})
+	return frame
+}
+
+// Recorder 包装一棵树，在每次修改之后把结构快照追加写入时间线（每行一个 JSON 帧），
+// 供可视化工具通过 Player 前后拖动回放
+type Recorder struct {
+	tree *BPlusTree
+	enc  *json.Encoder
+	err  error
+}
+
+// NewRecorder 创建记录器，写入时间线文件头以及树的初始结构
+func NewRecorder(tree *BPlusTree, w io.Writer) *Recorder {
+	r := &Recorder{tree: tree, enc: json.NewEncoder(w)}
+	r.err = r.enc.Encode(timelineHeader{Format: timelineFormat, Version: timelineVersion})
+	r.record("init")
+	return r
+}
+
+// 记录一帧；之前的写入失败后不再继续写入
+func (r *Recorder) record(op string) {
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(captureFrame(r.tree, op))
+}
+
+// Insert 插入键值对并记录一帧
+func (r *Recorder) Insert(key, value int) {
+	r.tree.Insert(key, value)
+	r.record(fmt.Sprintf("insert %d", key))
+}
+
+// Remove 删除 key，成功时记录一帧
+func (r *Recorder) Remove(key int) error {
+	if err := r.tree.Remove(key); err != nil {
+		return err
+	}
+	r.record(fmt.Sprintf("remove %d", key))
+	return nil
+}
+
+// Modify 修改 key 对应的 value，成功时记录一帧
+func (r *Recorder) Modify(key, newValue int) error {
+	if err := r.tree.Modify(key, newValue); err != nil {
+		return err
+	}
+	r.record(fmt.Sprintf("modify %d", key))
+	return nil
+}
+
+// Err 返回写入时间线时遇到的第一个错误
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// Player 回放时间线，支持逐帧前进、后退与跳转
+type Player struct {
+	frames []Frame
+	pos    int
+}
+
+// LoadTimeline 读取 Recorder 写出的时间线
+func LoadTimeline(r io.Reader) (*Player, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var header timelineHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("读取时间线失败：%w", err)
+	}
+	if header.Format != timelineFormat || header.Version != timelineVersion {
+		return nil, fmt.Errorf("读取时间线失败：不支持的格式 %q 版本 %d", header.Format, header.Version)
+	}
+	p := &Player{}
+	for {
+		var frame Frame
+		err := dec.Decode(&frame)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取时间线失败：第 %d 帧：%w", len(p.frames), err)
+		}
+		p.frames = append(p.frames, frame)
+	}
+	if len(p.frames) == 0 {
+		return nil, fmt.Errorf("读取时间线失败：时间线为空")
+	}
+	return p, nil
+}
+
+// Len 返回帧数
+func (p *Player) Len() int {
+	return len(p.frames)
+}
+
+// Pos 返回当前帧的下标
+func (p *Player) Pos() int {
+	return p.pos
+}
+
+// Frame 返回当前帧
+func (p *Player) Frame() Frame {
+	return p.frames[p.pos]
+}
+
+// Next 前进一帧，已在最后一帧时返回 false
+func (p *Player) Next() bool {
+	if p.pos+1 >= len(p.frames) {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+// Prev 后退一帧，已在第一帧时返回 false
+func (p *Player) Prev() bool {
+	if p.pos == 0 {
+		return false
+	}
+	p.pos--
+	return true
+}
+
+// Seek 跳转到第 i 帧
+func (p *Player) Seek(i int) error {
+	if i < 0 || i >= len(p.frames) {
+		return fmt.Errorf("跳转失败：帧下标 %d 超出范围 [0, %d)", i, len(p.frames))
+	}
+	p.pos = i
+	return nil
+}
@@ -0,0 +1,185 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bplus-go/bplustree"
+)
+
+// 一个连接上由 MULTI 开启、尚未 EXEC 或 DISCARD 的事务。
+// 事务中的命令在排队时即解析参数，EXEC 时在同一个写锁内通过 bplustree.Txn 一次性提交
+type multi struct {
+	queue   []queued
+	aborted bool // 排队时有命令出错，EXEC 将放弃整个事务
+}
+
+// 排队中的一条命令，参数已解析为整数
+type queued struct {
+	name  string
+	keys  []int    // GET、SET 为一个 key，DEL 为全部 key
+	value int      // SET 的值
+	msg   []string // PING 的可选参数
+}
+
+var errNotInMulti = errors.New("command not supported inside MULTI")
+
+// 处理 MULTI、EXEC、DISCARD 以及事务中排队的命令；args 与事务无关时返回 false，由调用方直接执行
+func (s *Server) handleMulti(m **multi, args []string, w *bufio.Writer) bool {
+	name := strings.ToLower(args[0])
+	switch name {
+	case "multi":
+		if *m != nil {
+			writeError(w, "ERR MULTI calls can not be nested")
+			return true
+		}
+		*m = &multi{}
+		fmt.Fprint(w, "+OK\r\n")
+	case "exec":
+		tx := *m
+		if tx == nil {
+			writeError(w, "ERR EXEC without MULTI")
+			return true
+		}
+		*m = nil
+		if tx.aborted {
+			writeError(w, "EXECABORT Transaction discarded because of previous errors.")
+			return true
+		}
+		s.exec(tx.queue, w)
+	case "discard":
+		if *m == nil {
+			writeError(w, "ERR DISCARD without MULTI")
+			return true
+		}
+		*m = nil
+		fmt.Fprint(w, "+OK\r\n")
+	default:
+		tx := *m
+		if tx == nil {
+			return false
+		}
+		q, err := parseQueued(name, args[1:])
+		if err != nil {
+			// 与 Redis 相同：排队时出错的事务在 EXEC 时整体放弃
+			tx.aborted = true
+			switch {
+			case errors.Is(err, errArity):
+				writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+			case errors.Is(err, errNotInMulti):
+				writeError(w, fmt.Sprintf("ERR '%s' %v", name, err))
+			default:
+				writeError(w, "ERR "+err.Error())
+			}
+			return true
+		}
+		tx.queue = append(tx.queue, q)
+		fmt.Fprint(w, "+QUEUED\r\n")
+	}
+	return true
+}
+
+// 解析事务中的一条命令。只有 GET、SET、DEL 与 PING 可以排队：
+// 范围读取命令无法看到事务中尚未提交的写入，因此不允许出现在事务中
+func parseQueued(name string, args []string) (queued, error) {
+	q := queued{name: name}
+	switch name {
+	case "get", "set":
+		if name == "get" && len(args) != 1 || name == "set" && len(args) != 2 {
+			return q, errArity
+		}
+		key, err := parseInt(args[0])
+		if err != nil {
+			return q, err
+		}
+		q.keys = []int{key}
+		if name == "set" {
+			if q.value, err = parseInt(args[1]); err != nil {
+				return q, err
+			}
+		}
+	case "del":
+		if len(args) == 0 {
+			return q, errArity
+		}
+		for _, a := range args {
+			k, err := parseInt(a)
+			if err != nil {
+				return q, err
+			}
+			q.keys = append(q.keys, k)
+		}
+	case "ping":
+		if len(args) > 1 {
+			return q, errArity
+		}
+		q.msg = args
+	case "scan", "zrangebyscore", "dbsize", "command":
+		return q, errNotInMulti
+	default:
+		return q, fmt.Errorf("unknown command '%s'", name)
+	}
+	return q, nil
+}
+
+// 在写锁内把排队的命令依次应用到一个 Txn 上并提交，回复由各命令的回复组成的数组。
+// 提交失败时（树已因内部错误停止服务）没有任何修改被确认，回复一个错误
+func (s *Server) exec(queue []queued, w *bufio.Writer) {
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	var err error
+	s.tree.Update(func(t *bplustree.BPlusTree) {
+		txn := t.Begin()
+		for _, q := range queue {
+			if err = execQueued(txn, q, out); err != nil {
+				txn.Rollback()
+				return
+			}
+		}
+		err = txn.Commit()
+	})
+	if err != nil {
+		writeError(w, "EXECABORT "+err.Error())
+		return
+	}
+	out.Flush()
+	fmt.Fprintf(w, "*%d\r\n", len(queue))
+	w.Write(buf.Bytes())
+}
+
+// 在事务中执行一条排队的命令并写出它的回复
+func execQueued(txn *bplustree.Txn, q queued, w *bufio.Writer) error {
+	switch q.name {
+	case "get":
+		value, ok := txn.Lookup(q.keys[0])
+		if !ok {
+			fmt.Fprint(w, "$-1\r\n")
+			return nil
+		}
+		writeBulk(w, strconv.Itoa(value))
+	case "set":
+		if err := txn.Insert(q.keys[0], q.value); err != nil {
+			return err
+		}
+		fmt.Fprint(w, "+OK\r\n")
+	case "del":
+		n := 0
+		for _, k := range q.keys {
+			if txn.Remove(k) == nil {
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case "ping":
+		if len(q.msg) > 0 {
+			writeBulk(w, q.msg[0])
+		} else {
+			fmt.Fprint(w, "+PONG\r\n")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bplus-go/bplustree"
+)
+
+// 键空间通知的频道前缀，与 Redis 0 号数据库的频道名相同
+const (
+	keyspacePrefix = "__keyspace@0__:"
+	keyeventPrefix = "__keyevent@0__:"
+)
+
+// notify-keyspace-events 接受的标志，与 Redis 相同。本服务只产生 set（$ 类）与 del（g 类）两种事件，
+// 其余类别的标志被接受但不会产生通知
+const notifyFlags = "KEg$lshzxetmdnA"
+
+// 每个订阅连接待写出的通知数上限；连接消费过慢时新的通知被丢弃，写操作从不因订阅者而阻塞
+const subscriberBuffer = bplustree.WatchBuffer
+
+// 服务端的发布订阅状态。有订阅者时以一个覆盖全部 key 的 Watch 接收树的变更，转换为键空间通知分发给订阅者
+type pubsub struct {
+	mu     sync.Mutex
+	subs   map[*subscriber]struct{}
+	events <-chan bplustree.Event // 非 nil 表示已向树订阅
+	flags  string                 // CONFIG SET notify-keyspace-events 设置的标志，空表示不发出通知
+}
+
+// 处于订阅模式的连接订阅的频道与模式，两个集合受 pubsub.mu 保护
+type subscriber struct {
+	channels map[string]bool
+	patterns map[string]bool
+	out      chan []byte // 待写出的通知，由连接的写出 goroutine 消费
+}
+
+func (sub *subscriber) count() int {
+	return len(sub.channels) + len(sub.patterns)
+}
+
+// 将通知交给订阅者的写出 goroutine；缓冲已满时丢弃，调用方须持有 pubsub.mu
+func (sub *subscriber) send(msg []byte) {
+	select {
+	case sub.out <- msg:
+	default:
+	}
+}
+
+// 处理订阅模式下的命令与进入订阅模式的 SUBSCRIBE、PSUBSCRIBE；args 与订阅无关时返回 false。
+// 调用方须持有 c.mu
+func (s *Server) handlePubSub(c *conn, args []string) bool {
+	name := strings.ToLower(args[0])
+	switch name {
+	case "subscribe", "psubscribe":
+		if len(args) < 2 {
+			writeError(c.w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+			return true
+		}
+		if c.sub == nil {
+			s.attach(c)
+		}
+		for _, ch := range args[1:] {
+			writeSubscription(c.w, name, ch, s.subscribe(c.sub, name == "psubscribe", ch))
+		}
+	case "unsubscribe", "punsubscribe":
+		if c.sub == nil {
+			// 不在订阅模式时与 Redis 相同，回复当前没有任何订阅
+			for _, ch := range args[1:] {
+				writeSubscription(c.w, name, ch, 0)
+			}
+			if len(args) == 1 {
+				writeSubscription(c.w, name, "", 0)
+			}
+			return true
+		}
+		pattern := name == "punsubscribe"
+		names := args[1:]
+		if len(names) == 0 {
+			names = s.subscriptions(c.sub, pattern)
+			if len(names) == 0 {
+				writeSubscription(c.w, name, "", c.sub.count())
+			}
+		}
+		for _, ch := range names {
+			writeSubscription(c.w, name, ch, s.unsubscribe(c.sub, pattern, ch))
+		}
+		if c.sub.count() == 0 {
+			s.detach(c)
+		}
+	case "ping":
+		if c.sub == nil {
+			return false
+		}
+		// 订阅模式下 PING 的回复与通知一样是数组
+		msg := ""
+		if len(args) > 1 {
+			msg = args[1]
+		}
+		writeArray(c.w, []string{"pong", msg})
+	default:
+		if c.sub == nil {
+			return false
+		}
+		writeError(c.w, fmt.Sprintf("ERR Can't execute '%s': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / QUIT are allowed in this context", name))
+	}
+	return true
+}
+
+// 回复 SUBSCRIBE 等命令对单个频道的处理结果；channel 为空表示没有可取消的订阅，回复 nil
+func writeSubscription(w *bufio.Writer, kind, channel string, count int) {
+	fmt.Fprint(w, "*3\r\n")
+	writeBulk(w, kind)
+	if channel == "" {
+		fmt.Fprint(w, "$-1\r\n")
+	} else {
+		writeBulk(w, channel)
+	}
+	fmt.Fprintf(w, ":%d\r\n", count)
+}
+
+// 使连接进入订阅模式，并启动把通知写到连接上的 goroutine
+func (s *Server) attach(c *conn) {
+	sub := &subscriber{
+		channels: make(map[string]bool),
+		patterns: make(map[string]bool),
+		out:      make(chan []byte, subscriberBuffer),
+	}
+	c.sub = sub
+	go func() {
+		for msg := range sub.out {
+			c.mu.Lock()
+			// 连接已结束时丢弃剩余的通知
+			if !c.closed {
+				c.w.Write(msg)
+				c.w.Flush()
+			}
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// 使连接退出订阅模式；最后一个订阅者退出时取消对树的 Watch。调用方须持有 c.mu
+func (s *Server) detach(c *conn) {
+	sub := c.sub
+	if sub == nil {
+		return
+	}
+	c.sub = nil
+	s.ps.mu.Lock()
+	delete(s.ps.subs, sub)
+	var stop <-chan bplustree.Event
+	if len(s.ps.subs) == 0 {
+		stop, s.ps.events = s.ps.events, nil
+	}
+	s.ps.mu.Unlock()
+	close(sub.out)
+	if stop != nil {
+		s.tree.Unwatch(stop)
+	}
+}
+
+// 为 sub 增加一个频道或模式的订阅，返回它此后的订阅总数
+func (s *Server) subscribe(sub *subscriber, pattern bool, name string) int {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	if pattern {
+		sub.patterns[name] = true
+	} else {
+		sub.channels[name] = true
+	}
+	if s.ps.subs == nil {
+		s.ps.subs = make(map[*subscriber]struct{})
+	}
+	s.ps.subs[sub] = struct{}{}
+	if s.ps.events == nil {
+		s.ps.events = s.tree.Watch(math.MinInt, math.MaxInt)
+		go s.publish(s.ps.events)
+	}
+	return sub.count()
+}
+
+// 取消 sub 的一个频道或模式的订阅，返回它此后的订阅总数
+func (s *Server) unsubscribe(sub *subscriber, pattern bool, name string) int {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	if pattern {
+		delete(sub.patterns, name)
+	} else {
+		delete(sub.channels, name)
+	}
+	return sub.count()
+}
+
+// 返回 sub 当前订阅的全部频道或模式，按名称排序
+func (s *Server) subscriptions(sub *subscriber, pattern bool) []string {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	set := sub.channels
+	if pattern {
+		set = sub.patterns
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// 把树的变更转换为键空间通知分发给订阅者，直到 Watch 被取消。
+// 变更过多、Watch 因消费过慢被关闭时重新订阅；期间的变更不再通知，与 Redis 的发布订阅一样不保证送达
+func (s *Server) publish(events <-chan bplustree.Event) {
+	for {
+		for e := range events {
+			s.fanout(e)
+		}
+		s.ps.mu.Lock()
+		if s.ps.events != events {
+			// 最后一个订阅者已退出，detach 取消了 Watch
+			s.ps.mu.Unlock()
+			return
+		}
+		events = s.tree.Watch(math.MinInt, math.MaxInt)
+		s.ps.events = events
+		s.ps.mu.Unlock()
+	}
+}
+
+// 按 notify-keyspace-events 的设置为一次变更发出 __keyspace@0__ 与 __keyevent@0__ 通知
+func (s *Server) fanout(e bplustree.Event) {
+	event, class := "set", "$"
+	if e.Kind == bplustree.EventDelete {
+		event, class = "del", "g"
+	}
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	flags := s.ps.flags
+	if !strings.Contains(flags, class) && !strings.Contains(flags, "A") {
+		return
+	}
+	key := strconv.Itoa(e.Key)
+	if strings.Contains(flags, "K") {
+		s.ps.publish(keyspacePrefix+key, event)
+	}
+	if strings.Contains(flags, "E") {
+		s.ps.publish(keyeventPrefix+event, key)
+	}
+}
+
+// 把 msg 发布到 channel 上，调用方须持有 ps.mu
+func (ps *pubsub) publish(channel, msg string) {
+	for sub := range ps.subs {
+		if sub.channels[channel] {
+			sub.send(encodeArray("message", channel, msg))
+		}
+		for p := range sub.patterns {
+			if ok, _ := path.Match(p, channel); ok {
+				sub.send(encodeArray("pmessage", p, channel, msg))
+			}
+		}
+	}
+}
+
+func encodeArray(items ...string) []byte {
+	b := fmt.Appendf(nil, "*%d\r\n", len(items))
+	for _, it := range items {
+		b = fmt.Appendf(b, "$%d\r\n%s\r\n", len(it), it)
+	}
+	return b
+}
+
+// CONFIG GET|SET notify-keyspace-events：Redis 客户端的缓存失效工具通过它开启键空间通知
+func (s *Server) config(args []string, w *bufio.Writer) error {
+	if len(args) < 2 {
+		return errArity
+	}
+	param := strings.ToLower(args[1])
+	switch strings.ToLower(args[0]) {
+	case "get":
+		if len(args) != 2 {
+			return errArity
+		}
+		if ok, _ := path.Match(param, "notify-keyspace-events"); !ok {
+			fmt.Fprint(w, "*0\r\n")
+			return nil
+		}
+		s.ps.mu.Lock()
+		flags := s.ps.flags
+		s.ps.mu.Unlock()
+		writeArray(w, []string{"notify-keyspace-events", flags})
+	case "set":
+		if len(args) != 3 {
+			return errArity
+		}
+		if param != "notify-keyspace-events" {
+			return fmt.Errorf("Unsupported CONFIG parameter: %s", args[1])
+		}
+		if i := strings.IndexFunc(args[2], func(r rune) bool { return !strings.ContainsRune(notifyFlags, r) }); i >= 0 {
+			return errors.New("Invalid argument for CONFIG SET 'notify-keyspace-events'")
+		}
+		s.ps.mu.Lock()
+		s.ps.flags = args[2]
+		s.ps.mu.Unlock()
+		fmt.Fprint(w, "+OK\r\n")
+	default:
+		return errSyntax
+	}
+	return nil
+}
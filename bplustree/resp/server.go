@@ -0,0 +1,488 @@
+// Package resp 以 Redis 的 RESP 协议对外提供 int 键值的 B+ 树，
+// 现有的 Redis 客户端（包括 redis-cli）可以直接连接，用于测试与调试索引。
+// 键与值均须为十进制整数，支持的命令：
+//
+//	GET key                                   返回值，key 不存在时返回 nil
+//	SET key value                             写入或覆盖
+//	DEL key [key ...]                         返回删除的 key 数
+//	SCAN cursor [COUNT n]                     按 key 升序分批返回 key，游标为 0 时表示遍历结束
+//	ZRANGEBYSCORE name min max [WITHSCORES] [LIMIT offset count]
+//	                                          把整棵树视为一个以 key 为分值、以 value 为成员的有序集合，
+//	                                          按分值升序返回 [min, max] 内的成员；name 被忽略，
+//	                                          min、max 支持 -inf、+inf 与表示开区间的 ( 前缀
+//	DBSIZE、PING、QUIT
+//	MULTI、EXEC、DISCARD                      事务：MULTI 之后的命令排队，EXEC 时在同一个写锁内原子地提交，
+//	                                          回复各命令的结果；事务中只能使用 GET、SET、DEL 与 PING
+//	SUBSCRIBE、PSUBSCRIBE、UNSUBSCRIBE、PUNSUBSCRIBE
+//	                                          订阅键空间通知：写入与覆盖发出 set 事件，删除发出 del 事件，
+//	                                          频道名与 Redis 的 __keyspace@0__:key、__keyevent@0__:event 相同
+//	CONFIG GET|SET notify-keyspace-events     与 Redis 相同，默认为空，即不发出通知
+//
+// 命令既可以按 RESP 数组发送，也可以按空格分隔的内联命令发送
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bplus-go/bplustree"
+)
+
+// 协议限制：单条命令最多 1024 个参数，单个参数最长 512 字节，足以容纳任意整数参数
+const (
+	maxArgs   = 1024
+	maxArgLen = 512
+)
+
+// SCAN 未指定 COUNT 时每批返回的 key 数，与 Redis 的默认值相同
+const defaultScanCount = 10
+
+var errProtocol = errors.New("resp: 协议错误")
+
+// Server 在 B+ 树上实现 RESP 协议，可同时服务多个连接，并发命令由 ConcurrentBPlusTree 的读写锁串行化
+type Server struct {
+	tree *bplustree.ConcurrentBPlusTree
+	ps   pubsub
+}
+
+// 一个客户端连接的状态
+type conn struct {
+	mu     sync.Mutex // 保护 w 与 closed：订阅模式下写出通知的 goroutine 与命令的回复共用 w
+	w      *bufio.Writer
+	closed bool        // ServeConn 已返回，不再写出通知
+	multi  *multi      // 非 nil 表示连接正处于 MULTI 开启的事务中
+	sub    *subscriber // 非 nil 表示连接处于订阅模式
+}
+
+// NewServer 创建以 tree 为存储的 Server；tree 为 nil 时使用一棵新建的空树
+func NewServer(tree *bplustree.ConcurrentBPlusTree) *Server {
+	if tree == nil {
+		tree = bplustree.NewConcurrentBPlusTree()
+	}
+	return &Server{tree: tree}
+}
+
+// ListenAndServe 监听 TCP 地址 addr 并处理连接，直到监听失败
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 接受 l 上的连接，每个连接由单独的 goroutine 处理；l 被关闭后返回 Accept 的错误
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil {
+				log.Printf("resp: %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn 处理一个连接上的全部命令，直到客户端发送 QUIT 或关闭连接。
+// 无法解析的请求使连接关闭，因为之后的字节流已无法可靠地切分成命令
+func (s *Server) ServeConn(rw io.ReadWriter) error {
+	r := bufio.NewReader(rw)
+	c := &conn{w: bufio.NewWriter(rw)}
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		s.detach(c)
+		c.closed = true
+	}()
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if errors.Is(err, errProtocol) {
+				c.mu.Lock()
+				writeError(c.w, "ERR Protocol error")
+				c.w.Flush()
+				c.mu.Unlock()
+			}
+			return err
+		}
+		if len(args) == 0 {
+			continue
+		}
+		c.mu.Lock()
+		quit := s.execute(c, args)
+		// 流水线中的后续命令已在缓冲区时合并写出
+		if quit || r.Buffered() == 0 {
+			err = c.w.Flush()
+		}
+		c.mu.Unlock()
+		if quit || err != nil {
+			return err
+		}
+	}
+}
+
+// 执行一条命令并写出回复，返回 true 表示客户端发送了 QUIT；调用方须持有 c.mu
+func (s *Server) execute(c *conn, args []string) (quit bool) {
+	switch {
+	case strings.EqualFold(args[0], "quit"):
+		fmt.Fprint(c.w, "+OK\r\n")
+		return true
+	case s.handlePubSub(c, args):
+	case s.handleMulti(&c.multi, args, c.w):
+	default:
+		s.dispatch(args, c.w)
+	}
+	return false
+}
+
+// 读取一条命令：以 * 开头时按 RESP 数组解析，否则按内联命令以空白切分
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxArgs {
+		return nil, fmt.Errorf("%w：数组长度 %q", errProtocol, line[1:])
+	}
+	args := make([]string, 0, max(n, 0))
+	for range n {
+		hdr, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimPrefix(hdr, "$"))
+		if !strings.HasPrefix(hdr, "$") || err != nil || size < 0 || size > maxArgLen {
+			return nil, fmt.Errorf("%w：批量字符串头 %q", errProtocol, hdr)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if string(buf[size:]) != "\r\n" {
+			return nil, fmt.Errorf("%w：批量字符串缺少结尾的 CRLF", errProtocol)
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// 读取一行并去掉结尾的 CRLF；行过长视为协议错误
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", fmt.Errorf("%w：行过长", errProtocol)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// 执行一条命令；命令的错误以 RESP 错误回复客户端
+func (s *Server) dispatch(args []string, w *bufio.Writer) {
+	name := strings.ToLower(args[0])
+	var err error
+	switch name {
+	case "get":
+		err = s.get(args[1:], w)
+	case "set":
+		err = s.set(args[1:], w)
+	case "del":
+		err = s.del(args[1:], w)
+	case "scan":
+		err = s.scan(args[1:], w)
+	case "zrangebyscore":
+		err = s.zrangeByScore(args[1:], w)
+	case "dbsize":
+		if len(args) != 1 {
+			err = errArity
+			break
+		}
+		fmt.Fprintf(w, ":%d\r\n", s.tree.Stats().Entries)
+	case "ping":
+		if len(args) > 1 {
+			writeBulk(w, args[1])
+		} else {
+			fmt.Fprint(w, "+PONG\r\n")
+		}
+	case "config":
+		err = s.config(args[1:], w)
+	case "command":
+		// redis-cli 连接时会发送 COMMAND DOCS 获取命令说明，回复空数组即可
+		fmt.Fprint(w, "*0\r\n")
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		return
+	}
+	if errors.Is(err, errArity) {
+		writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+	} else if err != nil {
+		writeError(w, "ERR "+err.Error())
+	}
+}
+
+var (
+	errArity  = errors.New("wrong number of arguments")
+	errNotInt = errors.New("value is not an integer or out of range")
+	errSyntax = errors.New("syntax error")
+)
+
+func parseInt(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errNotInt
+	}
+	return n, nil
+}
+
+// 查找 key，区分"不存在"与值恰为 -1 的情况
+func lookup(t *bplustree.BPlusTree, key int) (value int, found bool) {
+	t.Range(key, key, func(_, v int) bool {
+		value, found = v, true
+		return false
+	})
+	return value, found
+}
+
+// GET key
+func (s *Server) get(args []string, w *bufio.Writer) error {
+	if len(args) != 1 {
+		return errArity
+	}
+	key, err := parseInt(args[0])
+	if err != nil {
+		return err
+	}
+	var value int
+	found := false
+	s.tree.View(func(t *bplustree.BPlusTree) {
+		value, found = lookup(t, key)
+	})
+	if !found {
+		fmt.Fprint(w, "$-1\r\n")
+		return nil
+	}
+	writeBulk(w, strconv.Itoa(value))
+	return nil
+}
+
+// SET key value
+func (s *Server) set(args []string, w *bufio.Writer) error {
+	if len(args) != 2 {
+		return errArity
+	}
+	key, err := parseInt(args[0])
+	if err != nil {
+		return err
+	}
+	value, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	s.tree.Update(func(t *bplustree.BPlusTree) {
+		if _, found := lookup(t, key); found {
+			err = t.Modify(key, value)
+			return
+		}
+		t.Insert(key, value)
+		err = t.Err()
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, "+OK\r\n")
+	return nil
+}
+
+// DEL key [key ...]
+func (s *Server) del(args []string, w *bufio.Writer) error {
+	if len(args) == 0 {
+		return errArity
+	}
+	keys := make([]int, len(args))
+	for i, a := range args {
+		k, err := parseInt(a)
+		if err != nil {
+			return err
+		}
+		keys[i] = k
+	}
+	n := 0
+	s.tree.Update(func(t *bplustree.BPlusTree) {
+		for _, k := range keys {
+			if t.Remove(k) == nil {
+				n++
+			}
+		}
+	})
+	fmt.Fprintf(w, ":%d\r\n", n)
+	return nil
+}
+
+// SCAN cursor [COUNT n]。游标编码上一批返回的最后一个 key，下一批从它之后开始，
+// 因此遍历期间插入、删除的 key 不会使已返回的 key 重复出现
+func (s *Server) scan(args []string, w *bufio.Writer) error {
+	if len(args) != 1 && len(args) != 3 {
+		return errArity
+	}
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	count := defaultScanCount
+	if len(args) == 3 {
+		if !strings.EqualFold(args[1], "count") {
+			return errSyntax
+		}
+		if count, err = parseInt(args[2]); err != nil {
+			return err
+		}
+		if count < 1 {
+			return errSyntax
+		}
+	}
+	lo := math.MinInt
+	if cursor != 0 {
+		lo = decodeCursor(cursor) + 1
+	}
+	var keys []string
+	last := 0
+	s.tree.Range(lo, math.MaxInt, func(k, _ int) bool {
+		keys = append(keys, strconv.Itoa(k))
+		last = k
+		return len(keys) < count
+	})
+	next := uint64(0)
+	if len(keys) == count && last != math.MaxInt {
+		next = encodeCursor(last)
+	}
+	fmt.Fprint(w, "*2\r\n")
+	writeBulk(w, strconv.FormatUint(next, 10))
+	writeArray(w, keys)
+	return nil
+}
+
+// 游标是 key 的保序编码加一，0 留作开始与结束的标记；key 为 math.MaxInt 时遍历已结束，不会被编码
+func encodeCursor(key int) uint64 {
+	return uint64(key) ^ 1<<63 + 1
+}
+
+func decodeCursor(c uint64) int {
+	return int((c - 1) ^ 1<<63)
+}
+
+// ZRANGEBYSCORE name min max [WITHSCORES] [LIMIT offset count]
+func (s *Server) zrangeByScore(args []string, w *bufio.Writer) error {
+	if len(args) < 3 {
+		return errArity
+	}
+	lo, loOpen, err := parseScore(args[1])
+	if err != nil {
+		return err
+	}
+	hi, hiOpen, err := parseScore(args[2])
+	if err != nil {
+		return err
+	}
+	// 开区间转换为闭区间；端点已是 int 的极值时区间为空
+	empty := loOpen && lo == math.MaxInt || hiOpen && hi == math.MinInt
+	if loOpen {
+		lo++
+	}
+	if hiOpen {
+		hi--
+	}
+	withScores := false
+	offset, limit := 0, -1
+	for i := 3; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], "withscores"):
+			withScores = true
+		case strings.EqualFold(args[i], "limit") && i+2 < len(args):
+			if offset, err = parseInt(args[i+1]); err != nil {
+				return err
+			}
+			if limit, err = parseInt(args[i+2]); err != nil {
+				return err
+			}
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+	var out []string
+	if !empty && offset >= 0 && limit != 0 && lo <= hi {
+		skipped := 0
+		s.tree.Range(lo, hi, func(k, v int) bool {
+			if skipped < offset {
+				skipped++
+				return true
+			}
+			out = append(out, strconv.Itoa(v))
+			if withScores {
+				out = append(out, strconv.Itoa(k))
+			}
+			// limit 为负数时不限制条数
+			return limit < 0 || len(out) < limit*(1+btoi(withScores))
+		})
+	}
+	writeArray(w, out)
+	return nil
+}
+
+// 解析 ZRANGEBYSCORE 的分值边界，open 表示带有 ( 前缀的开区间端点
+func parseScore(s string) (n int, open bool, err error) {
+	switch strings.ToLower(s) {
+	case "-inf":
+		return math.MinInt, false, nil
+	case "+inf", "inf":
+		return math.MaxInt, false, nil
+	}
+	open = strings.HasPrefix(s, "(")
+	n, err = strconv.Atoi(strings.TrimPrefix(s, "("))
+	if err != nil {
+		return 0, false, errors.New("min or max is not an integer")
+	}
+	return n, open, nil
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeArray(w *bufio.Writer, items []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(items))
+	for _, it := range items {
+		writeBulk(w, it)
+	}
+}
+
+func writeError(w *bufio.Writer, msg string) {
+	fmt.Fprintf(w, "-%s\r\n", msg)
+}
@@ -0,0 +1,303 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"bplus-go/bplustree"
+)
+
+// 以 in 作为客户端发送的全部数据执行一个连接，返回服务端的回复
+func serve(t *testing.T, s *Server, in string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := s.ServeConn(struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(in), &out})
+	return out.String(), err
+}
+
+func TestServeConn(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "set-get-inline",
+			in:   "SET 1 10\r\nGET 1\r\nGET 2\r\n",
+			want: "+OK\r\n$2\r\n10\r\n$-1\r\n",
+		},
+		{
+			name: "resp-array",
+			in:   "*3\r\n$3\r\nSET\r\n$1\r\n1\r\n$2\r\n-1\r\n*2\r\n$3\r\nGET\r\n$1\r\n1\r\n",
+			want: "+OK\r\n$2\r\n-1\r\n",
+		},
+		{
+			name: "overwrite-and-del",
+			in:   "SET 1 10\r\nSET 1 11\r\nDBSIZE\r\nDEL 1 2\r\nDBSIZE\r\n",
+			want: "+OK\r\n+OK\r\n:1\r\n:1\r\n:0\r\n",
+		},
+		{
+			name: "scan",
+			in:   "SET 1 1\r\nSET 2 2\r\nSET 3 3\r\nSCAN 0 COUNT 2\r\n",
+			want: "+OK\r\n+OK\r\n+OK\r\n*2\r\n$19\r\n9223372036854775811\r\n*2\r\n$1\r\n1\r\n$1\r\n2\r\n",
+		},
+		{
+			name: "zrangebyscore",
+			in:   "SET 1 10\r\nSET 2 20\r\nSET 3 30\r\nZRANGEBYSCORE z (1 +inf WITHSCORES\r\n",
+			want: "+OK\r\n+OK\r\n+OK\r\n*4\r\n$2\r\n20\r\n$1\r\n2\r\n$2\r\n30\r\n$1\r\n3\r\n",
+		},
+		{
+			name: "errors",
+			in:   "GET x\r\nGET\r\nBOGUS\r\n",
+			want: "-ERR value is not an integer or out of range\r\n-ERR wrong number of arguments for 'get' command\r\n-ERR unknown command 'BOGUS'\r\n",
+		},
+		{
+			name: "config",
+			in:   "CONFIG SET notify-keyspace-events KEA\r\nCONFIG GET notify-keyspace-events\r\nCONFIG GET maxmemory\r\nCONFIG SET notify-keyspace-events Q\r\nCONFIG SET maxmemory 1\r\n",
+			want: "+OK\r\n*2\r\n$22\r\nnotify-keyspace-events\r\n$3\r\nKEA\r\n*0\r\n" +
+				"-ERR Invalid argument for CONFIG SET 'notify-keyspace-events'\r\n-ERR Unsupported CONFIG parameter: maxmemory\r\n",
+		},
+		{
+			name: "unsubscribe-without-subscriptions",
+			in:   "UNSUBSCRIBE\r\nPING\r\n",
+			want: "*3\r\n$11\r\nunsubscribe\r\n$-1\r\n:0\r\n+PONG\r\n",
+		},
+		{
+			name: "quit",
+			in:   "PING\r\nQUIT\r\nPING\r\n",
+			want: "+PONG\r\n+OK\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serve(t, NewServer(nil), tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("回复为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeConnProtocolError(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"bad-array-length", "*x\r\n"},
+		{"bad-bulk-header", "*1\r\n+GET\r\n"},
+		{"bulk-too-long", "*1\r\n$513\r\n"},
+		{"missing-crlf", "*1\r\n$3\r\nGETxx"},
+		{"line-too-long", strings.Repeat("x", 8192) + "\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serve(t, NewServer(nil), tt.in+"PING\r\n")
+			if err == nil {
+				t.Fatal("期望协议错误关闭连接")
+			}
+			if got != "-ERR Protocol error\r\n" {
+				t.Errorf("回复为 %q", got)
+			}
+		})
+	}
+}
+
+func TestMulti(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  string
+		after map[int]int // EXEC 后树中的内容
+	}{
+		{
+			name:  "exec",
+			in:    "SET 1 10\r\nMULTI\r\nSET 1 11\r\nSET 2 20\r\nGET 1\r\nDEL 1 3\r\nGET 1\r\nPING\r\nEXEC\r\n",
+			want:  "+OK\r\n+OK\r\n+QUEUED\r\n+QUEUED\r\n+QUEUED\r\n+QUEUED\r\n+QUEUED\r\n+QUEUED\r\n*6\r\n+OK\r\n+OK\r\n$2\r\n11\r\n:1\r\n$-1\r\n+PONG\r\n",
+			after: map[int]int{2: 20},
+		},
+		{
+			name:  "discard",
+			in:    "MULTI\r\nSET 1 10\r\nDISCARD\r\nGET 1\r\n",
+			want:  "+OK\r\n+QUEUED\r\n+OK\r\n$-1\r\n",
+			after: map[int]int{},
+		},
+		{
+			name:  "queue-error-aborts",
+			in:    "MULTI\r\nSET 1 10\r\nSET 2 x\r\nEXEC\r\n",
+			want:  "+OK\r\n+QUEUED\r\n-ERR value is not an integer or out of range\r\n-EXECABORT Transaction discarded because of previous errors.\r\n",
+			after: map[int]int{},
+		},
+		{
+			name:  "range-command-not-allowed",
+			in:    "MULTI\r\nSCAN 0\r\nEXEC\r\n",
+			want:  "+OK\r\n-ERR 'scan' command not supported inside MULTI\r\n-EXECABORT Transaction discarded because of previous errors.\r\n",
+			after: map[int]int{},
+		},
+		{
+			name:  "empty-exec",
+			in:    "MULTI\r\nEXEC\r\n",
+			want:  "+OK\r\n*0\r\n",
+			after: map[int]int{},
+		},
+		{
+			name:  "misuse",
+			in:    "EXEC\r\nDISCARD\r\nMULTI\r\nMULTI\r\nEXEC\r\n",
+			want:  "-ERR EXEC without MULTI\r\n-ERR DISCARD without MULTI\r\n+OK\r\n-ERR MULTI calls can not be nested\r\n*0\r\n",
+			after: map[int]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := bplustree.NewConcurrentBPlusTree()
+			got, err := serve(t, NewServer(tree), tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("回复为 %q，期望 %q", got, tt.want)
+			}
+			n := 0
+			tree.Range(-1<<31, 1<<31, func(k, v int) bool {
+				n++
+				if want, ok := tt.after[k]; !ok || want != v {
+					t.Errorf("EXEC 后树中有 %d=%d", k, v)
+				}
+				return true
+			})
+			if n != len(tt.after) {
+				t.Errorf("EXEC 后树中有 %d 个条目，期望 %d", n, len(tt.after))
+			}
+		})
+	}
+}
+
+// 连接关闭时未 EXEC 的事务被丢弃
+func TestMultiDroppedOnDisconnect(t *testing.T) {
+	s := NewServer(nil)
+	got, err := serve(t, s, "MULTI\r\nSET 1 1\r\n")
+	if err != nil || got != "+OK\r\n+QUEUED\r\n" {
+		t.Fatalf("回复为 %q, %v", got, err)
+	}
+	if got, _ := serve(t, s, "GET 1\r\n"); got != "$-1\r\n" {
+		t.Fatalf("未提交的事务生效了：%q", got)
+	}
+}
+
+// 通过 net.Pipe 与服务端保持的一个连接，用于订阅模式下异步到达的通知
+type pipeClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialPipe(t *testing.T, s *Server) *pipeClient {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		s.ServeConn(server)
+	}()
+	t.Cleanup(func() { client.Close() })
+	return &pipeClient{t: t, conn: client, r: bufio.NewReader(client)}
+}
+
+// 发送 cmd 并读取恰好 len(want) 字节的回复与 want 比较
+func (c *pipeClient) expect(cmd, want string) {
+	c.t.Helper()
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if cmd != "" {
+		if _, err := c.conn.Write([]byte(cmd)); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(c.r, got); err != nil {
+		c.t.Fatalf("读取 %q 的回复：已读到 %q：%v", cmd, got, err)
+	}
+	if string(got) != want {
+		c.t.Fatalf("%q 的回复为 %q，期望 %q", cmd, got, want)
+	}
+}
+
+func message(channel, msg string) string {
+	return string(encodeArray("message", channel, msg))
+}
+
+func pmessage(pattern, channel, msg string) string {
+	return string(encodeArray("pmessage", pattern, channel, msg))
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags string
+		ops   string
+		want  string // 订阅 __keyspace@0__:1 与 __keyevent@0__:* 的连接依次收到的通知
+	}{
+		{
+			name:  "all",
+			flags: "KEA",
+			ops:   "SET 1 10\r\nSET 1 11\r\nDEL 1\r\nSET 2 20\r\n",
+			want: message("__keyspace@0__:1", "set") + pmessage("__keyevent@0__:*", "__keyevent@0__:set", "1") +
+				message("__keyspace@0__:1", "set") + pmessage("__keyevent@0__:*", "__keyevent@0__:set", "1") +
+				message("__keyspace@0__:1", "del") + pmessage("__keyevent@0__:*", "__keyevent@0__:del", "1") +
+				pmessage("__keyevent@0__:*", "__keyevent@0__:set", "2"),
+		},
+		{
+			name:  "keyspace-set-only",
+			flags: "K$",
+			ops:   "SET 1 10\r\nDEL 1\r\nSET 2 20\r\nSET 1 5\r\n",
+			want:  message("__keyspace@0__:1", "set") + message("__keyspace@0__:1", "set"),
+		},
+		{
+			name:  "keyevent-del-only",
+			flags: "Eg",
+			ops:   "SET 1 10\r\nDEL 1 2\r\nSET 2 20\r\nMULTI\r\nDEL 2\r\nEXEC\r\n",
+			want:  pmessage("__keyevent@0__:*", "__keyevent@0__:del", "1") + pmessage("__keyevent@0__:*", "__keyevent@0__:del", "2"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(nil)
+			if _, err := serve(t, s, "CONFIG SET notify-keyspace-events "+tt.flags+"\r\n"); err != nil {
+				t.Fatal(err)
+			}
+			sub := dialPipe(t, s)
+			sub.expect("SUBSCRIBE __keyspace@0__:1\r\n", "*3\r\n$9\r\nsubscribe\r\n$16\r\n__keyspace@0__:1\r\n:1\r\n")
+			sub.expect("PSUBSCRIBE __keyevent@0__:*\r\n", "*3\r\n$10\r\npsubscribe\r\n$16\r\n__keyevent@0__:*\r\n:2\r\n")
+			if _, err := serve(t, s, tt.ops); err != nil {
+				t.Fatal(err)
+			}
+			sub.expect("", tt.want)
+		})
+	}
+}
+
+func TestSubscribedMode(t *testing.T) {
+	s := NewServer(nil)
+	sub := dialPipe(t, s)
+	sub.expect("SUBSCRIBE a b\r\n", "*3\r\n$9\r\nsubscribe\r\n$1\r\na\r\n:1\r\n*3\r\n$9\r\nsubscribe\r\n$1\r\nb\r\n:2\r\n")
+	sub.expect("GET 1\r\n", "-ERR Can't execute 'get': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / QUIT are allowed in this context\r\n")
+	sub.expect("PING\r\n", "*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+	sub.expect("UNSUBSCRIBE a\r\n", "*3\r\n$11\r\nunsubscribe\r\n$1\r\na\r\n:1\r\n")
+	sub.expect("PUNSUBSCRIBE\r\n", "*3\r\n$12\r\npunsubscribe\r\n$-1\r\n:1\r\n")
+	sub.expect("UNSUBSCRIBE\r\n", "*3\r\n$11\r\nunsubscribe\r\n$1\r\nb\r\n:0\r\n")
+	// 取消全部订阅后回到普通模式
+	sub.expect("SET 1 10\r\nGET 1\r\n", "+OK\r\n$2\r\n10\r\n")
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	if s.ps.events != nil {
+		t.Fatal("最后一个订阅者退出后仍在 Watch 树")
+	}
+}
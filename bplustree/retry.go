@@ -0,0 +1,97 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy 描述对存储后端瞬时错误的重试策略
+type RetryPolicy struct {
+	MaxAttempts int              // 最多尝试次数（含首次），不大于 1 表示不重试
+	BaseDelay   time.Duration    // 第一次重试前的等待时间，之后每次翻倍
+	MaxDelay    time.Duration    // 单次等待时间的上限，0 表示不限
+	Jitter      float64          // 随机抖动比例，取值 [0, 1]：实际等待时间在 delay*(1-Jitter) 与 delay 之间
+	Retryable   func(error) bool // 判断错误是否值得重试，nil 时使用 DefaultRetryable
+	Rand        *rand.Rand       // 抖动使用的随机源，nil 时使用全局随机源；固定种子可以重放等待时间序列
+}
+
+// DefaultRetryable 是默认的错误分类：页超出范围、熔断器断开与超时属于确定性结果，不重试；
+// 其余错误视为瞬时故障
+func DefaultRetryable(err error) bool {
+	return !errors.Is(err, ErrPageOutOfRange) &&
+		!errors.Is(err, ErrStoreUnavailable) &&
+		!errors.Is(err, ErrTimeout)
+}
+
+// 第 attempt 次重试（从 1 开始）前的等待时间
+func (p RetryPolicy) delay(attempt int, rnd *randSource) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d < p.BaseDelay || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		// 移位溢出或超过上限
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rnd.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// RetryStore 是按 RetryPolicy 重试失败请求的 PageStore 包装，
+// 用于对象存储等可能返回瞬时错误的远程后端
+type RetryStore struct {
+	store  PageStore
+	policy RetryPolicy
+	rand   *randSource
+}
+
+// NewRetryStore 在 store 之外包装重试逻辑
+func NewRetryStore(store PageStore, policy RetryPolicy) *RetryStore {
+	if policy.Retryable == nil {
+		policy.Retryable = DefaultRetryable
+	}
+	return &RetryStore{store: store, policy: policy, rand: newRandSource(policy.Rand)}
+}
+
+// WithRetry 为树的存储加上重试。与 WithCircuitBreaker 同时使用时应放在其之前，
+// 使一次完整的重试序列全部失败后才计为熔断器的一次失败
+func WithRetry(policy RetryPolicy) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.store = NewRetryStore(t.store, policy)
+	}
+}
+
+// 执行 op，对可重试的错误按策略退避重试
+func (r *RetryStore) do(op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= r.policy.MaxAttempts || !r.policy.Retryable(err) {
+			if err != nil && attempt > 1 {
+				return fmt.Errorf("尝试 %d 次后仍失败：%w", attempt, err)
+			}
+			return err
+		}
+		time.Sleep(r.policy.delay(attempt, r.rand))
+	}
+}
+
+// ReadPage 读取第 id 页，失败时按策略重试
+func (r *RetryStore) ReadPage(id PageID, buf []byte) error {
+	return r.do(func() error { return r.store.ReadPage(id, buf) })
+}
+
+// WritePage 写入第 id 页，失败时按策略重试
+func (r *RetryStore) WritePage(id PageID, buf []byte) error {
+	return r.do(func() error { return r.store.WritePage(id, buf) })
+}
+
+// Sync 同步底层存储，失败时按策略重试
+func (r *RetryStore) Sync() error {
+	return r.do(r.store.Sync)
+}
+
+// Close 关闭底层存储，不重试
+func (r *RetryStore) Close() error {
+	return r.store.Close()
+}
@@ -0,0 +1,50 @@
+package bplustree
+
+// 最右叶节点的缓存。键单调递增（时间戳、自增 ID）的插入总是落在最右叶节点，
+// 命中缓存时直接使用记录的右边界路径，跳过自根的下降
+type rightEdge struct {
+	root  *Node      // 记录路径时的根节点
+	shape uint64     // 记录路径时的结构调整次数
+	leaf  *Node      // 最右叶节点
+	path  []pathStep // 沿右边界到 leaf 父节点为止的路径
+}
+
+// 结构调整次数：分裂、合并与借补都会改变右边界上的节点或其子节点下标，
+// 次数不变即说明记录的路径仍然有效。三者均为原子计数，LatchedBPlusTree 的并发写入不会与此冲突
+func (bpt *BPlusTree) shape() uint64 {
+	return bpt.ops.splits.Load() + bpt.ops.merges.Load() + bpt.ops.borrows.Load()
+}
+
+// 若 key 大于树中的最大键且缓存仍然有效，返回最右叶节点及其路径。
+// 重复的键可能跨越多个叶节点，等于最大键时仍需正常下降
+func (bpt *BPlusTree) rightmostPath(key int) (*Node, []pathStep, bool) {
+	e := &bpt.edge
+	if e.leaf == nil || e.root != bpt.root || e.shape != bpt.shape() {
+		return nil, nil, false
+	}
+	if len(e.leaf.keys) == 0 || key <= maxKey(e.leaf) {
+		return nil, nil, false
+	}
+	return e.leaf, e.path, true
+}
+
+// 沿右边界重新记录最右叶节点及其路径，复用已有的路径缓冲区
+func (bpt *BPlusTree) refreshRightEdge() {
+	e := &bpt.edge
+	if e.leaf != nil && e.root == bpt.root && e.shape == bpt.shape() {
+		return
+	}
+	e.path = e.path[:0]
+	node := bpt.root
+	for !node.isLeaf {
+		i := len(node.children) - 1
+		if i < 0 {
+			// 没有子节点的内部节点只出现在已损坏的树中，不缓存右边界
+			e.leaf = nil
+			return
+		}
+		e.path = append(e.path, pathStep{node, i})
+		node = node.children[i]
+	}
+	e.root, e.shape, e.leaf = bpt.root, bpt.shape(), node
+}
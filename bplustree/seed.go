@@ -0,0 +1,127 @@
+package bplustree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SeedFromFile 在树为空时从 path 读取初始数据插入树中，树非空时什么也不做，
+// 因此可以在每次启动时调用，只有第一次生效。文件格式由扩展名决定：
+//
+//	.json  ExportJSON 格式的键值对数组
+//	.csv   第 0 列为 key、第 1 列为 value，可以带表头（同 ImportCSV）
+//	.toml  每行一个 key = value，key 可以加引号，# 之后为注释
+//
+// 文件有误时返回带行号或元素序号的错误，树保持不变
+func (bpt *BPlusTree) SeedFromFile(path string) error {
+	if !bpt.IsEmpty() {
+		return nil
+	}
+	keys, values, err := readSeedFile(path)
+	if err != nil {
+		return err
+	}
+	bpt.load(keys, values)
+	return nil
+}
+
+// WithSeedFile 在打开时创建新的树文件后，从 path 读取初始数据写入树中，格式同 SeedFromFile。
+// 打开已有的树文件时不读取 path；种子文件有误时不会创建树文件的内容，打开返回错误
+func WithSeedFile(path string) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.seedPath = path
+	}
+}
+
+// 为新建的树文件写入种子数据，调用方须保证树刚刚初始化且未被其他 goroutine 使用
+func (t *DiskBPlusTree) seed(keys, values []int) error {
+	for i, key := range keys {
+		if err := t.Insert(key, values[i]); err != nil {
+			return fmt.Errorf("写入种子数据失败：%w", err)
+		}
+	}
+	return nil
+}
+
+// 按扩展名解析种子文件，返回按 key 升序排列的键值对
+func readSeedFile(path string) (keys, values []int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取种子文件失败：%w", err)
+	}
+	defer f.Close()
+	// JSON 与 CSV 复用导入函数：先导入一棵临时树，由它完成校验与排序
+	tmp := NewBPlusTree()
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = tmp.ImportJSON(f)
+	case ".csv":
+		err = tmp.ImportCSV(f, 0, 1)
+	case ".toml":
+		if keys, values, err = parseSeedTOML(f); err == nil {
+			tmp.load(keys, values)
+		}
+	default:
+		return nil, nil, fmt.Errorf("读取种子文件失败：不支持的扩展名 %q", ext)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取种子文件 %s 失败：%w", path, err)
+	}
+	keys, values = tmp.entries()
+	return keys, values, nil
+}
+
+// 解析 TOML 的一个子集：顶层的 key = value 整数键值对、注释与空行。
+// 整数可以带正负号与数字间的下划线，key 可以用双引号或单引号括起
+func parseSeedTOML(r io.Reader) (keys, values []int, err error) {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			return nil, nil, fmt.Errorf("第 %d 行：不支持表头 %s，键值对须写在顶层", line, text)
+		}
+		k, v, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("第 %d 行：应为 key = value", line)
+		}
+		k = strings.TrimSpace(k)
+		if len(k) >= 2 && (k[0] == '"' || k[0] == '\'') && k[len(k)-1] == k[0] {
+			k = k[1 : len(k)-1]
+		}
+		key, err := parseTOMLInt(k)
+		if err != nil {
+			return nil, nil, fmt.Errorf("第 %d 行：key %q 不是整数", line, k)
+		}
+		value, err := parseTOMLInt(strings.TrimSpace(v))
+		if err != nil {
+			return nil, nil, fmt.Errorf("第 %d 行：value %q 不是整数", line, strings.TrimSpace(v))
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+// TOML 的十进制整数允许数字之间出现单个下划线
+func parseTOMLInt(s string) (int, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if strings.HasPrefix(digits, "_") || strings.HasSuffix(digits, "_") || strings.Contains(digits, "__") {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.Atoi(strings.ReplaceAll(s, "_", ""))
+}
@@ -0,0 +1,169 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// 系统桶是目录中桶名为空的桶，保存字节值，key 为条目编号。条目布局：
+//
+//	[0:4]  魔数 "SYS\x01"
+//	[4]    种类：1 为序列，2 为计数器
+//	[5:13] 当前值
+//	[13:]  名称
+//
+// 打开 Store 时全部条目被读入内存，Next 与 Add 在 Store 的锁内先写条目、写入成功后才更新内存中的值
+const (
+	systemMagic     = "SYS\x01"
+	systemEntrySize = 13
+)
+
+type sysKind byte
+
+const (
+	kindSequence sysKind = 1
+	kindCounter  sysKind = 2
+)
+
+// 序列与计数器各自有独立的命名空间
+type sysKey struct {
+	kind sysKind
+	name string
+}
+
+type sysEntry struct {
+	id    int // 系统桶中的 key
+	value int
+}
+
+// Sequence 是保存在 Store 系统桶中的命名序列，用于生成不重复的编号
+type Sequence struct {
+	store *Store
+	name  string
+}
+
+// Counter 是保存在 Store 系统桶中的命名计数器
+type Counter struct {
+	store *Store
+	name  string
+}
+
+// Sequence 返回名为 name 的序列；序列在第一次 Next 时写入文件，此前其值为 0
+func (s *Store) Sequence(name string) *Sequence {
+	return &Sequence{store: s, name: name}
+}
+
+// Counter 返回名为 name 的计数器；计数器在第一次 Add 时写入文件，此前其值为 0
+func (s *Store) Counter(name string) *Counter {
+	return &Counter{store: s, name: name}
+}
+
+// Name 返回序列名
+func (q *Sequence) Name() string {
+	return q.name
+}
+
+// Next 把序列加一并返回新值：第一次调用返回 1，此后每次都比上一次大。
+// 新值写入文件后才返回，重新打开 Store 后不会再次返回同一个值；多个 goroutine 并发调用时各自得到不同的值
+func (q *Sequence) Next() (int, error) {
+	return q.store.addSystem(sysKey{kindSequence, q.name}, 1)
+}
+
+// Current 返回序列最近一次 Next 返回的值，从未调用过 Next 时返回 0
+func (q *Sequence) Current() int {
+	return q.store.systemValue(sysKey{kindSequence, q.name})
+}
+
+// Name 返回计数器名
+func (c *Counter) Name() string {
+	return c.name
+}
+
+// Add 把计数器加上 delta（可以为负）并返回新值，新值写入文件后才返回；结果溢出 int 时返回错误，计数器不变
+func (c *Counter) Add(delta int) (int, error) {
+	return c.store.addSystem(sysKey{kindCounter, c.name}, delta)
+}
+
+// Value 返回计数器的当前值，从未调用过 Add 时返回 0
+func (c *Counter) Value() int {
+	return c.store.systemValue(sysKey{kindCounter, c.name})
+}
+
+func (s *Store) systemValue(k sysKey) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.sysVals[k]; e != nil {
+		return e.value
+	}
+	return 0
+}
+
+// 把 k 的值加上 delta 并写入系统桶，系统桶不存在时先创建
+func (s *Store) addSystem(k sysKey, delta int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.sysVals[k]
+	if e == nil {
+		// 条目从不删除，编号即已有条目数
+		e = &sysEntry{id: len(s.sysVals)}
+	}
+	next := e.value + delta
+	if (delta > 0 && next < e.value) || (delta < 0 && next > e.value) {
+		return e.value, fmt.Errorf("%q 的值溢出", k.name)
+	}
+	if s.system == nil {
+		b, err := s.newBucket("", true, nil)
+		if err != nil {
+			return e.value, fmt.Errorf("创建系统桶失败：%w", err)
+		}
+		s.system = b
+	}
+	data := encodeSystemEntry(k, next)
+	if err := s.runLocked(s.system, func(t *DiskBPlusTree) error { return t.PutBytes(e.id, data) }); err != nil {
+		return e.value, err
+	}
+	e.value = next
+	s.sysVals[k] = e
+	return next, nil
+}
+
+// 读入系统桶中的全部条目
+func (s *Store) loadSystem() error {
+	s.sysVals = make(map[sysKey]*sysEntry)
+	if s.system == nil {
+		return nil
+	}
+	var entryErr error
+	err := s.runLocked(s.system, func(t *DiskBPlusTree) error {
+		return t.ScanBytes(func(id int, data []byte) bool {
+			k, value, ok := decodeSystemEntry(data)
+			if !ok {
+				entryErr = fmt.Errorf("打开 Store 失败：系统桶条目 %d 格式错误", id)
+				return false
+			}
+			s.sysVals[k] = &sysEntry{id: id, value: value}
+			return true
+		})
+	})
+	return errors.Join(err, entryErr)
+}
+
+func encodeSystemEntry(k sysKey, value int) []byte {
+	data := make([]byte, systemEntrySize, systemEntrySize+len(k.name))
+	copy(data, systemMagic)
+	data[4] = byte(k.kind)
+	binary.LittleEndian.PutUint64(data[5:], uint64(value))
+	return append(data, k.name...)
+}
+
+func decodeSystemEntry(data []byte) (k sysKey, value int, ok bool) {
+	if len(data) < systemEntrySize || string(data[:4]) != systemMagic {
+		return k, 0, false
+	}
+	k = sysKey{kind: sysKind(data[4]), name: string(data[systemEntrySize:])}
+	if k.kind != kindSequence && k.kind != kindCounter {
+		return k, 0, false
+	}
+	return k, int(int64(binary.LittleEndian.Uint64(data[5:]))), true
+}
@@ -0,0 +1,129 @@
+package bplustree
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSequenceAndCounter(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(s *Store) error // 在重新打开之前执行
+		seq     int                  // 重新打开后序列 "ids" 的当前值
+		counter int                  // 重新打开后计数器 "hits" 的当前值
+	}{
+		{"untouched", func(s *Store) error { return nil }, 0, 0},
+		{"sequence", func(s *Store) error {
+			q := s.Sequence("ids")
+			for want := 1; want <= 3; want++ {
+				if got, err := q.Next(); err != nil || got != want {
+					return fmt.Errorf("Next() = %d, %v，期望 %d", got, err, want)
+				}
+			}
+			return nil
+		}, 3, 0},
+		{"counter", func(s *Store) error {
+			c := s.Counter("hits")
+			c.Add(10)
+			if got, err := c.Add(-15); err != nil || got != -5 {
+				return fmt.Errorf("Add(-15) = %d, %v，期望 -5", got, err)
+			}
+			return nil
+		}, 0, -5},
+		{"same-name-separate", func(s *Store) error {
+			s.Sequence("hits").Next()
+			s.Counter("ids").Add(7)
+			s.Sequence("ids").Next()
+			return nil
+		}, 1, 0},
+		{"overflow", func(s *Store) error {
+			c := s.Counter("hits")
+			c.Add(math.MaxInt)
+			if got, err := c.Add(1); err == nil || got != math.MaxInt {
+				return fmt.Errorf("溢出的 Add 返回 %d, %v", got, err)
+			}
+			return nil
+		}, 0, math.MaxInt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, path := openTempStore(t)
+			s.CreateBucket("a")
+			if err := tt.run(s); err != nil {
+				t.Fatal(err)
+			}
+			s = reopenStore(t, s, path)
+			defer s.Close()
+			if got := s.Sequence("ids").Current(); got != tt.seq {
+				t.Fatalf("重新打开后序列为 %d，期望 %d", got, tt.seq)
+			}
+			if got := s.Counter("hits").Value(); got != tt.counter {
+				t.Fatalf("重新打开后计数器为 %d，期望 %d", got, tt.counter)
+			}
+			// 系统桶不作为普通的桶出现
+			if got := s.Buckets(); !slices.Equal(got, []string{"a"}) {
+				t.Fatalf("Buckets() = %v", got)
+			}
+			if st, err := s.Stats(); err != nil || len(st) != 1 {
+				t.Fatalf("Stats() = %v, %v", st, err)
+			}
+			// 重新打开后序列从上次的值继续
+			if got, err := s.Sequence("ids").Next(); err != nil || got != tt.seq+1 {
+				t.Fatalf("重新打开后 Next() = %d, %v，期望 %d", got, err, tt.seq+1)
+			}
+		})
+	}
+}
+
+func TestSequenceConcurrent(t *testing.T) {
+	s, _ := openTempStore(t)
+	defer s.Close()
+	const workers, each = 8, 50
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q := s.Sequence("ids")
+			for range each {
+				v, err := q.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[v] {
+					t.Errorf("Next 重复返回 %d", v)
+				}
+				seen[v] = true
+				mu.Unlock()
+				s.Counter("n").Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != workers*each || s.Counter("n").Value() != workers*each {
+		t.Fatalf("得到 %d 个不同的值，计数器为 %d", len(seen), s.Counter("n").Value())
+	}
+}
+
+func TestOpenStoreCorruptSystemEntry(t *testing.T) {
+	s, path := openTempStore(t)
+	s.Sequence("ids").Next()
+	s.mu.Lock()
+	err := s.runLocked(s.system, func(t *DiskBPlusTree) error { return t.PutBytes(1, []byte("garbage")) })
+	s.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if s, err := OpenStore(path); err == nil {
+		s.Close()
+		t.Fatal("系统桶条目损坏时 OpenStore 应失败")
+	}
+}
@@ -0,0 +1,109 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// SizeStats 是一组键或值的字节数分布，用于选择页大小与发现异常大的条目。
+// 百分位数按最近秩计算：P95 是不小于 95% 条目的最小长度。Count 为 0 时其余字段均为 0
+type SizeStats struct {
+	Count int
+	P50   int
+	P95   int
+	Max   int
+	Total int64 // 全部长度之和
+}
+
+func newSizeStats(sizes []int) SizeStats {
+	if len(sizes) == 0 {
+		return SizeStats{}
+	}
+	slices.Sort(sizes)
+	s := SizeStats{Count: len(sizes), Max: sizes[len(sizes)-1]}
+	rank := func(p int) int { return sizes[(p*len(sizes)+99)/100-1] }
+	s.P50, s.P95 = rank(50), rank(95)
+	for _, n := range sizes {
+		s.Total += int64(n)
+	}
+	return s
+}
+
+// DiskStats 描述磁盘树占用的页与字节值的大小分布
+type DiskStats struct {
+	Entries    int // 键值对总数
+	NodePages  int // 节点占用的页数
+	ValuePages int // 值页数，保存 int 值的树为 0
+	// 字节值的长度（解压后），保存 int 值的树为零值
+	ValueSizes SizeStats
+	// 字节值在值页中实际占用的字节数，使用 WithValueCompression 时小于 ValueSizes
+	StoredValueSizes SizeStats
+}
+
+// Stats 遍历全部节点页与值页，返回条目数、页数以及字节值的长度分布，开销与文件大小成正比
+func (t *DiskBPlusTree) Stats() (DiskStats, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startDeadline(t.scanTimeout)
+	defer t.unpinAll()
+	var s DiskStats
+	var err error
+	if s.NodePages, s.Entries, err = t.treeUsage(t.meta.root); err != nil || !t.meta.blobs {
+		return s, err
+	}
+	sizes := make([]int, 0, s.Entries)
+	stored := make([]int, 0, s.Entries)
+	scanErr := t.scanLocked(func(_, head int) bool {
+		var size, used, pages int
+		if size, used, pages, err = t.blobSize(PageID(head)); err != nil {
+			return false
+		}
+		sizes, stored = append(sizes, size), append(stored, used)
+		s.ValuePages += pages
+		return true
+	})
+	if err = errors.Join(scanErr, err); err != nil {
+		return s, err
+	}
+	s.ValueSizes, s.StoredValueSizes = newSizeStats(sizes), newSizeStats(stored)
+	return s, nil
+}
+
+// 返回值页链表中字节值的长度、在值页中占用的字节数与页数；压缩的值读取首页记录的原始长度，不解压
+func (t *DiskBPlusTree) blobSize(head PageID) (size, used, pages int, err error) {
+	raw := true
+	for id := head; id != 0; pages++ {
+		if uint32(pages) >= t.meta.numPages {
+			return 0, 0, 0, corruptPage(head, "值页链表成环")
+		}
+		chunk, next, err := t.readBlobPage(id)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if id == head && valueEncoding(t.buf[1]) != valueRaw {
+			raw = false
+			n, k := binary.Uvarint(chunk)
+			if k <= 0 {
+				return 0, 0, 0, corruptPage(head, "压缩的值记录的长度无效")
+			}
+			size = int(n)
+		}
+		used += len(chunk)
+		id = next
+	}
+	if raw {
+		size = used
+	}
+	return size, used, pages, nil
+}
+
+// BytesKeySizes 返回 []byte 键树中键的长度分布；前缀压缩的树按完整的键计算
+func BytesKeySizes[V any](t *OrderedTree[[]byte, V]) SizeStats {
+	sizes := make([]int, 0, t.Len())
+	t.Scan(func(k []byte, _ V) bool {
+		sizes = append(sizes, len(k))
+		return true
+	})
+	return newSizeStats(sizes)
+}
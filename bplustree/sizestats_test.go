@@ -0,0 +1,122 @@
+package bplustree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewSizeStats(t *testing.T) {
+	seq := func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = n - i // 逆序，验证会先排序
+		}
+		return s
+	}
+	tests := []struct {
+		name  string
+		sizes []int
+		want  SizeStats
+	}{
+		{"empty", nil, SizeStats{}},
+		{"single", []int{7}, SizeStats{Count: 1, P50: 7, P95: 7, Max: 7, Total: 7}},
+		{"1..100", seq(100), SizeStats{Count: 100, P50: 50, P95: 95, Max: 100, Total: 5050}},
+		{"1..10", seq(10), SizeStats{Count: 10, P50: 5, P95: 10, Max: 10, Total: 55}},
+		{"outlier", []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1000}, SizeStats{Count: 10, P50: 1, P95: 1000, Max: 1000, Total: 1009}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newSizeStats(tt.sizes); got != tt.want {
+				t.Fatalf("newSizeStats = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []DiskOption
+		fill       func(tree *DiskBPlusTree) error
+		entries    int
+		values     SizeStats
+		valuePages int
+		compressed bool // 实际占用的字节数应小于值的长度
+	}{
+		{"empty", nil, func(*DiskBPlusTree) error { return nil }, 0, SizeStats{}, 0, false},
+		{"int-values", nil, func(tree *DiskBPlusTree) error {
+			for i := range 10 {
+				if err := tree.Insert(i, i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, 10, SizeStats{}, 0, false},
+		{"byte-values", nil, func(tree *DiskBPlusTree) error {
+			for i := 1; i <= 20; i++ {
+				if err := tree.PutBytes(i, make([]byte, i)); err != nil {
+					return err
+				}
+			}
+			return tree.PutBytes(100, make([]byte, 2*blobPayloadSize+1))
+		}, 21, SizeStats{Count: 21, P50: 11, P95: 20, Max: 2*blobPayloadSize + 1, Total: 210 + 2*blobPayloadSize + 1}, 20 + 3, false},
+		{"compressed", []DiskOption{WithValueCompression(CompressionFlate)}, func(tree *DiskBPlusTree) error {
+			return tree.PutBytes(1, bytes.Repeat([]byte("abc"), blobPayloadSize))
+		}, 1, SizeStats{Count: 1, P50: 3 * blobPayloadSize, P95: 3 * blobPayloadSize, Max: 3 * blobPayloadSize, Total: 3 * blobPayloadSize}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, path := openTempDiskTree(t, tt.opts...)
+			if err := tt.fill(tree); err != nil {
+				t.Fatal(err)
+			}
+			tree.Close()
+			tree, err := OpenDiskBPlusTree(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tree.Close()
+			s, err := tree.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Entries != tt.entries || s.ValueSizes != tt.values || s.ValuePages != tt.valuePages || s.NodePages < 1 {
+				t.Fatalf("Stats() = %+v，期望 %d 个条目、%d 个值页、值的长度 %+v", s, tt.entries, tt.valuePages, tt.values)
+			}
+			if got := s.StoredValueSizes.Total < s.ValueSizes.Total; got != tt.compressed {
+				t.Fatalf("值页中占用 %d 字节，值的长度共 %d 字节", s.StoredValueSizes.Total, s.ValueSizes.Total)
+			}
+		})
+	}
+}
+
+// 值页损坏时 Stats 返回错误而不是不完整的统计
+func TestDiskStatsCorrupt(t *testing.T) {
+	tree, _ := openTempDiskTree(t)
+	defer tree.Close()
+	tree.PutBytes(1, []byte("value"))
+	tree.mu.Lock()
+	leaf, _, _ := tree.descend(1)
+	tree.unpinAll()
+	head := PageID(leaf.values[0])
+	page := make([]byte, PageSize)
+	tree.store.ReadPage(head, page)
+	page[pageHeaderSize] ^= 1
+	tree.store.WritePage(head, page)
+	tree.mu.Unlock()
+	if _, err := tree.Stats(); err == nil {
+		t.Fatal("值页校验和不匹配时 Stats 应返回错误")
+	}
+}
+
+func TestBytesKeySizes(t *testing.T) {
+	for _, tree := range []*OrderedTree[[]byte, int]{NewBytesTree[int](), NewPrefixBytesTree[int]()} {
+		for i := 1; i <= 100; i++ {
+			tree.Insert(bytes.Repeat([]byte("k"), i), i)
+		}
+		want := SizeStats{Count: 100, P50: 50, P95: 95, Max: 100, Total: 5050}
+		if got := BytesKeySizes(tree); got != want {
+			t.Fatalf("prefixed=%v: BytesKeySizes = %+v，期望 %+v", tree.prefixed, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package bplustree
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger 让树把运行事件写入 l：分裂、合并与借补以 Debug 级别记录，并附带受影响节点的键区间；
+// Validate 发现的结构异常以 Warn 级别记录，严格模式下使树停止服务的内部错误以 Error 级别记录。
+// 未设置时不记录；Debug 级别未开启时不计算键区间，对写操作几乎没有额外开销
+func WithLogger(l *slog.Logger) Option {
+	return func(bpt *BPlusTree) {
+		bpt.logger = l
+	}
+}
+
+// WithDiskLogger 让磁盘树把运行事件写入 l：隔离损坏页覆盖的区间、后台校验器发现不一致时以 Warn 级别记录
+func WithDiskLogger(l *slog.Logger) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.logger = l
+	}
+}
+
+// 报告是否需要记录 Debug 级别的结构事件
+func (bpt *BPlusTree) debugEnabled() bool {
+	return bpt.logger != nil && bpt.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// 以 Debug 级别记录一次结构变化；ranges 为交替出现的名称与 KeyRange
+func (bpt *BPlusTree) logStructural(msg string, leaf bool, ranges ...any) {
+	args := append([]any{slog.Bool("leaf", leaf)}, ranges...)
+	for i := 2; i < len(args); i += 2 {
+		if r, ok := args[i].(KeyRange); ok {
+			args[i] = slog.GroupValue(slog.Int("min", r.Min), slog.Int("max", r.Max))
+		}
+	}
+	bpt.logger.Debug(msg, args...)
+}
+
+func (bpt *BPlusTree) logWarn(msg string, args ...any) {
+	if bpt.logger != nil {
+		bpt.logger.Warn(msg, args...)
+	}
+}
+
+func (t *DiskBPlusTree) logWarn(msg string, args ...any) {
+	if t.logger != nil {
+		t.logger.Warn(msg, args...)
+	}
+}
@@ -0,0 +1,66 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+// 快照文件布局：
+//
+//	[0:8]   魔数 "BPTSNAP\x00"
+//	[8:12]  快照格式版本
+//	[12:20] 载荷长度
+//	[20:24] 载荷的 CRC32
+//	[24:]   载荷，即 MarshalBinary 的输出
+const (
+	snapshotMagic      = "BPTSNAP\x00"
+	snapshotVersion    = 1
+	snapshotHeaderSize = 24
+)
+
+// Save 将树保存为快照文件。文件先写入临时文件再原子重命名，
+// 保存过程中崩溃不会损坏 path 处已有的快照
+func (bpt *BPlusTree) Save(path string) error {
+	payload, err := bpt.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("保存快照失败：%w", err)
+	}
+	data := make([]byte, snapshotHeaderSize, snapshotHeaderSize+len(payload))
+	copy(data, snapshotMagic)
+	binary.LittleEndian.PutUint32(data[8:], snapshotVersion)
+	binary.LittleEndian.PutUint64(data[12:], uint64(len(payload)))
+	binary.LittleEndian.PutUint32(data[20:], crc32.ChecksumIEEE(payload))
+	data = append(data, payload...)
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("保存快照失败：%w", err)
+	}
+	return nil
+}
+
+// Load 读取 Save 写出的快照文件，校验文件头与 CRC 后还原出树
+func Load(path string) (*BPlusTree, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("加载快照失败：%w", err)
+	}
+	if len(data) < snapshotHeaderSize || string(data[:8]) != snapshotMagic {
+		return nil, fmt.Errorf("加载快照失败：%s 不是快照文件", path)
+	}
+	if v := binary.LittleEndian.Uint32(data[8:]); v != snapshotVersion {
+		return nil, fmt.Errorf("加载快照失败：不支持的快照版本 %d", v)
+	}
+	payload := data[snapshotHeaderSize:]
+	if size := binary.LittleEndian.Uint64(data[12:]); size != uint64(len(payload)) {
+		return nil, fmt.Errorf("加载快照失败：载荷长度应为 %d，实际为 %d", size, len(payload))
+	}
+	if crc := crc32.ChecksumIEEE(payload); crc != binary.LittleEndian.Uint32(data[20:]) {
+		return nil, fmt.Errorf("加载快照失败：CRC 校验不通过")
+	}
+	bpt := NewBPlusTree()
+	if err := bpt.UnmarshalBinary(payload); err != nil {
+		return nil, fmt.Errorf("加载快照失败：%w", err)
+	}
+	return bpt, nil
+}
@@ -0,0 +1,208 @@
+package bplustree
+
+import (
+	"sort"
+	"time"
+)
+
+// SplitAt 以 key 为界将树拆分为两棵：左树包含所有小于 key 的键，右树包含所有不小于 key 的键。
+// 拆分沿根到叶的路径进行：路径上每个节点左右两侧的子树被整体摘下，再按高度依次拼接，
+// 整个过程只涉及 O(log n) 个节点。两棵新树沿用原树的选项、回调与严格模式，
+// 各 key 的过期时间与二级索引随之分配到两棵树中。调用后原树被清空，只保留配置与索引的定义
+func (bpt *BPlusTree) SplitAt(key int) (*BPlusTree, *BPlusTree) {
+	bpt.tracef("op=split-at key=%d", key)
+	var leftPieces, rightPieces []*Node
+	// 右侧子树在下降过程中自上而下收集，拼接时需要按 key 升序（即自下而上）使用
+	var rightLevels [][]*Node
+
+	node := bpt.root
+	for !node.isLeaf {
+		i := sort.SearchInts(node.keys, key)
+		if i == len(node.keys) {
+			i = len(node.keys) - 1
+		}
+		leftPieces = append(leftPieces, node.children[:i]...)
+		rightLevels = append(rightLevels, node.children[i+1:])
+		node = node.children[i]
+	}
+
+	// 在叶节点内部切分
+	pos := sort.SearchInts(node.keys, key)
+	switch {
+	case pos == 0:
+		rightPieces = append(rightPieces, node)
+	case pos == len(node.keys):
+		leftPieces = append(leftPieces, node)
+	default:
+		rightLeaf := NewNode(true)
+		rightLeaf.keys = append(rightLeaf.keys, node.keys[pos:]...)
+		rightLeaf.values = append(rightLeaf.values, node.values[pos:]...)
+		rightLeaf.next = node.next
+		node.keys = node.keys[:pos]
+		node.values = node.values[:pos]
+		leftPieces = append(leftPieces, node)
+		rightPieces = append(rightPieces, rightLeaf)
+	}
+	for i := len(rightLevels) - 1; i >= 0; i-- {
+		rightPieces = append(rightPieces, rightLevels[i]...)
+	}
+
+	left, right := bpt.emptyLike(), bpt.emptyLike()
+	for _, piece := range leftPieces {
+		left.appendTree(piece)
+	}
+	for _, piece := range rightPieces {
+		right.appendTree(piece)
+	}
+	// 切断左树最后一个叶节点指向右树的链表指针
+	last := left.root
+	for !last.isLeaf {
+		last = last.children[len(last.children)-1]
+	}
+	last.next = nil
+
+	// 过期时间随 key 归入所在的新树，原树被清空后不再保留
+	for k, deadline := range bpt.ttl {
+		dst := right
+		if k < key {
+			dst = left
+		}
+		if dst.ttl == nil {
+			dst.ttl = make(map[int]time.Time)
+		}
+		dst.ttl[k] = deadline
+	}
+	bpt.ttl = nil
+	for name, ix := range bpt.indexes {
+		left.AddIndex(name, ix.keyOf)
+		right.AddIndex(name, ix.keyOf)
+	}
+
+	bpt.root = NewNode(true)
+	if bpt.indexes != nil {
+		bpt.rebuildIndexes()
+	}
+	return left, right
+}
+
+// 创建与 bpt 配置相同的空树：选项、回调、严格模式与时钟均沿用 bpt 的设置，
+// 内容、过期时间、订阅与二级索引不复制。原树的最右叶节点可能由偏置分裂产生，拆分后它仍是右树的最右叶节点，
+// 因此分裂偏置同样沿用
+func (bpt *BPlusTree) emptyLike() *BPlusTree {
+	t := &BPlusTree{
+		root:         NewNode(true),
+		trace:        bpt.trace,
+		readerStacks: bpt.readerStacks,
+		poolSize:     bpt.poolSize,
+		splitBias:    bpt.splitBias,
+		strict:       bpt.strict,
+		now:          bpt.now,
+		hooks:        bpt.hooks,
+		logger:       bpt.logger,
+	}
+	if bpt.arena != nil {
+		t.arena = &nodeArena{chunk: bpt.arena.chunk}
+	}
+	return t
+}
+
+// 返回以 node 为根的子树高度，叶节点高度为 0
+func height(node *Node) int {
+	h := 0
+	for !node.isLeaf {
+		node = node.children[0]
+		h++
+	}
+	return h
+}
+
+// 沿树的右边界（rightmost 为 true）或左边界下降到高度为 h 的节点，返回该节点及沿途的路径
+func (bpt *BPlusTree) edgePath(h int, rightmost bool) (*Node, []pathStep) {
+	var path []pathStep
+	node := bpt.root
+	for level := height(node); level > h; level-- {
+		i := 0
+		if rightmost {
+			i = len(node.children) - 1
+		}
+		path = append(path, pathStep{node, i})
+		node = node.children[i]
+	}
+	return node, path
+}
+
+// 将子树 sub 拼接到当前树的右侧，要求 sub 中的键均不小于当前树中的键。
+// sub 的根可能不满足最少关键字数，拼接后通过分裂与借补/合并恢复平衡
+func (bpt *BPlusTree) appendTree(sub *Node) {
+	if bpt.root.isLeaf && len(bpt.root.keys) == 0 {
+		bpt.root = sub
+		return
+	}
+	ha, hb := height(bpt.root), height(sub)
+	switch {
+	case ha == hb:
+		bpt.joinSiblings(bpt.root, sub)
+	case ha > hb:
+		// 沿右边界下降到高度为 hb+1 的节点，将 sub 作为其最后一个子节点
+		parent, path := bpt.edgePath(hb+1, true)
+		parent.children = append(parent.children, sub)
+		bpt.updateInternalKeys(parent)
+		bpt.updateParent(parent, path)
+		if len(parent.children) > MaxKeys {
+			bpt.splitInternal(parent, path)
+		}
+		if len(sub.keys) < getMinKeys() {
+			// 分裂可能改变了 sub 的祖先，重新沿右边界取得它的路径
+			_, path = bpt.edgePath(hb, true)
+			bpt.rebalance(sub, path)
+		}
+	default:
+		// 沿 sub 的左边界下降到高度为 ha+1 的节点，将原树作为其第一个子节点
+		oldRoot := bpt.root
+		bpt.root = sub
+		parent, path := bpt.edgePath(ha+1, false)
+		parent.children = append([]*Node{oldRoot}, parent.children...)
+		bpt.updateInternalKeys(parent)
+		if len(parent.children) > MaxKeys {
+			bpt.splitInternal(parent, path)
+		}
+		if len(oldRoot.keys) < getMinKeys() {
+			_, path = bpt.edgePath(ha, false)
+			bpt.rebalance(oldRoot, path)
+		}
+	}
+}
+
+// 拼接两个等高的根节点：容量允许时合并为一个节点，否则均分到两个节点并构造新根
+func (bpt *BPlusTree) joinSiblings(left, right *Node) {
+	if left.isLeaf {
+		keys := append(append([]int{}, left.keys...), right.keys...)
+		values := append(append([]int{}, left.values...), right.values...)
+		if len(keys) <= MaxKeys {
+			left.keys, left.values = keys, values
+			left.next = right.next
+			bpt.root = left
+			return
+		}
+		mid := len(keys) / 2
+		left.keys, right.keys = keys[:mid:mid], keys[mid:]
+		left.values, right.values = values[:mid:mid], values[mid:]
+	} else {
+		children := append(append([]*Node{}, left.children...), right.children...)
+		if len(children) <= MaxKeys {
+			left.children = children
+			bpt.updateInternalKeys(left)
+			bpt.root = left
+			return
+		}
+		mid := len(children) / 2
+		left.children, right.children = children[:mid:mid], children[mid:]
+		bpt.updateInternalKeys(left)
+		bpt.updateInternalKeys(right)
+	}
+
+	newRoot := NewNode(false)
+	newRoot.children = append(newRoot.children, left, right)
+	bpt.updateInternalKeys(newRoot)
+	bpt.root = newRoot
+}
@@ -0,0 +1,137 @@
+package bplustree
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// 沿叶节点链表读出树的全部键值对，同时确认结构不变式成立
+func treeContents(t *testing.T, bpt *BPlusTree) []KeyValue {
+	t.Helper()
+	if err := bpt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var kvs []KeyValue
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, k := range leaf.keys {
+			kvs = append(kvs, KeyValue{Key: k, Value: leaf.values[i]})
+		}
+	}
+	return kvs
+}
+
+// 拆分点位于最小键之下、最大键之上、叶节点内部、叶节点边界与重复的 key 上时，
+// 两棵新树恰好分得原树中小于与不小于拆分点的条目，结构与叶节点链表都保持正确
+func TestSplitAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		pivot func(bpt *BPlusTree) int
+	}{
+		{"below-min", func(*BPlusTree) int { return math.MinInt }},
+		{"at-min", func(*BPlusTree) int { return 0 }},
+		{"above-max", func(*BPlusTree) int { return 1000 }},
+		{"at-max", func(*BPlusTree) int { return 2 * 199 }},
+		{"inside-leaf", func(bpt *BPlusTree) int {
+			leaf := bpt.findLeaf(bpt.root, 2*50)
+			return leaf.keys[len(leaf.keys)-1]
+		}},
+		{"leaf-boundary", func(bpt *BPlusTree) int { return bpt.findLeaf(bpt.root, 2*50).next.keys[0] }},
+		{"missing-key", func(*BPlusTree) int { return 2*120 + 1 }},
+		{"duplicate", func(*BPlusTree) int { return 2 * 100 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bpt := NewBPlusTree()
+			for k := range 200 {
+				bpt.Insert(2*k, k)
+			}
+			// key 200 的重复条目跨越多个叶节点
+			for i := range 8 {
+				bpt.Insert(2*100, -i)
+			}
+			all := treeContents(t, bpt)
+			pivot := tt.pivot(bpt)
+			i := slices.IndexFunc(all, func(kv KeyValue) bool { return kv.Key >= pivot })
+			if i == -1 {
+				i = len(all)
+			}
+
+			left, right := bpt.SplitAt(pivot)
+			if got := treeContents(t, left); !slices.Equal(got, all[:i]) {
+				t.Fatalf("左树有 %d 个条目，期望 %d 个", len(got), i)
+			}
+			if got := treeContents(t, right); !slices.Equal(got, all[i:]) {
+				t.Fatalf("右树有 %d 个条目，期望 %d 个", len(got), len(all)-i)
+			}
+			if got := treeContents(t, bpt); len(got) != 0 {
+				t.Fatalf("拆分后原树仍有 %d 个条目", len(got))
+			}
+			// 拆分出的树可以照常写入
+			for k := range 50 {
+				left.Insert(math.MinInt+k, k)
+				right.Insert(math.MaxInt-k, k)
+			}
+			if err := left.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := right.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// 两棵新树沿用原树的选项、回调与严格模式，过期时间与二级索引随 key 分配；原树被清空
+func TestSplitAtKeepsConfig(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	splits := 0
+	bpt := NewBPlusTree(WithStrict(), WithClock(clock.Now), WithSplitBias(0.9),
+		WithHooks(Hooks{OnSplit: func(bool, KeyRange, KeyRange) { splits++ }}))
+	for k := range 20 {
+		bpt.Insert(k, k%3)
+	}
+	bpt.InsertWithTTL(2, 2, time.Second)
+	bpt.InsertWithTTL(15, 0, time.Second)
+	if err := bpt.AddIndex("mod3", func(v int) int { return v }); err != nil {
+		t.Fatal(err)
+	}
+
+	left, right := bpt.SplitAt(10)
+	for _, half := range []*BPlusTree{left, right} {
+		if !half.strict || half.splitBias != 0.9 || half.now == nil {
+			t.Fatal("拆分出的树没有沿用原树的配置")
+		}
+	}
+	if len(bpt.ttl) != 0 {
+		t.Fatalf("原树仍保留 %d 个过期时间", len(bpt.ttl))
+	}
+	if len(left.ttl) != 1 || len(right.ttl) != 1 {
+		t.Fatalf("过期时间分配为左 %v、右 %v", left.ttl, right.ttl)
+	}
+	clock.advance(time.Second)
+	if left.Search(2) != -1 || right.Search(15) != -1 || left.Search(3) != 0 {
+		t.Fatal("过期时间未随 key 归入新树")
+	}
+
+	keys, err := left.LookupIndex("mod3", 0)
+	if err != nil || !slices.Equal(keys, []int{0, 3, 6, 9}) {
+		t.Fatalf("左树 LookupIndex = %v, %v", keys, err)
+	}
+	keys, err = right.LookupIndex("mod3", 1)
+	if err != nil || !slices.Equal(keys, []int{10, 13, 16, 19}) {
+		t.Fatalf("右树 LookupIndex = %v, %v", keys, err)
+	}
+	if keys, err := bpt.LookupIndex("mod3", 0); err != nil || len(keys) != 0 {
+		t.Fatalf("原树 LookupIndex = %v, %v，期望保留空索引", keys, err)
+	}
+
+	before := splits
+	for k := 100; k < 120; k++ {
+		right.Insert(k, k)
+	}
+	if splits == before {
+		t.Fatal("拆分出的树没有沿用原树的回调")
+	}
+}
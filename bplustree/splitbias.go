@@ -0,0 +1,27 @@
+package bplustree
+
+// WithSplitBias 设置分裂最右叶节点时留在左侧的比例，如 0.9 表示 90/10 分裂。
+// 键单调递增时新键总是追加到最右叶节点，均分会让每个叶节点只填满一半，
+// 偏置分裂使左侧叶节点保持接近满载，右侧的新叶节点由后续追加填满。
+// 其余节点仍然均分；bias 不大于 0.5 时等同于均分。
+// 开启后最右叶节点允许低于最少关键字数，Validate 相应放宽这一项检查
+func WithSplitBias(bias float64) Option {
+	return func(bpt *BPlusTree) {
+		bpt.splitBias = bias
+	}
+}
+
+// WithDiskSplitBias 为磁盘树设置与 WithSplitBias 相同的最右叶节点分裂比例。
+// 偏置只影响此后的分裂，不写入文件，重新打开时需再次指定
+func WithDiskSplitBias(bias float64) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.splitBias = bias
+	}
+}
+
+// 计算含 total 个键的节点分裂时留在左侧的键数：按 bias 取整，但不少于均分时的数量，
+// 且两侧至少各留一个键、左侧不超过 maxKeys
+func splitPoint(total int, bias float64, maxKeys int) int {
+	mid := int(float64(total)*bias + 0.5)
+	return min(max(mid, total/2), total-1, maxKeys)
+}
@@ -0,0 +1,362 @@
+package bplustree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+)
+
+// SSTable 文件布局（整数均为小端）：
+//
+//	[数据块]   每块最多 256 个条目，条目为 [key int64][value int64]，按 key 升序
+//	[块索引]   每块一项：[首个 key int64][末尾 key int64][偏移 uint64][条目数 uint32][块的 CRC32]
+//	[布隆过滤器] [哈希函数个数 uint32][位数组]
+//	[文件尾]   [块索引偏移 uint64][布隆过滤器偏移 uint64][条目数 uint64][块数 uint32]
+//	           [块索引与布隆过滤器的 CRC32][格式版本 uint32][魔数 "BPTSST\x00\x00"]
+//
+// 读取方只需载入文件尾、块索引和布隆过滤器，点查最多再读一个数据块
+const (
+	sstMagic           = "BPTSST\x00\x00"
+	sstVersion         = 1
+	sstEntrySize       = 16
+	sstBlockEntries    = 256
+	sstIndexEntrySize  = 32
+	sstFooterSize      = 44
+	sstBloomBitsPerKey = 10
+	sstBloomHashes     = 7
+)
+
+// 对 key 做 splitmix64 混合，得到布隆过滤器使用的 64 位哈希
+func sstHash(key int) uint64 {
+	h := uint64(key) + 0x9e3779b97f4a7c15
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return h ^ (h >> 31)
+}
+
+// 依次把 key 在 bits 位的位数组中对应的 k 个位置（双重哈希）传给 fn，fn 返回 false 时停止并返回 false
+func bloomPositions(key int, k uint32, bits uint64, fn func(pos uint64) bool) bool {
+	h := sstHash(key)
+	h1, h2 := h&0xffffffff, h>>32|1
+	for i := uint64(0); i < uint64(k); i++ {
+		if !fn((h1 + i*h2) % bits) {
+			return false
+		}
+	}
+	return true
+}
+
+// 块索引项
+type sstBlock struct {
+	first, last int
+	offset      uint64
+	count       uint32
+	crc         uint32
+}
+
+// 流式写出 SSTable：数据块随写随出，块索引与布隆过滤器留在内存中最后写出
+type sstWriter struct {
+	w     *bufio.Writer
+	off   uint64
+	block []byte
+	first int
+	last  int
+	index []sstBlock
+	bloom []byte
+	total uint64
+}
+
+func newSSTWriter(w io.Writer, count int) *sstWriter {
+	bits := max(count*sstBloomBitsPerKey, 64)
+	return &sstWriter{
+		w:     bufio.NewWriter(w),
+		block: make([]byte, 0, sstBlockEntries*sstEntrySize),
+		bloom: make([]byte, (bits+7)/8),
+	}
+}
+
+func (s *sstWriter) add(key, value int) error {
+	if len(s.block) == 0 {
+		s.first = key
+	}
+	s.last = key
+	s.block = binary.LittleEndian.AppendUint64(s.block, uint64(int64(key)))
+	s.block = binary.LittleEndian.AppendUint64(s.block, uint64(int64(value)))
+	bloomPositions(key, sstBloomHashes, uint64(len(s.bloom))*8, func(pos uint64) bool {
+		s.bloom[pos/8] |= 1 << (pos % 8)
+		return true
+	})
+	s.total++
+	if len(s.block) == cap(s.block) {
+		return s.flushBlock()
+	}
+	return nil
+}
+
+func (s *sstWriter) flushBlock() error {
+	if len(s.block) == 0 {
+		return nil
+	}
+	s.index = append(s.index, sstBlock{
+		first:  s.first,
+		last:   s.last,
+		offset: s.off,
+		count:  uint32(len(s.block) / sstEntrySize),
+		crc:    crc32.ChecksumIEEE(s.block),
+	})
+	if _, err := s.w.Write(s.block); err != nil {
+		return err
+	}
+	s.off += uint64(len(s.block))
+	s.block = s.block[:0]
+	return nil
+}
+
+// 写出最后一个数据块、块索引、布隆过滤器与文件尾
+func (s *sstWriter) finish() error {
+	if err := s.flushBlock(); err != nil {
+		return err
+	}
+	var meta []byte
+	for _, b := range s.index {
+		meta = binary.LittleEndian.AppendUint64(meta, uint64(int64(b.first)))
+		meta = binary.LittleEndian.AppendUint64(meta, uint64(int64(b.last)))
+		meta = binary.LittleEndian.AppendUint64(meta, b.offset)
+		meta = binary.LittleEndian.AppendUint32(meta, b.count)
+		meta = binary.LittleEndian.AppendUint32(meta, b.crc)
+	}
+	bloomOff := s.off + uint64(len(meta))
+	meta = binary.LittleEndian.AppendUint32(meta, sstBloomHashes)
+	meta = append(meta, s.bloom...)
+
+	footer := binary.LittleEndian.AppendUint64(nil, s.off)
+	footer = binary.LittleEndian.AppendUint64(footer, bloomOff)
+	footer = binary.LittleEndian.AppendUint64(footer, s.total)
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(s.index)))
+	footer = binary.LittleEndian.AppendUint32(footer, crc32.ChecksumIEEE(meta))
+	footer = binary.LittleEndian.AppendUint32(footer, sstVersion)
+	footer = append(footer, sstMagic...)
+	if _, err := s.w.Write(meta); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(footer); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// ExportSSTable 沿叶节点链表把全部键值对写成不可变的有序表（SSTable），
+// 附带块索引与布隆过滤器，其他组件可以通过 OpenSSTable 直接查询而无需载入整棵树
+func (bpt *BPlusTree) ExportSSTable(w io.Writer) error {
+	count := 0
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		count += len(leaf.keys)
+	}
+	s := newSSTWriter(w, count)
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, key := range leaf.keys {
+			if err := s.add(key, leaf.values[i]); err != nil {
+				return fmt.Errorf("导出 SSTable 失败：%w", err)
+			}
+		}
+	}
+	if err := s.finish(); err != nil {
+		return fmt.Errorf("导出 SSTable 失败：%w", err)
+	}
+	return nil
+}
+
+// ExportSSTable 将磁盘树的全部键值对写成 SSTable，格式与 BPlusTree.ExportSSTable 相同。
+// 需要扫描两遍叶节点链表（第一遍统计条目数以确定布隆过滤器的大小），期间持有树的锁
+func (t *DiskBPlusTree) ExportSSTable(w io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer t.unpinAll()
+	if t.meta.blobs {
+		return fmt.Errorf("导出 SSTable 失败：%w", ErrByteValues)
+	}
+	count := 0
+	if err := t.scanLocked(func(key, value int) bool { count++; return true }); err != nil {
+		return fmt.Errorf("导出 SSTable 失败：%w", err)
+	}
+	s := newSSTWriter(w, count)
+	var werr error
+	err := t.scanLocked(func(key, value int) bool {
+		werr = s.add(key, value)
+		return werr == nil
+	})
+	if err == nil {
+		err = werr
+	}
+	if err == nil {
+		err = s.finish()
+	}
+	if err != nil {
+		return fmt.Errorf("导出 SSTable 失败：%w", err)
+	}
+	return nil
+}
+
+// SSTable 是 ExportSSTable 写出的有序表的只读视图。块索引与布隆过滤器常驻内存，
+// 数据块按需读取，可被多个 goroutine 同时使用
+type SSTable struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	index   []sstBlock
+	bloom   []byte
+	hashes  uint32
+	entries int
+}
+
+// OpenSSTable 打开 path 处的 SSTable 文件，使用完毕后须调用 Close
+func OpenSSTable(path string) (*SSTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SSTable 失败：%w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("打开 SSTable 失败：%w", err)
+	}
+	s, err := NewSSTable(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.closer = f
+	return s, nil
+}
+
+// NewSSTable 从任意 io.ReaderAt（如内存中的 bytes.Reader）读取长度为 size 的 SSTable
+func NewSSTable(r io.ReaderAt, size int64) (*SSTable, error) {
+	if size < sstFooterSize {
+		return nil, fmt.Errorf("打开 SSTable 失败：文件过短")
+	}
+	footer := make([]byte, sstFooterSize)
+	if _, err := r.ReadAt(footer, size-sstFooterSize); err != nil {
+		return nil, fmt.Errorf("打开 SSTable 失败：%w", err)
+	}
+	if string(footer[36:]) != sstMagic {
+		return nil, fmt.Errorf("打开 SSTable 失败：魔数不匹配")
+	}
+	if v := binary.LittleEndian.Uint32(footer[32:]); v != sstVersion {
+		return nil, fmt.Errorf("打开 SSTable 失败：不支持的格式版本 %d", v)
+	}
+	indexOff := binary.LittleEndian.Uint64(footer)
+	bloomOff := binary.LittleEndian.Uint64(footer[8:])
+	entries := binary.LittleEndian.Uint64(footer[16:])
+	blocks := uint64(binary.LittleEndian.Uint32(footer[24:]))
+	metaEnd := uint64(size - sstFooterSize)
+	if indexOff > bloomOff || bloomOff+4 > metaEnd || bloomOff-indexOff != blocks*sstIndexEntrySize {
+		return nil, fmt.Errorf("打开 SSTable 失败：文件尾无效")
+	}
+	meta := make([]byte, metaEnd-indexOff)
+	if _, err := r.ReadAt(meta, int64(indexOff)); err != nil {
+		return nil, fmt.Errorf("打开 SSTable 失败：%w", err)
+	}
+	if crc32.ChecksumIEEE(meta) != binary.LittleEndian.Uint32(footer[28:]) {
+		return nil, fmt.Errorf("打开 SSTable 失败：块索引 CRC 校验不通过")
+	}
+	s := &SSTable{r: r, entries: int(entries)}
+	for i := uint64(0); i < blocks; i++ {
+		e := meta[i*sstIndexEntrySize:]
+		s.index = append(s.index, sstBlock{
+			first:  int(int64(binary.LittleEndian.Uint64(e))),
+			last:   int(int64(binary.LittleEndian.Uint64(e[8:]))),
+			offset: binary.LittleEndian.Uint64(e[16:]),
+			count:  binary.LittleEndian.Uint32(e[24:]),
+			crc:    binary.LittleEndian.Uint32(e[28:]),
+		})
+	}
+	bloom := meta[bloomOff-indexOff:]
+	s.hashes = binary.LittleEndian.Uint32(bloom)
+	s.bloom = bloom[4:]
+	if len(s.bloom) == 0 {
+		return nil, fmt.Errorf("打开 SSTable 失败：布隆过滤器为空")
+	}
+	return s, nil
+}
+
+// Len 返回表中的条目数
+func (s *SSTable) Len() int {
+	return s.entries
+}
+
+// MayContain 通过布隆过滤器判断 key 是否可能存在：返回 false 时 key 一定不存在
+func (s *SSTable) MayContain(key int) bool {
+	return bloomPositions(key, s.hashes, uint64(len(s.bloom))*8, func(pos uint64) bool {
+		return s.bloom[pos/8]&(1<<(pos%8)) != 0
+	})
+}
+
+// 读取并校验第 i 个数据块
+func (s *SSTable) readBlock(i int) ([]byte, error) {
+	b := s.index[i]
+	data := make([]byte, int(b.count)*sstEntrySize)
+	if _, err := s.r.ReadAt(data, int64(b.offset)); err != nil {
+		return nil, fmt.Errorf("读取数据块 %d 失败：%w", i, err)
+	}
+	if crc32.ChecksumIEEE(data) != b.crc {
+		return nil, fmt.Errorf("读取数据块 %d 失败：CRC 校验不通过", i)
+	}
+	return data, nil
+}
+
+func sstEntry(data []byte, i int) (key, value int) {
+	e := data[i*sstEntrySize:]
+	return int(int64(binary.LittleEndian.Uint64(e))), int(int64(binary.LittleEndian.Uint64(e[8:])))
+}
+
+// Get 返回 key 对应的 value；布隆过滤器排除或未找到时返回 false。存在重复 key 时返回第一个
+func (s *SSTable) Get(key int) (int, bool, error) {
+	if !s.MayContain(key) {
+		return 0, false, nil
+	}
+	i := sort.Search(len(s.index), func(i int) bool { return s.index[i].last >= key })
+	if i == len(s.index) || s.index[i].first > key {
+		return 0, false, nil
+	}
+	data, err := s.readBlock(i)
+	if err != nil {
+		return 0, false, err
+	}
+	n := int(s.index[i].count)
+	j := sort.Search(n, func(j int) bool {
+		k, _ := sstEntry(data, j)
+		return k >= key
+	})
+	if j == n {
+		return 0, false, nil
+	}
+	k, v := sstEntry(data, j)
+	return v, k == key, nil
+}
+
+// Scan 按 key 升序遍历全部条目，fn 返回 false 时提前结束
+func (s *SSTable) Scan(fn func(key, value int) bool) error {
+	for i := range s.index {
+		data, err := s.readBlock(i)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < int(s.index[i].count); j++ {
+			if !fn(sstEntry(data, j)) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// Close 关闭由 OpenSSTable 打开的文件；对 NewSSTable 创建的表不做任何事
+func (s *SSTable) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
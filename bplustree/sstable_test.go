@@ -0,0 +1,200 @@
+package bplustree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// 导出为 SSTable 的条目数：跨越多个数据块，最后一块不满
+const sstTestEntries = 3*sstBlockEntries + 17
+
+// 读出 SSTable 的全部条目
+func sstContents(t *testing.T, s *SSTable) []KeyValue {
+	t.Helper()
+	var kvs []KeyValue
+	if err := s.Scan(func(k, v int) bool {
+		kvs = append(kvs, KeyValue{Key: k, Value: v})
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return kvs
+}
+
+// 将 tree 导出到临时文件后重新打开
+func exportSSTable(t *testing.T, export func(w *os.File) error) *SSTable {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "table.sst")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := export(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenSSTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// 内存树与磁盘树导出的表可以重新打开，点查存在与不存在的 key，并按序扫描跨越数据块边界的前缀
+func TestSSTableRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries int
+		disk    bool
+	}{
+		{"memory", sstTestEntries, false},
+		{"memory-empty", 0, false},
+		{"memory-one-block", sstBlockEntries, false},
+		{"disk", sstTestEntries, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make(map[int]int)
+			for i := range tt.entries {
+				want[3*i] = -i
+			}
+			var s *SSTable
+			if tt.disk {
+				tree, _ := openTempDiskTree(t)
+				defer tree.Close()
+				for k, v := range want {
+					tree.Insert(k, v)
+				}
+				s = exportSSTable(t, func(f *os.File) error { return tree.ExportSSTable(f) })
+			} else {
+				tree := NewBPlusTree()
+				for k, v := range want {
+					tree.Insert(k, v)
+				}
+				s = exportSSTable(t, func(f *os.File) error { return tree.ExportSSTable(f) })
+			}
+			if s.Len() != tt.entries {
+				t.Fatalf("Len() = %d，期望 %d", s.Len(), tt.entries)
+			}
+			all := sortedEntries(want)
+			if got := sstContents(t, s); !slices.Equal(got, all) {
+				t.Fatalf("Scan 读到 %d 个条目，期望 %d 个", len(got), len(all))
+			}
+			for k, v := range want {
+				if !s.MayContain(k) {
+					t.Fatalf("MayContain(%d) = false，存在的 key 不能被布隆过滤器排除", k)
+				}
+				if got, ok, err := s.Get(k); err != nil || !ok || got != v {
+					t.Fatalf("Get(%d) = %d, %v, %v，期望 %d", k, got, ok, err, v)
+				}
+			}
+			for _, k := range []int{-1, 1, 3*sstBlockEntries - 2, 3 * tt.entries, 1 << 40} {
+				if got, ok, err := s.Get(k); err != nil || ok {
+					t.Fatalf("Get(%d) = %d, %v, %v，key 不存在", k, got, ok, err)
+				}
+			}
+			// 在数据块边界前后提前结束的扫描
+			for _, n := range []int{1, sstBlockEntries - 1, sstBlockEntries, sstBlockEntries + 1, 2*sstBlockEntries + 5} {
+				n = min(n, len(all))
+				var got []KeyValue
+				if err := s.Scan(func(k, v int) bool {
+					got = append(got, KeyValue{Key: k, Value: v})
+					return len(got) < n
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if n > 0 && !slices.Equal(got, all[:n]) {
+					t.Fatalf("读取 %d 个条目后停止的扫描读到 %d 个", n, len(got))
+				}
+			}
+		})
+	}
+}
+
+// 重复的 key 跨越数据块时 Get 返回第一个
+func TestSSTableDuplicates(t *testing.T) {
+	tree := NewBPlusTree()
+	for i := range sstBlockEntries - 2 {
+		tree.Insert(i, i)
+	}
+	for i := range 10 {
+		tree.Insert(sstBlockEntries, 100+i)
+	}
+	var buf bytes.Buffer
+	if err := tree.ExportSSTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSSTable(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := treeContents(t, tree)[sstBlockEntries-2].Value
+	if v, ok, err := s.Get(sstBlockEntries); err != nil || !ok || v != first {
+		t.Fatalf("Get(%d) = %d, %v, %v，期望第一个条目的值 %d", sstBlockEntries, v, ok, err, first)
+	}
+}
+
+// 截断或损坏的文件：文件尾、块索引与布隆过滤器损坏时打开失败，数据块损坏时读取该块的操作返回错误
+func TestSSTableCorrupt(t *testing.T) {
+	tree := NewBPlusTree()
+	for i := range sstTestEntries {
+		tree.Insert(i, i)
+	}
+	var buf bytes.Buffer
+	if err := tree.ExportSSTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	footer := len(good) - sstFooterSize
+	indexOff := int(binary.LittleEndian.Uint64(good[footer:]))
+	tests := []struct {
+		name      string
+		tamper    func(data []byte) []byte
+		openFails bool
+	}{
+		{"too-short", func(d []byte) []byte { return d[:sstFooterSize-1] }, true},
+		{"truncated", func(d []byte) []byte { return d[:len(d)-1] }, true},
+		{"truncated-data", func(d []byte) []byte { return d[sstEntrySize:] }, true},
+		{"bad-magic", func(d []byte) []byte { d[len(d)-1] ^= 1; return d }, true},
+		{"bad-version", func(d []byte) []byte { d[footer+32]++; return d }, true},
+		{"bad-footer-offsets", func(d []byte) []byte { d[footer+8]++; return d }, true},
+		{"corrupt-index", func(d []byte) []byte { d[indexOff+3] ^= 1; return d }, true},
+		{"corrupt-bloom", func(d []byte) []byte { d[footer-1] ^= 1; return d }, true},
+		{"corrupt-block", func(d []byte) []byte { d[sstBlockEntries*sstEntrySize+8] ^= 1; return d }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.tamper(bytes.Clone(good))
+			s, err := NewSSTable(bytes.NewReader(data), int64(len(data)))
+			if tt.openFails {
+				if err == nil {
+					t.Fatal("打开损坏的文件应失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 其他数据块不受影响，读取被损坏的第二个数据块时报错
+			if v, ok, err := s.Get(0); err != nil || !ok || v != 0 {
+				t.Fatalf("Get(0) = %d, %v, %v", v, ok, err)
+			}
+			if _, _, err := s.Get(sstBlockEntries); err == nil {
+				t.Fatal("读取损坏的数据块应返回错误")
+			}
+			if err := s.Scan(func(int, int) bool { return true }); err == nil {
+				t.Fatal("扫描经过损坏的数据块应返回错误")
+			}
+		})
+	}
+	if _, err := OpenSSTable(filepath.Join(t.TempDir(), "missing.sst")); err == nil {
+		t.Fatal("文件不存在时应返回错误")
+	}
+}
@@ -0,0 +1,51 @@
+package bplustree
+
+// TreeStats 描述树的形状，用于调整阶数与发现病态的结构
+type TreeStats struct {
+	Height        int          // 层数，只有一个根叶节点时为 1
+	InternalNodes int          // 内部节点数
+	LeafNodes     int          // 叶节点数
+	Entries       int          // 键值对总数
+	Levels        []LevelStats // 各层的统计，下标 0 为根所在层，最后一项为叶节点层
+}
+
+// LevelStats 是树中一层节点的统计。填充率为节点的关键词数与 MaxKeys 之比
+type LevelStats struct {
+	Nodes   int
+	AvgFill float64
+	MinFill float64
+}
+
+// Stats 遍历整棵树，返回树高、各类节点数、条目总数以及每层的平均与最小填充率
+func (bpt *BPlusTree) Stats() TreeStats {
+	var s TreeStats
+	for level := []*Node{bpt.root}; len(level) > 0; {
+		ls := LevelStats{Nodes: len(level), MinFill: 1}
+		total := 0
+		var next []*Node
+		for _, node := range level {
+			fill := float64(len(node.keys)) / MaxKeys
+			ls.MinFill = min(ls.MinFill, fill)
+			total += len(node.keys)
+			if node.isLeaf {
+				s.LeafNodes++
+				s.Entries += len(node.keys)
+			} else {
+				s.InternalNodes++
+				next = append(next, node.children...)
+			}
+		}
+		ls.AvgFill = float64(total) / float64(len(level)*MaxKeys)
+		s.Levels = append(s.Levels, ls)
+		level = next
+	}
+	s.Height = len(s.Levels)
+	return s
+}
+
+// Stats 在读锁保护下返回树形状的统计
+func (c *ConcurrentBPlusTree) Stats() TreeStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Stats()
+}
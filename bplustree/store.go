@@ -0,0 +1,381 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrBucketNotFound 表示指定名称的桶不存在，或桶已被删除
+var ErrBucketNotFound = errors.New("桶不存在")
+
+// ErrBucketExists 表示创建的桶已存在
+var ErrBucketExists = errors.New("桶已存在")
+
+// 目录条目（目录树中的字节值）布局：
+//
+//	[0:4] 魔数 "BKT\x01"
+//	[4:8] 桶的根节点页号
+//	[8:]  桶名，为空时是保存序列与计数器的系统桶（见 sequence.go）
+const (
+	catalogMagic     = "BKT\x01"
+	catalogEntrySize = 8
+)
+
+// Store 在一个文件中保存多棵命名的 B+ 树（桶），所有桶共用文件的页与空闲页链表。
+// 文件头指向的树是目录：key 为桶的编号，字节值记录桶名与桶的根节点页号；打开时目录被整体读入内存。
+// 桶的根节点页号在桶的生命周期内不变，目录条目只在创建与删除桶时写入。
+// 与 DiskBPlusTree 一样，写操作原地改写节点页、最后写文件头，单次写操作不是崩溃原子的；
+// 创建与删除桶时目录条目与节点页的写入顺序保证崩溃后最多泄漏一些页，目录不会指向未分配或已释放的页。
+// 所有桶的操作由一把互斥锁串行执行，因此可以在多个 goroutine 中使用
+type Store struct {
+	mu      sync.Mutex
+	tree    *DiskBPlusTree     // 目录树；操作桶时暂时把根节点换成桶的根
+	buckets map[string]*Bucket // 桶名 -> 桶
+	nextID  int                // 下一个新建桶的编号
+	system  *Bucket            // 保存序列与计数器的系统桶，第一次使用时创建
+	sysVals map[sysKey]*sysEntry
+}
+
+// Bucket 是 Store 中的一棵命名的树，key 与 value 均为 int。
+// 桶被 DeleteBucket 删除后，其方法返回 ErrBucketNotFound
+type Bucket struct {
+	store *Store
+	name  string
+	id    int             // 目录树中的 key
+	root  PageID          // 桶的根节点页号
+	blobs bool            // 桶保存字节值，只有系统桶如此
+	ops   *bucketCounters // 改名前后的句柄共用同一组计数
+}
+
+// OpenStore 打开 path 处的 Store 文件，文件不存在时创建一个没有桶的 Store。
+// opts 作用于文件中的全部树；WithSeedFile 不适用于 Store
+func OpenStore(path string, opts ...DiskOption) (*Store, error) {
+	pager, err := OpenFilePager(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewStore(pager, opts...)
+	if err != nil {
+		pager.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewStore 在 pages 之上打开一个 Store；pages 为空时初始化一个没有桶的 Store
+func NewStore(pages PageStore, opts ...DiskOption) (*Store, error) {
+	t, err := NewDiskBPlusTree(pages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{tree: t, buckets: make(map[string]*Bucket)}
+	var catalogErr error
+	err = t.ScanBytes(func(id int, data []byte) bool {
+		var b *Bucket
+		if b, catalogErr = decodeCatalogEntry(id, data); catalogErr != nil {
+			return false
+		}
+		if _, dup := s.buckets[b.name]; dup {
+			catalogErr = fmt.Errorf("打开 Store 失败：目录中的桶名 %q 重复", b.name)
+			return false
+		}
+		b.store = s
+		if b.name == "" {
+			// 桶名为空的是系统桶，见 sequence.go
+			b.blobs = true
+			s.system = b
+			return true
+		}
+		s.buckets[b.name] = b
+		s.nextID = max(s.nextID, id+1)
+		return true
+	})
+	if errors.Is(err, ErrIntValues) {
+		return nil, fmt.Errorf("打开 Store 失败：文件是单棵树的树文件，不是 Store 文件")
+	}
+	if err = errors.Join(err, catalogErr); err != nil {
+		return nil, err
+	}
+	if err := s.loadSystem(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func decodeCatalogEntry(id int, data []byte) (*Bucket, error) {
+	if len(data) < catalogEntrySize || string(data[:4]) != catalogMagic {
+		return nil, fmt.Errorf("打开 Store 失败：目录条目 %d 格式错误，文件可能不是 Store 文件", id)
+	}
+	return &Bucket{
+		name: string(data[catalogEntrySize:]),
+		id:   id,
+		root: PageID(binary.LittleEndian.Uint32(data[4:])),
+		ops:  new(bucketCounters),
+	}, nil
+}
+
+func (b *Bucket) encode() []byte {
+	data := make([]byte, catalogEntrySize, catalogEntrySize+len(b.name))
+	copy(data, catalogMagic)
+	binary.LittleEndian.PutUint32(data[4:], uint32(b.root))
+	return append(data, b.name...)
+}
+
+// CreateBucket 创建名为 name 的空桶；name 不能为空，同名的桶已存在时返回 ErrBucketExists
+func (s *Store) CreateBucket(name string) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(name, nil)
+}
+
+// 创建名为 name 的桶，fn 不为 nil 时以它写入桶的初始内容，最后才写目录条目：
+// fn 出错时桶的页归还空闲页链表，中途崩溃时桶不会出现在目录中。调用方须持有 s.mu
+func (s *Store) create(name string, fn func(t *DiskBPlusTree) error) (*Bucket, error) {
+	if name == "" {
+		return nil, fmt.Errorf("创建桶失败：桶名不能为空")
+	}
+	if _, ok := s.buckets[name]; ok {
+		return nil, fmt.Errorf("%w：%s", ErrBucketExists, name)
+	}
+	b, err := s.newBucket(name, false, fn)
+	if err != nil {
+		return nil, err
+	}
+	s.buckets[name] = b
+	return b, nil
+}
+
+// 分配新桶的根节点页，以 fn 写入初始内容后写入目录条目，见 create。blobs 表示桶保存字节值
+func (s *Store) newBucket(name string, blobs bool, fn func(t *DiskBPlusTree) error) (*Bucket, error) {
+	t := s.tree
+	t.mu.Lock()
+	root, err := t.allocate(true)
+	if err == nil {
+		err = t.writeNode(root)
+	}
+	if err == nil {
+		// 先写回文件头使新的根节点页计入文件，再写目录条目：
+		// 两者之间崩溃只会泄漏这一页，不会出现目录指向未分配的页
+		err = t.writeMeta()
+	}
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	b := &Bucket{store: s, name: name, id: s.nextID, root: root.id, blobs: blobs, ops: new(bucketCounters)}
+	if fn != nil {
+		if err := s.runLocked(b, fn); err != nil {
+			return nil, errors.Join(err, s.freeBucket(b))
+		}
+	}
+	if err := t.PutBytes(b.id, b.encode()); err != nil {
+		return nil, err
+	}
+	s.nextID++
+	return b, nil
+}
+
+// CreateBucketIfNotExists 返回名为 name 的桶，不存在时创建
+func (s *Store) CreateBucketIfNotExists(name string) (*Bucket, error) {
+	if b := s.Bucket(name); b != nil {
+		return b, nil
+	}
+	b, err := s.CreateBucket(name)
+	if errors.Is(err, ErrBucketExists) {
+		// 另一个 goroutine 抢先创建了同名的桶
+		if b := s.Bucket(name); b != nil {
+			return b, nil
+		}
+	}
+	return b, err
+}
+
+// Bucket 返回名为 name 的桶，不存在时返回 nil
+func (s *Store) Bucket(name string) *Bucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buckets[name]
+}
+
+// Buckets 按名称升序返回全部桶名
+func (s *Store) Buckets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenameBucket 把名为 oldName 的桶改名为 newName 并返回新名称的桶，桶中的数据与页不变。
+// 改名只替换目录条目：替换写入之前旧名称照常服务，之后旧的 *Bucket 返回 ErrBucketNotFound；
+// 替换先写入新条目再改指向，崩溃后目录中的桶名是旧名或新名之一。newName 已存在时返回 ErrBucketExists
+func (s *Store) RenameBucket(oldName, newName string) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[oldName]
+	if !ok {
+		return nil, fmt.Errorf("%w：%s", ErrBucketNotFound, oldName)
+	}
+	if newName == "" {
+		return nil, fmt.Errorf("桶改名失败：桶名不能为空")
+	}
+	if _, ok := s.buckets[newName]; ok {
+		return nil, fmt.Errorf("%w：%s", ErrBucketExists, newName)
+	}
+	nb := &Bucket{store: s, name: newName, id: b.id, root: b.root, ops: b.ops}
+	if err := s.tree.PutBytes(b.id, nb.encode()); err != nil {
+		return nil, err
+	}
+	delete(s.buckets, oldName)
+	s.buckets[newName] = nb
+	return nb, nil
+}
+
+// DropBucket 删除名为 name 的桶，同 DeleteBucket
+func (s *Store) DropBucket(name string) error {
+	return s.DeleteBucket(name)
+}
+
+// DeleteBucket 删除名为 name 的桶，桶占用的全部页归还空闲页链表。
+// 目录条目删除之前桶照常服务，之后已有的 *Bucket 返回 ErrBucketNotFound
+func (s *Store) DeleteBucket(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[name]
+	if !ok {
+		return fmt.Errorf("%w：%s", ErrBucketNotFound, name)
+	}
+	// 先删除目录条目再释放页：中途出错时最多泄漏一些页，目录不会指向已释放的页
+	if err := s.tree.RemoveBytes(b.id); err != nil {
+		return err
+	}
+	delete(s.buckets, name)
+	return s.freeBucket(b)
+}
+
+// 释放桶 b 占用的全部页，调用方须持有 s.mu 且 b 已不在目录中
+func (s *Store) freeBucket(b *Bucket) error {
+	t := s.tree
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer t.unpinAll()
+	old := t.meta
+	t.dropQuarantine(b.root)
+	if err := t.freeTree(b.root); err != nil {
+		return err
+	}
+	return t.finish(old)
+}
+
+// 释放以 id 为根的子树占用的全部页，调用方须持有锁
+func (t *DiskBPlusTree) freeTree(id PageID) error {
+	node, err := t.readNode(id)
+	if err != nil {
+		return err
+	}
+	for _, child := range node.children {
+		if err := t.freeTree(child); err != nil {
+			return err
+		}
+	}
+	return t.free(id)
+}
+
+// 以 b 的根节点在目录树上执行 fn，结束后把页的分配情况写回文件头。
+// 桶的根节点页号固定不变（见 fixedRoot），操作桶时不需要改写目录条目，
+// 因此与单棵的 DiskBPlusTree 一样，每次写操作先写节点页，最后才写文件头
+func (s *Store) run(b *Bucket, fn func(t *DiskBPlusTree) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[b.name] != b {
+		return fmt.Errorf("%w：%s", ErrBucketNotFound, b.name)
+	}
+	return s.runLocked(b, fn)
+}
+
+// 与 run 相同，但不检查 b 是否仍在目录中，调用方须持有 s.mu
+func (s *Store) runLocked(b *Bucket, fn func(t *DiskBPlusTree) error) error {
+	t := s.tree
+	t.mu.Lock()
+	before := t.meta
+	// 目录树的字节值标记不适用于桶，桶的值类型由 b.blobs 决定
+	t.meta.root, t.meta.blobs = b.root, b.blobs
+	t.deferMeta, t.fixedRoot = true, true
+	t.mu.Unlock()
+
+	err := fn(t)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.meta.root, t.meta.blobs = before.root, before.blobs
+	t.deferMeta, t.fixedRoot = false, false
+	if t.meta != before {
+		err = errors.Join(err, t.writeMeta())
+	}
+	return err
+}
+
+// Name 返回桶名
+func (b *Bucket) Name() string {
+	return b.name
+}
+
+// Insert 插入键值对，见 DiskBPlusTree.Insert
+func (b *Bucket) Insert(key, value int) error {
+	return b.count(&b.ops.inserts, b.store.run(b, func(t *DiskBPlusTree) error { return t.Insert(key, value) }))
+}
+
+// Remove 删除 key，见 DiskBPlusTree.Remove
+func (b *Bucket) Remove(key int) error {
+	return b.count(&b.ops.removes, b.store.run(b, func(t *DiskBPlusTree) error { return t.Remove(key) }))
+}
+
+// Modify 修改 key 对应的 value，见 DiskBPlusTree.Modify
+func (b *Bucket) Modify(key, newValue int) error {
+	return b.count(&b.ops.modifies, b.store.run(b, func(t *DiskBPlusTree) error { return t.Modify(key, newValue) }))
+}
+
+// Search 返回 key 对应的 value；若不存在返回 -1
+func (b *Bucket) Search(key int) (value int, err error) {
+	err = b.store.run(b, func(t *DiskBPlusTree) error {
+		value, err = t.Search(key)
+		return err
+	})
+	return value, b.count(&b.ops.searches, err)
+}
+
+// Scan 按 key 升序遍历桶中的全部键值对，fn 返回 false 时提前结束。
+// 遍历期间持有 Store 的锁，fn 中不得再调用该 Store 或其中任何桶的方法
+func (b *Bucket) Scan(fn func(key, value int) bool) error {
+	return b.store.run(b, func(t *DiskBPlusTree) error { return t.Scan(fn) })
+}
+
+// Quarantined 返回桶中因页损坏而被隔离的区间，见 DiskBPlusTree.Quarantined
+func (b *Bucket) Quarantined() []QuarantinedRange {
+	t := b.store.tree
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ranges []QuarantinedRange
+	for _, q := range t.quarantine {
+		if q.Root == b.root {
+			ranges = append(ranges, q)
+		}
+	}
+	return ranges
+}
+
+// Sync 将已写入的页刷到持久存储
+func (s *Store) Sync() error {
+	return s.tree.Sync()
+}
+
+// Close 刷盘并关闭底层存储
+func (s *Store) Close() error {
+	return s.tree.Close()
+}
@@ -0,0 +1,315 @@
+package bplustree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func openTempStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+func reopenStore(t *testing.T, s *Store, path string) *Store {
+	t.Helper()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func bucketContents(t *testing.T, b *Bucket) map[int]int {
+	t.Helper()
+	got := make(map[int]int)
+	if err := b.Scan(func(k, v int) bool {
+		got[k] = v
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int // 每个桶插入的条目数，足够大时根节点会分裂
+		del  int // 随后删除的条目数，足够大时根节点会降低一层
+	}{
+		{"empty", 0, 0},
+		{"single-leaf", 10, 3},
+		{"root-split", 3 * DiskMaxKeys, 0},
+		{"root-collapse", 3 * DiskMaxKeys, 3*DiskMaxKeys - 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, path := openTempStore(t)
+			a, err := s.CreateBucket("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := s.CreateBucket("b")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rootA := a.root
+			for i := range tt.n {
+				if err := a.Insert(i, i); err != nil {
+					t.Fatal(err)
+				}
+				if err := b.Insert(i, -i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := range tt.del {
+				if err := a.Remove(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if a.root != rootA {
+				t.Fatalf("桶的根节点页号从 %d 变为 %d", rootA, a.root)
+			}
+
+			s = reopenStore(t, s, path)
+			defer s.Close()
+			if got := s.Buckets(); !slices.Equal(got, []string{"a", "b"}) {
+				t.Fatalf("Buckets() = %v", got)
+			}
+			a, b = s.Bucket("a"), s.Bucket("b")
+			gotA, gotB := bucketContents(t, a), bucketContents(t, b)
+			if len(gotA) != tt.n-tt.del || len(gotB) != tt.n {
+				t.Fatalf("重新打开后桶 a 有 %d 个条目、桶 b 有 %d 个，期望 %d 与 %d", len(gotA), len(gotB), tt.n-tt.del, tt.n)
+			}
+			for i := tt.del; i < tt.n; i++ {
+				if gotA[i] != i || gotB[i] != -i {
+					t.Fatalf("key %d 的值为 %d 与 %d", i, gotA[i], gotB[i])
+				}
+			}
+			if v, err := a.Search(tt.n); v != -1 || err != nil {
+				t.Fatalf("Search 不存在的 key 返回 %d, %v", v, err)
+			}
+		})
+	}
+}
+
+// 删除桶后其页归还空闲页链表，重新创建并写入同样多的数据时文件不再增长
+func TestStoreDeleteBucket(t *testing.T) {
+	s, path := openTempStore(t)
+	fill := func(name string) {
+		b, err := s.CreateBucket(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range 2 * DiskMaxKeys {
+			b.Insert(i, i)
+		}
+	}
+	fill("a")
+	fill("b")
+	info, _ := os.Stat(path)
+	stale := s.Bucket("a")
+	if err := s.DeleteBucket("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stale.Insert(1, 1); !errors.Is(err, ErrBucketNotFound) {
+		t.Fatalf("已删除的桶返回 %v，期望 ErrBucketNotFound", err)
+	}
+	fill("c")
+	if after, _ := os.Stat(path); after.Size() > info.Size() {
+		t.Fatalf("文件从 %d 字节增长到 %d 字节，删除的桶的页没有被复用", info.Size(), after.Size())
+	}
+	s = reopenStore(t, s, path)
+	defer s.Close()
+	if got := s.Buckets(); !slices.Equal(got, []string{"b", "c"}) {
+		t.Fatalf("Buckets() = %v", got)
+	}
+	if got := bucketContents(t, s.Bucket("c")); len(got) != 2*DiskMaxKeys {
+		t.Fatalf("桶 c 有 %d 个条目", len(got))
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s *Store) error
+		want error // nil 表示只要求返回错误
+	}{
+		{"create-empty-name", func(s *Store) error { _, err := s.CreateBucket(""); return err }, nil},
+		{"create-duplicate", func(s *Store) error {
+			s.CreateBucket("a")
+			_, err := s.CreateBucket("a")
+			return err
+		}, ErrBucketExists},
+		{"delete-missing", func(s *Store) error { return s.DeleteBucket("a") }, ErrBucketNotFound},
+		{"drop-missing", func(s *Store) error { return s.DropBucket("a") }, ErrBucketNotFound},
+		{"rename-missing", func(s *Store) error { _, err := s.RenameBucket("a", "b"); return err }, ErrBucketNotFound},
+		{"rename-to-existing", func(s *Store) error {
+			s.CreateBucket("a")
+			s.CreateBucket("b")
+			_, err := s.RenameBucket("a", "b")
+			return err
+		}, ErrBucketExists},
+		{"rename-to-empty", func(s *Store) error {
+			s.CreateBucket("a")
+			_, err := s.RenameBucket("a", "")
+			return err
+		}, nil},
+		{"create-if-not-exists", func(s *Store) error {
+			a, _ := s.CreateBucket("a")
+			b, err := s.CreateBucketIfNotExists("a")
+			if err != nil || a != b {
+				return errors.New("CreateBucketIfNotExists 没有返回已有的桶")
+			}
+			return ErrBucketExists
+		}, ErrBucketExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := openTempStore(t)
+			defer s.Close()
+			err := tt.run(s)
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Fatalf("返回 %v，期望 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenStoreOnTreeFile(t *testing.T) {
+	tree, path := openTempDiskTree(t)
+	tree.Insert(1, 1)
+	tree.Close()
+	if s, err := OpenStore(path); err == nil {
+		s.Close()
+		t.Fatal("以 Store 打开单棵树的树文件应失败")
+	}
+}
+
+// 一个桶中的页损坏只隔离该桶的区间，其他桶中相同的 key 照常服务
+func TestStoreQuarantineIsPerBucket(t *testing.T) {
+	s, path := openTempStore(t)
+	a, _ := s.CreateBucket("a")
+	b, _ := s.CreateBucket("b")
+	for i := range 2 * DiskMaxKeys {
+		a.Insert(i, i)
+		b.Insert(i, i)
+	}
+	root, err := s.tree.readNode(a.root)
+	if err != nil || root.isLeaf {
+		t.Fatalf("桶 a 的根节点应为内部节点：%v", err)
+	}
+	leaf := root.children[0]
+	s.Close()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{0xff, 0xff}, int64(leaf)*PageSize+pageHeaderSize)
+	f.Close()
+
+	s, err = OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	a, b = s.Bucket("a"), s.Bucket("b")
+	if _, err := a.Search(0); !errors.Is(err, ErrRangeUnavailable) {
+		t.Fatalf("桶 a 返回 %v，期望 ErrRangeUnavailable", err)
+	}
+	if v, err := b.Search(0); err != nil || v != 0 {
+		t.Fatalf("桶 b 的 Search(0) = %d, %v，不应受桶 a 的隔离影响", v, err)
+	}
+	if got := a.Quarantined(); len(got) != 1 || got[0].Page != leaf {
+		t.Fatalf("桶 a 的隔离区间为 %v", got)
+	}
+	if got := b.Quarantined(); len(got) != 0 {
+		t.Fatalf("桶 b 的隔离区间为 %v", got)
+	}
+	if err := s.DeleteBucket("a"); err != nil && !errors.As(err, new(*CorruptPageError)) {
+		t.Fatal(err)
+	}
+	if got := s.tree.Quarantined(); len(got) != 0 {
+		t.Fatalf("删除桶后仍有隔离区间 %v", got)
+	}
+}
+
+func TestStoreRenameBucket(t *testing.T) {
+	s, path := openTempStore(t)
+	a, _ := s.CreateBucket("a")
+	for i := range 2 * DiskMaxKeys {
+		a.Insert(i, i)
+	}
+	pages, _ := os.Stat(path)
+	b, err := s.RenameBucket("a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name() != "b" || b.root != a.root {
+		t.Fatalf("改名后的桶为 %q，根节点页 %d，期望 %q 与 %d", b.Name(), b.root, "b", a.root)
+	}
+	if _, err := a.Search(0); !errors.Is(err, ErrBucketNotFound) {
+		t.Fatalf("旧名称的桶返回 %v，期望 ErrBucketNotFound", err)
+	}
+	if s.Bucket("a") != nil || s.Bucket("b") != b {
+		t.Fatal("改名后 Bucket 返回的桶不正确")
+	}
+	if st, _ := b.Stats(); st.Inserts != 2*DiskMaxKeys {
+		t.Fatalf("改名后插入计数为 %d", st.Inserts)
+	}
+	// 改名只改写目录条目，不复制桶的数据
+	if after, _ := os.Stat(path); after.Size() > pages.Size()+PageSize {
+		t.Fatalf("改名使文件从 %d 字节增长到 %d 字节", pages.Size(), after.Size())
+	}
+	s = reopenStore(t, s, path)
+	defer s.Close()
+	if got := s.Buckets(); !slices.Equal(got, []string{"b"}) {
+		t.Fatalf("Buckets() = %v", got)
+	}
+	if got := bucketContents(t, s.Bucket("b")); len(got) != 2*DiskMaxKeys {
+		t.Fatalf("桶 b 有 %d 个条目", len(got))
+	}
+	// 旧名称可以重新创建为一个无关的空桶
+	a, err = s.CreateBucket("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bucketContents(t, a); len(got) != 0 {
+		t.Fatalf("新建的桶 a 有 %d 个条目", len(got))
+	}
+}
+
+// DropBucket 与 DeleteBucket 相同：页归还空闲页链表，旧的句柄失效
+func TestStoreDropBucket(t *testing.T) {
+	s, path := openTempStore(t)
+	defer s.Close()
+	a, _ := s.CreateBucket("a")
+	for i := range 2 * DiskMaxKeys {
+		a.Insert(i, i)
+	}
+	info, _ := os.Stat(path)
+	if err := s.DropBucket("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Insert(1, 1); !errors.Is(err, ErrBucketNotFound) {
+		t.Fatalf("已删除的桶返回 %v，期望 ErrBucketNotFound", err)
+	}
+	b, _ := s.CreateBucket("b")
+	for i := range 2 * DiskMaxKeys {
+		b.Insert(i, i)
+	}
+	if after, _ := os.Stat(path); after.Size() > info.Size() {
+		t.Fatalf("文件从 %d 字节增长到 %d 字节，删除的桶的页没有被复用", info.Size(), after.Size())
+	}
+}
@@ -0,0 +1,93 @@
+package bplustree
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// BucketStats 是一个桶的统计，供多租户场景把负载与增长归属到具体的桶。
+// 操作次数为本次打开 Store 以来的累计值，不写入文件；键数与占用空间在每次调用时遍历桶得到
+type BucketStats struct {
+	Name     string `json:"name"`
+	Inserts  uint64 `json:"inserts"`  // 成功插入的键值对数
+	Removes  uint64 `json:"removes"`  // 成功删除的键数
+	Modifies uint64 `json:"modifies"` // 成功修改的键数
+	Searches uint64 `json:"searches"` // 查找次数，未找到的 key 也计入
+	Keys     int    `json:"keys"`     // 桶中的键值对数
+	Pages    int    `json:"pages"`    // 桶的节点占用的页数
+	Bytes    int64  `json:"bytes"`    // 桶占用的字节数，即 Pages × PageSize
+}
+
+// 桶的累计操作次数。Bucket 的方法在 Store 的锁之外读取计数，因此使用原子计数
+type bucketCounters struct {
+	inserts, removes, modifies, searches atomic.Uint64
+}
+
+// 操作成功时把 n 加一，原样返回 err
+func (b *Bucket) count(n *atomic.Uint64, err error) error {
+	if err == nil {
+		n.Add(1)
+	}
+	return err
+}
+
+// Stats 返回桶的操作次数、键数与占用空间，遍历桶的全部节点页，开销与桶的大小成正比
+func (b *Bucket) Stats() (BucketStats, error) {
+	s := BucketStats{
+		Name:     b.name,
+		Inserts:  b.ops.inserts.Load(),
+		Removes:  b.ops.removes.Load(),
+		Modifies: b.ops.modifies.Load(),
+		Searches: b.ops.searches.Load(),
+	}
+	err := b.store.run(b, func(t *DiskBPlusTree) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		defer t.unpinAll()
+		var err error
+		s.Pages, s.Keys, err = t.treeUsage(t.meta.root)
+		return err
+	})
+	s.Bytes = int64(s.Pages) * PageSize
+	return s, err
+}
+
+// Stats 按桶名升序返回全部桶的统计，见 Bucket.Stats
+func (s *Store) Stats() ([]BucketStats, error) {
+	s.mu.Lock()
+	buckets := make([]*Bucket, 0, len(s.buckets))
+	for _, b := range s.buckets {
+		buckets = append(buckets, b)
+	}
+	s.mu.Unlock()
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].name < buckets[j].name })
+	stats := make([]BucketStats, 0, len(buckets))
+	for _, b := range buckets {
+		st, err := b.Stats()
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
+
+// 统计以 id 为根的子树占用的页数与其中的键值对数，调用方须持有锁
+func (t *DiskBPlusTree) treeUsage(id PageID) (pages, keys int, err error) {
+	node, err := t.readNode(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if node.isLeaf {
+		return 1, len(node.keys), nil
+	}
+	pages = 1
+	for _, child := range node.children {
+		p, k, err := t.treeUsage(child)
+		if err != nil {
+			return 0, 0, err
+		}
+		pages, keys = pages+p, keys+k
+	}
+	return pages, keys, nil
+}
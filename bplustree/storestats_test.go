@@ -0,0 +1,60 @@
+package bplustree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBucketStats(t *testing.T) {
+	s, path := openTempStore(t)
+	a, _ := s.CreateBucket("a")
+	b, _ := s.CreateBucket("b")
+	for i := range 3 * DiskMaxKeys {
+		a.Insert(i, i)
+	}
+	a.Modify(0, 1)
+	a.Remove(1)
+	a.Remove(1) // 失败的删除不计入
+	a.Search(2)
+	a.Search(-1)
+	b.Insert(1, 1)
+
+	tests := []struct {
+		name string
+		want BucketStats
+	}{
+		{"a", BucketStats{Name: "a", Inserts: 3 * DiskMaxKeys, Removes: 1, Modifies: 1, Searches: 2, Keys: 3*DiskMaxKeys - 1}},
+		{"b", BucketStats{Name: "b", Inserts: 1, Keys: 1, Pages: 1, Bytes: PageSize}},
+	}
+	all, err := s.Stats()
+	if err != nil || len(all) != len(tests) {
+		t.Fatalf("Store.Stats() = %v, %v", all, err)
+	}
+	for i, tt := range tests {
+		got := all[i]
+		if tt.want.Pages == 0 {
+			// 多层的桶只检查页数与字节数的关系
+			if got.Pages < 4 || got.Bytes != int64(got.Pages)*PageSize {
+				t.Errorf("%s: Pages = %d, Bytes = %d", tt.name, got.Pages, got.Bytes)
+			}
+			tt.want.Pages, tt.want.Bytes = got.Pages, got.Bytes
+		}
+		if got != tt.want {
+			t.Errorf("%s: Stats = %+v，期望 %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// 操作次数不写入文件，重新打开后从零开始；键数与占用空间不变
+	s = reopenStore(t, s, path)
+	defer s.Close()
+	got, err := s.Bucket("a").Stats()
+	if err != nil || got.Inserts != 0 || got.Keys != 3*DiskMaxKeys-1 || got.Pages != all[0].Pages {
+		t.Fatalf("重新打开后 Stats = %+v, %v", got, err)
+	}
+
+	stale := s.Bucket("b")
+	s.DeleteBucket("b")
+	if _, err := stale.Stats(); !errors.Is(err, ErrBucketNotFound) {
+		t.Fatalf("已删除的桶返回 %v，期望 ErrBucketNotFound", err)
+	}
+}
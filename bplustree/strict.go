@@ -0,0 +1,119 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTreeCorrupt 表示树的内部状态已被破坏（例如出现空的非根节点），严格模式下的操作因此中止
+var ErrTreeCorrupt = errors.New("树的内部状态异常")
+
+// InternalError 记录严格模式下因内部状态异常而中止的操作，可用 errors.Is(err, ErrTreeCorrupt) 判断
+type InternalError struct {
+	Op    string // 中止的操作，如 "insert"、"remove"
+	Key   int    // 操作的 key，PrintTree 等不针对单个 key 的操作为 0
+	Cause error  // 发现的异常，如下标越界的子节点或键值数量不一致的叶节点
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("%s key=%d 失败：%v：%v", e.Op, e.Key, ErrTreeCorrupt, e.Cause)
+}
+
+func (e *InternalError) Unwrap() error {
+	return ErrTreeCorrupt
+}
+
+// WithStrict 开启严格模式：Insert、Remove、Modify、Search 在修改或读取树之前，
+// 先沿下降路径检查将要访问的每个下标（子节点、兄弟节点、叶节点中的值），
+// PrintTree 与 PrintLeafValues 先校验整棵树；发现异常时操作不做任何修改，转为 *InternalError 返回，
+// 库的使用者不会看到来自树内部的越界 panic。
+// 首次出错后树即停止服务：Remove、Modify 返回同一个错误，Insert 不再生效，Search 返回 -1，
+// 没有错误返回值的方法可通过 Err 取得该错误。检查使每次操作多一次自根的下降
+func WithStrict() Option {
+	return func(bpt *BPlusTree) {
+		bpt.strict = true
+	}
+}
+
+// Err 返回严格模式下使树停止服务的内部错误；树正常或未开启严格模式时返回 nil
+func (bpt *BPlusTree) Err() error {
+	if e := bpt.err.Load(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// 记录使树停止服务的内部错误并返回它。
+// ConcurrentBPlusTree 的多个读者可能同时出错，只保留最先记录的错误
+func (bpt *BPlusTree) fail(op string, key int, cause error) error {
+	if bpt.err.CompareAndSwap(nil, &InternalError{Op: op, Key: key, Cause: cause}) {
+		if bpt.trace != nil {
+			bpt.tracef("step=internal-error err=%v", bpt.err.Load())
+		}
+		if bpt.logger != nil {
+			bpt.logger.Error("树因内部错误停止服务", "op", op, "key", key, "err", bpt.err.Load())
+		}
+	}
+	return bpt.err.Load()
+}
+
+// 严格模式下在操作开始前沿 key 的下降路径检查将要访问的下标，发现异常时记录并返回内部错误。
+// siblings 为 true 时一并检查路径上每个节点的左右兄弟，删除引起的借补与合并会访问它们
+func (bpt *BPlusTree) checkPath(op string, key int, siblings bool) error {
+	node := bpt.root
+	if err := checkNode(node, true); err != nil {
+		return bpt.fail(op, key, err)
+	}
+	for !node.isLeaf {
+		i := childIndex(node, key)
+		if i < 0 || i >= len(node.children) {
+			return bpt.fail(op, key, fmt.Errorf("内部节点 %v 选中的子节点下标 %d 越界（共 %d 个子节点）", node.keys, i, len(node.children)))
+		}
+		child := node.children[i]
+		if err := checkNode(child, false); err != nil {
+			return bpt.fail(op, key, err)
+		}
+		if siblings {
+			for _, j := range [2]int{i - 1, i + 1} {
+				if j < 0 || j >= len(node.children) {
+					continue
+				}
+				sibling := node.children[j]
+				if err := checkNode(sibling, false); err != nil {
+					return bpt.fail(op, key, fmt.Errorf("兄弟节点：%w", err))
+				}
+				if sibling.isLeaf != child.isLeaf {
+					return bpt.fail(op, key, fmt.Errorf("兄弟节点 %v 与 %v 不在同一层", sibling.keys, child.keys))
+				}
+			}
+		}
+		node = child
+	}
+	return nil
+}
+
+// 检查单个节点自身的下标不变式：叶节点的键值数量一致，内部节点的关键词与子节点一一对应且至少有一个子节点，
+// 非根节点不为空
+func checkNode(node *Node, isRoot bool) error {
+	switch {
+	case node == nil:
+		return errors.New("子节点为 nil")
+	case node.isLeaf && len(node.values) != len(node.keys):
+		return fmt.Errorf("叶节点 %v 有 %d 个键、%d 个值", node.keys, len(node.keys), len(node.values))
+	case !node.isLeaf && len(node.children) == 0:
+		return fmt.Errorf("内部节点 %v 没有子节点", node.keys)
+	case !node.isLeaf && len(node.children) != len(node.keys):
+		return fmt.Errorf("内部节点 %v 有 %d 个关键词、%d 个子节点", node.keys, len(node.keys), len(node.children))
+	case !isRoot && len(node.keys) == 0:
+		return errors.New("非根节点为空")
+	}
+	return nil
+}
+
+// 严格模式下在遍历整棵树之前校验其结构，发现异常时记录并返回内部错误
+func (bpt *BPlusTree) checkTree(op string) error {
+	if err := bpt.validate(); err != nil {
+		return bpt.fail(op, 0, err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package bplustree
+
+import (
+	"errors"
+	"testing"
+)
+
+// 构造一棵三层的严格模式树，key 为 0..29
+func strictTree(t *testing.T) *BPlusTree {
+	t.Helper()
+	bpt := NewBPlusTree(WithStrict())
+	for i := range 30 {
+		bpt.Insert(i, i*10)
+	}
+	if err := bpt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return bpt
+}
+
+func TestStrictCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(bpt *BPlusTree) // 破坏 key 0 所在路径上的节点
+		op      func(bpt *BPlusTree) error
+	}{
+		{
+			name:    "internal-without-children-search",
+			corrupt: func(bpt *BPlusTree) { bpt.root.children[0].children = nil },
+			op: func(bpt *BPlusTree) error {
+				if v := bpt.Search(0); v != -1 {
+					return errors.New("Search 应返回 -1")
+				}
+				return bpt.Err()
+			},
+		},
+		{
+			name:    "nil-child-insert",
+			corrupt: func(bpt *BPlusTree) { bpt.root.children[0].children[0] = nil },
+			op: func(bpt *BPlusTree) error {
+				bpt.Insert(-1, 1)
+				return bpt.Err()
+			},
+		},
+		{
+			name: "keys-longer-than-children",
+			corrupt: func(bpt *BPlusTree) {
+				n := bpt.root.children[0]
+				n.children = n.children[:1]
+			},
+			op: func(bpt *BPlusTree) error { return bpt.Modify(0, 1) },
+		},
+		{
+			name:    "leaf-missing-values",
+			corrupt: func(bpt *BPlusTree) { bpt.leftmostLeaf().values = nil },
+			op:      func(bpt *BPlusTree) error { return bpt.Remove(0) },
+		},
+		{
+			name: "empty-sibling-remove",
+			corrupt: func(bpt *BPlusTree) {
+				leaf := bpt.leftmostLeaf().next
+				leaf.keys, leaf.values = leaf.keys[:0], leaf.values[:0]
+			},
+			op: func(bpt *BPlusTree) error { return bpt.Remove(0) },
+		},
+		{
+			name: "sibling-on-wrong-level",
+			corrupt: func(bpt *BPlusTree) {
+				_, path := bpt.findPath(0)
+				parent := path[len(path)-1].node
+				parent.children[1] = parent
+			},
+			op: func(bpt *BPlusTree) error { return bpt.Remove(0) },
+		},
+		{
+			name:    "print-tree",
+			corrupt: func(bpt *BPlusTree) { bpt.root.children[1].children[0] = nil },
+			op: func(bpt *BPlusTree) error {
+				bpt.PrintTree()
+				return bpt.Err()
+			},
+		},
+		{
+			name:    "print-leaf-values",
+			corrupt: func(bpt *BPlusTree) { bpt.root.children[0].children = nil },
+			op: func(bpt *BPlusTree) error {
+				bpt.PrintLeafValues()
+				return bpt.Err()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bpt := strictTree(t)
+			tt.corrupt(bpt)
+			leaf := bpt.findLeaf(bpt.root, 29)
+			keys := append([]int(nil), leaf.keys...)
+
+			err := tt.op(bpt)
+			var internal *InternalError
+			if !errors.Is(err, ErrTreeCorrupt) || !errors.As(err, &internal) || internal.Cause == nil {
+				t.Fatalf("返回 %v，期望 *InternalError", err)
+			}
+			// 树停止服务：此后的操作返回同一个错误，且不再修改树
+			if err := bpt.Remove(29); err != internal {
+				t.Fatalf("停止服务后 Remove 返回 %v", err)
+			}
+			bpt.Insert(100, 100)
+			if v := bpt.Search(29); v != -1 {
+				t.Fatalf("停止服务后 Search 返回 %d", v)
+			}
+			if got := leaf.keys; len(got) != len(keys) || got[len(got)-1] != 29 {
+				t.Fatalf("停止服务后树仍被修改：%v", got)
+			}
+		})
+	}
+}
+
+// 检查在修改树之前进行：发现兄弟节点异常的 Remove 不会删除目标 key
+func TestStrictFailsBeforeModifying(t *testing.T) {
+	bpt := strictTree(t)
+	leaf := bpt.leftmostLeaf()
+	leaf.next.values = nil
+	if err := bpt.Remove(0); !errors.Is(err, ErrTreeCorrupt) {
+		t.Fatalf("Remove 返回 %v", err)
+	}
+	if leaf.keys[0] != 0 || leaf.values[0] != 0 {
+		t.Fatalf("出错的 Remove 修改了叶节点：%v", leaf.keys)
+	}
+}
+
+func TestStrictWellFormed(t *testing.T) {
+	bpt := strictTree(t)
+	for i := range 30 {
+		if v := bpt.Search(i); v != i*10 {
+			t.Fatalf("Search(%d) = %d", i, v)
+		}
+	}
+	for i := range 25 {
+		if err := bpt.Remove(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bpt.Modify(29, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := bpt.Remove(100); err == nil || errors.Is(err, ErrTreeCorrupt) {
+		t.Fatalf("删除不存在的 key 返回 %v，期望普通错误", err)
+	}
+	if err := bpt.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bpt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 偏置分裂产生的最右叶节点只有一个键时，删除该键使叶节点为空，随后由借补或合并移除
+func TestRemoveEmptiesBiasedTail(t *testing.T) {
+	for _, n := range []int{4, 5, 50} {
+		bpt := NewBPlusTree(WithSplitBias(0.9))
+		for i := range n {
+			bpt.Insert(i, i)
+		}
+		// 从最大的键开始删除，每次都落在最右叶节点上
+		for i := n - 1; i >= 0; i-- {
+			if err := bpt.Remove(i); err != nil {
+				t.Fatal(err)
+			}
+			if err := bpt.Validate(); err != nil {
+				t.Fatalf("n=%d 删除 %d 后：%v", n, i, err)
+			}
+			if i > 0 && bpt.Search(i-1) != i-1 {
+				t.Fatalf("n=%d 删除 %d 后找不到 %d", n, i, i-1)
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package bplustree
+
+// 非 Unix 平台不支持对目录刷盘，目录项的持久化由文件系统自行保证
+func syncDir(dir string) error {
+	return nil
+}
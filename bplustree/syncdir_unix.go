@@ -0,0 +1,17 @@
+//go:build unix
+
+package bplustree
+
+import (
+	"errors"
+	"os"
+)
+
+// 刷盘目录本身，使其中文件的创建、重命名与删除在崩溃后仍然有效
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
@@ -0,0 +1,44 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout 表示操作超过了设定的时限而被中止
+var ErrTimeout = errors.New("操作超时")
+
+// WithOpTimeout 为磁盘树的 Insert/Remove/Modify/Search 设置时限：
+// 下降过程中每读取一页前检查一次，超时则中止并返回包装了 ErrTimeout 的错误。
+// 时限只作用于只读的下降阶段，一旦开始写页（分裂、借补、合并）便会执行完毕，
+// 以免留下写了一半的结构。单次阻塞的页读取不会被打断
+func WithOpTimeout(d time.Duration) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.opTimeout = d
+	}
+}
+
+// WithScanTimeout 为磁盘树的 Scan 设置时限，每读取一个叶节点前检查一次
+func WithScanTimeout(d time.Duration) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.scanTimeout = d
+	}
+}
+
+// 开始一次时限为 d 的操作；d 不大于 0 表示不限时
+func (t *DiskBPlusTree) startDeadline(d time.Duration) {
+	t.started = time.Now()
+	t.deadline = time.Time{}
+	if d > 0 {
+		t.deadline = t.started.Add(d)
+	}
+}
+
+// 检查当前操作是否已超时
+func (t *DiskBPlusTree) checkDeadline() error {
+	if t.deadline.IsZero() || time.Now().Before(t.deadline) {
+		return nil
+	}
+	return fmt.Errorf("%w：已耗时 %s", ErrTimeout, time.Since(t.started).Round(time.Microsecond))
+}
@@ -0,0 +1,55 @@
+package bplustree
+
+import "time"
+
+// TimeTree 是以 time.Time 为键的 OrderedTree，按时刻先后排序，用于时间序列索引。
+// 插入时去掉键中的单调时钟读数，使 time.Now() 得到的键与解析得到的键按同一时钟比较
+type TimeTree[V any] struct {
+	*OrderedTree[time.Time, V]
+}
+
+// NewTimeTree 创建一棵以时刻为键的空树
+func NewTimeTree[V any]() *TimeTree[V] {
+	t := NewOrderedTree[time.Time, V](time.Time.Compare)
+	t.clone = func(ts time.Time) time.Time { return ts.Round(0) }
+	return &TimeTree[V]{t}
+}
+
+// RangeSince 按时间顺序对所有不早于 since 的条目调用 fn，fn 返回 false 时提前结束
+func (t *TimeTree[V]) RangeSince(since time.Time, fn func(ts time.Time, value V) bool) {
+	t.ascend(since, fn)
+}
+
+// TimeBucket 是按时间截断分组后的一组条目
+type TimeBucket[V any] struct {
+	Start  time.Time // 分组的起点，即组内条目时刻按分组宽度截断后的值
+	Values []V       // 组内条目的值，按时间顺序排列
+}
+
+// Buckets 将 [lo, hi) 内的条目按 time.Truncate(d) 的结果分组，按时间顺序返回非空的分组。
+// 与 time.Truncate 相同，分组边界按零时刻起算，以天为宽度时对齐到 UTC 零点。d 不为正时返回 nil
+func (t *TimeTree[V]) Buckets(lo, hi time.Time, d time.Duration) []TimeBucket[V] {
+	if d <= 0 {
+		return nil
+	}
+	var buckets []TimeBucket[V]
+	t.ascend(lo, func(ts time.Time, v V) bool {
+		if !ts.Before(hi) {
+			return false
+		}
+		start := BucketStart(ts, d)
+		if n := len(buckets); n == 0 || !buckets[n-1].Start.Equal(start) {
+			buckets = append(buckets, TimeBucket[V]{Start: start})
+		}
+		b := &buckets[len(buckets)-1]
+		b.Values = append(b.Values, v)
+		return true
+	})
+	return buckets
+}
+
+// BucketStart 返回 ts 所在的宽度为 d 的分组的起点，与 Buckets 的分组规则一致，
+// 可用于为按时间分组的汇总生成键
+func BucketStart(ts time.Time, d time.Duration) time.Time {
+	return ts.Truncate(d)
+}
@@ -0,0 +1,34 @@
+package bplustree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithTrace 开启教学用的逐步追踪：每个操作及其内部步骤（下降路径与比较、
+// 分裂、借补、合并等决策）以 "op=... key=value" / "step=... key=value" 的结构化行写入 w
+func WithTrace(w io.Writer) Option {
+	return func(bpt *BPlusTree) {
+		bpt.trace = w
+	}
+}
+
+// 写入一行追踪信息；未开启追踪时不做任何事。步骤行缩进两格，便于与所属操作区分
+func (bpt *BPlusTree) tracef(format string, args ...any) {
+	if bpt.trace == nil {
+		return
+	}
+	if strings.HasPrefix(format, "step=") {
+		io.WriteString(bpt.trace, "  ")
+	}
+	fmt.Fprintf(bpt.trace, format+"\n", args...)
+}
+
+// 描述下降时选择第 i 个子节点的比较依据
+func routeReason(node *Node, key, i int) string {
+	if key <= node.keys[i] {
+		return fmt.Sprintf("%d<=%d", key, node.keys[i])
+	}
+	return fmt.Sprintf("%d>%d", key, node.keys[len(node.keys)-1])
+}
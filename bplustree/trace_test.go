@@ -0,0 +1,51 @@
+package bplustree
+
+import (
+	"strings"
+	"testing"
+)
+
+// 未开启追踪时，写操作与查找不应因追踪参数装箱而分配内存
+func TestTraceDisabledNoAllocs(t *testing.T) {
+	bpt := NewBPlusTree()
+	for i := range 100 {
+		bpt.Insert(i, i)
+	}
+	tests := []struct {
+		name string
+		op   func()
+	}{
+		{"search", func() { bpt.Search(42) }},
+		{"modify", func() { bpt.Modify(42, 7) }},
+		{"search-missing", func() { bpt.Search(1000) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := testing.AllocsPerRun(100, tt.op); n != 0 {
+				t.Fatalf("%s 每次分配 %v 次，期望 0", tt.name, n)
+			}
+		})
+	}
+}
+
+func TestTraceOutput(t *testing.T) {
+	var sb strings.Builder
+	bpt := NewBPlusTree(WithTrace(&sb))
+	for i := range 5 {
+		bpt.Insert(i, i)
+	}
+	bpt.Search(3)
+	bpt.Remove(3)
+	out := sb.String()
+	for _, want := range []string{
+		"op=insert key=4 value=4",
+		"  step=split-leaf",
+		"op=search key=3",
+		"  step=result value=3",
+		"op=remove key=3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("追踪输出缺少 %q：\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package bplustree
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithClock 设置树判断条目是否过期时使用的时钟，默认为 time.Now。测试中可以注入可控的时钟
+func WithClock(now func() time.Time) Option {
+	return func(bpt *BPlusTree) {
+		bpt.now = now
+	}
+}
+
+func (bpt *BPlusTree) clock() time.Time {
+	if bpt.now != nil {
+		return bpt.now()
+	}
+	return time.Now()
+}
+
+// InsertWithTTL 插入键值对，条目在 ttl 之后过期；ttl 不为正时与 Insert 相同，永不过期。
+// 过期时间按 key 记录，对该 key 的全部重复条目生效：再次以 InsertWithTTL 插入同一个 key 会刷新过期时间，
+// 以 Insert 插入则清除过期时间（key 已过期时先删除过期的旧条目），删除该 key 的最后一个条目时也会清除它。
+// 过期的条目对 Search、Modify 与 Range 不可见（惰性过期），但仍占用节点，
+// 直到 SweepExpired 或后台清理器将其删除；Min、Max 与其他遍历在删除之前仍会看到它们
+func (bpt *BPlusTree) InsertWithTTL(key, value int, ttl time.Duration) {
+	bpt.Insert(key, value)
+	if ttl <= 0 || bpt.err.Load() != nil {
+		return
+	}
+	if bpt.ttl == nil {
+		bpt.ttl = make(map[int]time.Time)
+	}
+	bpt.ttl[key] = bpt.clock().Add(ttl)
+}
+
+// 报告 key 是否已过期；没有设置过期时间的 key 永不过期
+func (bpt *BPlusTree) expired(key int) bool {
+	if len(bpt.ttl) == 0 {
+		return false
+	}
+	deadline, ok := bpt.ttl[key]
+	return ok && !bpt.clock().Before(deadline)
+}
+
+// 清除 key 的过期时间，供 Insert 使用；key 已过期时先删除它的全部旧条目，避免旧条目随新条目重新可见
+func (bpt *BPlusTree) clearDeadline(key int) {
+	if _, ok := bpt.ttl[key]; !ok {
+		return
+	}
+	if bpt.expired(key) {
+		bpt.removeKeys([]int{key})
+	}
+	delete(bpt.ttl, key)
+}
+
+// 报告树中是否还有 key 的条目，已过期而尚未删除的条目也计算在内
+func (bpt *BPlusTree) contains(key int) bool {
+	leaf := bpt.findLeaf(bpt.root, key)
+	pos := sort.SearchInts(leaf.keys, key)
+	return pos < len(leaf.keys) && leaf.keys[pos] == key
+}
+
+// SweepExpired 沿叶节点链表找出全部已过期的条目并删除，返回删除的条目数
+func (bpt *BPlusTree) SweepExpired() int {
+	return bpt.removeKeys(bpt.expiredKeys())
+}
+
+// 沿叶节点链表收集已过期的 key，重复的 key 只出现一次
+func (bpt *BPlusTree) expiredKeys() []int {
+	if len(bpt.ttl) == 0 || bpt.err.Load() != nil {
+		return nil
+	}
+	var expired []int
+	for leaf := bpt.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for _, k := range leaf.keys {
+			if n := len(expired); (n == 0 || expired[n-1] != k) && bpt.expired(k) {
+				expired = append(expired, k)
+			}
+		}
+	}
+	return expired
+}
+
+// 删除 keys 中每个 key 的全部条目，返回删除的条目数
+func (bpt *BPlusTree) removeKeys(keys []int) int {
+	removed := 0
+	for _, k := range keys {
+		for bpt.Remove(k) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// InsertWithTTL 在写锁保护下插入一个会过期的键值对
+func (c *ConcurrentBPlusTree) InsertWithTTL(key, value int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	c.detachForks()
+	c.tree.InsertWithTTL(key, value, ttl)
+}
+
+// SweepExpired 在写锁保护下删除全部已过期的条目，返回删除的条目数。
+// 没有过期条目时不算作一次写操作，不会触发只读句柄的写时复制
+func (c *ConcurrentBPlusTree) SweepExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := c.tree.expiredKeys()
+	if len(keys) == 0 {
+		return 0
+	}
+	c.writes++
+	c.detachForks()
+	return c.tree.removeKeys(keys)
+}
+
+// Sweeper 是定期删除过期条目的后台清理器
+type Sweeper struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	removed  atomic.Uint64
+}
+
+// StartSweeper 启动后台清理器，每隔 interval 调用一次 SweepExpired，使用完毕后须调用 Stop
+func (c *ConcurrentBPlusTree) StartSweeper(interval time.Duration) *Sweeper {
+	s := &Sweeper{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.removed.Add(uint64(c.SweepExpired()))
+			}
+		}
+	}()
+	return s
+}
+
+// Removed 返回清理器累计删除的条目数
+func (s *Sweeper) Removed() uint64 {
+	return s.removed.Load()
+}
+
+// Stop 停止后台清理器并等待其退出
+func (s *Sweeper) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
@@ -0,0 +1,145 @@
+package bplustree
+
+import (
+	"testing"
+	"time"
+)
+
+// 可手动拨动的时钟
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func rangeValues(bpt *BPlusTree, key int) []int {
+	var values []int
+	bpt.Range(key, key, func(_, v int) bool {
+		values = append(values, v)
+		return true
+	})
+	return values
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTTL(t *testing.T) {
+	const key = 7
+	tests := []struct {
+		name   string
+		run    func(bpt *BPlusTree, clock *fakeClock)
+		values []int // 最后 key 可见的全部 value
+	}{
+		{
+			name: "expire",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				clock.advance(time.Second)
+			},
+		},
+		{
+			name: "not-yet-expired",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				clock.advance(time.Second - 1)
+			},
+			values: []int{1},
+		},
+		{
+			name: "expire-then-insert",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				clock.advance(2 * time.Second)
+				bpt.Insert(key, 2)
+				clock.advance(time.Hour)
+			},
+			values: []int{2},
+		},
+		{
+			name: "insert-clears-deadline",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				bpt.Remove(key)
+				bpt.Insert(key, 2)
+				clock.advance(time.Hour)
+			},
+			values: []int{2},
+		},
+		{
+			name: "expire-then-insert-with-ttl",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				clock.advance(2 * time.Second)
+				bpt.InsertWithTTL(key, 2, time.Minute)
+			},
+			values: []int{2},
+		},
+		{
+			name: "remove-one-duplicate-keeps-deadline",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				bpt.InsertWithTTL(key, 2, time.Second)
+				bpt.Remove(key)
+				clock.advance(time.Second)
+			},
+		},
+		{
+			name: "remove-last-duplicate-clears-deadline",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				bpt.InsertWithTTL(key, 1, time.Second)
+				bpt.InsertWithTTL(key, 2, time.Second)
+				bpt.Remove(key)
+				bpt.Remove(key)
+				if len(bpt.ttl) != 0 {
+					t.Errorf("删除全部条目后仍记录过期时间：%v", bpt.ttl)
+				}
+			},
+		},
+		{
+			name: "sweep",
+			run: func(bpt *BPlusTree, clock *fakeClock) {
+				for i := range 20 {
+					if i != key {
+						bpt.InsertWithTTL(i, i, time.Second)
+					}
+				}
+				bpt.Insert(key, 100)
+				clock.advance(time.Second)
+				if n := bpt.SweepExpired(); n != 19 {
+					t.Errorf("SweepExpired 删除 %d 个条目，期望 19", n)
+				}
+			},
+			values: []int{100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			bpt := NewBPlusTree(WithClock(clock.Now))
+			tt.run(bpt, clock)
+			if got := rangeValues(bpt, key); !equalInts(got, tt.values) {
+				t.Errorf("key %d 可见的值为 %v，期望 %v", key, got, tt.values)
+			}
+			want := -1
+			if len(tt.values) > 0 {
+				want = tt.values[0]
+			}
+			if got := bpt.Search(key); got != want {
+				t.Errorf("Search(%d) = %d，期望 %d", key, got, want)
+			}
+			if err := bpt.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTxnDone 表示事务已经提交或回滚，不能再继续使用
+var ErrTxnDone = errors.New("事务已提交或回滚")
+
+// ErrTxnPartial 表示事务通过校验后在应用过程中出错，只有一部分操作被应用到树上。
+// 这只会在树处于异常状态时发生，例如严格模式下树因内部错误停止服务
+var ErrTxnPartial = errors.New("事务只应用了一部分")
+
+// 事务中缓冲的操作类型
+type txnOpKind int
+
+const (
+	txnInsert txnOpKind = iota
+	txnRemove
+	txnModify
+)
+
+type txnOp struct {
+	kind  txnOpKind
+	key   int
+	value int
+}
+
+// 事务内对某个 key 的最新视图
+type txnEntry struct {
+	value  int
+	exists bool
+}
+
+// Txn 是一个写事务：Insert/Remove/Modify 先缓冲在事务内，Commit 时一次性应用到树上，
+// Rollback 则直接丢弃。事务内的 Search 能看到本事务尚未提交的写入。
+// Txn 与其所属的 BPlusTree 一样不是并发安全的
+type Txn struct {
+	tree    *BPlusTree
+	ops     []txnOp
+	overlay map[int]txnEntry // 事务内改动过的 key 的最新视图
+	done    bool
+}
+
+// Begin 开启一个新事务
+func (bpt *BPlusTree) Begin() *Txn {
+	return &Txn{tree: bpt, overlay: make(map[int]txnEntry)}
+}
+
+// 返回事务视角下 key 的当前状态
+func (t *Txn) view(key int) txnEntry {
+	if e, ok := t.overlay[key]; ok {
+		return e
+	}
+	value, ok := t.tree.lookup(key)
+	return txnEntry{value: value, exists: ok}
+}
+
+// 校验单个操作在给定视图下能否执行，并返回执行后的视图
+func applyTxnOp(op txnOp, e txnEntry) (txnEntry, error) {
+	switch op.kind {
+	case txnRemove:
+		if !e.exists {
+			return e, fmt.Errorf("删除失败：未找到 key = %d", op.key)
+		}
+		return txnEntry{}, nil
+	case txnModify:
+		if !e.exists {
+			return e, fmt.Errorf("修改失败：未找到 key = %d", op.key)
+		}
+	}
+	return txnEntry{value: op.value, exists: true}, nil
+}
+
+// 校验并缓冲一个操作
+func (t *Txn) buffer(op txnOp) error {
+	if t.done {
+		return ErrTxnDone
+	}
+	e, err := applyTxnOp(op, t.view(op.key))
+	if err != nil {
+		return err
+	}
+	t.overlay[op.key] = e
+	t.ops = append(t.ops, op)
+	return nil
+}
+
+// Insert 在事务中写入键值对；key 在提交时已存在则覆盖其值，而不是插入重复的 key，
+// 从而与事务内 Search 看到的结果一致
+func (t *Txn) Insert(key, value int) error {
+	return t.buffer(txnOp{kind: txnInsert, key: key, value: value})
+}
+
+// Remove 在事务中删除 key；key 在事务视角下不存在时立即返回错误
+func (t *Txn) Remove(key int) error {
+	return t.buffer(txnOp{kind: txnRemove, key: key})
+}
+
+// Modify 在事务中修改 key 对应的 value；key 在事务视角下不存在时立即返回错误
+func (t *Txn) Modify(key, newValue int) error {
+	return t.buffer(txnOp{kind: txnModify, key: key, value: newValue})
+}
+
+// Search 返回事务视角下 key 对应的 value；若不存在返回 -1
+func (t *Txn) Search(key int) int {
+	if value, ok := t.Lookup(key); ok {
+		return value
+	}
+	return -1
+}
+
+// Lookup 返回事务视角下 key 对应的 value，并以 ok 区分"不存在"与值恰为 -1 的情况
+func (t *Txn) Lookup(key int) (value int, ok bool) {
+	e := t.view(key)
+	return e.value, e.exists
+}
+
+// 按当前树的状态重新校验全部缓冲操作（事务开启后树可能被直接修改过）
+func (t *Txn) validate() error {
+	state := make(map[int]txnEntry)
+	for _, op := range t.ops {
+		e, ok := state[op.key]
+		if !ok {
+			value, exists := t.tree.lookup(op.key)
+			e = txnEntry{value: value, exists: exists}
+		}
+		next, err := applyTxnOp(op, e)
+		if err != nil {
+			return err
+		}
+		state[op.key] = next
+	}
+	return nil
+}
+
+// 将缓冲操作依次应用到树上；调用前必须已通过 validate，因此任何错误都意味着事务只应用了一部分
+func (t *Txn) apply() error {
+	for i, op := range t.ops {
+		var err error
+		switch op.kind {
+		case txnInsert:
+			if _, ok := t.tree.lookup(op.key); ok {
+				err = t.tree.Modify(op.key, op.value)
+			} else {
+				t.tree.Insert(op.key, op.value)
+				err = t.tree.Err()
+			}
+		case txnRemove:
+			err = t.tree.Remove(op.key)
+		case txnModify:
+			err = t.tree.Modify(op.key, op.value)
+		}
+		if err != nil {
+			return fmt.Errorf("%w（已应用 %d/%d 个操作）：%w", ErrTxnPartial, i, len(t.ops), err)
+		}
+	}
+	return nil
+}
+
+// Commit 原子地应用事务中的全部操作：先按树的当前状态校验所有操作，
+// 任一操作无法执行时不做任何修改并返回错误，事务随之结束。
+// 应用过程中树出错时返回包装了 ErrTxnPartial 的错误
+func (t *Txn) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+	if err := t.validate(); err != nil {
+		return fmt.Errorf("提交失败：%w", err)
+	}
+	if err := t.apply(); err != nil {
+		return fmt.Errorf("提交失败：%w", err)
+	}
+	return nil
+}
+
+// Rollback 丢弃事务中的全部操作
+func (t *Txn) Rollback() {
+	t.done = true
+	t.ops = nil
+	t.overlay = nil
+}
+
+// CommitAll 原子地提交分布在多棵树上的一组事务，用于把相关的索引分别存放在不同树中的场景。
+// 采用两阶段提交：先逐个校验全部事务（准备阶段），任一事务无法执行时全部放弃；
+// 全部通过后再依次应用（提交阶段）。无论成功与否，这些事务都会结束。
+// 每棵树最多只能参与一个事务，因为同一棵树上的多个事务是各自独立校验的
+func CommitAll(txns ...*Txn) error {
+	seen := make(map[*BPlusTree]bool, len(txns))
+	for i, t := range txns {
+		if t.done {
+			return fmt.Errorf("提交失败：第 %d 个事务：%w", i, ErrTxnDone)
+		}
+		if seen[t.tree] {
+			return fmt.Errorf("提交失败：第 %d 个事务与之前的事务作用于同一棵树", i)
+		}
+		seen[t.tree] = true
+	}
+	for _, t := range txns {
+		t.done = true
+	}
+	for i, t := range txns {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("提交失败：第 %d 个事务：%w", i, err)
+		}
+	}
+	for i, t := range txns {
+		if err := t.apply(); err != nil {
+			return fmt.Errorf("提交失败：第 %d 个事务：%w", i, err)
+		}
+	}
+	return nil
+}
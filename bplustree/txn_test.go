@@ -0,0 +1,197 @@
+package bplustree
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTxnCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(bpt *BPlusTree)
+		ops     func(tx *Txn) error
+		between func(bpt *BPlusTree) // Begin 与 Commit 之间对树的直接修改
+		wantErr bool
+		want    map[int]int // 提交后应可见的条目，值 -1 表示不存在
+	}{
+		{
+			name: "insert-modify-remove",
+			setup: func(bpt *BPlusTree) {
+				bpt.Insert(1, 10)
+				bpt.Insert(2, 20)
+			},
+			ops: func(tx *Txn) error {
+				return errors.Join(tx.Insert(3, 30), tx.Modify(1, 11), tx.Remove(2))
+			},
+			want: map[int]int{1: 11, 2: -1, 3: 30},
+		},
+		{
+			name: "modify-own-insert",
+			ops: func(tx *Txn) error {
+				return errors.Join(tx.Insert(5, 1), tx.Modify(5, 2))
+			},
+			want: map[int]int{5: 2},
+		},
+		{
+			name:  "conflict-aborts-everything",
+			setup: func(bpt *BPlusTree) { bpt.Insert(1, 10) },
+			ops: func(tx *Txn) error {
+				return errors.Join(tx.Insert(2, 20), tx.Modify(1, 11))
+			},
+			between: func(bpt *BPlusTree) { bpt.Remove(1) },
+			wantErr: true,
+			want:    map[int]int{1: -1, 2: -1},
+		},
+		{
+			name: "expired-key-is-absent",
+			setup: func(bpt *BPlusTree) {
+				bpt.InsertWithTTL(1, 10, time.Second)
+			},
+			ops: func(tx *Txn) error {
+				if tx.Search(1) != -1 {
+					return errors.New("事务看到了已过期的 key")
+				}
+				if tx.Modify(1, 11) == nil {
+					return errors.New("事务修改了已过期的 key")
+				}
+				return tx.Insert(1, 12)
+			},
+			want: map[int]int{1: 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			bpt := NewBPlusTree(WithClock(clock.Now))
+			if tt.setup != nil {
+				tt.setup(bpt)
+			}
+			clock.advance(time.Minute)
+			tx := bpt.Begin()
+			if err := tt.ops(tx); err != nil {
+				t.Fatal(err)
+			}
+			if tt.between != nil {
+				tt.between(bpt)
+			}
+			if err := tx.Commit(); (err != nil) != tt.wantErr {
+				t.Fatalf("Commit() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			for k, v := range tt.want {
+				if got := bpt.Search(k); got != v {
+					t.Errorf("Search(%d) = %d，期望 %d", k, got, v)
+				}
+			}
+			if err := tx.Commit(); !errors.Is(err, ErrTxnDone) {
+				t.Errorf("重复提交返回 %v，期望 ErrTxnDone", err)
+			}
+		})
+	}
+}
+
+// 对已存在的 key 执行 Insert 覆盖其值而不是插入重复的 key，提交后的树与事务内看到的一致
+func TestTxnInsertExisting(t *testing.T) {
+	tests := []struct {
+		name   string
+		ops    func(tx *Txn) error
+		value  int
+		exists bool
+	}{
+		{"insert", func(tx *Txn) error { return tx.Insert(5, 60) }, 60, true},
+		{"insert-remove", func(tx *Txn) error { return errors.Join(tx.Insert(5, 60), tx.Remove(5)) }, 0, false},
+		{"insert-twice", func(tx *Txn) error { return errors.Join(tx.Insert(5, 60), tx.Insert(5, 70)) }, 70, true},
+		{"remove-insert", func(tx *Txn) error { return errors.Join(tx.Remove(5), tx.Insert(5, 60)) }, 60, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bpt := NewBPlusTree()
+			bpt.Insert(5, 50)
+			tx := bpt.Begin()
+			if err := tt.ops(tx); err != nil {
+				t.Fatal(err)
+			}
+			if v, ok := tx.Lookup(5); v != tt.value || ok != tt.exists {
+				t.Fatalf("事务内 Lookup(5) = %d, %v，期望 %d, %v", v, ok, tt.value, tt.exists)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			var values []int
+			bpt.Range(5, 5, func(_, v int) bool {
+				values = append(values, v)
+				return true
+			})
+			if tt.exists && (len(values) != 1 || values[0] != tt.value) || !tt.exists && len(values) != 0 {
+				t.Fatalf("提交后 key 5 的条目为 %v，期望与事务内的视图一致", values)
+			}
+		})
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	bpt := NewBPlusTree()
+	bpt.Insert(1, 10)
+	tx := bpt.Begin()
+	tx.Insert(2, 20)
+	tx.Modify(1, 11)
+	if got := tx.Search(1); got != 11 {
+		t.Fatalf("事务内 Search(1) = %d，期望 11", got)
+	}
+	tx.Insert(3, -1)
+	if v, ok := tx.Lookup(3); v != -1 || !ok {
+		t.Fatalf("事务内 Lookup(3) = %d, %v", v, ok)
+	}
+	if _, ok := tx.Lookup(4); ok {
+		t.Fatal("事务内 Lookup 不存在的 key 返回 ok")
+	}
+	tx.Rollback()
+	if bpt.Search(1) != 10 || bpt.Search(2) != -1 {
+		t.Fatal("回滚后树被修改")
+	}
+	if err := tx.Insert(3, 30); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("回滚后 Insert 返回 %v，期望 ErrTxnDone", err)
+	}
+}
+
+// 校验通过后树在应用过程中出错时，Commit 必须报告事务只应用了一部分，而不是静默成功
+func TestTxnCommitPartial(t *testing.T) {
+	bpt := NewBPlusTree(WithStrict())
+	bpt.Insert(1, 10)
+	tx := bpt.Begin()
+	tx.Insert(2, 20)
+	tx.Remove(1)
+	bpt.err.Store(&InternalError{Op: "test"})
+	err := tx.Commit()
+	if !errors.Is(err, ErrTxnPartial) || !errors.Is(err, ErrTreeCorrupt) {
+		t.Fatalf("Commit() 错误 = %v，期望同时包装 ErrTxnPartial 与 ErrTreeCorrupt", err)
+	}
+}
+
+func TestCommitAll(t *testing.T) {
+	a, b := NewBPlusTree(), NewBPlusTree()
+	a.Insert(1, 1)
+	ta, tb := a.Begin(), b.Begin()
+	ta.Modify(1, 2)
+	tb.Insert(1, 1)
+	if err := CommitAll(ta, tb); err != nil {
+		t.Fatal(err)
+	}
+	if a.Search(1) != 2 || b.Search(1) != 1 {
+		t.Fatal("CommitAll 未应用全部事务")
+	}
+
+	ta, tb = a.Begin(), b.Begin()
+	ta.Insert(2, 2)
+	tb.Remove(1)
+	b.Remove(1)
+	if err := CommitAll(ta, tb); err == nil {
+		t.Fatal("有事务无法执行时 CommitAll 应返回错误")
+	}
+	if a.Search(2) != -1 {
+		t.Fatal("CommitAll 失败时修改了其他树")
+	}
+	if err := CommitAll(a.Begin(), a.Begin()); err == nil {
+		t.Fatal("同一棵树上的两个事务应被拒绝")
+	}
+}
@@ -0,0 +1,118 @@
+package bplustree
+
+import "fmt"
+
+// Validate 检查树的结构不变式，在发现第一处违例时返回描述该违例的错误：
+// 节点内的键有序；非根节点的关键词数在 [最少关键字数, MaxKeys] 之间（开启分裂偏置时最右叶节点不受下限约束），
+// 内部根节点至少有两个子节点；内部节点的关键词数等于子节点数，且每个关键词等于对应子节点的最大键；
+// 所有叶节点深度相同；叶节点链表按从左到右的顺序恰好串起全部叶节点，且键不递减。
+// 用于在测试与压力测试中尽早发现分裂、合并代码的回归
+func (bpt *BPlusTree) Validate() error {
+	err := bpt.validate()
+	if err != nil {
+		bpt.logWarn("发现结构异常", "err", err)
+	}
+	return err
+}
+
+func (bpt *BPlusTree) validate() error {
+	v := &validator{root: bpt.root, leafDepth: -1, splitBias: bpt.splitBias}
+	if err := v.node(bpt.root, nil); err != nil {
+		return fmt.Errorf("结构校验失败：%w", err)
+	}
+
+	node := bpt.leftmostLeaf()
+	for i, leaf := range v.leaves {
+		if node != leaf {
+			return fmt.Errorf("结构校验失败：叶节点链表中的第 %d 个节点不是从左到右的第 %d 个叶节点 %v", i, i, leaf.keys)
+		}
+		if i > 0 && len(leaf.keys) > 0 {
+			prev := v.leaves[i-1]
+			if last := prev.keys[len(prev.keys)-1]; last > leaf.keys[0] {
+				return fmt.Errorf("结构校验失败：叶节点链表中的键递减：%d 之后为 %d", last, leaf.keys[0])
+			}
+		}
+		node = node.next
+	}
+	if node != nil {
+		return fmt.Errorf("结构校验失败：最后一个叶节点的 next 指针不为空")
+	}
+	return nil
+}
+
+// 校验过程中的状态
+type validator struct {
+	root      *Node
+	leaves    []*Node // 按从左到右的顺序收集到的叶节点
+	leafDepth int     // 第一个叶节点的深度，-1 表示尚未遇到叶节点
+	splitBias float64 // 树的分裂偏置
+}
+
+// 递归校验以 node 为根的子树；path 为从根到 node 沿途选择的子节点下标，用于在错误中定位节点
+func (v *validator) node(node *Node, path []int) error {
+	isRoot := node == v.root
+	// 只在出错时才格式化节点的位置
+	where := func() string { return fmt.Sprintf("路径 %v 处的节点 %v", path, node.keys) }
+	for i := 1; i < len(node.keys); i++ {
+		if node.keys[i-1] > node.keys[i] {
+			return fmt.Errorf("%s 的键无序", where())
+		}
+	}
+	if len(node.keys) > MaxKeys {
+		return fmt.Errorf("%s 的关键词数 %d 超过上限 %d", where(), len(node.keys), MaxKeys)
+	}
+	// 开启分裂偏置时，最右叶节点由偏置分裂产生，允许低于下限
+	biasedTail := v.splitBias > 0.5 && node.isLeaf && node.next == nil
+	if !isRoot && !biasedTail && len(node.keys) < getMinKeys() {
+		return fmt.Errorf("%s 的关键词数 %d 低于下限 %d", where(), len(node.keys), getMinKeys())
+	}
+
+	if node.isLeaf {
+		if len(node.values) != len(node.keys) {
+			return fmt.Errorf("%s 有 %d 个键、%d 个值", where(), len(node.keys), len(node.values))
+		}
+		if len(node.children) != 0 {
+			return fmt.Errorf("%s 是叶节点却有 %d 个子节点", where(), len(node.children))
+		}
+		if v.leafDepth == -1 {
+			v.leafDepth = len(path)
+		} else if v.leafDepth != len(path) {
+			return fmt.Errorf("%s 位于第 %d 层，其他叶节点位于第 %d 层", where(), len(path), v.leafDepth)
+		}
+		v.leaves = append(v.leaves, node)
+		return nil
+	}
+
+	if len(node.children) != len(node.keys) {
+		return fmt.Errorf("%s 有 %d 个关键词、%d 个子节点", where(), len(node.keys), len(node.children))
+	}
+	if isRoot && len(node.children) < 2 {
+		return fmt.Errorf("内部根节点只有 %d 个子节点", len(node.children))
+	}
+	for i, child := range node.children {
+		if child == nil {
+			return fmt.Errorf("%s 的第 %d 个子节点为 nil", where(), i)
+		}
+		if len(child.keys) == 0 || child.keys[len(child.keys)-1] != node.keys[i] {
+			return fmt.Errorf("%s 的第 %d 个关键词 %d 不等于子节点 %v 的最大键", where(), i, node.keys[i], child.keys)
+		}
+		if err := v.node(child, append(path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate 在读锁保护下检查树的结构不变式
+func (c *ConcurrentBPlusTree) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Validate()
+}
+
+// Validate 检查树的结构不变式。它不获取节点闩锁，调用方须保证期间没有并发的写操作
+func (l *LatchedBPlusTree) Validate() error {
+	l.rootLatch.RLock()
+	defer l.rootLatch.RUnlock()
+	return l.tree.Validate()
+}
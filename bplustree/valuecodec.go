@@ -0,0 +1,167 @@
+package bplustree
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ValueCompression 是 PutBytes 把字节值写入值页之前使用的压缩方式
+type ValueCompression uint8
+
+const (
+	// CompressionNone 表示原样保存字节值
+	CompressionNone ValueCompression = iota
+	// CompressionFlate 表示以标准库的 DEFLATE 压缩字节值，不引入额外的依赖
+	CompressionFlate
+)
+
+func (c ValueCompression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionFlate:
+		return "flate"
+	default:
+		return fmt.Sprintf("ValueCompression(%d)", uint8(c))
+	}
+}
+
+// WithValueCompression 设置 PutBytes 写入字节值时的压缩方式，默认为 CompressionNone。
+// 每个值的编码记录在它的首个值页中，读取时按记录透明解压：同一棵树中可以混有以不同方式写入的值，
+// 重新打开时也可以改用其他方式。压缩后没有变小的值按原样保存
+func WithValueCompression(c ValueCompression) DiskOption {
+	return func(t *DiskBPlusTree) {
+		t.compression = c
+	}
+}
+
+// 值在值页中的编码，记录在首个值页的 [1] 中。
+// 压缩的编码在压缩数据之前以 uvarint 记录原始长度，解压后的长度必须与之相同；
+// 使用字典的编码随后再以 uvarint 记录字典的值页链表首页页号
+type valueEncoding byte
+
+const (
+	valueRaw       valueEncoding = 0
+	valueFlate     valueEncoding = 1
+	valueFlateDict valueEncoding = 2
+)
+
+// 没有字典时短于此长度的值不压缩：DEFLATE 的块头与长度前缀使它们几乎不可能变小。
+// 有字典时短值也可能大幅缩小，一律尝试压缩
+const minCompressSize = 64
+
+// DEFLATE 的压缩比不超过约 1032:1，记录的原始长度超过压缩数据的这个倍数即说明值页损坏
+const maxFlateRatio = 1100
+
+// 按树的压缩方式编码 value，返回编码与写入值页的数据
+func (t *DiskBPlusTree) encodeValue(value []byte) (valueEncoding, []byte) {
+	dict := t.meta.dict
+	if t.compression != CompressionFlate || dict == 0 && len(value) < minCompressSize {
+		return valueRaw, value
+	}
+	var buf bytes.Buffer
+	buf.Write(binary.AppendUvarint(nil, uint64(len(value))))
+	enc := valueFlate
+	if dict != 0 {
+		enc = valueFlateDict
+		buf.Write(binary.AppendUvarint(nil, uint64(dict)))
+	}
+	w, err := t.compressor(&buf)
+	if err != nil {
+		// 字典无法读出时原样保存；字典损坏会在读取以它压缩的值时报告
+		return valueRaw, value
+	}
+	w.Write(value)
+	w.Close()
+	if buf.Len() >= len(value) {
+		return valueRaw, value
+	}
+	return enc, buf.Bytes()
+}
+
+// 返回写入 dst 的压缩器。压缩器的初始化开销较大，在树上复用，字典改变时才重新创建
+func (t *DiskBPlusTree) compressor(dst io.Writer) (*flate.Writer, error) {
+	if t.zw != nil && t.zwDict == t.meta.dict {
+		t.zw.Reset(dst)
+		return t.zw, nil
+	}
+	var dict []byte
+	if t.meta.dict != 0 {
+		var err error
+		if dict, err = t.dictionary(t.meta.dict); err != nil {
+			return nil, err
+		}
+	}
+	// 标准库在 2 到 6 级压缩短输入时不在字典中查找匹配，直接输出未压缩块，有字典时使用最高级别
+	level := flate.DefaultCompression
+	if dict != nil {
+		level = flate.BestCompression
+	}
+	w, err := flate.NewWriterDict(dst, level, dict)
+	if err != nil {
+		return nil, err
+	}
+	t.zw, t.zwDict = w, t.meta.dict
+	return w, nil
+}
+
+// 按首个值页记录的编码还原值，head 用于报告损坏的位置
+func (t *DiskBPlusTree) decodeValue(head PageID, enc valueEncoding, data []byte) ([]byte, error) {
+	switch enc {
+	case valueRaw:
+		return data, nil
+	case valueFlate, valueFlateDict:
+		n, k := binary.Uvarint(data)
+		if k <= 0 || n > uint64(len(data))*maxFlateRatio {
+			return nil, corruptPage(head, "压缩的值记录的长度无效")
+		}
+		data = data[k:]
+		var dict []byte
+		if enc == valueFlateDict {
+			id, k := binary.Uvarint(data)
+			if k <= 0 || id == 0 || id >= uint64(t.meta.numPages) {
+				return nil, corruptPage(head, "压缩的值引用的字典页号无效")
+			}
+			var err error
+			if dict, err = t.dictionary(PageID(id)); err != nil {
+				return nil, err
+			}
+			data = data[k:]
+		}
+		r := flate.NewReaderDict(bytes.NewReader(data), dict)
+		defer r.Close()
+		value := make([]byte, n)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, corruptPage(head, "压缩的值无法解压：%v", err)
+		}
+		if extra, _ := r.Read(make([]byte, 1)); extra > 0 {
+			return nil, corruptPage(head, "压缩的值解压后比记录的 %d 字节长", n)
+		}
+		return value, nil
+	default:
+		return nil, corruptPage(head, "未知的值编码 %d", enc)
+	}
+}
+
+// 读出以 head 为首页的压缩字典，读出后缓存在树上
+func (t *DiskBPlusTree) dictionary(head PageID) ([]byte, error) {
+	if dict, ok := t.dicts[head]; ok {
+		return dict, nil
+	}
+	enc, dict, err := t.readBlobData(head)
+	if err != nil {
+		return nil, err
+	}
+	if enc != valueRaw {
+		// 字典总是原样保存，否则损坏的页可能让字典引用自身
+		return nil, corruptPage(head, "字典的编码为 %d", enc)
+	}
+	if t.dicts == nil {
+		t.dicts = make(map[PageID][]byte)
+	}
+	t.dicts[head] = dict
+	return dict, nil
+}
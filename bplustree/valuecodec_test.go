@@ -0,0 +1,171 @@
+package bplustree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestValueCompression(t *testing.T) {
+	random := make([]byte, 3*blobPayloadSize)
+	rand.New(rand.NewSource(1)).Read(random)
+	tests := []struct {
+		name  string
+		value []byte
+		enc   valueEncoding // 使用 CompressionFlate 时期望的编码
+	}{
+		{"empty", []byte{}, valueRaw},
+		{"short", []byte("hello"), valueRaw},
+		{"compressible", bytes.Repeat([]byte("row:0001,status=ok;"), 2*blobPayloadSize), valueFlate},
+		{"incompressible", random, valueRaw},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, _ := openTempDiskTree(t)
+			defer plain.Close()
+			tree, path := openTempDiskTree(t, WithValueCompression(CompressionFlate))
+			for _, tr := range []*DiskBPlusTree{plain, tree} {
+				if err := tr.PutBytes(1, tt.value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := valueEncoding(pageByte(t, tree, 1, 1)); got != tt.enc {
+				t.Fatalf("值的编码为 %d，期望 %d", got, tt.enc)
+			}
+			if tt.enc == valueFlate && tree.meta.numPages >= plain.meta.numPages {
+				t.Fatalf("压缩后占用 %d 页，未压缩时 %d 页", tree.meta.numPages, plain.meta.numPages)
+			}
+			tree.Close()
+			// 重新打开时不指定压缩方式，已写入的值照常读出
+			tree, err := OpenDiskBPlusTree(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok, err := tree.GetBytes(1); err != nil || !ok || !bytes.Equal(got, tt.value) {
+				t.Fatalf("重新打开后 GetBytes = %d 字节, %v, %v", len(got), ok, err)
+			}
+			tree.Close()
+			tree, err = OpenDiskBPlusTree(path, WithValueCompression(CompressionFlate))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tree.Close()
+			if err := tree.ScanBytes(func(_ int, v []byte) bool {
+				if !bytes.Equal(v, tt.value) {
+					t.Errorf("ScanBytes 读到 %d 字节，期望 %d 字节", len(v), len(tt.value))
+				}
+				return true
+			}); err != nil {
+				t.Fatal(err)
+			}
+			// 删除压缩的值时其页照常归还空闲页链表，再次写入不扩展文件
+			pages := tree.meta.numPages
+			if err := tree.RemoveBytes(1); err != nil {
+				t.Fatal(err)
+			}
+			if err := tree.PutBytes(1, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if tree.meta.numPages != pages {
+				t.Fatalf("删除后重新写入使文件从 %d 页增长到 %d 页", pages, tree.meta.numPages)
+			}
+		})
+	}
+}
+
+// 返回 key 对应的值页链表首页的第 i 个字节
+func pageByte(t *testing.T, tree *DiskBPlusTree, key, b int) byte {
+	t.Helper()
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	defer tree.unpinAll()
+	leaf, _, err := tree.descend(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.Index(leaf.keys, key)
+	if i < 0 {
+		t.Fatalf("key %d 不存在", key)
+	}
+	buf := make([]byte, PageSize)
+	if err := tree.store.ReadPage(PageID(leaf.values[i]), buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf[b]
+}
+
+// 校验和正确但压缩数据不合法的值页被报告为损坏，而不是返回错误的内容
+func TestValueCompressionCorrupt(t *testing.T) {
+	value := bytes.Repeat([]byte("abcdefgh"), 1000)
+	tests := []struct {
+		name    string
+		corrupt func(page []byte)
+	}{
+		{"unknown-encoding", func(page []byte) { page[1] = 9 }},
+		{"bad-stream", func(page []byte) {
+			for i := pageHeaderSize + 4; i < pageHeaderSize+40; i++ {
+				page[i] ^= 0x5a
+			}
+		}},
+		{"length-too-large", func(page []byte) {
+			n := binary.PutUvarint(page[pageHeaderSize:], 1<<40)
+			binary.LittleEndian.PutUint16(page[2:], uint16(n))
+		}},
+		{"length-mismatch", func(page []byte) {
+			binary.PutUvarint(page[pageHeaderSize:], uint64(len(value)-1))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, _ := openTempDiskTree(t, WithValueCompression(CompressionFlate))
+			defer tree.Close()
+			if err := tree.PutBytes(1, value); err != nil {
+				t.Fatal(err)
+			}
+			tree.mu.Lock()
+			leaf, _, _ := tree.descend(1)
+			tree.unpinAll()
+			head := PageID(leaf.values[0])
+			page := make([]byte, PageSize)
+			tree.store.ReadPage(head, page)
+			tt.corrupt(page)
+			tree.meta.checksum.setPageChecksum(head, page)
+			tree.store.WritePage(head, page)
+			tree.mu.Unlock()
+
+			var corrupt *CorruptPageError
+			if _, _, err := tree.GetBytes(1); !errors.As(err, &corrupt) || corrupt.Page != head {
+				t.Fatalf("GetBytes 返回 %v，期望页 %d 损坏", err, head)
+			}
+		})
+	}
+}
+
+// 版本 4 的文件没有压缩的值，可以直接打开
+func TestOpenVersion4File(t *testing.T) {
+	tree, path := openTempDiskTree(t)
+	tree.PutBytes(1, []byte("old"))
+	tree.Close()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := make([]byte, PageSize)
+	f.ReadAt(page, 0)
+	binary.LittleEndian.PutUint32(page[8:], 4)
+	ChecksumCRC32.setPageChecksum(0, page)
+	f.WriteAt(page, 0)
+	f.Close()
+	tree, err = OpenDiskBPlusTree(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if got, ok, err := tree.GetBytes(1); err != nil || !ok || string(got) != "old" {
+		t.Fatalf("GetBytes = %q, %v, %v", got, ok, err)
+	}
+}
@@ -0,0 +1,160 @@
+package bplustree
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VerifierOptions 配置后台校验器
+type VerifierOptions struct {
+	Interval time.Duration // 两轮抽样之间的间隔，默认为 1 分钟
+	Fraction float64       // 每轮抽查的页占总页数的比例，取值 (0, 1]，默认为 0.01（至少一页）
+	// OnInconsistency 在每次发现不一致时被调用，err 通常为 *CorruptPageError。
+	// 它在校验器的 goroutine 中执行，不得调用树的方法
+	OnInconsistency func(err error)
+	// Rand 为选取页号使用的随机源，nil 时使用全局随机源；固定种子可以重放同一组抽查
+	Rand *rand.Rand
+}
+
+// VerifierStats 是后台校验器的累计统计
+type VerifierStats struct {
+	PagesChecked    uint64 // 已抽查的页数
+	Inconsistencies uint64 // 发现的不一致次数
+}
+
+// Verifier 在后台持续抽查磁盘树的页：校验和、页内键的顺序、内部节点关键词与子节点最大键是否一致、
+// 叶节点链表的链接是否有序，在查询读到损坏数据之前尽早发现问题
+type Verifier struct {
+	tree *DiskBPlusTree
+	opts VerifierOptions
+	rand *randSource
+	stop chan struct{}
+	done chan struct{}
+
+	checked         atomic.Uint64
+	inconsistencies atomic.Uint64
+	stopOnce        sync.Once
+}
+
+// StartVerifier 启动后台校验器，使用完毕后须调用 Stop
+func (t *DiskBPlusTree) StartVerifier(opts VerifierOptions) *Verifier {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.Fraction <= 0 || opts.Fraction > 1 {
+		opts.Fraction = 0.01
+	}
+	v := &Verifier{tree: t, opts: opts, rand: newRandSource(opts.Rand), stop: make(chan struct{}), done: make(chan struct{})}
+	go v.run()
+	return v
+}
+
+func (v *Verifier) run() {
+	defer close(v.done)
+	ticker := time.NewTicker(v.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-v.stop:
+			return
+		case <-ticker.C:
+			v.sample()
+		}
+	}
+}
+
+// 抽查一轮：每页单独加锁检查，避免长时间阻塞前台操作
+func (v *Verifier) sample() {
+	t := v.tree
+	t.mu.Lock()
+	numPages := t.meta.numPages
+	t.mu.Unlock()
+	if numPages <= 1 {
+		return
+	}
+	n := max(int(float64(numPages-1)*v.opts.Fraction), 1)
+	for i := 0; i < n; i++ {
+		select {
+		case <-v.stop:
+			return
+		default:
+		}
+		id := PageID(1 + v.rand.Uint32N(numPages-1))
+		t.mu.Lock()
+		err := t.verifyPage(id)
+		t.mu.Unlock()
+		v.checked.Add(1)
+		if err != nil {
+			v.inconsistencies.Add(1)
+			t.logWarn("后台校验发现不一致", "page", id, "err", err)
+			if v.opts.OnInconsistency != nil {
+				v.opts.OnInconsistency(err)
+			}
+		}
+	}
+}
+
+// Stats 返回校验器的累计统计
+func (v *Verifier) Stats() VerifierStats {
+	return VerifierStats{PagesChecked: v.checked.Load(), Inconsistencies: v.inconsistencies.Load()}
+}
+
+// Stop 停止后台校验器并等待其退出
+func (v *Verifier) Stop() {
+	v.stopOnce.Do(func() { close(v.stop) })
+	<-v.done
+}
+
+// 检查单个页及其直接相关的页（内部节点的子节点、叶节点的后继），调用方须持有树的锁
+func (t *DiskBPlusTree) verifyPage(id PageID) error {
+	defer t.unpinAll()
+	if id == 0 || uint32(id) >= t.meta.numPages {
+		return nil // 抽样期间文件被截短（如 Compact）
+	}
+	if err := t.store.ReadPage(id, t.buf); err != nil {
+		return err
+	}
+	if err := t.meta.checksum.verifyPageChecksum(id, t.buf); err != nil {
+		return err
+	}
+	if t.buf[0] == pageTypeFree || t.buf[0] == pageTypeBlob {
+		return nil
+	}
+	n, err := t.readNode(id)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(n.keys); i++ {
+		if n.keys[i-1] > n.keys[i] {
+			return corruptPage(id, "第 %d 个关键词 %d 小于前一个关键词 %d", i, n.keys[i], n.keys[i-1])
+		}
+	}
+	if n.isLeaf {
+		if n.next == 0 || len(n.keys) == 0 {
+			return nil
+		}
+		next, err := t.readNode(n.next)
+		if err != nil {
+			return err
+		}
+		if !next.isLeaf {
+			return corruptPage(id, "叶节点链表指向了内部节点 %d", n.next)
+		}
+		if len(next.keys) > 0 && next.keys[0] < n.maxKey() {
+			return corruptPage(id, "叶节点链表无序：后继页 %d 的最小键 %d 小于本页最大键 %d", n.next, next.keys[0], n.maxKey())
+		}
+		return nil
+	}
+	for i, childID := range n.children {
+		child, err := t.readNode(childID)
+		if err != nil {
+			return err
+		}
+		if len(child.keys) == 0 || child.maxKey() != n.keys[i] {
+			return corruptPage(id, "第 %d 个关键词 %d 与子节点 %d 的最大键不一致", i, n.keys[i], childID)
+		}
+	}
+	return nil
+}
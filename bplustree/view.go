@@ -0,0 +1,51 @@
+package bplustree
+
+// NodeView 是节点的只读视图，供教学与调试工具逐层浏览树结构，
+// 只暴露读取方法，节点内部的修改逻辑仍保持私有。
+// 视图引用的是树中的实时节点：树被修改后，应重新从 Root() 获取视图
+type NodeView struct {
+	node *Node
+}
+
+// Root 返回根节点的只读视图
+func (bpt *BPlusTree) Root() NodeView {
+	return NodeView{node: bpt.root}
+}
+
+// IsLeaf 返回节点是否为叶节点
+func (v NodeView) IsLeaf() bool {
+	return v.node.isLeaf
+}
+
+// Keys 返回节点关键词的副本：叶节点为存储的键，内部节点为各子节点的最大键
+func (v NodeView) Keys() []int {
+	return append([]int(nil), v.node.keys...)
+}
+
+// Values 返回叶节点中值的副本；内部节点返回 nil
+func (v NodeView) Values() []int {
+	if !v.node.isLeaf {
+		return nil
+	}
+	return append([]int(nil), v.node.values...)
+}
+
+// Children 返回各子节点的视图；叶节点返回 nil
+func (v NodeView) Children() []NodeView {
+	if v.node.isLeaf {
+		return nil
+	}
+	children := make([]NodeView, len(v.node.children))
+	for i, child := range v.node.children {
+		children[i] = NodeView{node: child}
+	}
+	return children
+}
+
+// Next 返回叶节点链表中的下一个叶节点；内部节点或链表末尾返回 false
+func (v NodeView) Next() (NodeView, bool) {
+	if !v.node.isLeaf || v.node.next == nil {
+		return NodeView{}, false
+	}
+	return NodeView{node: v.node.next}, true
+}
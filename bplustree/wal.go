@@ -0,0 +1,485 @@
+package bplustree
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy 决定 WAL 何时将日志刷到磁盘
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // 每条记录写入后立即刷盘，崩溃时不丢失已返回的写操作
+	SyncInterval                   // 后台按固定间隔刷盘，崩溃时最多丢失一个间隔内的写操作
+	SyncNever                      // 不主动刷盘，由操作系统决定
+)
+
+// WAL 记录的操作类型
+const (
+	walInsert byte = iota + 1
+	walRemove
+	walModify
+)
+
+// WAL 文件布局：目录中按序号命名的日志段 <seq>.wal 与检查点 <seq>.ckpt。
+// 每条记录为 [长度 uint32][CRC32 uint32][载荷]，载荷为 [操作 1 字节][key int64][value int64]；
+// 启用加密时载荷以 AES-GCM 加密存储，长度随之变为 walSealedSize
+const (
+	walRecordHeader = 8
+	walPayloadSize  = 17
+	walSealedSize   = walPayloadSize + 12 + 16
+	walSegmentExt   = ".wal"
+	walCheckpoint   = ".ckpt"
+)
+
+// WALOption 用于调整 WALTree 的可选行为
+type WALOption func(*WALTree)
+
+// WithSyncPolicy 设置刷盘策略，默认为 SyncAlways
+func WithSyncPolicy(p SyncPolicy) WALOption {
+	return func(w *WALTree) {
+		w.policy = p
+	}
+}
+
+// WithSyncInterval 设置 SyncInterval 策略下的刷盘间隔，默认为 100ms
+func WithSyncInterval(d time.Duration) WALOption {
+	return func(w *WALTree) {
+		w.interval = d
+	}
+}
+
+// WithSegmentSize 设置单个日志段的大小上限（字节），超过后切换到新的日志段，默认为 4MiB
+func WithSegmentSize(n int64) WALOption {
+	return func(w *WALTree) {
+		w.segmentSize = n
+	}
+}
+
+// WithEncryptionKey 以 AES-GCM 加密日志记录与检查点，key 的长度须为 16、24 或 32 字节。
+// 加密与未加密的日志不能混用，以不同设置打开已有日志会返回错误
+func WithEncryptionKey(key []byte) WALOption {
+	return func(w *WALTree) {
+		w.key = key
+	}
+}
+
+// WALTree 是带预写日志的内存 B+ 树：每个 Insert/Remove/Modify 先追加写入日志再应用到树上，
+// 重新打开时通过回放日志恢复崩溃前的状态。日志按段轮转，Checkpoint 将当前内容
+// 写成检查点并删除更早的日志段。WALTree 是并发安全的
+type WALTree struct {
+	mu          sync.Mutex
+	tree        *BPlusTree
+	dir         string
+	policy      SyncPolicy
+	interval    time.Duration
+	segmentSize int64
+	key         []byte
+	aead        cipher.AEAD // 启用加密时非 nil
+
+	seq     uint64   // 当前日志段的序号
+	segment *os.File // 当前日志段
+	size    int64    // 当前日志段已写入的字节数
+	dirty   bool     // 当前日志段是否有尚未刷盘的记录
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// OpenWALTree 打开 dir 中的日志（不存在时创建），回放后返回恢复出的树
+func OpenWALTree(dir string, opts ...WALOption) (*WALTree, error) {
+	w := &WALTree{
+		tree:        NewBPlusTree(),
+		dir:         dir,
+		interval:    100 * time.Millisecond,
+		segmentSize: 4 << 20,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.key != nil {
+		aead, err := newAEAD(w.key)
+		if err != nil {
+			return nil, fmt.Errorf("打开 WAL 失败：%w", err)
+		}
+		w.aead = aead
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("打开 WAL 失败：%w", err)
+	}
+	if err := w.replay(); err != nil {
+		return nil, err
+	}
+	if err := w.openSegment(w.seq + 1); err != nil {
+		return nil, err
+	}
+	if w.policy == SyncInterval {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// 一个日志文件：序号及是否为检查点
+type walFile struct {
+	seq        uint64
+	checkpoint bool
+}
+
+func (f walFile) name() string {
+	if f.checkpoint {
+		return fmt.Sprintf("%016d%s", f.seq, walCheckpoint)
+	}
+	return fmt.Sprintf("%016d%s", f.seq, walSegmentExt)
+}
+
+// 按序号列出目录中的日志文件
+func (w *WALTree) listFiles() ([]walFile, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取 WAL 目录失败：%w", err)
+	}
+	var files []walFile
+	for _, e := range entries {
+		name := e.Name()
+		ext := filepath.Ext(name)
+		if ext != walSegmentExt && ext != walCheckpoint {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, walFile{seq: seq, checkpoint: ext == walCheckpoint})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].seq < files[j].seq })
+	return files, nil
+}
+
+// 从最近的检查点开始依次回放之后的日志段。最后一个日志段末尾不完整的记录
+// （写入过程中崩溃）会被截断丢弃；更早的文件损坏则返回错误
+func (w *WALTree) replay() error {
+	files, err := w.listFiles()
+	if err != nil {
+		return err
+	}
+	start := 0
+	for i, f := range files {
+		if f.checkpoint {
+			start = i
+		}
+	}
+	for i, f := range files[start:] {
+		last := start+i == len(files)-1
+		if err := w.replayFile(f, last); err != nil {
+			return err
+		}
+		w.seq = f.seq
+	}
+	return nil
+}
+
+// 回放单个日志文件
+func (w *WALTree) replayFile(f walFile, last bool) error {
+	path := filepath.Join(w.dir, f.name())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("回放 WAL 失败：%w", err)
+	}
+	off := 0
+	for off < len(data) {
+		stored, n, ok := decodeWALRecord(data[off:])
+		if !ok {
+			if !last || f.checkpoint {
+				return fmt.Errorf("回放 WAL 失败：%s 在偏移 %d 处损坏", f.name(), off)
+			}
+			return os.Truncate(path, int64(off))
+		}
+		payload, err := w.openRecord(stored)
+		if err != nil {
+			return fmt.Errorf("回放 WAL 失败：%s 在偏移 %d 处：%w", f.name(), off, err)
+		}
+		w.applyRecord(payload)
+		off += n
+	}
+	return nil
+}
+
+// 解码一条记录，返回存储的载荷（可能是密文）及记录的总长度；记录不完整或校验失败时返回 false
+func decodeWALRecord(data []byte) ([]byte, int, bool) {
+	if len(data) < walRecordHeader {
+		return nil, 0, false
+	}
+	size := int(binary.LittleEndian.Uint32(data))
+	if (size != walPayloadSize && size != walSealedSize) || len(data) < walRecordHeader+size {
+		return nil, 0, false
+	}
+	payload := data[walRecordHeader : walRecordHeader+size]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[4:]) {
+		return nil, 0, false
+	}
+	return payload, walRecordHeader + size, true
+}
+
+// 将一条记录应用到树上。记录在写入前已确认可以执行，因此回放时不会失败
+func (w *WALTree) applyRecord(payload []byte) {
+	key := int(int64(binary.LittleEndian.Uint64(payload[1:])))
+	value := int(int64(binary.LittleEndian.Uint64(payload[9:])))
+	switch payload[0] {
+	case walInsert:
+		w.tree.Insert(key, value)
+	case walRemove:
+		w.tree.Remove(key)
+	case walModify:
+		w.tree.Modify(key, value)
+	}
+}
+
+// 将存储的载荷还原为明文载荷；载荷是否加密与当前设置不符时返回错误
+func (w *WALTree) openRecord(stored []byte) ([]byte, error) {
+	if w.aead == nil {
+		if len(stored) != walPayloadSize {
+			return nil, fmt.Errorf("日志已加密，需要通过 WithEncryptionKey 提供密钥")
+		}
+		return stored, nil
+	}
+	if len(stored) != walSealedSize {
+		return nil, fmt.Errorf("日志未加密，不能以 WithEncryptionKey 打开")
+	}
+	payload, err := openSealed(w.aead, nil, stored, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败（密钥错误或日志被篡改）")
+	}
+	return payload, nil
+}
+
+// 编码一条记录，启用加密时加密载荷
+func (w *WALTree) encodeRecord(op byte, key, value int) []byte {
+	payload := make([]byte, walPayloadSize)
+	payload[0] = op
+	binary.LittleEndian.PutUint64(payload[1:], uint64(int64(key)))
+	binary.LittleEndian.PutUint64(payload[9:], uint64(int64(value)))
+	if w.aead != nil {
+		payload = sealBytes(w.aead, nil, payload, nil)
+	}
+	rec := make([]byte, walRecordHeader, walRecordHeader+len(payload))
+	binary.LittleEndian.PutUint32(rec, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(rec[4:], crc32.ChecksumIEEE(payload))
+	return append(rec, payload...)
+}
+
+// 创建并切换到序号为 seq 的新日志段；调用方须持有锁（或处于初始化阶段）
+func (w *WALTree) openSegment(seq uint64) error {
+	path := filepath.Join(w.dir, walFile{seq: seq}.name())
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("创建 WAL 段失败：%w", err)
+	}
+	info, err := f.Stat()
+	if err == nil {
+		// 新日志段的目录项须先落盘，否则崩溃后写入其中且已刷盘的记录可能随文件一起丢失
+		err = syncDir(w.dir)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("创建 WAL 段失败：%w", err)
+	}
+	w.seq, w.segment, w.size = seq, f, info.Size()
+	return nil
+}
+
+// 刷盘并关闭当前日志段，切换到下一个；调用方须持有锁
+func (w *WALTree) rotate() error {
+	if err := w.segment.Sync(); err != nil {
+		return fmt.Errorf("WAL 刷盘失败：%w", err)
+	}
+	if err := w.segment.Close(); err != nil {
+		return fmt.Errorf("关闭 WAL 段失败：%w", err)
+	}
+	w.dirty = false
+	return w.openSegment(w.seq + 1)
+}
+
+// 追加一条记录，并按刷盘策略刷盘；调用方须持有锁
+func (w *WALTree) append(op byte, key, value int) error {
+	if w.size >= w.segmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	rec := w.encodeRecord(op, key, value)
+	if _, err := w.segment.Write(rec); err != nil {
+		return fmt.Errorf("写入 WAL 失败：%w", err)
+	}
+	w.size += int64(len(rec))
+	w.dirty = true
+	if w.policy == SyncAlways {
+		return w.syncLocked()
+	}
+	return nil
+}
+
+func (w *WALTree) syncLocked() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.segment.Sync(); err != nil {
+		return fmt.Errorf("WAL 刷盘失败：%w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+// SyncInterval 策略下的后台刷盘
+func (w *WALTree) syncLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			// 刷盘失败时保留脏标记，下次继续尝试；Sync 与 Close 会把错误返回给调用方
+			_ = w.syncLocked()
+			w.mu.Unlock()
+		}
+	}
+}
+
+// Insert 先写日志，再插入键值对
+func (w *WALTree) Insert(key, value int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.append(walInsert, key, value); err != nil {
+		return err
+	}
+	w.tree.Insert(key, value)
+	return nil
+}
+
+// Remove 确认 key 存在后先写日志，再删除
+func (w *WALTree) Remove(key int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.tree.lookup(key); !ok {
+		return fmt.Errorf("删除失败：未找到 key = %d", key)
+	}
+	if err := w.append(walRemove, key, 0); err != nil {
+		return err
+	}
+	return w.tree.Remove(key)
+}
+
+// Modify 确认 key 存在后先写日志，再修改 value
+func (w *WALTree) Modify(key, newValue int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.tree.lookup(key); !ok {
+		return fmt.Errorf("修改失败：未找到 key = %d", key)
+	}
+	if err := w.append(walModify, key, newValue); err != nil {
+		return err
+	}
+	return w.tree.Modify(key, newValue)
+}
+
+// Search 返回 key 对应的 value；若不存在返回 -1
+func (w *WALTree) Search(key int) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tree.Search(key)
+}
+
+// Checkpoint 将树的当前内容写成检查点并删除更早的日志段与检查点，限制日志的增长。
+// 检查点先写入临时文件再原子重命名，重命名与新日志段的目录项都落盘之后才删除旧文件，
+// 中途崩溃时仍从旧的日志恢复
+func (w *WALTree) Checkpoint() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// 检查点占用当前日志段之后的序号，之后的写入进入再下一个日志段
+	ckpt := walFile{seq: w.seq + 1, checkpoint: true}
+	var buf []byte
+	for leaf := w.tree.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for i, key := range leaf.keys {
+			buf = append(buf, w.encodeRecord(walInsert, key, leaf.values[i])...)
+		}
+	}
+	if err := writeFileAtomic(filepath.Join(w.dir, ckpt.name()), buf); err != nil {
+		return fmt.Errorf("写入检查点失败：%w", err)
+	}
+	w.seq = ckpt.seq
+	if err := w.rotate(); err != nil {
+		return err
+	}
+	files, err := w.listFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.seq < ckpt.seq {
+			if err := os.Remove(filepath.Join(w.dir, f.name())); err != nil {
+				return fmt.Errorf("清理 WAL 失败：%w", err)
+			}
+		}
+	}
+	if err := syncDir(w.dir); err != nil {
+		return fmt.Errorf("清理 WAL 失败：%w", err)
+	}
+	return nil
+}
+
+// 先写入临时文件并刷盘，再重命名为 path 并刷盘所在目录，保证 path 要么是旧内容要么是完整的新内容，
+// 且返回之后重命名在崩溃后仍然有效
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// Sync 将已写入的日志刷到磁盘
+func (w *WALTree) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncLocked()
+}
+
+// Close 停止后台刷盘，刷盘并关闭当前日志段
+func (w *WALTree) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.syncLocked()
+	return errors.Join(err, w.segment.Close())
+}
@@ -0,0 +1,241 @@
+package bplustree
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 列出 WAL 目录中的日志文件名
+func walFileNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// 最后一个日志段的路径
+func lastSegment(t *testing.T, dir string) string {
+	t.Helper()
+	var last string
+	for _, name := range walFileNames(t, dir) {
+		if filepath.Ext(name) == walSegmentExt {
+			last = filepath.Join(dir, name)
+		}
+	}
+	if last == "" {
+		t.Fatal("目录中没有日志段")
+	}
+	return last
+}
+
+func walContents(w *WALTree) map[int]int {
+	got := make(map[int]int)
+	w.tree.Range(math.MinInt, math.MaxInt, func(k, v int) bool {
+		got[k] = v
+		return true
+	})
+	return got
+}
+
+func TestWALReplay(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	tests := []struct {
+		name  string
+		opts  []WALOption
+		ops   func(t *testing.T, w *WALTree)
+		crash func(t *testing.T, dir string) // 重新打开前对目录的破坏
+		want  map[int]int
+	}{
+		{
+			name: "reopen",
+			ops: func(t *testing.T, w *WALTree) {
+				w.Insert(1, 10)
+				w.Insert(2, 20)
+				w.Modify(1, 11)
+				w.Remove(2)
+			},
+			want: map[int]int{1: 11},
+		},
+		{
+			name: "torn-tail-is-truncated",
+			ops: func(t *testing.T, w *WALTree) {
+				w.Insert(1, 10)
+				w.Insert(2, 20)
+			},
+			crash: func(t *testing.T, dir string) {
+				f, err := os.OpenFile(lastSegment(t, dir), os.O_WRONLY|os.O_APPEND, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// 写到一半的记录：只有长度字段
+				f.Write([]byte{walPayloadSize, 0, 0})
+				f.Close()
+			},
+			want: map[int]int{1: 10, 2: 20},
+		},
+		{
+			name: "rotation",
+			opts: []WALOption{WithSegmentSize(3 * (walRecordHeader + walPayloadSize))},
+			ops: func(t *testing.T, w *WALTree) {
+				for i := range 10 {
+					w.Insert(i, i)
+				}
+			},
+			want: map[int]int{0: 0, 1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9},
+		},
+		{
+			name: "checkpoint",
+			opts: []WALOption{WithSegmentSize(2 * (walRecordHeader + walPayloadSize))},
+			ops: func(t *testing.T, w *WALTree) {
+				for i := range 6 {
+					w.Insert(i, i)
+				}
+				if err := w.Checkpoint(); err != nil {
+					t.Fatal(err)
+				}
+				w.Remove(0)
+				w.Modify(5, 50)
+			},
+			want: map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 50},
+		},
+		{
+			name: "checkpoint-leftover-tmp",
+			ops: func(t *testing.T, w *WALTree) {
+				w.Insert(1, 10)
+				if err := w.Checkpoint(); err != nil {
+					t.Fatal(err)
+				}
+				w.Insert(2, 20)
+			},
+			crash: func(t *testing.T, dir string) {
+				// 写检查点时崩溃留下的临时文件不应影响回放
+				os.WriteFile(filepath.Join(dir, walFile{seq: 99, checkpoint: true}.name()+".tmp"), []byte("garbage"), 0o644)
+			},
+			want: map[int]int{1: 10, 2: 20},
+		},
+		{
+			name: "encrypted",
+			opts: []WALOption{WithEncryptionKey(key)},
+			ops: func(t *testing.T, w *WALTree) {
+				w.Insert(1, 10)
+				w.Checkpoint()
+				w.Insert(2, 20)
+			},
+			want: map[int]int{1: 10, 2: 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := OpenWALTree(dir, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.ops(t, w)
+			// 不调用 Close：SyncAlways 下每条记录写入后已刷盘，直接重新打开相当于崩溃后恢复
+			if tt.crash != nil {
+				tt.crash(t, dir)
+			}
+			w2, err := OpenWALTree(dir, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w2.Close()
+			got := walContents(w2)
+			if len(got) != len(tt.want) {
+				t.Fatalf("恢复出 %v，期望 %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("恢复出 %v，期望 %v", got, tt.want)
+				}
+			}
+			// 恢复后的日志可以继续写入并再次恢复
+			if err := w2.Insert(100, 100); err != nil {
+				t.Fatal(err)
+			}
+			w2.Close()
+			w3, err := OpenWALTree(dir, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w3.Close()
+			if w3.Search(100) != 100 {
+				t.Fatal("再次恢复后丢失了恢复之后的写入")
+			}
+			w.Close()
+		})
+	}
+}
+
+func TestWALCheckpointRemovesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWALTree(dir, WithSegmentSize(walRecordHeader+walPayloadSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for i := range 5 {
+		w.Insert(i, i)
+	}
+	if err := w.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	names := walFileNames(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("检查点后目录中应只剩检查点与新日志段，实际为 %v", names)
+	}
+}
+
+func TestWALOpenErrors(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	tests := []struct {
+		name    string
+		create  []WALOption
+		open    []WALOption
+		corrupt bool // 破坏第一个日志段（之后还有日志段）
+	}{
+		{name: "missing-key", create: []WALOption{WithEncryptionKey(key)}},
+		{name: "wrong-key", create: []WALOption{WithEncryptionKey(key)}, open: []WALOption{WithEncryptionKey(bytes.Repeat([]byte{8}, 32))}},
+		{name: "unexpected-key", open: []WALOption{WithEncryptionKey(key)}},
+		{name: "bad-key-size", open: []WALOption{WithEncryptionKey([]byte("short"))}},
+		{name: "corrupt-middle-segment", corrupt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := OpenWALTree(dir, tt.create...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Insert(1, 10)
+			first := lastSegment(t, dir)
+			w.Close()
+			if tt.corrupt {
+				w, err := OpenWALTree(dir, tt.create...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Insert(2, 20)
+				w.Close()
+				data, _ := os.ReadFile(first)
+				data[len(data)-1] ^= 0xff
+				os.WriteFile(first, data, 0o644)
+				tt.open = tt.create
+			}
+			if w, err := OpenWALTree(dir, tt.open...); err == nil {
+				w.Close()
+				t.Fatal("期望打开失败")
+			}
+		})
+	}
+}
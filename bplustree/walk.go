@@ -0,0 +1,113 @@
+package bplustree
+
+import "math"
+
+// NodeInfo 是节点的只读快照，供树外部的工具（可视化、审计、导出等）使用。
+// 其中的切片均为副本，修改它们不会影响树本身；可直接编码为 JSON
+type NodeInfo struct {
+	ID          int   `json:"id"`               // 本次遍历中按层次顺序分配的节点编号，根为 0
+	ParentID    int   `json:"parentId"`         // 父节点编号，根节点为 -1
+	IsLeaf      bool  `json:"isLeaf"`           // 是否为叶节点
+	Keys        []int `json:"keys"`             // 叶节点为存储的键；内部节点为各子节点的最大键
+	Values      []int `json:"values,omitempty"` // 仅叶节点有效：与 Keys 一一对应的值
+	NumChildren int   `json:"numChildren"`      // 仅内部节点有效：子节点数量
+}
+
+// 生成节点的只读快照
+func newNodeInfo(node *Node, id, parentID int) NodeInfo {
+	info := NodeInfo{
+		ID:          id,
+		ParentID:    parentID,
+		IsLeaf:      node.isLeaf,
+		Keys:        append([]int(nil), node.keys...),
+		NumChildren: len(node.children),
+	}
+	if node.isLeaf {
+		info.Values = append([]int(nil), node.values...)
+	}
+	return info
+}
+
+// Walk 按层次顺序遍历整棵树，对每个节点调用 fn，level 为节点所在层（根为 0）
+func (bpt *BPlusTree) Walk(fn func(level int, n NodeInfo)) {
+	type item struct {
+		node     *Node
+		parentID int
+	}
+	current := []item{{bpt.root, -1}}
+	nextID := 0
+	for level := 0; len(current) > 0; level++ {
+		var next []item
+		for _, it := range current {
+			id := nextID
+			nextID++
+			fn(level, newNodeInfo(it.node, id, it.parentID))
+			for _, child := range it.node.children {
+				next = append(next, item{child, id})
+			}
+		}
+		current = next
+	}
+}
+
+// WalkLeaves 沿叶节点链表从左到右遍历所有叶节点，fn 返回 false 时提前结束。
+// 此时 NodeInfo 中的 ID 为叶节点在链表中的序号，ParentID 恒为 -1
+func (bpt *BPlusTree) WalkLeaves(fn func(n NodeInfo) bool) {
+	id := 0
+	bpt.leavesFrom(math.MinInt, func(node *Node) bool {
+		id++
+		return fn(newNodeInfo(node, id-1, -1))
+	})
+}
+
+// 从应存放 key 的叶节点开始从左到右访问叶节点，fn 返回 false 时停止；返回最后访问的叶节点之后是否已没有叶节点。
+// 只读句柄（unlinked）的链表指针可能已被原树改为指向新版本的节点，改为沿下降路径回溯寻找后继
+func (bpt *BPlusTree) leavesFrom(key int, fn func(leaf *Node) bool) (end bool) {
+	if !bpt.unlinked {
+		leaf := bpt.findLeaf(bpt.root, key)
+		for leaf != nil {
+			more := fn(leaf)
+			leaf = leaf.next
+			if !more {
+				break
+			}
+		}
+		return leaf == nil
+	}
+	var path []pathStep
+	node := bpt.root
+	for !node.isLeaf {
+		i := childIndex(node, key)
+		path = append(path, pathStep{node, i})
+		node = node.children[i]
+	}
+	for {
+		more := fn(node)
+		// 回溯到还有右侧子节点的祖先，再沿最左子节点下降到后继叶节点
+		for len(path) > 0 && path[len(path)-1].index == len(path[len(path)-1].node.children)-1 {
+			path = path[:len(path)-1]
+		}
+		if len(path) == 0 {
+			return true
+		}
+		if !more {
+			return false
+		}
+		top := &path[len(path)-1]
+		top.index++
+		node = top.node.children[top.index]
+		for !node.isLeaf {
+			path = append(path, pathStep{node, 0})
+			node = node.children[0]
+		}
+	}
+}
+
+// 返回最左侧叶节点，即叶节点链表的起点
+func (bpt *BPlusTree) leftmostLeaf() *Node {
+	node := bpt.root
+	for !node.isLeaf {
+		node = node.children[0]
+	}
+	return node
+}
@@ -0,0 +1,130 @@
+package bplustree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind 是变更通知的类型
+type EventKind int
+
+const (
+	EventInsert EventKind = iota // 插入了新条目
+	EventUpdate                  // 修改了已有条目的值
+	EventDelete                  // 删除了条目
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventInsert:
+		return "insert"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event 是一条变更通知
+type Event struct {
+	Kind     EventKind
+	Key      int
+	Value    int // 插入或修改后的值；删除时为被删除的值
+	OldValue int // 修改前的值，仅 EventUpdate 有效
+}
+
+// WatchBuffer 是每个订阅的通道容量。订阅者消费过慢、通道已满时，该订阅被取消并关闭通道，
+// 写操作从不因订阅者而阻塞；订阅者看到通道关闭后应重新读取区间并再次订阅
+const WatchBuffer = 256
+
+// 一个订阅：接收 [lo, hi] 内 key 的变更
+type watcher struct {
+	lo, hi int
+	ch     chan Event
+}
+
+// 订阅列表。有自己的锁，使 LatchedBPlusTree 中并发的写者也能安全地发出通知
+type watchHub struct {
+	mu       sync.Mutex
+	watchers []*watcher
+	n        atomic.Int32 // len(watchers)，供热路径在不加锁的情况下判断是否有订阅者
+}
+
+// Watch 订阅 key 在 [lo, hi] 内的插入、修改与删除，按发生顺序从返回的通道中接收。
+// 不再需要时调用 Unwatch；通道因消费过慢被关闭的情况见 WatchBuffer。
+// 有序批量构建（ImportJSON、ImportCSV 等在空树上整体构建）也会逐条发出插入通知；
+// Compact 不改变内容，不发出通知；Merge、SplitAt 与 UnmarshalBinary 直接替换节点，同样不发出通知
+func (bpt *BPlusTree) Watch(lo, hi int) <-chan Event {
+	w := &watcher{lo: lo, hi: hi, ch: make(chan Event, WatchBuffer)}
+	bpt.watch.mu.Lock()
+	defer bpt.watch.mu.Unlock()
+	bpt.watch.watchers = append(bpt.watch.watchers, w)
+	bpt.watch.n.Add(1)
+	return w.ch
+}
+
+// Unwatch 取消 Watch 返回的订阅并关闭其通道；订阅已被取消时什么也不做
+func (bpt *BPlusTree) Unwatch(ch <-chan Event) {
+	bpt.watch.mu.Lock()
+	defer bpt.watch.mu.Unlock()
+	for i, w := range bpt.watch.watchers {
+		if w.ch == ch {
+			bpt.watch.drop(i)
+			return
+		}
+	}
+}
+
+// 取消第 i 个订阅，调用方须持有 h.mu
+func (h *watchHub) drop(i int) {
+	close(h.watchers[i].ch)
+	h.watchers = append(h.watchers[:i], h.watchers[i+1:]...)
+	h.n.Add(-1)
+}
+
+// 将 e 发给区间覆盖 e.Key 的订阅者，通道已满的订阅被取消
+func (bpt *BPlusTree) notify(e Event) {
+	h := &bpt.watch
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := 0; i < len(h.watchers); i++ {
+		w := h.watchers[i]
+		if e.Key < w.lo || e.Key > w.hi {
+			continue
+		}
+		select {
+		case w.ch <- e:
+		default:
+			h.drop(i)
+			i--
+		}
+	}
+}
+
+// 报告是否有订阅者，用于在热路径上跳过构造通知
+func (bpt *BPlusTree) watched() bool {
+	return bpt.watch.n.Load() > 0
+}
+
+// Watch 订阅 [lo, hi] 内 key 的变更，见 BPlusTree.Watch
+func (c *ConcurrentBPlusTree) Watch(lo, hi int) <-chan Event {
+	return c.tree.Watch(lo, hi)
+}
+
+// Unwatch 取消 Watch 返回的订阅并关闭其通道
+func (c *ConcurrentBPlusTree) Unwatch(ch <-chan Event) {
+	c.tree.Unwatch(ch)
+}
+
+// Watch 订阅 [lo, hi] 内 key 的变更，见 BPlusTree.Watch。
+// 不同叶节点上的写操作可以并行，它们的通知之间的先后顺序不作保证
+func (l *LatchedBPlusTree) Watch(lo, hi int) <-chan Event {
+	return l.tree.Watch(lo, hi)
+}
+
+// Unwatch 取消 Watch 返回的订阅并关闭其通道
+func (l *LatchedBPlusTree) Unwatch(ch <-chan Event) {
+	l.tree.Unwatch(ch)
+}
@@ -0,0 +1,64 @@
+package workload
+
+import (
+	"slices"
+	"time"
+
+	"bplus-go/bplustree"
+)
+
+// Result 是一次重放的结果
+type Result struct {
+	Ops     int           // 重放的操作数
+	Elapsed time.Duration // 总耗时
+	P50     time.Duration // 单个操作耗时的中位数
+	P99     time.Duration // 单个操作耗时的第 99 百分位
+}
+
+// Replay 将 ops 依次重放到磁盘树 t 上：set 在键存在时修改、否则插入，值为操作的序号；
+// 匿名键按位转换为 int 作为树的键。遇到存储错误时停止并返回已完成部分的结果
+func Replay(ops []Op, t *bplustree.DiskBPlusTree) (Result, error) {
+	latencies := make([]time.Duration, 0, len(ops))
+	start := time.Now()
+	for i, op := range ops {
+		key := int(op.Key)
+		begin := time.Now()
+		var err error
+		switch op.Kind {
+		case OpGet:
+			_, err = t.Search(key)
+		case OpSet:
+			var v int
+			if v, err = t.Search(key); err == nil {
+				if v == -1 {
+					err = t.Insert(key, i)
+				} else {
+					err = t.Modify(key, i)
+				}
+			}
+		case OpDelete:
+			// 删除不存在的键在线上同样常见，不视为错误
+			if v, serr := t.Search(key); serr != nil {
+				err = serr
+			} else if v != -1 {
+				err = t.Remove(key)
+			}
+		}
+		if err != nil {
+			return summarize(latencies, time.Since(start)), err
+		}
+		latencies = append(latencies, time.Since(begin))
+	}
+	return summarize(latencies, time.Since(start)), nil
+}
+
+func summarize(latencies []time.Duration, elapsed time.Duration) Result {
+	r := Result{Ops: len(latencies), Elapsed: elapsed}
+	if len(latencies) == 0 {
+		return r
+	}
+	slices.Sort(latencies)
+	r.P50 = latencies[len(latencies)/2]
+	r.P99 = latencies[len(latencies)*99/100]
+	return r
+}
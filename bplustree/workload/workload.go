@@ -0,0 +1,145 @@
+// Package workload 抽样记录线上的真实操作序列（键经过带密钥的哈希匿名化）写入负载文件，
+// 并在离线环境中把负载文件重放到不同配置的树上，为调整缓冲池大小、分裂比例等参数提供依据。
+//
+// 负载文件以 8 字节的魔数开头，之后每个操作占 9 字节：1 字节操作类型与 8 字节小端序的匿名键
+package workload
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const magic = "BPTWKLD1"
+
+// OpKind 是负载中操作的类型
+type OpKind byte
+
+const (
+	OpGet    OpKind = iota + 1 // 读取
+	OpSet                      // 写入（插入或覆盖）
+	OpDelete                   // 删除
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpGet:
+		return "get"
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("OpKind(%d)", byte(k))
+}
+
+// Op 是负载中的一个操作，Key 为匿名化之后的键
+type Op struct {
+	Kind OpKind
+	Key  uint64
+}
+
+// Recorder 把抽中的操作写入负载文件，可被多个 goroutine 同时使用。
+// 抽样按键进行：同一个键的操作要么全部记录，要么全部不记录，
+// 使负载保留单个键上的访问序列，重放时的缓存命中情况与线上接近
+type Recorder struct {
+	mu        sync.Mutex
+	w         *bufio.Writer
+	key       []byte
+	threshold uint64 // 匿名键小于该值的操作被抽中
+	recorded  uint64
+	err       error
+}
+
+// NewRecorder 创建写入 w 的 Recorder：rate 为抽样比例，取值 (0, 1]；
+// hashKey 为 HMAC-SHA256 的密钥，不知道密钥就无法从负载文件还原或比对原始键。
+// 魔数立即写入缓冲区，随第一次 Flush 写出
+func NewRecorder(w io.Writer, rate float64, hashKey []byte) (*Recorder, error) {
+	if rate <= 0 || rate > 1 {
+		return nil, fmt.Errorf("抽样比例须在 (0, 1] 之间，得到 %v", rate)
+	}
+	if len(hashKey) == 0 {
+		return nil, errors.New("匿名化密钥不能为空")
+	}
+	r := &Recorder{w: bufio.NewWriter(w), key: hashKey, threshold: ^uint64(0)}
+	if rate < 1 {
+		r.threshold = uint64(rate * (1 << 63) * 2)
+	}
+	r.w.WriteString(magic)
+	return r, nil
+}
+
+// Anonymize 返回 key 的匿名键：以 HMAC-SHA256 计算后取前 8 字节
+func (r *Recorder) Anonymize(key []byte) uint64 {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write(key)
+	return binary.LittleEndian.Uint64(mac.Sum(nil))
+}
+
+// Record 在 key 被抽中时记录一次操作；写入失败后不再记录，错误由 Flush 返回
+func (r *Recorder) Record(kind OpKind, key []byte) {
+	anon := r.Anonymize(key)
+	if anon > r.threshold {
+		return
+	}
+	var rec [9]byte
+	rec[0] = byte(kind)
+	binary.LittleEndian.PutUint64(rec[1:], anon)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if _, r.err = r.w.Write(rec[:]); r.err == nil {
+		r.recorded++
+	}
+}
+
+// Recorded 返回已记录的操作数
+func (r *Recorder) Recorded() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recorded
+}
+
+// Flush 将缓冲区中的操作写出，返回记录过程中遇到的第一个错误
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+// Read 读取负载文件中的全部操作
+func Read(rd io.Reader) ([]Op, error) {
+	br := bufio.NewReader(rd)
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, head); err != nil || string(head) != magic {
+		return nil, errors.New("读取负载文件失败：魔数不匹配")
+	}
+	var ops []Op
+	var rec [9]byte
+	for {
+		_, err := io.ReadFull(br, rec[:])
+		if errors.Is(err, io.EOF) {
+			return ops, nil
+		}
+		if err != nil {
+			// 末尾不完整的记录通常是记录进程退出前没有 Flush，已读到的操作仍然可用
+			return ops, fmt.Errorf("读取负载文件失败：第 %d 个操作不完整：%w", len(ops), err)
+		}
+		kind := OpKind(rec[0])
+		if kind < OpGet || kind > OpDelete {
+			return ops, fmt.Errorf("读取负载文件失败：第 %d 个操作的类型 %d 未知", len(ops), rec[0])
+		}
+		ops = append(ops, Op{Kind: kind, Key: binary.LittleEndian.Uint64(rec[1:])})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bplus-go/bplustree"
+)
+
+// 基准测试的配置，对应 bench 命令的 name=value 参数
+type benchConfig struct {
+	keys    int     // 键空间大小，key 取 [0, keys)
+	ops     int     // 全部工作协程合计执行的操作数
+	reads   float64 // 读操作的比例
+	dist    string  // key 的分布：uniform、zipf 或 seq
+	workers int     // 并发的工作协程数
+	impl    string  // 被测的线程安全实现：latched 或 concurrent
+	seed    uint64
+}
+
+// 被测的线程安全树
+type benchTree interface {
+	Insert(key, value int)
+	Remove(key int) error
+	Search(key int) int
+}
+
+const benchUsage = "bench [keys=N] [ops=N] [reads=0.9] [dist=uniform|zipf|seq] [workers=N] [impl=latched|concurrent] [seed=N]"
+
+// 在一棵新建的线程安全树上运行基准测试，不影响会话中的树。
+// 先写入键空间中一半的 key，之后每个读操作查找一个 key，每个写操作删除该 key，key 不存在时改为插入，
+// 使存在的 key 始终约占一半，写操作持续触发分裂与合并
+func cmdBench(s *session, args []string) error {
+	cfg, err := parseBench(args)
+	if err != nil {
+		return err
+	}
+	var tree benchTree
+	switch cfg.impl {
+	case "latched":
+		tree = bplustree.NewLatchedBPlusTree()
+	case "concurrent":
+		tree = bplustree.NewConcurrentBPlusTree()
+	default:
+		return fmt.Errorf("未知的实现 %q，可选 latched、concurrent", cfg.impl)
+	}
+	for k := 0; k < cfg.keys; k += 2 {
+		tree.Insert(k, k)
+	}
+
+	latencies := make([][]time.Duration, cfg.workers)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < cfg.workers; w++ {
+		n := cfg.ops / cfg.workers
+		if w < cfg.ops%cfg.workers {
+			n++
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latencies[w] = benchWorker(tree, cfg, w, n)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	all := slices.Concat(latencies...)
+	slices.Sort(all)
+	pct := func(p float64) time.Duration {
+		if len(all) == 0 {
+			return 0
+		}
+		return all[min(int(p*float64(len(all))), len(all)-1)]
+	}
+	fmt.Fprintf(s.out, "%s，%d 个协程，%d 个 key（%s 分布），读比例 %.2f\n", cfg.impl, cfg.workers, cfg.keys, cfg.dist, cfg.reads)
+	fmt.Fprintf(s.out, "%d 个操作，耗时 %v，吞吐 %.0f ops/s\n", len(all), elapsed.Round(time.Millisecond), float64(len(all))/elapsed.Seconds())
+	fmt.Fprintf(s.out, "延迟 p50 %v  p90 %v  p99 %v  p99.9 %v  max %v\n", pct(0.5), pct(0.9), pct(0.99), pct(0.999), pct(1))
+	return nil
+}
+
+// 第 w 个工作协程执行 n 个操作，返回每个操作的耗时
+func benchWorker(tree benchTree, cfg benchConfig, w, n int) []time.Duration {
+	rng := rand.New(rand.NewPCG(cfg.seed, uint64(w)))
+	next := keyGenerator(rng, cfg, w)
+	out := make([]time.Duration, n)
+	for i := range out {
+		key := next()
+		read := rng.Float64() < cfg.reads
+		t := time.Now()
+		if read {
+			tree.Search(key)
+		} else if tree.Remove(key) != nil {
+			tree.Insert(key, key)
+		}
+		out[i] = time.Since(t)
+	}
+	return out
+}
+
+// 按 cfg.dist 返回生成 key 的函数。seq 下各协程从键空间中错开的位置开始顺序递增
+func keyGenerator(rng *rand.Rand, cfg benchConfig, w int) func() int {
+	switch cfg.dist {
+	case "zipf":
+		z := rand.NewZipf(rng, 1.1, 1, uint64(cfg.keys-1))
+		return func() int { return int(z.Uint64()) }
+	case "seq":
+		k := w * cfg.keys / cfg.workers
+		return func() int {
+			k = (k + 1) % cfg.keys
+			return k
+		}
+	}
+	return func() int { return rng.IntN(cfg.keys) }
+}
+
+func parseBench(args []string) (benchConfig, error) {
+	cfg := benchConfig{keys: 100000, ops: 1000000, reads: 0.9, dist: "uniform", workers: 1, impl: "latched", seed: 1}
+	for _, a := range args {
+		name, val, ok := strings.Cut(a, "=")
+		if !ok {
+			return cfg, fmt.Errorf("用法：%s", benchUsage)
+		}
+		var err error
+		switch name {
+		case "keys":
+			cfg.keys, err = strconv.Atoi(val)
+		case "ops":
+			cfg.ops, err = strconv.Atoi(val)
+		case "reads":
+			cfg.reads, err = strconv.ParseFloat(val, 64)
+		case "dist":
+			cfg.dist = val
+		case "workers":
+			cfg.workers, err = strconv.Atoi(val)
+		case "impl":
+			cfg.impl = val
+		case "seed":
+			cfg.seed, err = strconv.ParseUint(val, 10, 64)
+		default:
+			return cfg, fmt.Errorf("未知参数 %q，用法：%s", name, benchUsage)
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("解析参数 %s 失败：%v", name, err)
+		}
+	}
+	switch {
+	case cfg.keys < 2:
+		return cfg, fmt.Errorf("keys 至少为 2")
+	case cfg.ops < 1:
+		return cfg, fmt.Errorf("ops 至少为 1")
+	case cfg.workers < 1:
+		return cfg, fmt.Errorf("workers 至少为 1")
+	case cfg.reads < 0 || cfg.reads > 1:
+		return cfg, fmt.Errorf("reads 应在 [0, 1] 之间")
+	case cfg.dist != "uniform" && cfg.dist != "zipf" && cfg.dist != "seq":
+		return cfg, fmt.Errorf("未知的分布 %q，可选 uniform、zipf、seq", cfg.dist)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,229 @@
+// btree-cli 是一个交互式命令行，逐行读取命令并在内存中的 B+ 树上执行，
+// 用于试验树的行为，或把复现问题的操作序列写成脚本文件后通过标准输入重放。
+//
+// 用法：
+//
+//	go run ./cmd/btree-cli
+//	go run ./cmd/btree-cli < repro.txt
+//
+// 支持的命令见 help。以 # 开头的行视为注释
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"bplus-go/bplustree"
+)
+
+// 一条命令：args 为命令名之后的参数
+type command struct {
+	usage string
+	help  string
+	run   func(s *session, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"put":   {"put <key> <value>", "写入键值对，key 已存在时覆盖其值", cmdPut},
+		"get":   {"get <key>", "查找 key 对应的值", cmdGet},
+		"del":   {"del <key>", "删除 key", cmdDel},
+		"range": {"range <lo> <hi>", "按 key 升序列出 [lo, hi] 内的键值对", cmdRange},
+		"stats": {"stats", "打印树高、节点数、条目数与各层填充率", cmdStats},
+		"print": {"print", "打印树结构与叶节点链表", cmdPrint},
+		"save":  {"save <path>", "将树保存为快照文件", cmdSave},
+		"load":  {"load <path>", "从快照文件载入树，替换当前的树", cmdLoad},
+		"bench": {benchUsage, "在新建的线程安全树上运行基准测试，报告吞吐与延迟分位数", cmdBench},
+		"help":  {"help", "列出全部命令", cmdHelp},
+	}
+}
+
+// 一次交互会话的状态
+type session struct {
+	tree *bplustree.BPlusTree
+	out  io.Writer
+}
+
+var errQuit = errors.New("quit")
+
+func main() {
+	s := &session{tree: bplustree.NewBPlusTree(), out: os.Stdout}
+	// 标准输入不是终端（例如重定向自脚本文件）时不打印提示符
+	interactive := false
+	if fi, err := os.Stdin.Stat(); err == nil {
+		interactive = fi.Mode()&os.ModeCharDevice != 0
+	}
+	if interactive {
+		fmt.Println(`btree-cli：输入 help 查看命令，quit 退出`)
+	}
+	if err := s.repl(os.Stdin, interactive); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// 逐行读取并执行命令，直到输入结束或遇到 quit；单条命令出错时打印错误并继续
+func (s *session) repl(r io.Reader, prompt bool) error {
+	sc := bufio.NewScanner(r)
+	for line := 1; ; line++ {
+		if prompt {
+			fmt.Fprint(s.out, "> ")
+		}
+		if !sc.Scan() {
+			return sc.Err()
+		}
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		err := s.exec(fields[0], fields[1:])
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			if prompt {
+				fmt.Fprintf(s.out, "错误：%v\n", err)
+			} else {
+				fmt.Fprintf(s.out, "第 %d 行：错误：%v\n", line, err)
+			}
+		}
+	}
+}
+
+func (s *session) exec(name string, args []string) error {
+	if name == "quit" || name == "exit" {
+		return errQuit
+	}
+	c, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("未知命令 %q，输入 help 查看命令", name)
+	}
+	return c.run(s, args)
+}
+
+// 将 args 解析为 n 个整数
+func parseInts(args []string, n int, usage string) ([]int, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("用法：%s", usage)
+	}
+	out := make([]int, n)
+	for i, a := range args {
+		v, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是整数", a)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func cmdPut(s *session, args []string) error {
+	kv, err := parseInts(args, 2, commands["put"].usage)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.lookup(kv[0]); ok {
+		return s.tree.Modify(kv[0], kv[1])
+	}
+	s.tree.Insert(kv[0], kv[1])
+	return s.tree.Err()
+}
+
+func cmdGet(s *session, args []string) error {
+	k, err := parseInts(args, 1, commands["get"].usage)
+	if err != nil {
+		return err
+	}
+	if v, ok := s.lookup(k[0]); ok {
+		fmt.Fprintln(s.out, v)
+	} else {
+		fmt.Fprintln(s.out, "(不存在)")
+	}
+	return s.tree.Err()
+}
+
+// 查找 key，区分"不存在"与值恰为 -1 的情况
+func (s *session) lookup(key int) (value int, ok bool) {
+	s.tree.Range(key, key, func(_, v int) bool {
+		value, ok = v, true
+		return false
+	})
+	return value, ok
+}
+
+func cmdDel(s *session, args []string) error {
+	k, err := parseInts(args, 1, commands["del"].usage)
+	if err != nil {
+		return err
+	}
+	return s.tree.Remove(k[0])
+}
+
+func cmdRange(s *session, args []string) error {
+	lh, err := parseInts(args, 2, commands["range"].usage)
+	if err != nil {
+		return err
+	}
+	n := 0
+	s.tree.Range(lh[0], lh[1], func(k, v int) bool {
+		fmt.Fprintf(s.out, "%d\t%d\n", k, v)
+		n++
+		return true
+	})
+	fmt.Fprintf(s.out, "(%d 条)\n", n)
+	return s.tree.Err()
+}
+
+func cmdStats(s *session, args []string) error {
+	st := s.tree.Stats()
+	fmt.Fprintf(s.out, "高度 %d，内部节点 %d，叶节点 %d，条目 %d\n", st.Height, st.InternalNodes, st.LeafNodes, st.Entries)
+	for i, l := range st.Levels {
+		fmt.Fprintf(s.out, "第 %d 层：%d 个节点，平均填充率 %.2f，最小填充率 %.2f\n", i, l.Nodes, l.AvgFill, l.MinFill)
+	}
+	c := s.tree.Counters()
+	fmt.Fprintf(s.out, "分裂 %d 次，合并 %d 次，借补 %d 次\n", c.Splits, c.Merges, c.Borrows)
+	return nil
+}
+
+func cmdPrint(s *session, args []string) error {
+	// PrintTree 与 PrintLeafValues 固定写到标准输出
+	s.tree.PrintTree()
+	s.tree.PrintLeafValues()
+	return nil
+}
+
+func cmdSave(s *session, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法：%s", commands["save"].usage)
+	}
+	return s.tree.Save(args[0])
+}
+
+func cmdLoad(s *session, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法：%s", commands["load"].usage)
+	}
+	t, err := bplustree.Load(args[0])
+	if err != nil {
+		return err
+	}
+	s.tree = t
+	return nil
+}
+
+func cmdHelp(s *session, args []string) error {
+	names := []string{"put", "get", "del", "range", "stats", "print", "save", "load", "bench", "help"}
+	for _, name := range names {
+		c := commands[name]
+		fmt.Fprintf(s.out, "  %s\n      %s\n", c.usage, c.help)
+	}
+	fmt.Fprintf(s.out, "  quit\n      退出\n")
+	return nil
+}
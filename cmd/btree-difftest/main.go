@@ -0,0 +1,61 @@
+// btree-difftest 对本仓库的 B+ 树与 google/btree 执行相同的随机操作序列并比较结果，
+// 出现分歧时缩减操作序列，打印能复现分歧的最短操作列表与随机种子。
+//
+// 用法：
+//
+//	go run ./cmd/btree-difftest -impl bplus -ops 100000 -keys 1000 -rounds 20
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"bplus-go/bplustree/difftest"
+)
+
+func main() {
+	impl := flag.String("impl", "bplus", "被测实现：bplus（BPlusTree）或 ordered（泛型 OrderedTree）")
+	ops := flag.Int("ops", 100000, "每轮的操作数")
+	keys := flag.Int("keys", 1000, "key 的取值范围 [0, keys)")
+	rounds := flag.Int("rounds", 10, "轮数，每轮使用不同的随机种子")
+	seed := flag.Int64("seed", 0, "第一轮的随机种子，0 表示使用当前时间")
+	every := flag.Int("check-every", 100, "每隔多少个操作比较一次全部条目")
+	degree := flag.Int("degree", 8, "google/btree 的阶数")
+	flag.Parse()
+
+	var newTarget func() difftest.Target
+	switch *impl {
+	case "bplus":
+		newTarget = func() difftest.Target { return difftest.NewBPlusTarget() }
+	case "ordered":
+		newTarget = func() difftest.Target { return difftest.NewOrderedTarget() }
+	default:
+		log.Fatalf("未知的实现 %q", *impl)
+	}
+	newRef := func() difftest.Target { return difftest.NewBTreeTarget(*degree) }
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	for round := range *rounds {
+		s := *seed + int64(round)
+		seq := difftest.RandomOps(rand.New(rand.NewSource(s)), *ops, *keys)
+		err := difftest.Run(seq, newTarget(), newRef(), difftest.Options{CheckEvery: *every})
+		var d *difftest.Divergence
+		if !errors.As(err, &d) {
+			continue
+		}
+		log.Printf("第 %d 轮（seed=%d）：%v", round, s, err)
+		minimal := difftest.Shrink(seq[:d.Index+1], newTarget, newRef)
+		log.Printf("缩减后仍能复现分歧的 %d 个操作：", len(minimal))
+		for _, op := range minimal {
+			log.Printf("  %s", op)
+		}
+		os.Exit(1)
+	}
+	log.Printf("%d 轮、每轮 %d 个操作，%s 与 google/btree 的结果一致", *rounds, *ops, *impl)
+}
@@ -0,0 +1,90 @@
+// btree-memcached 启动一个兼容 memcached 文本协议的服务，数据保存在内存中的 B+ 树里，
+// 现有的 memcached 客户端（get、set、delete、incr、decr）可以直接连接使用。
+// 指定 -capture 时按 -capture-rate 抽样记录操作，键经 HMAC 匿名化后写入负载文件，
+// 可用 btree-replay 离线重放。
+//
+// 用法：
+//
+//	go run ./cmd/btree-memcached -addr localhost:11211 -purge 1m
+//	go run ./cmd/btree-memcached -capture prod.wkld -capture-rate 0.01
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"bplus-go/bplustree/memcache"
+	"bplus-go/bplustree/workload"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:11211", "监听地址")
+	purge := flag.Duration("purge", time.Minute, "清理过期数据的间隔，0 表示只在访问时删除")
+	capture := flag.String("capture", "", "负载文件路径，为空时不记录")
+	rate := flag.Float64("capture-rate", 0.01, "抽样记录的键的比例，取值 (0, 1]")
+	hashKey := flag.String("capture-key", "", "匿名化密钥（十六进制），为空时随机生成；多次记录需要可比对时指定同一个密钥")
+	flag.Parse()
+
+	s := memcache.NewServer()
+	if *purge > 0 {
+		go func() {
+			for range time.Tick(*purge) {
+				if n := s.PurgeExpired(); n > 0 {
+					log.Printf("清理了 %d 条过期数据", n)
+				}
+			}
+		}()
+	}
+	if *capture != "" {
+		startCapture(s, *capture, *rate, *hashKey)
+	}
+	log.Printf("btree-memcached 正在监听 %s", *addr)
+	log.Fatal(s.ListenAndServe(*addr))
+}
+
+// 打开负载文件并开始记录：每秒写出一次缓冲区，收到中断信号时写出剩余的操作后退出
+func startCapture(s *memcache.Server, path string, rate float64, hexKey string) {
+	key := make([]byte, 32)
+	if hexKey != "" {
+		var err error
+		if key, err = hex.DecodeString(hexKey); err != nil {
+			log.Fatalf("解析 -capture-key 失败：%v", err)
+		}
+	} else {
+		rand.Read(key)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rec, err := workload.NewRecorder(f, rate, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Capture(rec)
+	go func() {
+		for range time.Tick(time.Second) {
+			if err := rec.Flush(); err != nil {
+				log.Printf("写入负载文件失败：%v", err)
+			}
+		}
+	}()
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		err := rec.Flush()
+		f.Close()
+		log.Printf("已记录 %d 个操作到 %s", rec.Recorded(), path)
+		if err != nil {
+			log.Fatalf("写入负载文件失败：%v", err)
+		}
+		os.Exit(0)
+	}()
+	log.Printf("按 %.4g 的比例记录操作到 %s", rate, path)
+}
@@ -0,0 +1,119 @@
+// btree-replay 把 btree-memcached -capture 记录的负载文件离线重放到不同配置的磁盘树上，
+// 对每种配置报告吞吐、延迟分位数、文件页数与缓冲池命中率，用于挑选缓冲池大小与分裂比例。
+// 节点阶数（MaxKeys、DiskMaxKeys）与页大小（PageSize）是编译期常量，需要改动源码后重新编译比较。
+//
+// 用法：
+//
+//	go run ./cmd/btree-replay -workload prod.wkld -pool 64KiB,1MiB,16MiB -split-bias 0,0.9
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bplus-go/bplustree"
+	"bplus-go/bplustree/workload"
+)
+
+func main() {
+	path := flag.String("workload", "", "负载文件路径")
+	pools := flag.String("pool", "64KiB,1MiB,16MiB", "逗号分隔的缓冲池大小，可带 KiB、MiB、GiB 后缀")
+	biases := flag.String("split-bias", "0", "逗号分隔的最右叶节点分裂比例（见 WithDiskSplitBias）")
+	flag.Parse()
+	if *path == "" {
+		log.Fatal("需要指定 -workload")
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ops, err := workload.Read(f)
+	f.Close()
+	if err != nil && len(ops) == 0 {
+		log.Fatal(err)
+	}
+	if err != nil {
+		log.Printf("%v；重放已读到的 %d 个操作", err, len(ops))
+	}
+
+	dir, err := os.MkdirTemp("", "btree-replay")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Printf("%-10s %-6s %12s %10s %10s %8s %8s\n", "pool", "bias", "ops/s", "p50", "p99", "pages", "hit%")
+	for _, p := range strings.Split(*pools, ",") {
+		budget, err := parseSize(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, b := range strings.Split(*biases, ",") {
+			bias, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+			if err != nil {
+				log.Fatalf("解析分裂比例 %q 失败：%v", b, err)
+			}
+			if err := run(filepath.Join(dir, fmt.Sprintf("%d-%s.db", budget, b)), ops, p, budget, bias); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// 在新建的树文件上重放一种配置并打印一行结果
+func run(file string, ops []workload.Op, label string, budget int, bias float64) error {
+	pager, err := bplustree.OpenFilePager(file)
+	if err != nil {
+		return err
+	}
+	pool := bplustree.NewBufferPool(pager, budget)
+	t, err := bplustree.NewDiskBPlusTree(pool, bplustree.WithDiskSplitBias(bias))
+	if err != nil {
+		pool.Close()
+		return err
+	}
+	res, err := workload.Replay(ops, t)
+	if err != nil {
+		t.Close()
+		return fmt.Errorf("重放失败（pool=%s bias=%v）：%w", label, bias, err)
+	}
+	st := pool.Stats()
+	// 关闭时写回缓冲池中的脏页，之后文件大小才反映实际的页数
+	if err := t.Close(); err != nil {
+		return err
+	}
+	hit := 0.0
+	if total := st.Hits + st.Misses; total > 0 {
+		hit = 100 * float64(st.Hits) / float64(total)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-10s %-6v %12.0f %10s %10s %8d %8.1f\n", label, bias,
+		float64(res.Ops)/res.Elapsed.Seconds(), res.P50, res.P99, info.Size()/bplustree.PageSize, hit)
+	return nil
+}
+
+// 解析带 KiB、MiB、GiB 后缀的字节数
+func parseSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	mult := 1
+	for suffix, m := range map[string]int{"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30} {
+		if strings.HasSuffix(s, suffix) {
+			s, mult = strings.TrimSuffix(s, suffix), m
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("解析缓冲池大小 %q 失败：%v", s, err)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,24 @@
+// btree-resp 启动一个兼容 Redis RESP 协议的服务，数据保存在内存中的 int 键值 B+ 树里，
+// 可以用 redis-cli 或其他 Redis 客户端的 GET、SET、DEL、SCAN、ZRANGEBYSCORE 直接操作索引。
+//
+// 用法：
+//
+//	go run ./cmd/btree-resp -addr localhost:6379
+//	redis-cli -p 6379 ZRANGEBYSCORE idx 10 20 WITHSCORES
+package main
+
+import (
+	"flag"
+	"log"
+
+	"bplus-go/bplustree/resp"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:6379", "监听地址")
+	flag.Parse()
+
+	s := resp.NewServer(nil)
+	log.Printf("btree-resp 正在监听 %s", *addr)
+	log.Fatal(s.ListenAndServe(*addr))
+}
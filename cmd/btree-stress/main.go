@@ -0,0 +1,212 @@
+// btree-stress 以可配置数量的读写 goroutine 并发操作线程安全的 B+ 树，
+// 每次操作都与加锁保护的参考 map 交叉核对，并定期暂停全部写入、校验树的结构不变式。
+// 建议配合竞态检测器运行：
+//
+//	go run -race ./cmd/btree-stress -readers 8 -writers 4 -duration 10s
+package main
+
+import (
+	"flag"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bplus-go/bplustree"
+)
+
+// 被测的并发 B+ 树实现
+type tree interface {
+	Insert(key, value int)
+	Remove(key int) error
+	Modify(key, newValue int) error
+	Search(key int) int
+	Validate() error
+}
+
+// 参考 map 按 key 分片加锁：同一分片内对树与参考 map 的操作成对、原子地完成，
+// 不同分片之间的操作则可以并行地打到树上
+const stripes = 64
+
+type checker struct {
+	tree    tree
+	stripes [stripes]sync.Mutex
+	refMu   sync.RWMutex
+	ref     map[int]int
+
+	ops      atomic.Int64
+	failures atomic.Int64
+}
+
+func (c *checker) stripe(key int) *sync.Mutex {
+	return &c.stripes[uint(key)%stripes]
+}
+
+func (c *checker) refGet(key int) (int, bool) {
+	c.refMu.RLock()
+	defer c.refMu.RUnlock()
+	v, ok := c.ref[key]
+	return v, ok
+}
+
+func (c *checker) refSet(key, value int) {
+	c.refMu.Lock()
+	defer c.refMu.Unlock()
+	c.ref[key] = value
+}
+
+func (c *checker) refDelete(key int) {
+	c.refMu.Lock()
+	defer c.refMu.Unlock()
+	delete(c.ref, key)
+}
+
+func (c *checker) fail(format string, args ...any) {
+	c.failures.Add(1)
+	log.Printf("FAIL: "+format, args...)
+}
+
+// 对 key 执行一次随机写操作，并核对结果与参考 map 一致
+func (c *checker) write(rng *rand.Rand, key int) {
+	mu := c.stripe(key)
+	mu.Lock()
+	defer mu.Unlock()
+	_, exists := c.refGet(key)
+	value := rng.Int()
+	switch op := rng.Intn(3); {
+	case op == 0 && !exists:
+		// 参考 map 中不存在时才插入，保证树中的 key 唯一
+		c.tree.Insert(key, value)
+		c.refSet(key, value)
+	case op == 0 || op == 1:
+		err := c.tree.Modify(key, value)
+		if (err == nil) != exists {
+			c.fail("modify key=%d: err=%v, 参考 map 中存在=%v", key, err, exists)
+		}
+		if exists {
+			c.refSet(key, value)
+		}
+	default:
+		err := c.tree.Remove(key)
+		if (err == nil) != exists {
+			c.fail("remove key=%d: err=%v, 参考 map 中存在=%v", key, err, exists)
+		}
+		c.refDelete(key)
+	}
+	c.ops.Add(1)
+}
+
+// 查找 key 并核对结果与参考 map 一致
+func (c *checker) read(key int) {
+	mu := c.stripe(key)
+	mu.Lock()
+	defer mu.Unlock()
+	got := c.tree.Search(key)
+	want, ok := c.refGet(key)
+	if !ok {
+		want = -1
+	}
+	if got != want {
+		c.fail("search key=%d: got %d, want %d", key, got, want)
+	}
+	c.ops.Add(1)
+}
+
+// 锁住全部分片使树静止，核对全部内容并校验结构不变式
+func (c *checker) verify(keySpace int) {
+	for i := range c.stripes {
+		c.stripes[i].Lock()
+	}
+	defer func() {
+		for i := range c.stripes {
+			c.stripes[i].Unlock()
+		}
+	}()
+	for key := 0; key < keySpace; key++ {
+		want, ok := c.refGet(key)
+		if !ok {
+			want = -1
+		}
+		if got := c.tree.Search(key); got != want {
+			c.fail("verify key=%d: got %d, want %d", key, got, want)
+		}
+	}
+	if err := c.tree.Validate(); err != nil {
+		c.fail("invariant: %v", err)
+	}
+}
+
+func main() {
+	readers := flag.Int("readers", 8, "读 goroutine 数量")
+	writers := flag.Int("writers", 4, "写 goroutine 数量")
+	keySpace := flag.Int("keys", 1000, "key 的取值范围 [0, keys)")
+	duration := flag.Duration("duration", 5*time.Second, "运行时长")
+	interval := flag.Duration("check", 250*time.Millisecond, "全量校验的间隔")
+	impl := flag.String("impl", "concurrent", "被测实现：concurrent 或 latched")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "随机数种子")
+	flag.Parse()
+
+	c := &checker{ref: make(map[int]int)}
+	switch *impl {
+	case "concurrent":
+		c.tree = bplustree.NewConcurrentBPlusTree()
+	case "latched":
+		c.tree = bplustree.NewLatchedBPlusTree()
+	default:
+		log.Fatalf("未知的实现 %q", *impl)
+	}
+	log.Printf("impl=%s readers=%d writers=%d keys=%d duration=%s seed=%d",
+		*impl, *readers, *writers, *keySpace, *duration, *seed)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	worker := func(id int64, fn func(rng *rand.Rand)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(*seed + id))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					fn(rng)
+				}
+			}
+		}()
+	}
+	for i := 0; i < *writers; i++ {
+		worker(int64(i), func(rng *rand.Rand) { c.write(rng, rng.Intn(*keySpace)) })
+	}
+	for i := 0; i < *readers; i++ {
+		worker(int64(*writers+i), func(rng *rand.Rand) { c.read(rng.Intn(*keySpace)) })
+	}
+
+	ticker := time.NewTicker(*interval)
+	deadline := time.After(*duration)
+	checks := 0
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			c.verify(*keySpace)
+			checks++
+		case <-deadline:
+			break loop
+		}
+	}
+	ticker.Stop()
+	close(stop)
+	wg.Wait()
+	c.verify(*keySpace)
+	checks++
+
+	log.Printf("ops=%d checks=%d failures=%d", c.ops.Load(), checks, c.failures.Load())
+	if c.failures.Load() > 0 {
+		// 各 goroutine 的随机序列由种子决定，交错顺序仍取决于调度，重放时可能需要多跑几次
+		log.Printf("失败，重放：-seed %d -impl %s -readers %d -writers %d -keys %d", *seed, *impl, *readers, *writers, *keySpace)
+		os.Exit(1)
+	}
+}
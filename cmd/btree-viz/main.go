@@ -0,0 +1,129 @@
+// btree-viz 启动一个本地 Web 服务，在浏览器中实时渲染 B+ 树结构，
+// 并支持在页面上插入、删除键，以动画展示分裂与合并过程。
+//
+// 用法：
+//
+//	go run ./cmd/btree-viz -addr localhost:8080
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bplus-go/bplustree"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// 一次操作后返回给页面的完整状态
+type snapshot struct {
+	Nodes []levelNode `json:"nodes"` // 按层次顺序排列的全部节点
+	Steps []string    `json:"steps"` // 本次操作的追踪步骤，用于驱动动画
+	Error string      `json:"error,omitempty"`
+}
+
+type levelNode struct {
+	Level int `json:"level"`
+	bplustree.NodeInfo
+}
+
+type server struct {
+	mu    sync.Mutex
+	trace bytes.Buffer
+	tree  *bplustree.BPlusTree
+}
+
+func newServer() *server {
+	s := &server{}
+	s.tree = bplustree.NewBPlusTree(bplustree.WithTrace(&s.trace))
+	return s
+}
+
+// 在锁内执行 op，并返回操作后的树结构及其追踪步骤
+func (s *server) apply(op func() error) snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trace.Reset()
+	var snap snapshot
+	if op != nil {
+		if err := op(); err != nil {
+			snap.Error = err.Error()
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(s.trace.String()), "\n") {
+		if line != "" {
+			snap.Steps = append(snap.Steps, strings.TrimSpace(line))
+		}
+	}
+	s.tree.Walk(func(level int, n bplustree.NodeInfo) {
+		snap.Nodes = append(snap.Nodes, levelNode{Level: level, NodeInfo: n})
+	})
+	return snap
+}
+
+func (s *server) handleTree(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.apply(nil))
+}
+
+func (s *server) handleInsert(w http.ResponseWriter, r *http.Request) {
+	key, err := strconv.Atoi(r.FormValue("key"))
+	if err != nil {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+	value := key
+	if v := r.FormValue("value"); v != "" {
+		if value, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid value", http.StatusBadRequest)
+			return
+		}
+	}
+	writeJSON(w, s.apply(func() error {
+		s.tree.Insert(key, value)
+		return nil
+	}))
+}
+
+func (s *server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	key, err := strconv.Atoi(r.FormValue("key"))
+	if err != nil {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, s.apply(func() error {
+		return s.tree.Remove(key)
+	}))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("encode response: %v", err)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "监听地址")
+	flag.Parse()
+
+	s := newServer()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexHTML)
+	})
+	mux.HandleFunc("GET /api/tree", s.handleTree)
+	mux.HandleFunc("POST /api/insert", s.handleInsert)
+	mux.HandleFunc("POST /api/delete", s.handleDelete)
+
+	log.Printf("btree-viz 正在监听 http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}